@@ -0,0 +1,199 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"mail-receiver/receiver"
+)
+
+// adminHandler 内置HTTP服务的状态和管理接口
+//
+//	GET  /api/accounts                 所有账号状态（/api/status 同）
+//	GET  /api/accounts/{name}          单个账号状态
+//	GET  /api/accounts/{name}/folders  文件夹树（?status=1 同时查询邮件数和未读数）
+//	POST /api/accounts/{name}/pause    暂停账号
+//	POST /api/accounts/{name}/resume   恢复账号
+//	POST /api/accounts/{name}/fetch    立即获取邮件
+//	POST /api/accounts/{name}/unsubscribe  一键退订最近收到的邮件（{"message_id": "..."}）
+//	POST /api/reload                   重新加载配置文件
+type adminHandler struct {
+	recv  *receiver.Receiver
+	token string // 为空时修改类请求和连接邮件服务器的请求只接受本机访问
+}
+
+// newAdminHandler 创建管理接口
+func newAdminHandler(recv *receiver.Receiver, token string) *adminHandler {
+	return &adminHandler{recv: recv, token: token}
+}
+
+func (h *adminHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if status, err := h.authorize(r); err != nil {
+		writeError(w, status, err)
+		return
+	}
+
+	switch path := strings.TrimSuffix(r.URL.Path, "/"); {
+	case path == "/api/status" || path == "/api/accounts":
+		if !allowMethod(w, r, http.MethodGet) {
+			return
+		}
+		writeJSON(w, http.StatusOK, h.recv.Status())
+	case path == "/api/reload":
+		if !allowMethod(w, r, http.MethodPost) {
+			return
+		}
+		h.reload(w)
+	case strings.HasPrefix(path, "/api/accounts/"):
+		h.account(w, r, strings.TrimPrefix(path, "/api/accounts/"))
+	default:
+		writeError(w, http.StatusNotFound, errors.New("接口不存在"))
+	}
+}
+
+// account 处理 /api/accounts/{name}[/action]
+func (h *adminHandler) account(w http.ResponseWriter, r *http.Request, rest string) {
+	name, action, _ := strings.Cut(rest, "/")
+	name, err := url.PathUnescape(name)
+	if err != nil || name == "" {
+		writeError(w, http.StatusBadRequest, errors.New("账号名称无效"))
+		return
+	}
+
+	if action == "" {
+		if !allowMethod(w, r, http.MethodGet) {
+			return
+		}
+		for _, st := range h.recv.Status() {
+			if st.Name == name {
+				writeJSON(w, http.StatusOK, st)
+				return
+			}
+		}
+		writeError(w, http.StatusNotFound, receiver.ErrAccountNotFound)
+		return
+	}
+
+	if action == "folders" {
+		if !allowMethod(w, r, http.MethodGet) {
+			return
+		}
+		withStatus, _ := strconv.ParseBool(r.URL.Query().Get("status"))
+		folders, err := h.recv.Folders(name, withStatus)
+		if err != nil {
+			status := http.StatusBadGateway
+			if errors.Is(err, receiver.ErrAccountNotFound) {
+				status = http.StatusNotFound
+			}
+			writeError(w, status, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, folders)
+		return
+	}
+
+	var op func(string) error
+	switch action {
+	case "pause":
+		op = h.recv.Pause
+	case "resume":
+		op = h.recv.Resume
+	case "fetch":
+		op = h.recv.Fetch
+	case "unsubscribe":
+		op = func(name string) error {
+			var req struct {
+				MessageID string `json:"message_id"`
+			}
+			if err := json.NewDecoder(io.LimitReader(r.Body, 64<<10)).Decode(&req); err != nil || req.MessageID == "" {
+				return errors.New("请求体需要包含 message_id")
+			}
+			return h.recv.Unsubscribe(name, req.MessageID)
+		}
+	default:
+		writeError(w, http.StatusNotFound, errors.New("接口不存在"))
+		return
+	}
+	if !allowMethod(w, r, http.MethodPost) {
+		return
+	}
+	if err := op(name); err != nil {
+		status := http.StatusConflict
+		if errors.Is(err, receiver.ErrAccountNotFound) {
+			status = http.StatusNotFound
+		}
+		writeError(w, status, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]bool{"ok": true})
+}
+
+// reload 重新加载配置文件
+func (h *adminHandler) reload(w http.ResponseWriter) {
+	result, err := reloadConfig(h.recv, "管理接口")
+	if result == nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err != nil {
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]interface{}{"error": err.Error(), "result": result})
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// authorize 校验访问令牌；未配置令牌时只读请求不限制，修改类请求和连接邮件服务器的请求只接受本机访问
+func (h *adminHandler) authorize(r *http.Request) (int, error) {
+	if h.token != "" {
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(h.token)) != 1 {
+			return http.StatusUnauthorized, errors.New("访问令牌无效")
+		}
+		return 0, nil
+	}
+	if (r.Method == http.MethodGet || r.Method == http.MethodHead) && !opensConnection(r) {
+		return 0, nil
+	}
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+			return 0, nil
+		}
+	}
+	return http.StatusForbidden, errors.New("未配置 http.token 时管理操作只接受本机访问")
+}
+
+// opensConnection 请求是否会连接邮件服务器：查询文件夹会登录 IMAP 并对每个文件夹执行 STATUS，
+// 虽然只读，但会占用连接数并可能触发服务器的频率限制
+func opensConnection(r *http.Request) bool {
+	path := strings.TrimSuffix(r.URL.Path, "/")
+	return strings.HasPrefix(path, "/api/accounts/") && strings.HasSuffix(path, "/folders")
+}
+
+// allowMethod 检查请求方法，不符合时返回 405
+func allowMethod(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method == method || (method == http.MethodGet && r.Method == http.MethodHead) {
+		return true
+	}
+	w.Header().Set("Allow", method)
+	writeError(w, http.StatusMethodNotAllowed, errors.New("请求方法不支持"))
+	return false
+}
+
+// writeJSON 输出 JSON 响应
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError 输出 JSON 错误响应
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,380 @@
+package amqp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// pipe 返回通过内存连接相连的两端
+func pipe(t *testing.T) (*Conn, *Conn) {
+	t.Helper()
+	a, b := net.Pipe()
+	t.Cleanup(func() { a.Close(); b.Close() })
+	return &Conn{conn: a, r: bufio.NewReader(a), timeout: 5 * time.Second, frameMax: 4096},
+		&Conn{conn: b, r: bufio.NewReader(b), timeout: 5 * time.Second, frameMax: 4096}
+}
+
+func TestFrameRoundTrip(t *testing.T) {
+	tests := []struct {
+		kind    byte
+		channel uint16
+		payload []byte
+	}{
+		{frameMethod, 0, []byte{0, 10, 0, 11}},
+		{frameHeader, 1, []byte("header")},
+		{frameBody, 65535, bytes.Repeat([]byte{0xce}, 100)},
+		{frameBody, 1, nil},
+		{frameHeartbeat, 0, nil},
+		{frameBody, 1, make([]byte, 4096)},
+	}
+	client, server := pipe(t)
+	for _, tt := range tests {
+		go client.writeFrame(tt.kind, tt.channel, tt.payload)
+		kind, channel, payload, err := server.readFrame()
+		if err != nil {
+			t.Fatalf("读取帧失败: %v", err)
+		}
+		if kind != tt.kind || channel != tt.channel || !bytes.Equal(payload, tt.payload) {
+			t.Errorf("帧 (%d, %d, %d 字节)，期望 (%d, %d, %d 字节)", kind, channel, len(payload), tt.kind, tt.channel, len(tt.payload))
+		}
+	}
+}
+
+func TestFrameGolden(t *testing.T) {
+	client, server := pipe(t)
+	var chOpen args
+	chOpen.shortString("")
+	go client.writeMethod(1, channelOpen, chOpen.b)
+
+	// 类型 1、通道 1、长度 5、Channel.Open (20.10)、空字符串、结束标记
+	want, _ := hex.DecodeString("01" + "0001" + "00000005" + "0014000a" + "00" + "ce")
+	got := make([]byte, len(want))
+	if _, err := io.ReadFull(server.conn, got); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("方法帧 % x，期望 % x", got, want)
+	}
+}
+
+func TestReadFrameErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		frame string
+		err   string
+	}{
+		{"结束标记无效", "01 0000 00000001 00 00", "AMQP 帧结束标记无效"},
+		{"超过帧大小", "03 0001 00001001", "AMQP 帧过大: 4097"},
+		{"内容不完整", "01 0000 00000004 00", "读取 AMQP 响应失败"},
+	}
+	for _, tt := range tests {
+		client, server := pipe(t)
+		raw, _ := hex.DecodeString(strings.ReplaceAll(tt.frame, " ", ""))
+		go func() {
+			client.conn.Write(raw)
+			client.conn.Close()
+		}()
+		if _, _, _, err := server.readFrame(); err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: 错误 %v，期望包含 %q", tt.name, err, tt.err)
+		}
+	}
+}
+
+// fakeBroker 在本机模拟 broker，完成握手后由 serve 处理连接
+func fakeBroker(t *testing.T, serve func(b *Conn)) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("无法监听本机端口: %v", err)
+	}
+	done := make(chan struct{})
+	t.Cleanup(func() {
+		l.Close()
+		<-done
+	})
+	go func() {
+		defer close(done)
+		nc, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer nc.Close()
+		b := &Conn{conn: nc, r: bufio.NewReader(nc), timeout: 5 * time.Second, frameMax: 131072}
+		serve(b)
+	}()
+	return l.Addr().String()
+}
+
+// handshake broker 端的握手：检查登录信息和虚拟主机，帧大小协商为 4096
+func handshake(t *testing.T, b *Conn, user, password, vhost string) bool {
+	header := make([]byte, 8)
+	if _, err := io.ReadFull(b.r, header); err != nil || string(header) != "AMQP\x00\x00\x09\x01" {
+		t.Errorf("协议头 %q (%v)", header, err)
+		return false
+	}
+	var start args
+	start.octet(0)
+	start.octet(9)
+	start.table(nil)
+	start.longString("PLAIN")
+	start.longString("en_US")
+	b.writeMethod(0, connectionStart, start.b)
+
+	startOk, err := b.expect(0, connectionStartOk)
+	if err != nil {
+		t.Errorf("读取 StartOk 失败: %v", err)
+		return false
+	}
+	if !bytes.Contains(startOk, []byte("\x00"+user+"\x00"+password)) {
+		// 认证失败时 RabbitMQ 直接关闭连接
+		return false
+	}
+
+	var tune args
+	tune.uint16(1)
+	tune.uint32(4096)
+	tune.uint16(60)
+	b.writeMethod(0, connectionTune, tune.b)
+	tuneOk, err := b.expect(0, connectionTuneOk)
+	if err != nil || binary.BigEndian.Uint32(tuneOk[2:6]) != 4096 {
+		t.Errorf("TuneOk % x (%v)，期望帧大小 4096", tuneOk, err)
+		return false
+	}
+	b.frameMax = 4096
+
+	open, err := b.expect(0, connectionOpen)
+	if err != nil || int(open[0]) != len(vhost) || string(open[1:1+len(vhost)]) != vhost {
+		t.Errorf("Open % x (%v)，期望虚拟主机 %s", open, err, vhost)
+		return false
+	}
+	b.writeMethod(0, connectionOpenOk, []byte{0})
+	if _, err := b.expect(1, channelOpen); err != nil {
+		t.Errorf("读取 Channel.Open 失败: %v", err)
+		return false
+	}
+	b.writeMethod(1, channelOpenOk, []byte{0, 0, 0, 0})
+	return true
+}
+
+// published broker 收到的消息
+type published struct {
+	exchange, routingKey string
+	mandatory            bool
+	flags                uint16
+	body                 []byte
+	frames               int // 消息体帧数
+}
+
+// receive 读取一条发布的消息（Basic.Publish、内容头和消息体）
+func receive(t *testing.T, b *Conn) (*published, bool) {
+	data, err := b.expect(1, basicPublish)
+	if err != nil {
+		t.Errorf("读取 Basic.Publish 失败: %v", err)
+		return nil, false
+	}
+	p := &published{}
+	n := int(data[2])
+	p.exchange = string(data[3 : 3+n])
+	data = data[3+n:]
+	p.routingKey = string(data[1 : 1+int(data[0])])
+	p.mandatory = data[1+int(data[0])]&1 == 1
+
+	kind, _, header, err := b.readFrame()
+	if err != nil || kind != frameHeader {
+		t.Errorf("读取内容头失败: %d %v", kind, err)
+		return nil, false
+	}
+	size := binary.BigEndian.Uint64(header[4:12])
+	p.flags = binary.BigEndian.Uint16(header[12:14])
+	for uint64(len(p.body)) < size {
+		kind, _, body, err := b.readFrame()
+		if err != nil || kind != frameBody {
+			t.Errorf("读取消息体失败: %d %v", kind, err)
+			return nil, false
+		}
+		p.body = append(p.body, body...)
+		p.frames++
+	}
+	return p, true
+}
+
+// confirm 发送 Basic.Ack 或 Basic.Nack
+func confirm(b *Conn, method uint32, tag uint64, multiple bool) {
+	var a args
+	a.uint64(tag)
+	if multiple {
+		a.octet(1)
+	} else {
+		a.octet(0)
+	}
+	b.writeMethod(1, method, a.b)
+}
+
+func TestPublishConfirms(t *testing.T) {
+	addr := fakeBroker(t, func(b *Conn) {
+		if !handshake(t, b, "user", "pass", "mail") {
+			return
+		}
+		if _, err := b.expect(1, confirmSelect); err != nil {
+			t.Errorf("读取 Confirm.Select 失败: %v", err)
+			return
+		}
+		b.writeMethod(1, confirmSelectOk, nil)
+
+		// 1：确认；2：先确认更早的序号（忽略），再拒绝；3：批量确认
+		if _, ok := receive(t, b); !ok {
+			return
+		}
+		confirm(b, basicAck, 1, false)
+		if _, ok := receive(t, b); !ok {
+			return
+		}
+		confirm(b, basicAck, 1, false)
+		confirm(b, basicNack, 2, false)
+		if _, ok := receive(t, b); !ok {
+			return
+		}
+		b.writeFrame(frameHeartbeat, 0, nil)
+		confirm(b, basicAck, 5, true)
+
+		// 4：mandatory 消息无法路由，先返回消息再确认
+		p, ok := receive(t, b)
+		if !ok {
+			return
+		}
+		if !p.mandatory {
+			t.Errorf("消息未设置 mandatory")
+		}
+		var ret args
+		ret.uint16(312)
+		ret.shortString("NO_ROUTE")
+		ret.shortString(p.exchange)
+		ret.shortString(p.routingKey)
+		b.writeMethod(1, basicReturn, ret.b)
+		b.writeFrame(frameHeader, 1, make([]byte, 14))
+		b.writeFrame(frameBody, 1, p.body)
+		confirm(b, basicAck, 4, false)
+
+		// 客户端关闭连接
+		closeArgs, err := b.expect(0, connectionClose)
+		if err != nil || binary.BigEndian.Uint16(closeArgs) != 200 {
+			t.Errorf("Connection.Close % x (%v)，期望 200", closeArgs, err)
+			return
+		}
+		b.writeMethod(0, connectionCloseOk, nil)
+	})
+
+	c, err := Dial(Options{URL: "amqp://user:pass@" + addr + "/mail", Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatalf("连接失败: %v", err)
+	}
+	if err := c.EnableConfirms(); err != nil {
+		t.Fatalf("开启发布确认失败: %v", err)
+	}
+	msg := &Message{Body: []byte("hello")}
+	if err := c.Publish("mail", "inbox", false, msg); err != nil {
+		t.Errorf("第 1 条消息: %v", err)
+	}
+	if err := c.Publish("mail", "inbox", false, msg); err == nil || err.Error() != "broker 拒绝了消息 (nack)" {
+		t.Errorf("第 2 条消息返回 %v，期望 nack", err)
+	}
+	if err := c.Publish("mail", "inbox", false, msg); err != nil {
+		t.Errorf("第 3 条消息（批量确认）: %v", err)
+	}
+	if err := c.Publish("mail", "missing", true, msg); err == nil || err.Error() != "消息无法路由: NO_ROUTE (312)" {
+		t.Errorf("第 4 条消息返回 %v，期望无法路由", err)
+	}
+	if err := c.Close(); err != nil {
+		t.Errorf("关闭连接失败: %v", err)
+	}
+}
+
+func TestPublishFrames(t *testing.T) {
+	body := bytes.Repeat([]byte("x"), 10000)
+	got := make(chan *published, 1)
+	addr := fakeBroker(t, func(b *Conn) {
+		if !handshake(t, b, "guest", "guest", "/") {
+			return
+		}
+		if p, ok := receive(t, b); ok {
+			got <- p
+		}
+	})
+
+	c, err := Dial(Options{URL: "amqp://" + addr, Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatalf("连接失败: %v", err)
+	}
+	defer c.conn.Close()
+	msg := &Message{ContentType: "application/json", MessageID: "<id@example.com>", Persistent: true, Timestamp: time.Unix(1700000000, 0), Body: body}
+	if err := c.Publish("", "queue", false, msg); err != nil {
+		t.Fatalf("发布失败: %v", err)
+	}
+	p := <-got
+	if p.exchange != "" || p.routingKey != "queue" || p.mandatory {
+		t.Errorf("发布参数 %+v", p)
+	}
+	// content-type、delivery-mode、message-id、timestamp、app-id
+	if want := uint16(1<<15 | 1<<12 | 1<<7 | 1<<6 | 1<<3); p.flags != want {
+		t.Errorf("属性标志 %016b，期望 %016b", p.flags, want)
+	}
+	// 帧大小 4096，每帧最多 4088 字节消息体
+	if !bytes.Equal(p.body, body) || p.frames != 3 {
+		t.Errorf("消息体 %d 字节 %d 帧，期望 %d 字节 3 帧", len(p.body), p.frames, len(body))
+	}
+}
+
+func TestBrokerClose(t *testing.T) {
+	addr := fakeBroker(t, func(b *Conn) {
+		if !handshake(t, b, "guest", "guest", "/") {
+			return
+		}
+		if _, err := b.expect(1, confirmSelect); err != nil {
+			return
+		}
+		b.writeMethod(1, confirmSelectOk, nil)
+		if _, ok := receive(t, b); !ok {
+			return
+		}
+		var a args
+		a.uint16(404)
+		a.shortString("NOT_FOUND - no exchange 'mail' in vhost '/'")
+		a.uint16(60)
+		a.uint16(40)
+		b.writeMethod(1, channelClose, a.b)
+	})
+
+	c, err := Dial(Options{URL: "amqp://" + addr, Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatalf("连接失败: %v", err)
+	}
+	defer c.conn.Close()
+	if err := c.EnableConfirms(); err != nil {
+		t.Fatal(err)
+	}
+	err = c.Publish("mail", "", false, &Message{Body: []byte("hello")})
+	if want := "AMQP 通道 1 被关闭: NOT_FOUND - no exchange 'mail' in vhost '/' (404)"; err == nil || err.Error() != want {
+		t.Errorf("错误 %v，期望 %q", err, want)
+	}
+}
+
+func TestDialErrors(t *testing.T) {
+	addr := fakeBroker(t, func(b *Conn) {
+		handshake(t, b, "user", "pass", "/")
+	})
+	_, err := Dial(Options{URL: "amqp://user:wrong@" + addr, Timeout: 5 * time.Second})
+	if err == nil || err.Error() != "AMQP 认证失败（用户名或密码错误）" {
+		t.Errorf("密码错误时返回 %v", err)
+	}
+
+	if _, err := Dial(Options{URL: "http://localhost"}); err == nil || !strings.Contains(err.Error(), "AMQP 地址协议无效: http") {
+		t.Errorf("协议无效时返回 %v", err)
+	}
+}
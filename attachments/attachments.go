@@ -0,0 +1,216 @@
+package attachments
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"mail-receiver/imap"
+	"mail-receiver/secure"
+)
+
+// Backend 附件存储后端
+type Backend interface {
+	// Put 保存附件，key 为 账号/日期/Message-ID/文件名 形式的相对路径，返回保存位置（文件路径或URL）
+	Put(key string, data []byte) (string, error)
+}
+
+// previewBackend 预览图（缩略图）与附件保存方式不同的存储：推送后端通过 URL 直接加载预览图
+type previewBackend interface {
+	// PutPreview 保存预览图，返回保存位置
+	PutPreview(key string, data []byte) (string, error)
+}
+
+// Saver 附件保存器，按 账号/日期/Message-ID 目录结构保存附件
+type Saver struct {
+	backend    Backend
+	maxSize    int64
+	extensions map[string]bool // 允许保存的扩展名（小写，含点），为空时不限制
+	thumbSize  int             // 缩略图最长边像素，0 表示不生成缩略图
+	inline     bool            // 是否保存内联图片
+}
+
+// NewSaver 创建附件保存器，maxSize 为单个附件的最大字节数（0 不限制）
+func NewSaver(backend Backend, maxSize int64, extensions []string) *Saver {
+	s := &Saver{backend: backend, maxSize: maxSize}
+	for _, ext := range extensions {
+		ext = strings.ToLower(strings.TrimSpace(ext))
+		if ext == "" {
+			continue
+		}
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		if s.extensions == nil {
+			s.extensions = make(map[string]bool)
+		}
+		s.extensions[ext] = true
+	}
+	return s
+}
+
+// Save 保存邮件的附件，返回已保存的位置
+// 超过大小限制、扩展名不在允许列表中或 match 返回false（match 为nil时不过滤）的附件会被跳过
+func (s *Saver) Save(account string, email *imap.EmailMessage, match func(filename string) bool) ([]string, error) {
+	if len(email.Attachments) == 0 {
+		return nil, nil
+	}
+
+	prefix := path.Join(sanitize(account), email.Date.Format("2006-01-02"), messageDir(email))
+	used := make(map[string]bool)
+	var saved []string
+
+	for _, att := range email.Attachments {
+		name := sanitize(att.Filename)
+		if name == "" {
+			name = "attachment"
+		}
+		if att.Data == nil || (s.maxSize > 0 && att.Size > s.maxSize) {
+			continue
+		}
+		if s.extensions != nil && !s.extensions[strings.ToLower(filepath.Ext(name))] {
+			continue
+		}
+		if match != nil && !match(name) {
+			continue
+		}
+		name = uniqueName(name, used)
+
+		location, err := s.backend.Put(path.Join(prefix, name), att.Data)
+		if err != nil {
+			return saved, fmt.Errorf("保存附件 %s 失败: %w", name, err)
+		}
+		saved = append(saved, location)
+	}
+	return saved, nil
+}
+
+// SetInlineImages 启用内联图片保存
+func (s *Saver) SetInlineImages() {
+	s.inline = true
+}
+
+// SaveInline 保存邮件的内联图片（不受扩展名限制），保存在附件目录的 inline 子目录中，返回 Content-ID 到保存位置的映射
+// 未启用内联图片保存时返回nil；超过大小限制或没有 Content-ID 的图片会被跳过
+func (s *Saver) SaveInline(account string, email *imap.EmailMessage) (map[string]string, error) {
+	if !s.inline || len(email.InlineImages) == 0 {
+		return nil, nil
+	}
+
+	prefix := path.Join(sanitize(account), email.Date.Format("2006-01-02"), messageDir(email), "inline")
+	used := make(map[string]bool)
+	saved := make(map[string]string)
+
+	for _, img := range email.InlineImages {
+		if img.ContentID == "" || saved[img.ContentID] != "" {
+			continue
+		}
+		if img.Data == nil || (s.maxSize > 0 && img.Size > s.maxSize) {
+			continue
+		}
+		name := sanitize(img.Filename)
+		if name == "" {
+			name = sanitize(img.ContentID)
+		}
+		if name == "" {
+			name = "image"
+		}
+		name = uniqueName(name, used)
+
+		location, err := s.backend.Put(path.Join(prefix, name), img.Data)
+		if err != nil {
+			return saved, fmt.Errorf("保存内联图片 %s 失败: %w", name, err)
+		}
+		saved[img.ContentID] = location
+	}
+	return saved, nil
+}
+
+// dirBackend 本地目录存储
+type dirBackend struct {
+	dir     string
+	baseURL string         // 可选，目录对外访问的URL前缀
+	cipher  *secure.Cipher // 可选，加密保存
+}
+
+// NewDirBackend 创建本地目录存储，baseURL 不为空时返回 baseURL/key 形式的地址，cipher 不为nil时加密保存
+func NewDirBackend(dir, baseURL string, cipher *secure.Cipher) Backend {
+	return &dirBackend{dir: dir, baseURL: strings.TrimRight(baseURL, "/"), cipher: cipher}
+}
+
+// Put 实现 Backend
+func (b *dirBackend) Put(key string, data []byte) (string, error) {
+	return b.put(key, data, b.cipher != nil)
+}
+
+// PutPreview 实现 previewBackend：设置了 base_url 时缩略图不加密保存，否则推送后端通过 URL 加载到的是密文
+func (b *dirBackend) PutPreview(key string, data []byte) (string, error) {
+	return b.put(key, data, b.cipher != nil && b.baseURL == "")
+}
+
+// put 保存文件，encrypt 为 true 时加密保存
+func (b *dirBackend) put(key string, data []byte, encrypt bool) (string, error) {
+	p := filepath.Join(b.dir, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return "", fmt.Errorf("创建附件目录失败: %w", err)
+	}
+	write := os.WriteFile
+	if encrypt {
+		write = b.cipher.WriteFile
+	}
+	if err := write(p, data, 0o600); err != nil {
+		return "", err
+	}
+	if b.baseURL != "" {
+		return b.baseURL + "/" + escapeKey(key), nil
+	}
+	return p, nil
+}
+
+// escapeKey 按路径段转义对象键
+func escapeKey(key string) string {
+	parts := strings.Split(key, "/")
+	for i, part := range parts {
+		parts[i] = url.PathEscape(part)
+	}
+	return strings.Join(parts, "/")
+}
+
+// messageDir 邮件目录名：使用 Message-ID，缺失时使用UID
+func messageDir(email *imap.EmailMessage) string {
+	id := strings.Trim(email.MessageID, "<> ")
+	if id = sanitize(id); id != "" {
+		return id
+	}
+	return fmt.Sprintf("uid-%d", email.UID)
+}
+
+// uniqueName 同一封邮件内附件重名时追加序号
+func uniqueName(name string, used map[string]bool) string {
+	candidate := name
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	for i := 2; used[candidate]; i++ {
+		candidate = fmt.Sprintf("%s-%d%s", base, i, ext)
+	}
+	used[candidate] = true
+	return candidate
+}
+
+// sanitize 去掉路径分隔符和不安全字符，防止写出目标目录
+func sanitize(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	if name == "." || name == ".." || name == "/" {
+		return ""
+	}
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r < 0x20, strings.ContainsRune(`<>:"/\|?*`, r):
+			return '_'
+		}
+		return r
+	}, name)
+}
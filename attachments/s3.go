@@ -0,0 +1,205 @@
+package attachments
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// S3Options S3 兼容对象存储配置
+type S3Options struct {
+	Endpoint  string // 服务地址，如 https://s3.amazonaws.com、https://oss-cn-hangzhou.aliyuncs.com
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	Prefix    string        // 可选，对象键前缀
+	PathStyle bool          // 使用 endpoint/bucket/key 形式（MinIO 等），默认使用 bucket.endpoint/key
+	URLExpiry time.Duration // 返回的预签名URL的有效期，0 时为最长的7天
+}
+
+// maxPresignExpiry SigV4 预签名URL的最长有效期
+const maxPresignExpiry = 7 * 24 * time.Hour
+
+// s3Backend S3 兼容对象存储（AWS Signature V4）
+type s3Backend struct {
+	opts   S3Options
+	base   *url.URL
+	client *http.Client
+}
+
+// NewS3Backend 创建 S3 存储
+func NewS3Backend(opts S3Options) (Backend, error) {
+	if opts.Bucket == "" || opts.AccessKey == "" || opts.SecretKey == "" {
+		return nil, fmt.Errorf("S3 配置缺少 bucket/access_key/secret_key")
+	}
+	if opts.Endpoint == "" {
+		opts.Endpoint = "https://s3.amazonaws.com"
+	}
+	if opts.Region == "" {
+		opts.Region = "us-east-1"
+	}
+	if opts.URLExpiry <= 0 || opts.URLExpiry > maxPresignExpiry {
+		opts.URLExpiry = maxPresignExpiry
+	}
+	base, err := url.Parse(strings.TrimRight(opts.Endpoint, "/"))
+	if err != nil || base.Host == "" {
+		return nil, fmt.Errorf("S3 endpoint 无效: %s", opts.Endpoint)
+	}
+	return &s3Backend{
+		opts:   opts,
+		base:   base,
+		client: &http.Client{Timeout: 60 * time.Second},
+	}, nil
+}
+
+// Put 实现 Backend，返回对象的预签名下载URL（存储桶不公开时也可以直接打开）
+func (b *s3Backend) Put(key string, data []byte) (string, error) {
+	u := *b.base
+	objectKey := path.Join(b.opts.Prefix, key)
+	if b.opts.PathStyle {
+		u.Path = "/" + b.opts.Bucket + "/" + objectKey
+	} else {
+		u.Host = b.opts.Bucket + "." + u.Host
+		u.Path = "/" + objectKey
+	}
+	// 文件名可能含空格、中文、+ 等字符，请求路径和签名都使用 RFC 3986 编码
+	u.RawPath = s3EscapePath(u.Path)
+
+	req, err := http.NewRequest(http.MethodPut, u.String(), bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("创建 S3 请求失败: %w", err)
+	}
+	now := time.Now().UTC()
+	b.sign(req, data, now)
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("S3 上传失败: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return "", fmt.Errorf("S3 上传失败: [%d] %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return b.presign(&u, now), nil
+}
+
+// presign 生成对象的预签名 GET URL（AWS Signature V4 查询参数签名），有效期为 URLExpiry
+func (b *s3Backend) presign(u *url.URL, now time.Time) string {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	scope := date + "/" + b.opts.Region + "/s3/aws4_request"
+
+	query := url.Values{}
+	query.Set("X-Amz-Algorithm", "AWS4-HMAC-SHA256")
+	query.Set("X-Amz-Credential", b.opts.AccessKey+"/"+scope)
+	query.Set("X-Amz-Date", amzDate)
+	query.Set("X-Amz-Expires", strconv.Itoa(int(b.opts.URLExpiry/time.Second)))
+	query.Set("X-Amz-SignedHeaders", "host")
+	// url.Values.Encode 按键排序，空格编码为 +，SigV4 要求 %20
+	canonicalQuery := strings.ReplaceAll(query.Encode(), "+", "%20")
+
+	canonicalRequest := strings.Join([]string{
+		http.MethodGet,
+		s3EscapePath(u.Path),
+		canonicalQuery,
+		"host:" + u.Host + "\n",
+		"host",
+		"UNSIGNED-PAYLOAD",
+	}, "\n")
+	signature := b.signature(date, strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n"))
+
+	signed := *u
+	signed.RawQuery = canonicalQuery + "&X-Amz-Signature=" + signature
+	return signed.String()
+}
+
+// sign 按 AWS Signature V4 签名请求
+func (b *s3Backend) sign(req *http.Request, payload []byte, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256Hex(payload)
+
+	req.Header.Set("Host", req.URL.Host)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalHeaders := "host:" + req.URL.Host + "\n" +
+		"x-amz-content-sha256:" + payloadHash + "\n" +
+		"x-amz-date:" + amzDate + "\n"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		s3EscapePath(req.URL.Path),
+		req.URL.RawQuery,
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + b.opts.Region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		b.opts.AccessKey, scope, signedHeaders, b.signature(date, stringToSign)))
+}
+
+// signature 用当天的签名密钥计算签名
+func (b *s3Backend) signature(date, stringToSign string) string {
+	key := hmacSHA256([]byte("AWS4"+b.opts.SecretKey), date)
+	key = hmacSHA256(key, b.opts.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+// s3EscapePath 按 RFC 3986 分别编码路径的每一段（SigV4 要求除 A-Z a-z 0-9 - _ . ~ 外的字符都编码）
+func s3EscapePath(p string) string {
+	segments := strings.Split(p, "/")
+	for i, seg := range segments {
+		var b strings.Builder
+		for j := 0; j < len(seg); j++ {
+			c := seg[j]
+			if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-' || c == '_' || c == '.' || c == '~' {
+				b.WriteByte(c)
+			} else {
+				fmt.Fprintf(&b, "%%%02X", c)
+			}
+		}
+		segments[i] = b.String()
+	}
+	return strings.Join(segments, "/")
+}
+
+// sha256Hex 计算 SHA-256 十六进制摘要
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// hmacSHA256 计算 HMAC-SHA256
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
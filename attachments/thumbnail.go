@@ -0,0 +1,145 @@
+package attachments
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif" // 注册 GIF 解码
+	"image/jpeg"
+	_ "image/png" // 注册 PNG 解码
+	"path"
+	"strings"
+
+	"mail-receiver/imap"
+)
+
+// DefaultThumbnailSize 缩略图默认最长边（像素）
+const DefaultThumbnailSize = 320
+
+// maxImagePixels 生成缩略图的图片最大像素数，防止解码超大图片占用过多内存
+const maxImagePixels = 50 * 1000 * 1000
+
+// SetThumbnail 启用缩略图，size 为最长边像素，小于等于0时使用默认值
+func (s *Saver) SetThumbnail(size int) {
+	if size <= 0 {
+		size = DefaultThumbnailSize
+	}
+	s.thumbSize = size
+}
+
+// SaveThumbnail 为邮件中第一张可解码的图片附件生成 JPEG 缩略图并保存，返回保存位置
+// 未启用缩略图或没有图片附件时返回空字符串
+func (s *Saver) SaveThumbnail(account string, email *imap.EmailMessage) (string, error) {
+	if s.thumbSize == 0 {
+		return "", nil
+	}
+	for _, att := range email.Attachments {
+		if !isImage(att) || att.Data == nil || (s.maxSize > 0 && att.Size > s.maxSize) {
+			continue
+		}
+		data, err := Thumbnail(att.Data, s.thumbSize)
+		if err != nil {
+			// 无法解码（如 HEIC、WebP）时尝试下一张
+			continue
+		}
+
+		name := sanitize(att.Filename)
+		name = strings.TrimSuffix(name, path.Ext(name))
+		if name == "" {
+			name = "image"
+		}
+		key := path.Join(sanitize(account), email.Date.Format("2006-01-02"), messageDir(email), "thumb-"+name+".jpg")
+		put := s.backend.Put
+		if pb, ok := s.backend.(previewBackend); ok {
+			put = pb.PutPreview
+		}
+		location, err := put(key, data)
+		if err != nil {
+			return "", fmt.Errorf("保存缩略图失败: %w", err)
+		}
+		return location, nil
+	}
+	return "", nil
+}
+
+// Thumbnail 将图片（JPEG/PNG/GIF）等比缩小到最长边不超过 size 像素，编码为 JPEG
+func Thumbnail(data []byte, size int) ([]byte, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("解析图片失败: %w", err)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > maxImagePixels {
+		return nil, fmt.Errorf("图片尺寸不支持: %dx%d", cfg.Width, cfg.Height)
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("解码图片失败: %w", err)
+	}
+
+	w, h := cfg.Width, cfg.Height
+	if w > size || h > size {
+		if w >= h {
+			h = max(1, h*size/w)
+			w = size
+		} else {
+			w = max(1, w*size/h)
+			h = size
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, resize(src, w, h), &jpeg.Options{Quality: 80}); err != nil {
+		return nil, fmt.Errorf("编码缩略图失败: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// resize 按区域平均缩小图片，透明部分以白色背景合成
+func resize(src image.Image, w, h int) *image.RGBA {
+	b := src.Bounds()
+	sw, sh := b.Dx(), b.Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+
+	for y := 0; y < h; y++ {
+		y0 := b.Min.Y + y*sh/h
+		y1 := max(y0+1, b.Min.Y+(y+1)*sh/h)
+		for x := 0; x < w; x++ {
+			x0 := b.Min.X + x*sw/w
+			x1 := max(x0+1, b.Min.X+(x+1)*sw/w)
+
+			var r, g, bl, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					// 预乘 alpha 的颜色叠加到白色背景
+					white := 0xffff - uint64(ca)
+					r += uint64(cr) + white
+					g += uint64(cg) + white
+					bl += uint64(cb) + white
+					n++
+				}
+			}
+			dst.SetRGBA(x, y, color.RGBA{
+				R: uint8(r / n >> 8),
+				G: uint8(g / n >> 8),
+				B: uint8(bl / n >> 8),
+				A: 0xff,
+			})
+		}
+	}
+	return dst
+}
+
+// isImage 根据内容类型或扩展名判断是否为图片附件
+func isImage(att *imap.Attachment) bool {
+	if strings.HasPrefix(strings.ToLower(att.ContentType), "image/") {
+		return true
+	}
+	switch strings.ToLower(path.Ext(att.Filename)) {
+	case ".jpg", ".jpeg", ".png", ".gif":
+		return true
+	}
+	return false
+}
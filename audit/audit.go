@@ -0,0 +1,84 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// 操作类型
+const (
+	ActionMarkRead   = "mark_read"
+	ActionAddFlag    = "add_flag"
+	ActionRemoveFlag = "remove_flag"
+	ActionMove       = "move"
+	ActionDelete     = "delete"
+	ActionReply      = "reply"
+	ActionFlagChange = "flag_change" // 其他客户端修改了已推送邮件的标志或删除了邮件
+)
+
+// Entry 审计日志条目
+type Entry struct {
+	Time      time.Time `json:"time"`
+	Account   string    `json:"account"`
+	Folder    string    `json:"folder"`
+	UID       uint32    `json:"uid"`
+	MessageID string    `json:"message_id,omitempty"`
+	Action    string    `json:"action"`
+	Detail    string    `json:"detail,omitempty"` // 如标志名称、目标文件夹
+	Trigger   string    `json:"trigger"`          // 触发原因，如 push_success、rule:xxx
+	Error     string    `json:"error,omitempty"`  // 操作失败时的错误
+}
+
+// Logger 追加式审计日志（JSON Lines），记录所有修改邮箱的操作
+type Logger struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// Open 打开审计日志文件（只追加写入）
+func Open(path string) (*Logger, error) {
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("创建审计日志目录失败: %w", err)
+		}
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("打开审计日志失败: %w", err)
+	}
+	return &Logger{file: file}, nil
+}
+
+// Log 写入一条审计记录，Logger 为nil时忽略
+func (l *Logger) Log(e Entry) error {
+	if l == nil {
+		return nil
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("序列化审计记录失败: %w", err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, err := l.file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("写入审计日志失败: %w", err)
+	}
+	return nil
+}
+
+// Close 关闭审计日志
+func (l *Logger) Close() error {
+	if l == nil {
+		return nil
+	}
+	return l.file.Close()
+}
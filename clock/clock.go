@@ -0,0 +1,208 @@
+package clock
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock 时间来源：心跳、轮询、重试退避、合并/摘要窗口等周期性行为通过它获取当前时间和等待，
+// 模拟运行或测试时替换为 Fake 即可快进时间
+type Clock interface {
+	Now() time.Time
+	Since(t time.Time) time.Duration
+	Sleep(d time.Duration)
+	NewTimer(d time.Duration) Timer
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// Timer 定时器，AfterFunc 创建的定时器 C 返回nil
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+	Reset(d time.Duration) bool
+}
+
+// Real 使用系统时间
+var Real Clock = realClock{}
+
+// Or 返回 c，c 为nil时返回 Real
+func Or(c Clock) Clock {
+	if c == nil {
+		return Real
+	}
+	return c
+}
+
+// SleepContext 按 c 等待 d，ctx 取消时提前返回 ctx.Err()
+func SleepContext(ctx context.Context, c Clock, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := Or(c).NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C():
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                  { return time.Now() }
+func (realClock) Since(t time.Time) time.Duration { return time.Since(t) }
+func (realClock) Sleep(d time.Duration)           { time.Sleep(d) }
+
+func (realClock) NewTimer(d time.Duration) Timer {
+	return realTimer{time.NewTimer(d)}
+}
+
+func (realClock) AfterFunc(d time.Duration, f func()) Timer {
+	return realTimer{time.AfterFunc(d, f)}
+}
+
+type realTimer struct{ t *time.Timer }
+
+func (t realTimer) C() <-chan time.Time        { return t.t.C }
+func (t realTimer) Stop() bool                 { return t.t.Stop() }
+func (t realTimer) Reset(d time.Duration) bool { return t.t.Reset(d) }
+
+// Fake 手动推进的时钟：只有调用 Advance / Set 时时间才会前进，到期的定时器随之触发
+type Fake struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+// NewFake 创建从 start 开始的手动时钟
+func NewFake(start time.Time) *Fake {
+	return &Fake{now: start}
+}
+
+// Now 实现 Clock
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// Since 实现 Clock
+func (f *Fake) Since(t time.Time) time.Duration {
+	return f.Now().Sub(t)
+}
+
+// Sleep 实现 Clock：阻塞直到时钟被推进 d
+func (f *Fake) Sleep(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	<-f.NewTimer(d).C()
+}
+
+// NewTimer 实现 Clock
+func (f *Fake) NewTimer(d time.Duration) Timer {
+	t := &fakeTimer{clock: f, ch: make(chan time.Time, 1)}
+	t.Reset(d)
+	return t
+}
+
+// AfterFunc 实现 Clock：到期时在新的协程中调用 fn
+func (f *Fake) AfterFunc(d time.Duration, fn func()) Timer {
+	t := &fakeTimer{clock: f, fn: fn}
+	t.Reset(d)
+	return t
+}
+
+// Advance 将时钟推进 d，依次触发期间到期的定时器
+func (f *Fake) Advance(d time.Duration) {
+	f.Set(f.Now().Add(d))
+}
+
+// Set 将时钟设为 t（不能倒退），依次触发到期的定时器
+func (f *Fake) Set(t time.Time) {
+	f.mu.Lock()
+	if t.Before(f.now) {
+		f.mu.Unlock()
+		return
+	}
+	f.now = t
+	var due []*fakeTimer
+	rest := f.timers[:0]
+	for _, ft := range f.timers {
+		if ft.when.After(t) {
+			rest = append(rest, ft)
+		} else {
+			due = append(due, ft)
+		}
+	}
+	f.timers = rest
+	f.mu.Unlock()
+
+	sort.Slice(due, func(i, j int) bool { return due[i].when.Before(due[j].when) })
+	for _, ft := range due {
+		ft.fire()
+	}
+}
+
+// Waiting 返回尚未到期的定时器数，测试中用于确认被测代码已开始等待再推进时间
+func (f *Fake) Waiting() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.timers)
+}
+
+// remove 取消定时器，返回定时器是否仍在等待
+func (f *Fake) remove(t *fakeTimer) bool {
+	for i, ft := range f.timers {
+		if ft == t {
+			f.timers = append(f.timers[:i], f.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+type fakeTimer struct {
+	clock *Fake
+	when  time.Time
+	ch    chan time.Time
+	fn    func()
+}
+
+func (t *fakeTimer) C() <-chan time.Time { return t.ch }
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	return t.clock.remove(t)
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	f := t.clock
+	f.mu.Lock()
+	active := f.remove(t)
+	t.when = f.now.Add(d)
+	if d > 0 {
+		f.timers = append(f.timers, t)
+		f.mu.Unlock()
+		return active
+	}
+	f.mu.Unlock()
+	t.fire()
+	return active
+}
+
+// fire 定时器到期：发送到通道（不阻塞）或调用函数
+func (t *fakeTimer) fire() {
+	if t.fn != nil {
+		go t.fn()
+		return
+	}
+	select {
+	case t.ch <- t.when:
+	default:
+	}
+}
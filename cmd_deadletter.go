@@ -0,0 +1,93 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+
+	"mail-receiver/config"
+	"mail-receiver/push"
+	"mail-receiver/receiver"
+)
+
+// runDeadLetter 执行 dead-letter 子命令：列出重试多次仍失败的推送，或按当前配置重新推送
+// 重新推送后文件只保留仍然失败的记录，建议在服务停止时执行
+func runDeadLetter(args []string) error {
+	fs := flag.NewFlagSet("dead-letter", flag.ExitOnError)
+	configPath := fs.String("config", "config.json", "配置文件路径")
+	file := fs.String("file", "", "推送失败记录文件（默认使用配置中的 app.dead_letter）")
+	account := fs.String("account", "", "只处理指定账号")
+	replay := fs.Bool("replay", false, "重新推送，成功的记录从文件中删除")
+	fs.Parse(args)
+
+	cfg, err := config.LoadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+	path := *file
+	if path == "" {
+		path = cfg.App.DeadLetter
+	}
+	if path == "" {
+		return fmt.Errorf("未配置推送失败记录文件，请使用 -file 指定或在配置中设置 app.dead_letter")
+	}
+
+	entries, err := push.ReadDeadLetters(path)
+	if err != nil {
+		return err
+	}
+
+	if !*replay {
+		count := 0
+		for _, e := range entries {
+			if *account != "" && e.Account != *account {
+				continue
+			}
+			count++
+			fmt.Printf("%s  %s  %s  %s\n", e.Time.Local().Format("2006-01-02 15:04"), e.Account, e.Target, e.Title)
+			fmt.Printf("    尝试 %d 次，最后错误: %s\n", e.Attempts, e.Error)
+		}
+		log.Printf("共 %d 条推送失败记录", count)
+		return nil
+	}
+
+	// 同一账号和推送目标只创建一次推送器
+	pushers := make(map[string]push.Pusher)
+	var remaining []*push.DeadLetterEntry
+	pushed, failed := 0, 0
+	for _, e := range entries {
+		if *account != "" && e.Account != *account {
+			remaining = append(remaining, e)
+			continue
+		}
+		key := e.Account + "\x00" + e.Target
+		p, ok := pushers[key]
+		if !ok {
+			p, err = receiver.TargetPusher(cfg, e.Account, e.Target)
+			if err != nil {
+				log.Printf("[%s] 创建推送目标 %s 失败: %v", e.Account, e.Target, err)
+			}
+			pushers[key] = p
+		}
+		if p == nil {
+			remaining = append(remaining, e)
+			failed++
+			continue
+		}
+		if err := p.Push(e.Title, e.Message, e.Meta()); err != nil {
+			log.Printf("[%s] 重新推送失败 (%s): %v", e.Account, e.Title, err)
+			e.Attempts++
+			e.Error = err.Error()
+			remaining = append(remaining, e)
+			failed++
+			continue
+		}
+		pushed++
+	}
+
+	if err := push.WriteDeadLetters(path, remaining); err != nil {
+		return err
+	}
+	log.Printf("已重新推送 %d 条，%d 条仍然失败", pushed, failed)
+	return nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"mail-receiver/config"
+	"mail-receiver/imap"
+	"mail-receiver/receiver"
+)
+
+// runFolders 执行 folders 子命令：连接账号并列出文件夹树，便于确定 folders 配置中使用的名称
+func runFolders(args []string) error {
+	fs := flag.NewFlagSet("folders", flag.ExitOnError)
+	configPath := fs.String("config", "config.json", "配置文件路径")
+	account := fs.String("account", "", "账号名称（只有一个账号时可省略）")
+	withStatus := fs.Bool("status", false, "同时查询每个文件夹的邮件数和未读数")
+	format := fs.String("format", "tree", "输出格式: tree/json")
+	fs.Parse(args)
+
+	cfg, err := config.LoadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+	name := *account
+	if name == "" {
+		if len(cfg.Accounts) != 1 {
+			names := make([]string, 0, len(cfg.Accounts))
+			for n := range cfg.Accounts {
+				names = append(names, n)
+			}
+			sort.Strings(names)
+			return fmt.Errorf("请使用 -account 指定账号: %s", strings.Join(names, ", "))
+		}
+		for n := range cfg.Accounts {
+			name = n
+		}
+	}
+
+	folders, err := receiver.ListFolders(cfg, name, *withStatus)
+	if err != nil {
+		return err
+	}
+
+	switch *format {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(folders)
+	case "tree":
+		printFolders(folders, 0)
+		return nil
+	default:
+		return fmt.Errorf("不支持的输出格式: %s (可选: tree/json)", *format)
+	}
+}
+
+// printFolders 按层级缩进输出文件夹，行首为配置中使用的完整名称
+func printFolders(folders []*imap.Folder, depth int) {
+	for _, f := range folders {
+		line := strings.Repeat("  ", depth) + f.Name
+		if f.Status != nil {
+			line += fmt.Sprintf("  (%d 封，%d 未读)", f.Status.Messages, f.Status.Unseen)
+		}
+		if len(f.Attributes) > 0 {
+			line += "  " + strings.Join(f.Attributes, " ")
+		}
+		fmt.Println(line)
+		printFolders(f.Children, depth+1)
+	}
+}
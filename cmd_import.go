@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"mail-receiver/config"
+	"mail-receiver/importer"
+)
+
+// runImport 执行 import 子命令：从 Thunderbird 配置或 CSV 批量生成账号配置
+// 结果为可被 include 加载的配置文件，默认输出到标准输出
+func runImport(args []string) error {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	from := fs.String("from", importer.SourceCSV, "导入来源: csv/thunderbird")
+	input := fs.String("input", "", "输入文件（CSV 文件，或 Thunderbird 配置目录，留空时自动查找），CSV 留空时读取标准输入")
+	output := fs.String("output", "", "输出文件（不能已存在），默认输出到标准输出")
+	fs.Parse(args)
+
+	var (
+		result *importer.Result
+		err    error
+	)
+	switch *from {
+	case importer.SourceCSV:
+		var r io.Reader = os.Stdin
+		if *input != "" {
+			f, err := os.Open(*input)
+			if err != nil {
+				return fmt.Errorf("打开输入文件失败: %w", err)
+			}
+			defer f.Close()
+			r = f
+		}
+		result, err = importer.FromCSV(r)
+	case importer.SourceThunderbird:
+		result, err = importer.FromThunderbird(*input)
+	default:
+		return fmt.Errorf("不支持的导入来源: %s (可选: csv/thunderbird)", *from)
+	}
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(map[string]interface{}{
+		"version":  config.CurrentVersion,
+		"accounts": result.Accounts,
+	}, "", "    ")
+	if err != nil {
+		return fmt.Errorf("序列化配置失败: %w", err)
+	}
+	data = append(data, '\n')
+
+	for _, w := range result.Warnings {
+		log.Printf("注意: %s", w)
+	}
+	if *output == "" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	// 文件中包含密码，只允许当前用户读写
+	f, err := os.OpenFile(*output, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return fmt.Errorf("创建输出文件失败: %w", err)
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return fmt.Errorf("写入输出文件失败: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("写入输出文件失败: %w", err)
+	}
+	log.Printf("已导入 %d 个账号到 %s，将其加入主配置的 include 后生效", len(result.Accounts), *output)
+	return nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"mail-receiver/config"
+)
+
+// runMigrate 执行 migrate 子命令：将旧版本配置文件升级到当前格式并写回
+func runMigrate(args []string) error {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	configPath := fs.String("config", "config.json", "配置文件路径")
+	dryRun := fs.Bool("dry-run", false, "只输出升级后的配置，不写回文件")
+	fs.Parse(args)
+
+	data, err := os.ReadFile(*configPath)
+	if err != nil {
+		return fmt.Errorf("打开配置文件失败: %w", err)
+	}
+
+	migrated, version, err := config.Migrate(data)
+	if err != nil {
+		return err
+	}
+	if version == config.CurrentVersion {
+		log.Printf("配置文件已是最新版本 (%d)", version)
+		return nil
+	}
+
+	if *dryRun {
+		_, err := os.Stdout.Write(migrated)
+		return err
+	}
+
+	// 写回前保留原文件备份
+	backup := *configPath + ".bak"
+	if err := os.WriteFile(backup, data, 0o600); err != nil {
+		return fmt.Errorf("备份配置文件失败: %w", err)
+	}
+	tmp := *configPath + ".tmp"
+	if err := os.WriteFile(tmp, migrated, 0o600); err != nil {
+		return fmt.Errorf("写入配置文件失败: %w", err)
+	}
+	if err := os.Rename(tmp, *configPath); err != nil {
+		return fmt.Errorf("写入配置文件失败: %w", err)
+	}
+
+	log.Printf("配置文件已从版本 %d 升级到 %d，原文件备份为 %s", version, config.CurrentVersion, backup)
+	return nil
+}
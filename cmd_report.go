@@ -0,0 +1,89 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"mail-receiver/config"
+	"mail-receiver/report"
+	"mail-receiver/secure"
+	"mail-receiver/storage"
+)
+
+// runReport 执行 report 子命令：从存储生成已处理邮件统计报表
+func runReport(args []string) error {
+	fs := flag.NewFlagSet("report", flag.ExitOnError)
+	configPath := fs.String("config", "config.json", "配置文件路径")
+	from := fs.String("from", "", "起始日期（YYYY-MM-DD，含当天）")
+	to := fs.String("to", "", "结束日期（YYYY-MM-DD，不含当天）")
+	groupBy := fs.String("group-by", report.GroupBySender, "分组方式: sender/day/account")
+	format := fs.String("format", report.FormatCSV, "输出格式: csv/json")
+	output := fs.String("output", "", "输出文件，默认输出到标准输出")
+	fs.Parse(args)
+
+	cfg, err := config.LoadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+	if cfg.App.Storage.Path == "" {
+		return fmt.Errorf("未配置存储 (app.storage)")
+	}
+
+	fromTime, err := parseDate(*from)
+	if err != nil {
+		return err
+	}
+	toTime, err := parseDate(*to)
+	if err != nil {
+		return err
+	}
+
+	var cipher *secure.Cipher
+	if ec := cfg.App.Encryption; ec.Enabled {
+		if cipher, err = secure.Load(ec.KeyEnv, ec.KeyFile); err != nil {
+			return err
+		}
+	}
+
+	store, err := storage.Open(cfg.App.Storage.Type, cfg.App.Storage.Path, cipher)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	records, err := store.Query(fromTime, toTime)
+	if err != nil {
+		return err
+	}
+
+	rows, err := report.Build(records, *groupBy)
+	if err != nil {
+		return err
+	}
+
+	w := os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return fmt.Errorf("创建输出文件失败: %w", err)
+		}
+		defer file.Close()
+		w = file
+	}
+
+	return report.Write(w, rows, *groupBy, *format)
+}
+
+// parseDate 解析 YYYY-MM-DD 格式日期（本地时区），空字符串返回零值
+func parseDate(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", s, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("日期格式无效 (%s)，应为 YYYY-MM-DD", s)
+	}
+	return t, nil
+}
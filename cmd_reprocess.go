@@ -0,0 +1,175 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"mail-receiver/config"
+	"mail-receiver/receiver"
+	"mail-receiver/secure"
+	"mail-receiver/storage"
+)
+
+// runReprocess 执行 reprocess 子命令：按当前规则重新评估存储中的邮件，列出处理方式的变化，不连接 IMAP 服务器
+func runReprocess(args []string) error {
+	fs := flag.NewFlagSet("reprocess", flag.ExitOnError)
+	configPath := fs.String("config", "config.json", "配置文件路径")
+	since := fs.String("since", "7d", "只处理最近一段时间内处理过的邮件（如 7d、12h）或起始日期（YYYY-MM-DD）")
+	account := fs.String("account", "", "只处理指定账号")
+	all := fs.Bool("all", false, "列出所有邮件，而不只是处理方式变化的邮件")
+	doPush := fs.Bool("push", false, "重新推送按当前规则应推送、但之前未推送的邮件")
+	dryRun := fs.Bool("dry-run", false, "只列出结果，不推送（默认行为，与 --push 同时使用时以此为准）")
+	fs.Parse(args)
+
+	cfg, err := config.LoadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+	if cfg.App.Storage.Path == "" {
+		return fmt.Errorf("未配置存储 (app.storage)")
+	}
+	from, err := parseSince(*since)
+	if err != nil {
+		return err
+	}
+
+	var cipher *secure.Cipher
+	if ec := cfg.App.Encryption; ec.Enabled {
+		if cipher, err = secure.Load(ec.KeyEnv, ec.KeyFile); err != nil {
+			return err
+		}
+	}
+	store, err := storage.Open(cfg.App.Storage.Type, cfg.App.Storage.Path, cipher)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	records, err := store.Query(from, time.Time{})
+	if err != nil {
+		return err
+	}
+	if cfg.App.Storage.Type != storage.TypeSQLite {
+		log.Printf("注意: JSON Lines 存储不保存正文，按正文匹配的规则和分类标签可能与实际不同")
+	}
+
+	rp, err := receiver.NewReplayer(cfg, nil)
+	if err != nil {
+		return err
+	}
+
+	checked, changed, pushed := 0, 0, 0
+	for _, rec := range records {
+		if *account != "" && rec.Account != *account {
+			continue
+		}
+		res, err := rp.Evaluate(rec)
+		if err != nil {
+			log.Printf("跳过 %s: %v", rec.Subject, err)
+			continue
+		}
+		checked++
+		if res.Changed {
+			changed++
+		}
+		if !res.Changed && !*all {
+			continue
+		}
+		printReplay(res)
+
+		if *doPush && !*dryRun && res.Push && !rec.Pushed {
+			if err := rp.Push(res); err != nil {
+				log.Printf("重新推送失败 (%s): %v", rec.Subject, err)
+				continue
+			}
+			pushed++
+		}
+	}
+
+	log.Printf("共检查 %d 封邮件，%d 封处理方式变化", checked, changed)
+	if *doPush && !*dryRun {
+		log.Printf("已重新推送 %d 封邮件", pushed)
+	}
+	return nil
+}
+
+// printReplay 输出一封邮件之前和现在的处理方式
+func printReplay(res *receiver.ReplayResult) {
+	rec := res.Record
+	fmt.Printf("%s  %s/%s  %s  %s\n", rec.ProcessedAt.Local().Format("2006-01-02 15:04"), rec.Account, rec.Folder, rec.From, rec.Subject)
+
+	before := "未推送"
+	if rec.Pushed {
+		before = "已推送"
+	}
+	now := "不推送"
+	if res.Push {
+		now = "推送"
+	}
+	if res.Rule != "" {
+		now += "（规则 " + res.Rule + "）"
+	}
+	if res.MarkRead {
+		now += "，标记已读"
+	}
+	if res.MoveTo != "" {
+		now += "，移动到 " + res.MoveTo
+	}
+	line := fmt.Sprintf("    之前: %s  现在: %s", before, now)
+	if diff := tagDiff(rec.Tags, res.Tags); diff != "" {
+		line += "  标签: " + diff
+	}
+	fmt.Println(line)
+}
+
+// tagDiff 标签变化，如 "+invoice -promo"
+func tagDiff(old, cur []string) string {
+	in := func(list []string, s string) bool {
+		for _, v := range list {
+			if v == s {
+				return true
+			}
+		}
+		return false
+	}
+	var parts []string
+	for _, t := range cur {
+		if !in(old, t) {
+			parts = append(parts, "+"+t)
+		}
+	}
+	for _, t := range old {
+		if !in(cur, t) {
+			parts = append(parts, "-"+t)
+		}
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, " ")
+}
+
+// parseSince 解析相对时间（如 7d、12h、30m）或日期（YYYY-MM-DD），空字符串表示不限
+func parseSince(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if strings.HasSuffix(s, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(s, "d"))
+		if err != nil || days < 0 {
+			return time.Time{}, fmt.Errorf("时间范围无效 (%s)，应为 7d、12h 或 YYYY-MM-DD", s)
+		}
+		return time.Now().AddDate(0, 0, -days), nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	t, err := parseDate(s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("时间范围无效 (%s)，应为 7d、12h 或 YYYY-MM-DD", s)
+	}
+	return t, nil
+}
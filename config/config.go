@@ -3,51 +3,534 @@ package config
 import (
 	"encoding/json"
 	"fmt"
-	"os"
+	"strings"
+	"time"
 )
 
 // Config 应用配置
 type Config struct {
+	Version  int                       `json:"version"` // 配置格式版本，旧版本加载时自动升级
+	Include  []string                  `json:"include"` // 拆分的配置文件（通配符，相对主配置文件目录）
 	Accounts map[string]*AccountConfig `json:"accounts"`
 	App      AppConfig                 `json:"app"`
+	Contacts map[string]ContactConfig  `json:"contacts"` // 联系人（邮箱地址 → 联系人信息）
+	Tagging  TaggingConfig             `json:"tagging"`
+
+	Files []string `json:"-"` // 实际加载的配置文件（主配置文件及 include 匹配到的文件）
+}
+
+// TaggingConfig 邮件分类标签配置
+type TaggingConfig struct {
+	DisableBuiltin bool            `json:"disable_builtin"` // 禁用内置规则（invoice/otp/alert/newsletter）
+	Rules          []TagRuleConfig `json:"rules"`
+}
+
+// SignatureConfig 推送正文去除签名配置
+type SignatureConfig struct {
+	Enabled        bool     `json:"enabled"`
+	DisableBuiltin bool     `json:"disable_builtin"` // 禁用内置规则（"-- "、"发自我的iPhone"、免责声明等）
+	Patterns       []string `json:"patterns"`        // 自定义签名起始行正则
+}
+
+// OTPConfig 验证码提取配置：从邮件中提取验证码放入推送标题
+type OTPConfig struct {
+	Enabled        bool     `json:"enabled"`
+	DisableBuiltin bool     `json:"disable_builtin"` // 禁用内置规则（"验证码：123456"、"code is 123456" 等）
+	Patterns       []string `json:"patterns"`        // 自定义提取正则，第一个分组为验证码
+	Title          string   `json:"title"`           // 推送标题模板，默认 "验证码 {{.Code}} | {{.Subject}}"
+	HideBody       bool     `json:"hide_body"`       // 提取到验证码时推送内容不含正文
+}
+
+// AuthCheckConfig 发件人真实性检查：验证 DKIM 签名，读取收件服务器记录的 SPF/DMARC 结果（Authentication-Results）
+type AuthCheckConfig struct {
+	Enabled    bool   `json:"enabled"`
+	AuthServID string `json:"authserv_id"` // 可选，只信任该服务器添加的 Authentication-Results（如 mx.google.com），未配置时不读取
+	Timeout    int    `json:"timeout"`     // DNS 查询超时（秒），默认 5
+}
+
+// SMIMEConfig S/MIME：用本账号的证书解密加密邮件，验证签名邮件的签名
+type SMIMEConfig struct {
+	Enabled  bool   `json:"enabled"`
+	PKCS12   string `json:"pkcs12"`   // 可选，本账号的证书和私钥（PKCS#12 文件），未配置时只验证签名
+	Password string `json:"password"` // PKCS#12 文件的密码
+	CAFile   string `json:"ca_file"`  // 可选，验证签名时额外信任的根证书（PEM），默认只信任系统根证书
+}
+
+// TagRuleConfig 标签规则：任一关键字命中或正则匹配即打上标签
+type TagRuleConfig struct {
+	Tag      string   `json:"tag"`
+	Keywords []string `json:"keywords"`
+	Pattern  string   `json:"pattern"`
+	Fields   []string `json:"fields"` // subject/from/body，默认 subject+body
+}
+
+// ContactConfig 联系人配置
+type ContactConfig struct {
+	Name     string `json:"name"`
+	Category string `json:"category"`
+	Priority int    `json:"priority"`
 }
 
 // AccountConfig 邮箱账号配置
 type AccountConfig struct {
-	Server       string   `json:"server"`
-	Port         int      `json:"port"`
-	Username     string   `json:"username"`
-	Password     string   `json:"password"`
-	PollInterval int      `json:"pollinterval"`
-	SendPush     string   `json:"sendpush"`
-	Folders      []string `json:"folders"`
-	IdleTimeout  int      `json:"idletimeout"`
+	Server       string    `json:"server"`
+	Port         int       `json:"port"`
+	Username     string    `json:"username"`
+	Password     string    `json:"password"`
+	AuthType     string    `json:"auth_type"` // password（默认）/ xoauth2
+	Security     string    `json:"security"`  // tls（默认）/ starttls / none
+	TLS          TLSConfig `json:"tls"`
+	PollInterval int       `json:"pollinterval"`
+	Folders      []string  `json:"folders"`
+	IdleTimeout  int       `json:"idletimeout"`
+
+	Push              PushConfig           `json:"push"`
+	PushTargets       []PushConfig         `json:"push_targets"` // 其他推送目标，与 push 并行推送
+	OAuth2            OAuth2Config         `json:"oauth2"`
+	AdaptivePoll      AdaptivePollConfig   `json:"adaptive_poll"`
+	Retry             RetryConfig          `json:"retry"`
+	Rules             []RuleConfig         `json:"rules"` // 过滤规则，按顺序匹配，第一条命中的规则生效
+	SLA               SLAConfig            `json:"sla"`
+	AttachmentOnly    AttachmentOnlyConfig `json:"attachment_only"`
+	StrictDelivery    bool                 `json:"strict_delivery"` // 严格投递：推送确认后才标记已读，启动时对账
+	ReadOnly          bool                 `json:"read_only"`       // 只读模式：从不修改邮箱（不标记已读）
+	TrimQuotes        bool                 `json:"trim_quotes"`     // 推送正文去掉引用的原邮件，只保留新内容
+	TrimSignature     SignatureConfig      `json:"trim_signature"`
+	OTP               OTPConfig            `json:"otp"`                 // 提取验证码放入推送标题
+	AuthCheck         AuthCheckConfig      `json:"auth_check"`          // 发件人真实性检查（DKIM/SPF/DMARC）
+	SMIME             SMIMEConfig          `json:"smime"`               // S/MIME 解密和签名验证
+	MaxConnections    int                  `json:"max_connections"`     // 同一用户（服务器+用户名）同时打开的连接数上限，0 不限制
+	PushServerNotices bool                 `json:"push_server_notices"` // 推送服务器主动发送的 ALERT/BYE 提示（如停机维护）
+	Translate         TranslateConfig      `json:"translate"`
+	Summarize         SummarizeConfig      `json:"summarize"`
+	Collapse          CollapseConfig       `json:"collapse"`
+	Digest            DigestConfig         `json:"digest"`
+	QuietHours        QuietHoursConfig     `json:"quiet_hours"`
+	SMTP              SMTPConfig           `json:"smtp"` // 自动回复使用的发信服务器
+	ParseFailure      ParseFailureConfig   `json:"parse_failure"`
+	FlagChanges       FlagChangesConfig    `json:"flag_changes"`
+	TextExtraction    TextExtractionConfig `json:"text_extraction"`
+}
+
+// TextExtractionConfig HTML 正文转纯文本的调整（推送正文和规则匹配使用）
+type TextExtractionConfig struct {
+	TableCells bool              `json:"table_cells"` // 表格每行输出为一行："键: 值"（两列）或用 " | " 分隔
+	BlockTags  []string          `json:"block_tags"`  // 替换为换行的标签，留空使用默认（br/p/div/tr/li）
+	Entities   map[string]string `json:"entities"`    // 额外的HTML实体映射（如 "&yen;": "¥"）
+}
+
+// 邮件解析失败时的处理方式
+const (
+	ParseFailureEnvelope   = "envelope"   // 推送信封信息（主题、发件人、时间）和已解析的部分正文
+	ParseFailureQuarantine = "quarantine" // 不推送，原始邮件保存到隔离目录
+	ParseFailureFlag       = "flag"       // 不推送，在邮箱中加星标
+)
+
+// ParseFailureConfig 邮件正文无法解析时的处理方式，处理后标记为已读，避免反复获取
+type ParseFailureConfig struct {
+	Action        string `json:"action"`         // envelope（默认）/ quarantine / flag
+	QuarantineDir string `json:"quarantine_dir"` // quarantine 时保存原始邮件的目录
+}
+
+// 已推送邮件在服务器上状态变化时的处理方式
+const (
+	FlagChangeNotify = "notify" // 推送通知
+	FlagChangeRecord = "record" // 只记录日志和审计日志
+)
+
+// FlagChangesConfig 已推送邮件的状态变化（其他人已读、回复、加星标或删除），通过 IDLE 期间的未标记 FETCH/EXPUNGE 响应发现
+type FlagChangesConfig struct {
+	Action     string `json:"action"`      // notify / record，留空不启用
+	MaxTracked int    `json:"max_tracked"` // 每个文件夹跟踪的最近推送邮件数
+}
+
+// CollapseConfig 邮件风暴合并：短时间内收到大量邮件时合并为一条汇总推送
+type CollapseConfig struct {
+	Threshold   int `json:"threshold"`    // 时间窗口内推送超过多少封后开始合并，0 不启用
+	Window      int `json:"window"`       // 时间窗口（秒）
+	MaxSubjects int `json:"max_subjects"` // 汇总推送中列出的主题数
+}
+
+// DigestConfig 摘要推送：按固定时间窗口汇总邮件，一次推送列出所有主题和发件人，代替逐封推送
+type DigestConfig struct {
+	Window   int `json:"window"`    // 汇总时间窗口（秒），0 不启用
+	MaxItems int `json:"max_items"` // 摘要中列出的邮件数
+}
+
+// QuietHoursConfig 免打扰时段：期间暂存推送，结束时按推送目标合并为汇总推送；优先级为 urgent 的规则仍立即推送
+type QuietHoursConfig struct {
+	Start       string `json:"start"`        // 开始时间（HH:MM），留空不启用
+	End         string `json:"end"`          // 结束时间（HH:MM），早于开始时间时跨越午夜
+	Timezone    string `json:"timezone"`     // 时区（如 Asia/Shanghai），默认本地时区
+	MaxSubjects int    `json:"max_subjects"` // 汇总推送中列出的邮件数
+}
+
+// SMTPConfig 账号的发信服务器（规则的 reply 动作使用）
+type SMTPConfig struct {
+	Host     string `json:"host"`
+	Port     int    `json:"port"`     // 默认按加密方式：tls 465，starttls 587，none 25
+	Security string `json:"security"` // tls / starttls（默认）/ none
+	Username string `json:"username"` // 默认使用账号的 username
+	Password string `json:"password"` // 默认使用账号的 password
+	From     string `json:"from"`     // 发件人地址，默认为 username
+	FromName string `json:"from_name"`
+}
+
+// ReplyConfig 规则的自动回复（reply 动作）
+type ReplyConfig struct {
+	Subject  string `json:"subject"`  // 主题模板，默认 "Re: {{.Subject}}"
+	Body     string `json:"body"`     // 正文模板（text/template 语法）
+	Interval int    `json:"interval"` // 同一规则对同一发件人的最短回复间隔（秒）
+}
+
+// RuleConfig 邮件过滤规则
+type RuleConfig struct {
+	Name    string          `json:"name"`
+	Match   RuleMatchConfig `json:"match"`
+	Actions []string        `json:"actions"` // push / drop / mark_read / move
+	MoveTo  string          `json:"move_to"` // move 动作的目标文件夹
+	Push    *PushConfig     `json:"push"`    // 可选，命中时使用的其他推送目标
+	Targets []string        `json:"targets"` // 可选，命中时推送到的推送目标名称（账号及全局 push_targets 中的 name）
+	Reply   *ReplyConfig    `json:"reply"`   // reply 动作的回复内容
+
+	Title     string `json:"title"`      // 可选，推送标题模板（如 "[银行] {{.Subject}}"）
+	TitleExpr string `json:"title_expr"` // 可选，推送标题表达式，不能与 title 同时设置
+	Sound     string `json:"sound"`      // 可选，通知铃声（如 Bark 铃声名）
+	Priority  string `json:"priority"`   // 可选，通知优先级：low / normal / high / urgent
+}
+
+// RuleMatchConfig 规则匹配条件，所有已设置的条件都满足才算命中
+type RuleMatchConfig struct {
+	From          string   `json:"from"` // 正则，不区分大小写
+	To            string   `json:"to"`
+	Subject       string   `json:"subject"`
+	Body          string   `json:"body"`
+	HasAttachment *bool    `json:"has_attachment"`
+	MinSize       uint32   `json:"min_size"` // 字节
+	MaxSize       uint32   `json:"max_size"`
+	Tags          []string `json:"tags"`     // 含任一标签
+	Category      string   `json:"category"` // 发件人联系人分类
+	KnownContact  *bool    `json:"known_contact"`
+	DKIMPass      *bool    `json:"dkim_pass"` // DKIM 签名是否通过验证且与发件人域名一致，需要启用账号的 auth_check
+	Expr          string   `json:"expr"`      // 条件表达式，如 from endsWith "@bank.com" && body contains "验证码"
+}
+
+// AttachmentOnlyConfig 附件模式：只保存匹配的附件并推送保存位置，忽略邮件正文
+type AttachmentOnlyConfig struct {
+	Enabled bool   `json:"enabled"`
+	Pattern string `json:"pattern"` // 附件文件名正则（不区分大小写），留空匹配所有附件
+}
+
+// SLAConfig 推送延迟告警配置
+type SLAConfig struct {
+	MaxLatency    int `json:"max_latency"`    // 邮件发出到推送送达的最大延迟（秒），超过时告警，0 不启用
+	AlertInterval int `json:"alert_interval"` // 两次告警的最短间隔（秒）
+}
+
+// RetryConfig 连接失败重试策略（指数退避）
+type RetryConfig struct {
+	UnhealthyAfter int `json:"unhealthy_after"` // 连续失败多少次后标记为异常并告警
+	InitialDelay   int `json:"initial_delay"`   // 首次重试间隔（秒）
+	MaxDelay       int `json:"max_delay"`       // 最大重试间隔（秒）
+	MaxAttempts    int `json:"max_attempts"`    // 连续失败多少次后停止该账号，0 表示无限重试
+
+	AuthRetryInterval int `json:"auth_retry_interval"` // 登录凭据被拒绝后重新尝试登录的间隔（秒）
+}
+
+// SummarizeConfig 摘要配置（OpenAI 兼容接口，推送摘要代替全文）
+type SummarizeConfig struct {
+	Enabled       bool   `json:"enabled"`
+	URL           string `json:"url"` // chat/completions 接口地址
+	APIKey        string `json:"api_key"`
+	Model         string `json:"model"`
+	Prompt        string `json:"prompt"`          // 可选，自定义提示词
+	MaxTokens     int    `json:"max_tokens"`      // 摘要最大输出 token 数
+	MaxInputChars int    `json:"max_input_chars"` // 发送给模型的正文最大字符数
+}
+
+// TranslateConfig 机器翻译配置（非中文邮件在推送前翻译）
+type TranslateConfig struct {
+	Enabled    bool   `json:"enabled"`
+	Provider   string `json:"provider"` // deepl / google
+	URL        string `json:"url"`      // 可选，自定义接口地址（如 DeepL Pro）
+	APIKey     string `json:"api_key"`
+	TargetLang string `json:"target_lang"` // 可选，目标语言
+}
+
+// TLSConfig IMAP 连接的TLS选项
+type TLSConfig struct {
+	CAFile             string `json:"ca_file"`     // 校验服务器证书的CA证书
+	CertFile           string `json:"cert_file"`   // 客户端证书
+	KeyFile            string `json:"key_file"`    // 客户端私钥
+	MinVersion         string `json:"min_version"` // 最低TLS版本：1.0 / 1.1 / 1.2 / 1.3
+	InsecureSkipVerify bool   `json:"insecure_skip_verify"`
+}
+
+// 连接加密方式
+const (
+	SecurityTLS      = "tls"
+	SecuritySTARTTLS = "starttls"
+	SecurityNone     = "none"
+)
+
+// PushConfig 推送配置
+// 通用字段在此定义，各推送后端的专有字段（如 Telegram 的 bot_token）由后端从 Raw 中解析
+type PushConfig struct {
+	Type string          `json:"type"` // 推送类型：form（默认，使用 sendpush 地址）/ telegram
+	Name string          `json:"name"` // 可选，推送目标名称（多个目标时在日志和状态中区分，规则按名称引用），默认为推送类型
+	Raw  json.RawMessage `json:"-"`    // 完整的 push 配置块
+
+	RuleOnly bool `json:"rule_only"` // 可选，只推送规则通过 targets 指定到该目标的邮件
+
+	MaxBodyLength int `json:"max_body_length"` // 可选，推送正文的最大字符数，超出时截断
+
+	Secret             string `json:"secret"`              // 可选，HMAC-SHA256 签名密钥
+	SignatureHeader    string `json:"signature_header"`    // 可选，签名请求头（默认 X-Signature-256）
+	SignatureTimestamp bool   `json:"signature_timestamp"` // 可选，签名包含时间戳（X-Signature-Timestamp），防止重放
+
+	TLS PushTLSConfig `json:"tls"` // 可选，推送请求的双向TLS配置（与IMAP的TLS设置相互独立）
+
+	Headers     map[string]string `json:"headers"`      // 可选，附加请求头（如 Authorization），覆盖后端的设置
+	BasicAuth   BasicAuthConfig   `json:"basic_auth"`   // 可选，Basic 认证
+	ContentType string            `json:"content_type"` // 可选，覆盖请求的内容类型
+
+	Retry     PushRetryConfig     `json:"retry"`      // 可选，推送失败后在后台重试
+	RateLimit PushRateLimitConfig `json:"rate_limit"` // 可选，推送限速
+}
+
+// PushRateLimitConfig 推送限速（令牌桶），超出时排队等待
+type PushRateLimitConfig struct {
+	Limit  int `json:"limit"`  // 每个周期最多推送的条数，0 不限速
+	Period int `json:"period"` // 周期（秒）
+	Burst  int `json:"burst"`  // 允许的突发条数
+}
+
+// PushRetryConfig 推送失败后的重试队列（指数退避），超过最多尝试次数后写入 app.dead_letter
+type PushRetryConfig struct {
+	MaxAttempts  int `json:"max_attempts"`  // 最多尝试次数（含首次推送），大于 1 时启用
+	InitialDelay int `json:"initial_delay"` // 首次重试间隔（秒）
+	MaxDelay     int `json:"max_delay"`     // 最大重试间隔（秒）
+	QueueSize    int `json:"queue_size"`    // 最多排队的推送数
+}
+
+// BasicAuthConfig HTTP Basic 认证
+type BasicAuthConfig struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// UnmarshalJSON 解析通用字段并保留完整配置块
+func (p *PushConfig) UnmarshalJSON(data []byte) error {
+	type plain PushConfig
+	if err := json.Unmarshal(data, (*plain)(p)); err != nil {
+		return err
+	}
+	p.Raw = append(json.RawMessage(nil), data...)
+	return nil
+}
+
+// PushTLSConfig 推送请求的TLS配置
+type PushTLSConfig struct {
+	CertFile string `json:"cert_file"` // 客户端证书
+	KeyFile  string `json:"key_file"`  // 客户端私钥
+	CAFile   string `json:"ca_file"`   // 可选，校验推送服务端证书的CA
+}
+
+// 认证方式
+const (
+	AuthPassword = "password"
+	AuthXOAuth2  = "xoauth2"
+)
+
+// OAuth2Config XOAUTH2 认证配置
+type OAuth2Config struct {
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	RefreshToken string `json:"refresh_token"`
+	TokenURL     string `json:"token_url"` // 可选，Gmail / Office 365 可自动推断
+}
+
+// AdaptivePollConfig 自适应轮询配置（仅对不支持IDLE的账号生效）
+type AdaptivePollConfig struct {
+	Enabled     bool `json:"enabled"`
+	MinInterval int  `json:"min_interval"` // 最短轮询间隔（秒）
+	MaxInterval int  `json:"max_interval"` // 最长轮询间隔（秒）
 }
 
 // AppConfig 应用级配置
 type AppConfig struct {
 	HeartbeatURL      string `json:"heartbeat_url"`
 	HeartbeatInterval int    `json:"heartbeat_interval"`
+	ContactsFile      string `json:"contacts_file"` // 联系人CSV文件（address,name,category,priority）
+
+	CardDAV   CardDAVConfig `json:"carddav"`
+	Storage   StorageConfig `json:"storage"`
+	StateFile string        `json:"state_file"` // 处理进度文件，记录每个文件夹已处理的最大UID，重启后不重复推送
+
+	WatchConfig bool `json:"watch_config"` // 配置文件修改后自动重新加载账号配置（也可发送 SIGHUP）
+
+	ConnectionLimits map[string]int `json:"connection_limits"` // 按服务器地址限制所有账号同时打开的连接数（如 outlook.office365.com: 4）
+
+	NetworkCheck NetworkCheckConfig `json:"network_check"`
+
+	Encryption EncryptionConfig `json:"encryption"`
+	AuditLog   string           `json:"audit_log"` // 审计日志文件，记录标记已读、设置标志等修改邮箱的操作
+
+	HTTP        HTTPConfig        `json:"http"`
+	Metrics     MetricsConfig     `json:"metrics"`
+	Attachments AttachmentsConfig `json:"attachments"`
+	Alerts      AlertsConfig      `json:"alerts"`
+	MailView    MailViewConfig    `json:"mail_view"`
+
+	PushTargets []PushConfig `json:"push_targets"` // 所有账号共用的推送目标，与账号自己的推送并行推送
+	DeadLetter  string       `json:"dead_letter"`  // 重试多次仍失败的推送记录文件（JSON Lines），留空只记录日志
+}
+
+// NetworkCheckConfig 连接前的出口网络检查：网络不可用时暂停所有账号，恢复后再连接，不消耗重试次数
+type NetworkCheckConfig struct {
+	Enabled  bool     `json:"enabled"`
+	Targets  []string `json:"targets"`  // 探测目标：host:port 建立 TCP 连接，域名则做 DNS 解析；任一可达即网络可用
+	Timeout  int      `json:"timeout"`  // 单个目标的探测超时（秒）
+	Interval int      `json:"interval"` // 探测结果的有效期及网络中断时的检查间隔（秒）
+}
+
+// 运维告警级别
+const (
+	AlertInfo     = "info"     // 提示（如连接已恢复）
+	AlertWarning  = "warning"  // 警告（如连续失败、推送延迟、服务器提示）
+	AlertCritical = "critical" // 严重（如认证失败、停止监控、磁盘已满）
+)
+
+// AlertsConfig 运维告警的推送策略，与邮件推送分开配置
+type AlertsConfig struct {
+	Push        *PushConfig `json:"push"`         // 可选，告警推送目标，留空时使用各账号的推送
+	MinSeverity string      `json:"min_severity"` // 推送的最低级别：info（默认）/ warning / critical
+	Interval    int         `json:"interval"`     // 同一账号同类告警的最短间隔（秒）
+	MaxPerHour  int         `json:"max_per_hour"` // 每小时最多推送的告警数，0 不限制
+}
+
+// AttachmentsConfig 附件保存配置
+type AttachmentsConfig struct {
+	Dir        string   `json:"dir"`        // 保存目录，留空不保存
+	MaxSize    int64    `json:"max_size"`   // 单个附件最大字节数
+	Extensions []string `json:"extensions"` // 允许保存的扩展名（如 pdf、.xlsx），留空不限制
+	S3         S3Config `json:"s3"`         // 可选，保存到 S3 兼容对象存储（代替本地目录）
+	BaseURL    string   `json:"base_url"`   // 可选，本地目录对外访问的URL前缀（如通过 nginx 提供）
+
+	Thumbnail    ThumbnailConfig `json:"thumbnail"`
+	InlineImages bool            `json:"inline_images"` // 可选，同时保存 HTML 正文通过 cid: 引用的内联图片
+}
+
+// ThumbnailConfig 图片附件缩略图配置
+type ThumbnailConfig struct {
+	Enabled bool `json:"enabled"` // 为第一张图片附件生成缩略图，在支持图片的推送中预览
+	Size    int  `json:"size"`    // 缩略图最长边像素（默认 320）
+}
+
+// S3Config S3 兼容对象存储配置
+type S3Config struct {
+	Endpoint  string `json:"endpoint"`
+	Region    string `json:"region"`
+	Bucket    string `json:"bucket"` // 留空不启用
+	AccessKey string `json:"access_key"`
+	SecretKey string `json:"secret_key"`
+	Prefix    string `json:"prefix"`
+	PathStyle bool   `json:"path_style"`
+	URLExpiry int    `json:"url_expiry"` // 推送中对象链接（预签名URL）的有效期（秒，默认且最长7天）
+}
+
+// HTTPConfig 内置HTTP服务配置（状态接口等）
+type HTTPConfig struct {
+	Listen string        `json:"listen"` // 监听地址（如 127.0.0.1:8080），留空不启用
+	Token  string        `json:"token"`  // 可选，管理接口访问令牌（Authorization: Bearer <token>）
+	TLS    HTTPTLSConfig `json:"tls"`
+}
+
+// MailViewConfig 网页查看完整邮件（内置HTTP服务的 /mail/{id}，邮件内容来自 SQLite 存储）
+type MailViewConfig struct {
+	Enabled      bool   `json:"enabled"`
+	BaseURL      string `json:"base_url"`      // 可选，对外访问地址（如 https://mail.example.com），设置后推送中附上查看链接
+	Secret       string `json:"secret"`        // 生成查看链接的密钥，链接由密钥签名，无法被猜测
+	RemoteImages bool   `json:"remote_images"` // 默认加载远程图片，关闭时点击后才加载
+}
+
+// MetricsConfig Prometheus 指标接口配置
+type MetricsConfig struct {
+	Listen string `json:"listen"` // 监听地址，留空不启用；与 http.listen 相同时共用内置HTTP服务
+	Path   string `json:"path"`   // 指标路径（默认 /metrics）
+}
+
+// HTTPTLSConfig 内置HTTP服务的TLS配置：静态证书或 ACME 自动申请二选一
+type HTTPTLSConfig struct {
+	CertFile string     `json:"cert_file"`
+	KeyFile  string     `json:"key_file"`
+	ACME     ACMEConfig `json:"acme"`
+}
+
+// ACMEConfig ACME（Let's Encrypt）自动证书配置
+type ACMEConfig struct {
+	Domains      []string `json:"domains"` // 申请证书的域名，留空不启用
+	Email        string   `json:"email"`
+	CacheDir     string   `json:"cache_dir"`     // 证书缓存目录
+	Challenge    string   `json:"challenge"`     // http-01 / tls-alpn-01（默认）
+	HTTPListen   string   `json:"http_listen"`   // http-01 验证监听地址（默认 :80）
+	DirectoryURL string   `json:"directory_url"` // 可选，ACME 目录地址（默认 Let's Encrypt 正式环境）
+}
+
+// EncryptionConfig 本地存储静态加密配置（AES-256-GCM）
+type EncryptionConfig struct {
+	Enabled bool   `json:"enabled"`
+	KeyEnv  string `json:"key_env"`  // 保存密钥的环境变量名（默认 MAIL_RECEIVER_KEY）
+	KeyFile string `json:"key_file"` // 密钥文件，环境变量未设置时使用
+}
+
+// StorageConfig 已处理邮件存储配置
+type StorageConfig struct {
+	Type string `json:"type"` // 存储类型：jsonl（默认，仅统计信息）或 sqlite（完整邮件归档）
+	Path string `json:"path"` // 存储文件路径，留空不启用
+}
+
+// CardDAVConfig CardDAV 通讯录配置
+type CardDAVConfig struct {
+	URL      string `json:"url"` // 通讯录集合地址，留空不启用
+	Username string `json:"username"`
+	Password string `json:"password"`
+	CacheTTL int    `json:"cache_ttl"` // 查询结果缓存时间（秒）
 }
 
 // LoadConfig 从文件加载配置
 func LoadConfig(path string) (*Config, error) {
-	file, err := os.Open(path)
-	if err != nil {
-		return nil, fmt.Errorf("打开配置文件失败: %w", err)
-	}
-	defer file.Close()
-
 	var config Config
-	decoder := json.NewDecoder(file)
-	if err := decoder.Decode(&config); err != nil {
-		return nil, fmt.Errorf("解析配置文件失败: %w", err)
+	if err := readFile(path, &config); err != nil {
+		return nil, err
 	}
+	if err := config.loadIncludes(path); err != nil {
+		return nil, err
+	}
+	if err := config.SetDefaults(); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
 
-	// 设置默认值
-	for name, acc := range config.Accounts {
-		if acc.Port == 0 {
-			acc.Port = 993
+// SetDefaults 为未设置的选项填充默认值并校验配置，LoadConfig 会自动调用；
+// 嵌入使用时在代码中构建配置后调用
+func (c *Config) SetDefaults() error {
+	for name, acc := range c.Accounts {
+		if acc.Security == "" {
+			acc.Security = SecurityTLS
+		}
+		switch acc.Security {
+		case SecurityTLS:
+			if acc.Port == 0 {
+				acc.Port = 993
+			}
+		case SecuritySTARTTLS, SecurityNone:
+			if acc.Port == 0 {
+				acc.Port = 143
+			}
+		default:
+			return fmt.Errorf("账号 %s 的连接加密方式无效: %s (可选: tls/starttls/none)", name, acc.Security)
 		}
 		if acc.PollInterval == 0 {
 			acc.PollInterval = 60
@@ -55,19 +538,194 @@ func LoadConfig(path string) (*Config, error) {
 		if acc.IdleTimeout == 0 {
 			acc.IdleTimeout = 20
 		}
+		if acc.AdaptivePoll.MinInterval == 0 {
+			acc.AdaptivePoll.MinInterval = 15
+		}
+		if acc.AdaptivePoll.MaxInterval == 0 {
+			acc.AdaptivePoll.MaxInterval = 600
+		}
+		if acc.SLA.AlertInterval == 0 {
+			acc.SLA.AlertInterval = 3600
+		}
+		if acc.Retry.UnhealthyAfter == 0 {
+			acc.Retry.UnhealthyAfter = 3
+		}
+		if acc.Retry.InitialDelay == 0 {
+			acc.Retry.InitialDelay = 30
+		}
+		if acc.Retry.MaxDelay == 0 {
+			acc.Retry.MaxDelay = 1800
+		}
+		if acc.Retry.AuthRetryInterval == 0 {
+			acc.Retry.AuthRetryInterval = 3600
+		}
+		if acc.Summarize.MaxTokens == 0 {
+			acc.Summarize.MaxTokens = 200
+		}
+		if acc.Summarize.MaxInputChars == 0 {
+			acc.Summarize.MaxInputChars = 8000
+		}
+		if acc.Collapse.Window == 0 {
+			acc.Collapse.Window = 300
+		}
+		if acc.Collapse.MaxSubjects == 0 {
+			acc.Collapse.MaxSubjects = 5
+		}
+		if acc.Digest.MaxItems == 0 {
+			acc.Digest.MaxItems = 20
+		}
+		if acc.Digest.Window > 0 && acc.Collapse.Threshold > 0 {
+			return fmt.Errorf("账号 %s 不能同时启用 collapse 和 digest", name)
+		}
+		if qh := &acc.QuietHours; qh.Start != "" || qh.End != "" {
+			if _, err := time.Parse("15:04", qh.Start); err != nil {
+				return fmt.Errorf("账号 %s 的免打扰开始时间无效: %q（格式 HH:MM）", name, qh.Start)
+			}
+			if _, err := time.Parse("15:04", qh.End); err != nil {
+				return fmt.Errorf("账号 %s 的免打扰结束时间无效: %q（格式 HH:MM）", name, qh.End)
+			}
+			if qh.Start == qh.End {
+				return fmt.Errorf("账号 %s 的免打扰开始时间和结束时间相同", name)
+			}
+			if _, err := time.LoadLocation(qh.Timezone); err != nil {
+				return fmt.Errorf("账号 %s 的免打扰时区无效: %w", name, err)
+			}
+			if qh.MaxSubjects == 0 {
+				qh.MaxSubjects = 20
+			}
+		}
+		switch acc.ParseFailure.Action {
+		case "":
+			acc.ParseFailure.Action = ParseFailureEnvelope
+		case ParseFailureEnvelope, ParseFailureQuarantine, ParseFailureFlag:
+		default:
+			return fmt.Errorf("账号 %s 的解析失败处理方式无效: %s (可选: envelope/quarantine/flag)", name, acc.ParseFailure.Action)
+		}
+		switch acc.FlagChanges.Action {
+		case "", FlagChangeNotify, FlagChangeRecord:
+		default:
+			return fmt.Errorf("账号 %s 的状态变化处理方式无效: %s (可选: notify/record)", name, acc.FlagChanges.Action)
+		}
+		if acc.FlagChanges.MaxTracked == 0 {
+			acc.FlagChanges.MaxTracked = 200
+		}
+		if acc.ParseFailure.QuarantineDir == "" {
+			acc.ParseFailure.QuarantineDir = "data/quarantine"
+		}
 		if len(acc.Folders) == 0 {
 			acc.Folders = []string{"INBOX"}
 		}
+		if acc.Push.Type == "" {
+			acc.Push.Type = "form"
+		}
+		for i := range acc.PushTargets {
+			if acc.PushTargets[i].Type == "" {
+				acc.PushTargets[i].Type = "form"
+			}
+		}
+		for _, rule := range acc.Rules {
+			if rule.Push != nil && rule.Push.Type == "" {
+				rule.Push.Type = "form"
+			}
+		}
+		if acc.AuthType == "" {
+			acc.AuthType = AuthPassword
+		}
 		// 验证必填字段
-		if acc.Server == "" || acc.Username == "" || acc.Password == "" {
-			return nil, fmt.Errorf("账号 %s 缺少必填字段 (server/username/password)", name)
+		switch acc.AuthType {
+		case AuthPassword:
+			if acc.Server == "" || acc.Username == "" || acc.Password == "" {
+				return fmt.Errorf("账号 %s 缺少必填字段 (server/username/password)", name)
+			}
+		case AuthXOAuth2:
+			if acc.Server == "" || acc.Username == "" || acc.OAuth2.RefreshToken == "" {
+				return fmt.Errorf("账号 %s 缺少必填字段 (server/username/oauth2.refresh_token)", name)
+			}
+		default:
+			return fmt.Errorf("账号 %s 的认证方式无效: %s", name, acc.AuthType)
 		}
 	}
 
 	// 设置心跳默认值
-	if config.App.HeartbeatInterval == 0 {
-		config.App.HeartbeatInterval = 60
+	if c.App.HeartbeatInterval == 0 {
+		c.App.HeartbeatInterval = 60
+	}
+	if c.App.Encryption.KeyEnv == "" {
+		c.App.Encryption.KeyEnv = "MAIL_RECEIVER_KEY"
+	}
+	if acme := &c.App.HTTP.TLS.ACME; len(acme.Domains) > 0 {
+		if acme.CacheDir == "" {
+			acme.CacheDir = "data/acme"
+		}
+		if acme.Challenge == "" {
+			acme.Challenge = "tls-alpn-01"
+		}
+		if acme.HTTPListen == "" {
+			acme.HTTPListen = ":80"
+		}
+		if acme.Challenge != "http-01" && acme.Challenge != "tls-alpn-01" {
+			return fmt.Errorf("ACME 验证方式无效: %s (可选: http-01/tls-alpn-01)", acme.Challenge)
+		}
+	}
+	if c.App.Metrics.Path == "" {
+		c.App.Metrics.Path = "/metrics"
+	}
+	if c.App.Attachments.MaxSize == 0 {
+		c.App.Attachments.MaxSize = 25 << 20
+	}
+	if c.App.Attachments.Thumbnail.Size == 0 {
+		c.App.Attachments.Thumbnail.Size = 320
+	}
+	if s3 := &c.App.Attachments.S3; s3.URLExpiry == 0 {
+		s3.URLExpiry = 7 * 24 * 3600
+	} else if s3.URLExpiry < 0 || s3.URLExpiry > 7*24*3600 {
+		return fmt.Errorf("S3 链接有效期无效: %d (1-604800 秒)", s3.URLExpiry)
+	}
+	if c.App.CardDAV.CacheTTL == 0 {
+		c.App.CardDAV.CacheTTL = 3600
+	}
+	if nc := &c.App.NetworkCheck; nc.Enabled {
+		if len(nc.Targets) == 0 {
+			nc.Targets = []string{"223.5.5.5:53", "1.1.1.1:53"}
+		}
+		if nc.Timeout == 0 {
+			nc.Timeout = 3
+		}
+		if nc.Interval == 0 {
+			nc.Interval = 10
+		}
+	}
+	alerts := &c.App.Alerts
+	switch alerts.MinSeverity {
+	case "":
+		alerts.MinSeverity = AlertInfo
+	case AlertInfo, AlertWarning, AlertCritical:
+	default:
+		return fmt.Errorf("告警最低级别无效: %s (可选: info/warning/critical)", alerts.MinSeverity)
+	}
+	if alerts.Interval == 0 {
+		alerts.Interval = 300
+	}
+	if alerts.Push != nil && alerts.Push.Type == "" {
+		alerts.Push.Type = "form"
+	}
+	for i := range c.App.PushTargets {
+		if c.App.PushTargets[i].Type == "" {
+			c.App.PushTargets[i].Type = "form"
+		}
+	}
+	if mv := &c.App.MailView; mv.Enabled {
+		if c.App.HTTP.Listen == "" {
+			return fmt.Errorf("启用了网页查看邮件，但未配置内置HTTP服务 (app.http.listen)")
+		}
+		if c.App.Storage.Path == "" || c.App.Storage.Type != "sqlite" {
+			return fmt.Errorf("网页查看邮件需要 SQLite 存储 (app.storage.type 为 sqlite)")
+		}
+		if mv.Secret == "" {
+			return fmt.Errorf("网页查看邮件缺少 secret")
+		}
+		mv.BaseURL = strings.TrimRight(mv.BaseURL, "/")
 	}
 
-	return &config, nil
+	return nil
 }
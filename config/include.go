@@ -0,0 +1,79 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// readFile 读取单个配置文件，旧版本内容先升级到当前格式
+func readFile(path string, v interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("打开配置文件失败: %w", err)
+	}
+
+	data, version, err := Migrate(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if version < CurrentVersion {
+		log.Printf("配置文件 %s 版本 %d 已自动升级到 %d，可运行 migrate 子命令写回配置文件", path, version, CurrentVersion)
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("解析配置文件 %s 失败: %w", path, err)
+	}
+	return nil
+}
+
+// includeFile 被包含的配置文件内容（只允许账号和联系人）
+type includeFile struct {
+	Accounts map[string]*AccountConfig `json:"accounts"`
+	Contacts map[string]ContactConfig  `json:"contacts"`
+}
+
+// loadIncludes 按 include 中的通配符加载拆分的配置文件并合并
+// 相对路径以主配置文件所在目录为准；同名账号重复定义时报错
+func (c *Config) loadIncludes(mainPath string) error {
+	c.Files = []string{mainPath}
+	dir := filepath.Dir(mainPath)
+
+	for _, pattern := range c.Include {
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(dir, pattern)
+		}
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return fmt.Errorf("include 通配符无效 %s: %w", pattern, err)
+		}
+		sort.Strings(matches)
+
+		for _, path := range matches {
+			var inc includeFile
+			if err := readFile(path, &inc); err != nil {
+				return err
+			}
+			if c.Accounts == nil {
+				c.Accounts = make(map[string]*AccountConfig)
+			}
+			for name, acc := range inc.Accounts {
+				if _, exists := c.Accounts[name]; exists {
+					return fmt.Errorf("账号 %s 重复定义 (%s)", name, path)
+				}
+				c.Accounts[name] = acc
+			}
+			if c.Contacts == nil {
+				c.Contacts = make(map[string]ContactConfig)
+			}
+			for addr, contact := range inc.Contacts {
+				c.Contacts[addr] = contact
+			}
+			c.Files = append(c.Files, path)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// CurrentVersion 当前配置文件格式版本
+const CurrentVersion = 2
+
+// migration 将配置从 version-1 升级到 version
+type migration func(doc map[string]interface{}) error
+
+// migrations 按版本索引的升级步骤：migrations[v] 将版本 v 升级到 v+1
+var migrations = map[int]migration{
+	1: migrateSendPush,
+}
+
+// Migrate 将配置内容升级到当前版本，返回升级后的内容和原始版本
+// 未写 version 字段的配置视为版本 1；已是当前版本时原样返回
+func Migrate(data []byte) ([]byte, int, error) {
+	doc := make(map[string]interface{})
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, 0, fmt.Errorf("解析配置文件失败: %w", err)
+	}
+
+	version := 1
+	if v, ok := doc["version"]; ok {
+		n, ok := v.(json.Number)
+		if !ok {
+			return nil, 0, fmt.Errorf("配置版本号无效: %v", v)
+		}
+		i, err := n.Int64()
+		if err != nil {
+			return nil, 0, fmt.Errorf("配置版本号无效: %v", v)
+		}
+		version = int(i)
+	}
+	if version > CurrentVersion {
+		return nil, version, fmt.Errorf("配置版本 %d 高于程序支持的版本 %d，请升级程序", version, CurrentVersion)
+	}
+	if version == CurrentVersion {
+		return data, version, nil
+	}
+
+	for v := version; v < CurrentVersion; v++ {
+		if err := migrations[v](doc); err != nil {
+			return nil, version, fmt.Errorf("升级配置到版本 %d 失败: %w", v+1, err)
+		}
+	}
+	doc["version"] = CurrentVersion
+
+	out, err := json.MarshalIndent(doc, "", "    ")
+	if err != nil {
+		return nil, version, fmt.Errorf("序列化配置失败: %w", err)
+	}
+	return append(out, '\n'), version, nil
+}
+
+// migrateSendPush 版本 1 → 2：账号的 sendpush 地址移入 push 块（push.url）
+func migrateSendPush(doc map[string]interface{}) error {
+	accounts, _ := doc["accounts"].(map[string]interface{})
+	for name, v := range accounts {
+		acc, ok := v.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("账号 %s 配置格式无效", name)
+		}
+		sendPush, ok := acc["sendpush"]
+		if !ok {
+			continue
+		}
+		delete(acc, "sendpush")
+
+		push, _ := acc["push"].(map[string]interface{})
+		if push == nil {
+			push = make(map[string]interface{})
+		}
+		// sendpush 只用于表单推送，其他推送类型下原本就被忽略
+		if t, _ := push["type"].(string); t != "" && t != "form" {
+			continue
+		}
+		if url, _ := sendPush.(string); url != "" {
+			if _, exists := push["url"]; !exists {
+				push["url"] = url
+			}
+		}
+		if len(push) > 0 {
+			acc["push"] = push
+		}
+	}
+	return nil
+}
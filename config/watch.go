@@ -0,0 +1,73 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// WatchInterval 检查配置文件修改的间隔
+const WatchInterval = 5 * time.Second
+
+// Watch 在后台定期检查主配置文件及 include 匹配的文件（包括新增/删除的文件），
+// 内容变化并稳定一个检查周期后调用 onChange（串行调用，避免编辑器分多次写入时重复加载）
+func Watch(path string, interval time.Duration, onChange func()) {
+	go func() {
+		last := fingerprint(path)
+		pending := last
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			fp := fingerprint(path)
+			if fp == last {
+				pending = last
+				continue
+			}
+			if fp != pending {
+				// 首次发现变化，等下一个周期确认写入完成
+				pending = fp
+				continue
+			}
+			last = fp
+			onChange()
+		}
+	}()
+}
+
+// fingerprint 配置文件集合的修改时间和大小摘要
+func fingerprint(path string) string {
+	files := []string{path}
+
+	// 读取 include 通配符，匹配到的文件一起检查
+	if data, err := os.ReadFile(path); err == nil {
+		var main struct {
+			Include []string `json:"include"`
+		}
+		if json.Unmarshal(data, &main) == nil {
+			dir := filepath.Dir(path)
+			for _, pattern := range main.Include {
+				if !filepath.IsAbs(pattern) {
+					pattern = filepath.Join(dir, pattern)
+				}
+				matches, _ := filepath.Glob(pattern)
+				files = append(files, matches...)
+			}
+		}
+	}
+	sort.Strings(files[1:])
+
+	var b strings.Builder
+	for _, f := range files {
+		if info, err := os.Stat(f); err == nil {
+			fmt.Fprintf(&b, "%s:%d:%d;", f, info.ModTime().UnixNano(), info.Size())
+		} else {
+			fmt.Fprintf(&b, "%s:-;", f)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,82 @@
+package connlimit
+
+import (
+	"errors"
+	"strings"
+	"sync"
+)
+
+// ErrAborted 等待连接预算时被取消
+var ErrAborted = errors.New("等待连接被取消")
+
+// Manager 连接预算管理：按服务器（服务商）和用户（服务器+用户名）限制同时打开的 IMAP 连接数
+// 多个账号配置指向同一邮箱（如分别监控不同文件夹）时共用用户预算
+type Manager struct {
+	mu          sync.Mutex
+	serverLimit map[string]int // 服务器 → 所有账号合计的连接上限
+	servers     map[string]int // 服务器 → 当前连接数
+	users       map[string]int // 服务器+用户名 → 当前连接数
+	released    chan struct{}  // 有连接释放时关闭并替换，唤醒等待者
+}
+
+// NewManager 创建连接预算管理，serverLimits 为按服务器地址的连接上限（0 或未配置不限制）
+func NewManager(serverLimits map[string]int) *Manager {
+	m := &Manager{
+		serverLimit: make(map[string]int),
+		servers:     make(map[string]int),
+		users:       make(map[string]int),
+		released:    make(chan struct{}),
+	}
+	for server, n := range serverLimits {
+		m.serverLimit[strings.ToLower(server)] = n
+	}
+	return m
+}
+
+// TryAcquire 尝试占用一个连接，预算不足时返回false；userLimit 为该用户的连接上限（0 不限制）
+func (m *Manager) TryAcquire(server, username string, userLimit int) (release func(), ok bool) {
+	server = strings.ToLower(server)
+	user := server + "\x00" + strings.ToLower(username)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if limit := m.serverLimit[server]; limit > 0 && m.servers[server] >= limit {
+		return nil, false
+	}
+	if userLimit > 0 && m.users[user] >= userLimit {
+		return nil, false
+	}
+	m.servers[server]++
+	m.users[user]++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			m.mu.Lock()
+			m.servers[server]--
+			m.users[user]--
+			close(m.released)
+			m.released = make(chan struct{})
+			m.mu.Unlock()
+		})
+	}, true
+}
+
+// Acquire 占用一个连接，预算不足时等待其他连接释放；abort 收到信号时返回 ErrAborted
+func (m *Manager) Acquire(server, username string, userLimit int, abort <-chan struct{}) (func(), error) {
+	for {
+		m.mu.Lock()
+		released := m.released
+		m.mu.Unlock()
+
+		if release, ok := m.TryAcquire(server, username, userLimit); ok {
+			return release, nil
+		}
+		select {
+		case <-released:
+		case <-abort:
+			return nil, ErrAborted
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package contacts
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CardDAV CardDAV 通讯录查询（如 Nextcloud / iCloud）
+type CardDAV struct {
+	url      string
+	username string
+	password string
+	ttl      time.Duration
+	client   *http.Client
+
+	mu    sync.Mutex
+	cache map[string]cardDAVEntry
+}
+
+// cardDAVEntry 查询结果缓存（contact 为nil表示未找到）
+type cardDAVEntry struct {
+	contact *Contact
+	expires time.Time
+}
+
+// NewCardDAV 创建CardDAV查询器，url 为通讯录集合地址，ttl 为查询结果缓存时间
+func NewCardDAV(url, username, password string, ttl time.Duration) *CardDAV {
+	return &CardDAV{
+		url:      url,
+		username: username,
+		password: password,
+		ttl:      ttl,
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+		cache: make(map[string]cardDAVEntry),
+	}
+}
+
+// SetRemote 设置本地未匹配时使用的CardDAV查询器
+func (b *Book) SetRemote(remote *CardDAV) {
+	b.remote = remote
+}
+
+// Lookup 按邮箱地址查询联系人（带缓存），查询失败时返回nil
+func (d *CardDAV) Lookup(address string) *Contact {
+	key := normalize(address)
+
+	d.mu.Lock()
+	if entry, ok := d.cache[key]; ok && time.Now().Before(entry.expires) {
+		d.mu.Unlock()
+		return entry.contact
+	}
+	d.mu.Unlock()
+
+	contact, err := d.query(key)
+	if err != nil {
+		// 查询失败不缓存，下次重试
+		log.Printf("CardDAV 查询 %s 失败: %v", key, err)
+		return nil
+	}
+
+	d.mu.Lock()
+	d.cache[key] = cardDAVEntry{contact: contact, expires: time.Now().Add(d.ttl)}
+	d.mu.Unlock()
+
+	return contact
+}
+
+// cardDAVMultistatus REPORT 响应
+type cardDAVMultistatus struct {
+	Responses []struct {
+		AddressData string `xml:"propstat>prop>address-data"`
+	} `xml:"response"`
+}
+
+// query 发送 addressbook-query REPORT 请求
+func (d *CardDAV) query(address string) (*Contact, error) {
+	var escaped bytes.Buffer
+	xml.EscapeText(&escaped, []byte(address))
+
+	body := `<?xml version="1.0" encoding="utf-8"?>
+<C:addressbook-query xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:carddav">
+  <D:prop><D:getetag/><C:address-data/></D:prop>
+  <C:filter>
+    <C:prop-filter name="EMAIL">
+      <C:text-match collation="i;unicode-casemap" match-type="equals">` + escaped.String() + `</C:text-match>
+    </C:prop-filter>
+  </C:filter>
+</C:addressbook-query>`
+
+	req, err := http.NewRequest("REPORT", d.url, strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/xml; charset=utf-8")
+	req.Header.Set("Depth", "1")
+	if d.username != "" {
+		req.SetBasicAuth(d.username, d.password)
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusMultiStatus && resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("状态码 %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var ms cardDAVMultistatus
+	if err := xml.Unmarshal(data, &ms); err != nil {
+		return nil, fmt.Errorf("解析响应失败: %w", err)
+	}
+
+	for _, r := range ms.Responses {
+		if r.AddressData == "" {
+			continue
+		}
+		c := parseVCard(r.AddressData)
+		c.Address = address
+		return c, nil
+	}
+
+	return nil, nil
+}
+
+// parseVCard 从vCard中提取名称和分类
+func parseVCard(card string) *Contact {
+	// 展开折叠行（以空格或制表符开头的行属于上一行）
+	card = strings.ReplaceAll(card, "\r\n", "\n")
+	card = strings.ReplaceAll(card, "\n ", "")
+	card = strings.ReplaceAll(card, "\n\t", "")
+
+	c := &Contact{}
+	for _, line := range strings.Split(card, "\n") {
+		idx := strings.Index(line, ":")
+		if idx < 0 {
+			continue
+		}
+		// 去掉参数部分，如 FN;CHARSET=UTF-8
+		name := strings.ToUpper(strings.SplitN(line[:idx], ";", 2)[0])
+		value := strings.TrimSpace(line[idx+1:])
+
+		switch name {
+		case "FN":
+			c.Name = value
+		case "CATEGORIES":
+			if c.Category == "" {
+				c.Category = strings.SplitN(value, ",", 2)[0]
+			}
+		}
+	}
+	return c
+}
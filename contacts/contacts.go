@@ -0,0 +1,125 @@
+package contacts
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Contact 联系人信息
+type Contact struct {
+	Address  string `json:"address"`
+	Name     string `json:"name"`     // 友好名称，推送时替代原始地址
+	Category string `json:"category"` // 分类，可用于规则匹配
+	Priority int    `json:"priority"` // 优先级
+}
+
+// Book 联系人通讯录（按小写邮箱地址索引）
+// 匹配到联系人（本地或CardDAV）即视为已知联系人
+type Book struct {
+	entries map[string]*Contact
+	remote  *CardDAV // 可选，本地未匹配时查询
+}
+
+// NewBook 创建通讯录，entries 为配置文件中的联系人，csvPath 为可选的CSV文件
+// CSV 格式：address,name,category,priority（首行可为表头）
+func NewBook(entries map[string]Contact, csvPath string) (*Book, error) {
+	b := &Book{entries: make(map[string]*Contact)}
+
+	if csvPath != "" {
+		if err := b.loadCSV(csvPath); err != nil {
+			return nil, err
+		}
+	}
+
+	// 配置文件中的联系人优先级高于CSV
+	for addr, c := range entries {
+		c := c
+		c.Address = addr
+		b.add(&c)
+	}
+
+	return b, nil
+}
+
+// Lookup 按邮箱地址查找联系人，未找到返回nil
+func (b *Book) Lookup(address string) *Contact {
+	if b == nil || address == "" {
+		return nil
+	}
+	if c, ok := b.entries[normalize(address)]; ok {
+		return c
+	}
+	if b.remote != nil {
+		return b.remote.Lookup(address)
+	}
+	return nil
+}
+
+// Len 返回联系人数量
+func (b *Book) Len() int {
+	if b == nil {
+		return 0
+	}
+	return len(b.entries)
+}
+
+// add 添加联系人
+func (b *Book) add(c *Contact) {
+	if c.Address == "" {
+		return
+	}
+	b.entries[normalize(c.Address)] = c
+}
+
+// loadCSV 从CSV文件加载联系人
+func (b *Book) loadCSV(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("打开联系人文件失败: %w", err)
+	}
+	defer file.Close()
+
+	r := csv.NewReader(file)
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	for line := 1; ; line++ {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("解析联系人文件失败: %w", err)
+		}
+
+		// 跳过表头和空行
+		if len(record) == 0 || record[0] == "" || (line == 1 && strings.EqualFold(record[0], "address")) {
+			continue
+		}
+
+		c := &Contact{Address: record[0]}
+		if len(record) > 1 {
+			c.Name = record[1]
+		}
+		if len(record) > 2 {
+			c.Category = record[2]
+		}
+		if len(record) > 3 && record[3] != "" {
+			if c.Priority, err = strconv.Atoi(record[3]); err != nil {
+				return fmt.Errorf("联系人文件第 %d 行优先级无效: %s", line, record[3])
+			}
+		}
+		b.add(c)
+	}
+
+	return nil
+}
+
+// normalize 规范化邮箱地址
+func normalize(address string) string {
+	return strings.ToLower(strings.TrimSpace(address))
+}
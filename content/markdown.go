@@ -0,0 +1,203 @@
+package content
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	xhtml "golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// MarkdownStyle 推送渠道的 Markdown 方言：普通文本的转义方式和各种格式的写法
+// Bold/Italic/Code/Link 的参数已经转义
+type MarkdownStyle struct {
+	Escape func(s string) string
+	Bold   func(s string) string
+	Italic func(s string) string
+	Code   func(s string) string
+	Link   func(text, href string) string
+}
+
+// markdownFrame 尚未结束的格式标签（粗体、链接、引用等）及其中已转换的内容
+type markdownFrame struct {
+	tag  atom.Atom
+	href string
+	buf  strings.Builder
+}
+
+// markdownList 列表层级
+type markdownList struct {
+	ordered bool
+	n       int
+}
+
+var (
+	markdownSpace       = regexp.MustCompile(`[ \t\r\n\f]+`)
+	markdownInlineSpace = regexp.MustCompile(`[ \t]{2,}`)
+	markdownBlankRuns   = regexp.MustCompile(`\n{3,}`)
+)
+
+// HTMLToMarkdown 将 HTML 正文转为 Markdown：保留粗体、斜体、行内代码、链接（http/https/mailto）、列表、标题（转为粗体）和引用，
+// 其他标签只保留文本；脚本、样式等连同内容去掉
+func HTMLToMarkdown(s string, style MarkdownStyle) string {
+	root := &markdownFrame{}
+	stack := []*markdownFrame{root}
+	var lists []markdownList
+	skip := 0 // 处于需要去掉内容的标签中的层数
+	pre := 0  // 处于 <pre> 中的层数，保留换行
+
+	top := func() *markdownFrame { return stack[len(stack)-1] }
+	write := func(s string) { top().buf.WriteString(s) }
+	push := func(t xhtml.Token) {
+		stack = append(stack, &markdownFrame{tag: t.DataAtom, href: attrValue(t, "href")})
+	}
+	// pop 结束最近的同名标签（中间未闭合的标签一并结束）
+	pop := func(a atom.Atom) {
+		for i := len(stack) - 1; i > 0; i-- {
+			if stack[i].tag != a {
+				continue
+			}
+			for len(stack) > i {
+				f := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				top().buf.WriteString(renderFrame(f, style))
+			}
+			return
+		}
+	}
+
+	z := xhtml.NewTokenizer(strings.NewReader(s))
+	for {
+		tt := z.Next()
+		if tt == xhtml.ErrorToken {
+			break
+		}
+		t := z.Token()
+		switch tt {
+		case xhtml.StartTagToken, xhtml.SelfClosingTagToken:
+			if sanitizeDroppedTags[t.DataAtom] || t.DataAtom == atom.Head {
+				if tt == xhtml.StartTagToken && !isVoid(t.DataAtom) {
+					skip++
+				}
+				continue
+			}
+			if skip > 0 {
+				continue
+			}
+			switch t.DataAtom {
+			case atom.Br:
+				write("\n")
+			case atom.P, atom.Div, atom.Table, atom.Section, atom.Article, atom.Header, atom.Footer, atom.Hr:
+				write("\n\n")
+			case atom.Tr:
+				write("\n")
+			case atom.Td, atom.Th:
+				write(" ")
+			case atom.Pre:
+				pre++
+				write("\n\n")
+			case atom.Ul, atom.Ol:
+				lists = append(lists, markdownList{ordered: t.DataAtom == atom.Ol})
+				write("\n")
+			case atom.Li:
+				marker := "• "
+				if n := len(lists); n > 0 && lists[n-1].ordered {
+					lists[n-1].n++
+					marker = strconv.Itoa(lists[n-1].n) + ". "
+				}
+				write("\n" + style.Escape(marker))
+			case atom.B, atom.Strong, atom.I, atom.Em, atom.Code, atom.A, atom.Blockquote,
+				atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+				if tt == xhtml.StartTagToken {
+					push(t)
+				}
+			}
+		case xhtml.EndTagToken:
+			if sanitizeDroppedTags[t.DataAtom] || t.DataAtom == atom.Head {
+				if skip > 0 {
+					skip--
+				}
+				continue
+			}
+			if skip > 0 {
+				continue
+			}
+			switch t.DataAtom {
+			case atom.P, atom.Div, atom.Table, atom.Section, atom.Article, atom.Header, atom.Footer:
+				write("\n\n")
+			case atom.Pre:
+				if pre > 0 {
+					pre--
+				}
+				write("\n\n")
+			case atom.Ul, atom.Ol:
+				if n := len(lists); n > 0 {
+					lists = lists[:n-1]
+				}
+				write("\n")
+			case atom.B, atom.Strong, atom.I, atom.Em, atom.Code, atom.A, atom.Blockquote,
+				atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+				pop(t.DataAtom)
+			}
+		case xhtml.TextToken:
+			if skip > 0 {
+				continue
+			}
+			text := t.Data
+			if pre == 0 {
+				text = markdownSpace.ReplaceAllString(text, " ")
+			}
+			write(style.Escape(text))
+		}
+	}
+	// 未闭合的标签
+	for len(stack) > 1 {
+		pop(top().tag)
+	}
+	return cleanMarkdown(root.buf.String())
+}
+
+// renderFrame 按方言输出结束的格式标签，内容首尾的空白移到格式标记之外
+func renderFrame(f *markdownFrame, style MarkdownStyle) string {
+	inner := f.buf.String()
+	trimmed := strings.TrimSpace(inner)
+	if trimmed == "" {
+		return inner
+	}
+	lead := inner[:strings.Index(inner, trimmed)]
+	tail := inner[len(lead)+len(trimmed):]
+
+	switch f.tag {
+	case atom.B, atom.Strong:
+		return lead + style.Bold(trimmed) + tail
+	case atom.I, atom.Em:
+		return lead + style.Italic(trimmed) + tail
+	case atom.Code:
+		return lead + style.Code(trimmed) + tail
+	case atom.A:
+		if !safeURL(f.href, "http", "https", "mailto") {
+			return inner
+		}
+		return lead + style.Link(trimmed, strings.TrimSpace(f.href)) + tail
+	case atom.Blockquote:
+		lines := strings.Split(cleanMarkdown(trimmed), "\n")
+		for i, line := range lines {
+			lines[i] = "> " + line
+		}
+		return "\n\n" + strings.Join(lines, "\n") + "\n\n"
+	}
+	// 标题
+	return "\n\n" + style.Bold(trimmed) + "\n\n"
+}
+
+// cleanMarkdown 合并行内连续的空白、去掉行首尾空白，最多保留一个空行
+func cleanMarkdown(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(markdownInlineSpace.ReplaceAllString(line, " "))
+	}
+	s = strings.Join(lines, "\n")
+	s = markdownBlankRuns.ReplaceAllString(s, "\n\n")
+	return strings.TrimSpace(s)
+}
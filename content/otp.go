@@ -0,0 +1,59 @@
+package content
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// builtinOTPPatterns 内置验证码提取规则（不区分大小写），第一个分组为验证码；要求验证码附近有关键字，避免误取日期、金额、单号
+var builtinOTPPatterns = []string{
+	// 验证码：123456 / 验证码为 123456 / 动态密码是123456
+	`(?:验证码|校验码|动态码|确认码|安全码|动态密码|验证代码|授权码)[^0-9a-z]{0,20}?\b([0-9]{4,8})\b`,
+	// 123456 是您的验证码 / 123456（登录验证码）
+	`\b([0-9]{4,8})\b[^0-9a-z]{0,20}?(?:验证码|校验码|动态码|动态密码)`,
+	// your verification code is 123456 / code: 123456 / OTP 123456（关键字须为完整单词，避免 zipcode、spin 等误匹配）
+	`\b(?:code|otp|passcode|pin)\b(?:\s+is)?\s*[:：]?\s*\b([0-9]{4,8})\b`,
+	// 123456 is your verification code
+	`\b([0-9]{4,8})\s+is\s+your\b.{0,40}?\b(?:code|otp|passcode|pin)\b`,
+}
+
+// OTPExtractor 从邮件主题和正文中提取验证码
+type OTPExtractor struct {
+	patterns []*regexp.Regexp
+}
+
+// NewOTPExtractor 创建验证码提取器，patterns 为自定义提取正则（有分组时取第一个分组，否则取整个匹配），builtin 为是否启用内置规则
+// 自定义规则优先于内置规则
+func NewOTPExtractor(patterns []string, builtin bool) (*OTPExtractor, error) {
+	e := &OTPExtractor{}
+	for _, p := range patterns {
+		re, err := regexp.Compile("(?i)" + p)
+		if err != nil {
+			return nil, fmt.Errorf("验证码提取正则无效 %s: %w", p, err)
+		}
+		e.patterns = append(e.patterns, re)
+	}
+	if builtin {
+		for _, p := range builtinOTPPatterns {
+			e.patterns = append(e.patterns, regexp.MustCompile("(?i)"+p))
+		}
+	}
+	return e, nil
+}
+
+// Extract 依次在主题和正文中按规则顺序查找验证码，未找到时返回空字符串
+func (e *OTPExtractor) Extract(subject, body string) string {
+	for _, text := range []string{subject, body} {
+		for _, re := range e.patterns {
+			m := re.FindStringSubmatch(text)
+			switch {
+			case m == nil:
+				continue
+			case len(m) > 1:
+				return m[1]
+			}
+			return m[0]
+		}
+	}
+	return ""
+}
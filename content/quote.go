@@ -0,0 +1,129 @@
+package content
+
+import (
+	"regexp"
+	"strings"
+)
+
+// htmlQuoteMarkers 常见邮件客户端引用原邮件的起始标记（小写），回复内容在前、引用在后，从第一个标记处截断
+var htmlQuoteMarkers = []string{
+	`<div class="gmail_quote`, // Gmail
+	`<blockquote class="gmail_quote`,
+	`<div id="appendonsend"`,  // Outlook
+	`<div id="divrplyfwdmsg"`, // Outlook
+	`<div class="outlookmessageheader"`,
+	`<blockquote type="cite"`,      // Apple Mail / Thunderbird
+	`<div class="moz-cite-prefix"`, // Thunderbird
+	`<div class="yahoo_quoted"`,    // Yahoo
+	`<div id="original-content"`,   // QQ邮箱
+	`<div id="isreplycontent"`,     // 网易邮箱
+}
+
+// htmlQuoteStyles Outlook 桌面版引用原邮件时，原邮件头所在 <div> 的内联样式（小写），从该标签处截断
+var htmlQuoteStyles = []string{
+	`border:none;border-top:solid #e1e1e1 1.0pt`,
+	`border:none;border-top:solid #b5c4df 1.0pt`,
+}
+
+// textQuoteHeaders 纯文本中引用原邮件的起始行
+var textQuoteHeaders = []*regexp.Regexp{
+	regexp.MustCompile(`^在.{0,200}写道[:：]\s*$`),
+	regexp.MustCompile(`(?i)^on\s.{0,200}\swrote:\s*$`),
+	regexp.MustCompile(`(?i)^-{2,}\s*(original message|原始邮件|回复的原邮件)\s*-{2,}\s*$`),
+}
+
+// wroteStart/wroteEnd Gmail 等客户端较长的 "On … wrote:" 会折成两行
+var (
+	wroteStart = regexp.MustCompile(`(?i)^on\s.{0,200}$`)
+	wroteEnd   = regexp.MustCompile(`(?i)^.{0,100}wrote:\s*$`)
+)
+
+// outlookFrom/outlookSent Outlook 风格的原邮件头（发件人/发送时间），两行同时出现才认为是引用
+var (
+	outlookFrom = regexp.MustCompile(`(?i)^\*?(from|发件人)\s*[:：]`)
+	outlookSent = regexp.MustCompile(`(?i)^\*?(sent|date|发送时间|时间)\s*[:：]`)
+	// outlookRule Outlook 纯文本回复在原邮件头之前插入的下划线分隔线
+	outlookRule = regexp.MustCompile(`^_{10,}$`)
+)
+
+// TrimQuotedHTML 去掉 HTML 正文中引用的原邮件（gmail_quote、blockquote 等）
+func TrimQuotedHTML(html string) string {
+	lower := strings.ToLower(html)
+	cut := len(html)
+	for _, marker := range htmlQuoteMarkers {
+		if i := strings.Index(lower, marker); i >= 0 && i < cut {
+			cut = i
+		}
+	}
+	for _, style := range htmlQuoteStyles {
+		if i := strings.Index(lower, style); i >= 0 {
+			if i = strings.LastIndex(lower[:i], "<"); i >= 0 && i < cut {
+				cut = i
+			}
+		}
+	}
+	if cut == len(html) || strings.TrimSpace(stripTags(html[:cut])) == "" {
+		// 没有引用，或引用前没有新内容（如转发）时保留原文
+		return html
+	}
+	return html[:cut]
+}
+
+// TrimQuotedText 去掉纯文本正文中引用的原邮件："在…写道:"、"On … wrote:"、原始邮件分隔线、Outlook 原邮件头之后的内容以及 "> " 引用行
+// 去掉后没有剩余内容时返回原文
+func TrimQuotedText(text string) string {
+	lines := strings.Split(text, "\n")
+
+	end := len(lines)
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		if matchAny(textQuoteHeaders, line) {
+			end = i
+			break
+		}
+		if i+1 < len(lines) {
+			next := strings.TrimSpace(lines[i+1])
+			if (outlookFrom.MatchString(line) && outlookSent.MatchString(next)) ||
+				(wroteStart.MatchString(line) && wroteEnd.MatchString(next)) {
+				end = i
+				// 原邮件头之前的下划线分隔线一并去掉
+				if i > 0 && outlookRule.MatchString(strings.TrimSpace(lines[i-1])) {
+					end = i - 1
+				}
+				break
+			}
+		}
+	}
+
+	var kept []string
+	for _, line := range lines[:end] {
+		if strings.HasPrefix(strings.TrimLeft(line, " \t"), ">") {
+			continue
+		}
+		kept = append(kept, line)
+	}
+
+	result := strings.TrimSpace(strings.Join(kept, "\n"))
+	if result == "" {
+		return text
+	}
+	return result
+}
+
+// matchAny 检查是否匹配任一正则
+func matchAny(patterns []*regexp.Regexp, s string) bool {
+	for _, re := range patterns {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// tagRegex HTML标签
+var tagRegex = regexp.MustCompile(`<[^>]*>`)
+
+// stripTags 去掉HTML标签（仅用于判断是否有可见文本）
+func stripTags(html string) string {
+	return strings.ReplaceAll(tagRegex.ReplaceAllString(html, ""), "&nbsp;", " ")
+}
@@ -0,0 +1,201 @@
+package content
+
+import (
+	"html"
+	"io"
+	"net/url"
+	"strings"
+
+	xhtml "golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// sanitizeAllowedTags 允许保留的标签（白名单），其他标签去掉但保留其中的文本
+var sanitizeAllowedTags = map[atom.Atom]bool{
+	atom.A: true, atom.Abbr: true, atom.Address: true, atom.Article: true, atom.B: true, atom.Big: true,
+	atom.Blockquote: true, atom.Br: true, atom.Caption: true, atom.Center: true, atom.Code: true,
+	atom.Col: true, atom.Colgroup: true, atom.Dd: true, atom.Del: true, atom.Div: true, atom.Dl: true,
+	atom.Dt: true, atom.Em: true, atom.Font: true, atom.Footer: true, atom.H1: true, atom.H2: true,
+	atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true, atom.Header: true, atom.Hr: true,
+	atom.I: true, atom.Img: true, atom.Ins: true, atom.Li: true, atom.Ol: true, atom.P: true,
+	atom.Pre: true, atom.S: true, atom.Section: true, atom.Small: true, atom.Span: true,
+	atom.Strike: true, atom.Strong: true, atom.Sub: true, atom.Sup: true, atom.Table: true,
+	atom.Tbody: true, atom.Td: true, atom.Tfoot: true, atom.Th: true, atom.Thead: true, atom.Tr: true,
+	atom.U: true, atom.Ul: true,
+}
+
+// sanitizeDroppedTags 连同内容一起去掉的标签
+var sanitizeDroppedTags = map[atom.Atom]bool{
+	atom.Applet: true, atom.Audio: true, atom.Button: true, atom.Embed: true, atom.Frame: true,
+	atom.Frameset: true, atom.Iframe: true, atom.Math: true, atom.Noscript: true,
+	atom.Object: true, atom.Script: true, atom.Select: true, atom.Style: true, atom.Svg: true,
+	atom.Template: true, atom.Textarea: true, atom.Title: true, atom.Video: true,
+}
+
+// sanitizeAllowedAttrs 允许保留的属性（href/src/style 另行检查）
+var sanitizeAllowedAttrs = map[string]bool{
+	"align": true, "alt": true, "bgcolor": true, "border": true, "cellpadding": true, "cellspacing": true,
+	"color": true, "colspan": true, "dir": true, "face": true, "height": true, "rowspan": true,
+	"size": true, "title": true, "valign": true, "width": true,
+}
+
+// unsafeStyle 可能加载外部资源或执行代码的 CSS（含转义时无法可靠判断，一并去掉）
+var unsafeStyle = []string{"url(", "expression", "import", "behavior", "javascript", "\\", "image-set"}
+
+// SanitizeHTML 按白名单清理邮件 HTML，用于网页查看邮件
+// 去掉脚本、样式表、表单和事件属性，链接只保留 http/https/mailto 并在新窗口打开；
+// remoteImages 为 false 时远程图片不加载（只保留 data: 内嵌图片），返回值 blocked 表示是否有被拦截的远程图片
+func SanitizeHTML(s string, remoteImages bool) (out string, blocked bool) {
+	var b strings.Builder
+	z := xhtml.NewTokenizer(strings.NewReader(s))
+	skip := 0 // 处于需要去掉内容的标签中的层数
+	for {
+		tt := z.Next()
+		if tt == xhtml.ErrorToken {
+			if z.Err() != io.EOF {
+				return b.String(), blocked
+			}
+			break
+		}
+		t := z.Token()
+		switch tt {
+		case xhtml.StartTagToken, xhtml.SelfClosingTagToken:
+			if sanitizeDroppedTags[t.DataAtom] {
+				if tt == xhtml.StartTagToken && !isVoid(t.DataAtom) {
+					skip++
+				}
+				continue
+			}
+			if skip > 0 || !sanitizeAllowedTags[t.DataAtom] {
+				continue
+			}
+			attrs, imgBlocked := sanitizeAttrs(t, remoteImages)
+			if imgBlocked {
+				blocked = true
+			}
+			if t.DataAtom == atom.Img && imgBlocked {
+				b.WriteString(`<span class="blocked-image">[图片`)
+				if alt := attrValue(t, "alt"); alt != "" {
+					b.WriteString(": " + html.EscapeString(alt))
+				}
+				b.WriteString("]</span>")
+				continue
+			}
+			b.WriteString("<" + t.Data)
+			for _, a := range attrs {
+				b.WriteString(" " + a.Key + `="` + html.EscapeString(a.Val) + `"`)
+			}
+			if tt == xhtml.SelfClosingTagToken || isVoid(t.DataAtom) {
+				b.WriteString("/>")
+			} else {
+				b.WriteString(">")
+			}
+		case xhtml.EndTagToken:
+			if sanitizeDroppedTags[t.DataAtom] {
+				if skip > 0 {
+					skip--
+				}
+				continue
+			}
+			if skip > 0 || !sanitizeAllowedTags[t.DataAtom] || isVoid(t.DataAtom) {
+				continue
+			}
+			b.WriteString("</" + t.Data + ">")
+		case xhtml.TextToken:
+			if skip == 0 {
+				b.WriteString(html.EscapeString(t.Data))
+			}
+		}
+	}
+	return b.String(), blocked
+}
+
+// sanitizeAttrs 过滤标签属性，返回保留的属性及是否拦截了远程图片
+func sanitizeAttrs(t xhtml.Token, remoteImages bool) ([]xhtml.Attribute, bool) {
+	var attrs []xhtml.Attribute
+	blocked := false
+	for _, a := range t.Attr {
+		key := strings.ToLower(a.Key)
+		switch {
+		case key == "href" && t.DataAtom == atom.A:
+			if safeURL(a.Val, "http", "https", "mailto") {
+				attrs = append(attrs, xhtml.Attribute{Key: key, Val: a.Val})
+			}
+		case key == "src" && t.DataAtom == atom.Img:
+			val := strings.TrimSpace(a.Val)
+			switch {
+			case strings.HasPrefix(strings.ToLower(val), "data:image/"):
+				attrs = append(attrs, xhtml.Attribute{Key: key, Val: val})
+			case safeURL(val, "http", "https"):
+				if !remoteImages {
+					blocked = true
+					continue
+				}
+				attrs = append(attrs, xhtml.Attribute{Key: key, Val: val})
+			}
+		case key == "style":
+			if safeStyle(a.Val) {
+				attrs = append(attrs, xhtml.Attribute{Key: key, Val: a.Val})
+			}
+		case sanitizeAllowedAttrs[key]:
+			attrs = append(attrs, xhtml.Attribute{Key: key, Val: a.Val})
+		}
+	}
+	if t.DataAtom == atom.A {
+		attrs = append(attrs,
+			xhtml.Attribute{Key: "target", Val: "_blank"},
+			xhtml.Attribute{Key: "rel", Val: "noopener noreferrer"},
+		)
+	}
+	return attrs, blocked
+}
+
+// safeURL 检查链接的协议是否在允许列表中
+func safeURL(raw string, schemes ...string) bool {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return false
+	}
+	scheme := strings.ToLower(u.Scheme)
+	for _, s := range schemes {
+		if scheme == s {
+			return true
+		}
+	}
+	return false
+}
+
+// safeStyle 检查内联样式是否不会加载外部资源
+func safeStyle(style string) bool {
+	lower := strings.ToLower(style)
+	for _, s := range unsafeStyle {
+		if strings.Contains(lower, s) {
+			return false
+		}
+	}
+	return true
+}
+
+// attrValue 返回标签的属性值
+func attrValue(t xhtml.Token, key string) string {
+	for _, a := range t.Attr {
+		if strings.EqualFold(a.Key, key) {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// isVoid 判断是否为没有结束标签的元素
+func isVoid(a atom.Atom) bool {
+	switch a {
+	case atom.Br, atom.Col, atom.Hr, atom.Img, atom.Embed, atom.Frame:
+		return true
+	}
+	return false
+}
+
+// TextToHTML 将纯文本正文转换为 HTML（转义并保留换行），纯文本邮件在网页中显示
+func TextToHTML(s string) string {
+	return `<pre class="text-body">` + html.EscapeString(s) + "</pre>"
+}
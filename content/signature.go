@@ -0,0 +1,56 @@
+package content
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// builtinSignatures 内置签名起始行规则（匹配去掉首尾空白的整行，不区分大小写）
+var builtinSignatures = []string{
+	`^--\s*$`, // RFC 3676 签名分隔符 "-- "
+	`^(——\s*)?(发自|来自)(我的)?\s*.{0,20}(iphone|ipad|android|手机|邮箱|客户端|mail|outlook)`,
+	`^sent from (my )?.{0,40}$`,
+	`^(get|download) outlook for (ios|android)`,
+	`^(confidentiality notice|disclaimer|important notice)\b`,
+	`^this (e-?mail|message)( and any attachments?| \(including any attachments\))? (is|are|may|contains?|and)\b.{0,40}(confidential|intended|privileged)`,
+	`^(免责声明|保密声明|保密提示)`,
+	`^本邮件(及其附件)?(含有|包含|可能包含|仅供|仅限)`,
+}
+
+// SignatureTrimmer 按启发式规则去掉签名、手机客户端落款和免责声明：从第一个匹配的行开始截断
+type SignatureTrimmer struct {
+	patterns []*regexp.Regexp
+}
+
+// NewSignatureTrimmer 创建签名去除器，patterns 为自定义签名起始行正则，builtin 为是否启用内置规则
+func NewSignatureTrimmer(patterns []string, builtin bool) (*SignatureTrimmer, error) {
+	t := &SignatureTrimmer{}
+	if builtin {
+		for _, p := range builtinSignatures {
+			t.patterns = append(t.patterns, regexp.MustCompile("(?i)"+p))
+		}
+	}
+	for _, p := range patterns {
+		re, err := regexp.Compile("(?i)" + p)
+		if err != nil {
+			return nil, fmt.Errorf("签名规则正则无效 %s: %w", p, err)
+		}
+		t.patterns = append(t.patterns, re)
+	}
+	return t, nil
+}
+
+// Trim 去掉签名，第一行就匹配（没有其他内容）时返回原文
+func (t *SignatureTrimmer) Trim(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if i == 0 || !matchAny(t.patterns, strings.TrimSpace(line)) {
+			continue
+		}
+		if result := strings.TrimSpace(strings.Join(lines[:i], "\n")); result != "" {
+			return result
+		}
+	}
+	return text
+}
@@ -0,0 +1,50 @@
+package enrich
+
+import "unicode"
+
+// 语言代码
+const (
+	LangChinese  = "zh"
+	LangJapanese = "ja"
+	LangKorean   = "ko"
+	LangEnglish  = "en"
+	LangUnknown  = ""
+)
+
+// DetectLanguage 根据字符所属文字体系粗略判断文本语言
+// 汉字占比较高时判定为中文；含假名判定为日文；含谚文判定为韩文；以拉丁字母为主判定为英文
+func DetectLanguage(text string) string {
+	var han, kana, hangul, latin, total int
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			han++
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			kana++
+		case unicode.Is(unicode.Hangul, r):
+			hangul++
+		case unicode.Is(unicode.Latin, r):
+			latin++
+		default:
+			continue
+		}
+		total++
+	}
+
+	if total == 0 {
+		return LangUnknown
+	}
+
+	switch {
+	case kana > 0 && kana*10 >= total:
+		return LangJapanese
+	case hangul > 0 && hangul*10 >= total:
+		return LangKorean
+	case han*5 >= total:
+		// 中文邮件常夹杂英文单词，汉字超过两成即视为中文
+		return LangChinese
+	case latin*2 >= total:
+		return LangEnglish
+	}
+	return LangUnknown
+}
@@ -0,0 +1,109 @@
+package enrich
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// defaultSummaryPrompt 默认的摘要提示词
+const defaultSummaryPrompt = "请用2到3句话概括以下邮件的主要内容，只输出摘要本身。"
+
+// Summarizer 通过 OpenAI 兼容接口生成邮件摘要
+type Summarizer struct {
+	endpoint      string
+	apiKey        string
+	model         string
+	prompt        string
+	maxTokens     int
+	maxInputChars int
+	client        *http.Client
+}
+
+// NewSummarizer 创建摘要客户端，endpoint 为 chat/completions 接口地址
+func NewSummarizer(endpoint, apiKey, model, prompt string, maxTokens, maxInputChars int) (*Summarizer, error) {
+	if endpoint == "" {
+		endpoint = "https://api.openai.com/v1/chat/completions"
+	}
+	if model == "" {
+		return nil, fmt.Errorf("摘要配置缺少 model")
+	}
+	if prompt == "" {
+		prompt = defaultSummaryPrompt
+	}
+
+	return &Summarizer{
+		endpoint:      endpoint,
+		apiKey:        apiKey,
+		model:         model,
+		prompt:        prompt,
+		maxTokens:     maxTokens,
+		maxInputChars: maxInputChars,
+		client: &http.Client{
+			Timeout: 60 * time.Second,
+		},
+	}, nil
+}
+
+// chatMessage 对话消息
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// Summarize 生成摘要，正文超过输入上限时截断后再发送
+func (s *Summarizer) Summarize(subject, body string) (string, error) {
+	if s.maxInputChars > 0 {
+		if runes := []rune(body); len(runes) > s.maxInputChars {
+			body = string(runes[:s.maxInputChars])
+		}
+	}
+
+	payload, err := json.Marshal(map[string]interface{}{
+		"model":      s.model,
+		"max_tokens": s.maxTokens,
+		"messages": []chatMessage{
+			{Role: "system", Content: s.prompt},
+			{Role: "user", Content: "主题: " + subject + "\n\n" + body},
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, s.endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+s.apiKey)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("摘要请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("摘要请求失败: 状态码 %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Choices []struct {
+			Message chatMessage `json:"message"`
+		} `json:"choices"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("解析摘要响应失败: %w", err)
+	}
+	if len(result.Choices) == 0 {
+		return "", fmt.Errorf("摘要响应为空")
+	}
+
+	return strings.TrimSpace(result.Choices[0].Message.Content), nil
+}
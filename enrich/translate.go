@@ -0,0 +1,162 @@
+package enrich
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// 翻译服务提供方
+const (
+	ProviderDeepL  = "deepl"
+	ProviderGoogle = "google"
+)
+
+// Translator 机器翻译客户端（DeepL / Google Translate）
+type Translator struct {
+	provider   string
+	endpoint   string
+	apiKey     string
+	targetLang string
+	client     *http.Client
+}
+
+// NewTranslator 创建翻译客户端，endpoint 留空时使用各服务的默认地址
+func NewTranslator(provider, endpoint, apiKey, targetLang string) (*Translator, error) {
+	switch provider {
+	case ProviderDeepL:
+		if endpoint == "" {
+			endpoint = "https://api-free.deepl.com/v2/translate"
+		}
+		if targetLang == "" {
+			targetLang = "ZH"
+		}
+	case ProviderGoogle:
+		if endpoint == "" {
+			endpoint = "https://translation.googleapis.com/language/translate/v2"
+		}
+		if targetLang == "" {
+			targetLang = "zh-CN"
+		}
+	default:
+		return nil, fmt.Errorf("不支持的翻译服务: %s", provider)
+	}
+
+	if apiKey == "" {
+		return nil, fmt.Errorf("翻译服务 %s 缺少 api_key", provider)
+	}
+
+	return &Translator{
+		provider:   provider,
+		endpoint:   endpoint,
+		apiKey:     apiKey,
+		targetLang: targetLang,
+		client: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}, nil
+}
+
+// Translate 批量翻译文本，返回结果与输入一一对应
+func (t *Translator) Translate(texts ...string) ([]string, error) {
+	var (
+		result []string
+		err    error
+	)
+	switch t.provider {
+	case ProviderDeepL:
+		result, err = t.translateDeepL(texts)
+	default:
+		result, err = t.translateGoogle(texts)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("翻译失败: %w", err)
+	}
+	if len(result) != len(texts) {
+		return nil, fmt.Errorf("翻译失败: 返回结果数量不匹配 (%d/%d)", len(result), len(texts))
+	}
+	return result, nil
+}
+
+// translateDeepL 调用 DeepL API
+func (t *Translator) translateDeepL(texts []string) ([]string, error) {
+	form := url.Values{}
+	for _, text := range texts {
+		form.Add("text", text)
+	}
+	form.Set("target_lang", t.targetLang)
+
+	req, err := http.NewRequest(http.MethodPost, t.endpoint, bytes.NewBufferString(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Authorization", "DeepL-Auth-Key "+t.apiKey)
+
+	var resp struct {
+		Translations []struct {
+			Text string `json:"text"`
+		} `json:"translations"`
+	}
+	if err := t.do(req, &resp); err != nil {
+		return nil, err
+	}
+
+	var result []string
+	for _, tr := range resp.Translations {
+		result = append(result, tr.Text)
+	}
+	return result, nil
+}
+
+// translateGoogle 调用 Google Cloud Translation v2 API
+func (t *Translator) translateGoogle(texts []string) ([]string, error) {
+	payload, err := json.Marshal(map[string]interface{}{
+		"q":      texts,
+		"target": t.targetLang,
+		"format": "text",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, t.endpoint+"?key="+url.QueryEscape(t.apiKey), bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	var resp struct {
+		Data struct {
+			Translations []struct {
+				TranslatedText string `json:"translatedText"`
+			} `json:"translations"`
+		} `json:"data"`
+	}
+	if err := t.do(req, &resp); err != nil {
+		return nil, err
+	}
+
+	var result []string
+	for _, tr := range resp.Data.Translations {
+		result = append(result, tr.TranslatedText)
+	}
+	return result, nil
+}
+
+// do 发送请求并解析JSON响应
+func (t *Translator) do(req *http.Request, out interface{}) error {
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("状态码 %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,369 @@
+package expr
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Env 求值时的变量值：字符串为 string，数字为 float64（也接受整数类型），布尔值为 bool，列表为 []string
+type Env map[string]interface{}
+
+// Eval 求值，结果类型为 Type() 对应的 Go 类型
+func (p *Program) Eval(env Env) (interface{}, error) {
+	return p.root.eval(env)
+}
+
+// EvalBool 求值布尔表达式（如规则条件）
+func (p *Program) EvalBool(env Env) (bool, error) {
+	v, err := p.root.eval(env)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("表达式的结果不是布尔值")
+	}
+	return b, nil
+}
+
+// EvalString 求值并转为字符串（如推送标题）
+func (p *Program) EvalString(env Env) (string, error) {
+	v, err := p.root.eval(env)
+	if err != nil {
+		return "", err
+	}
+	return toString(v), nil
+}
+
+// node 语法树节点
+type node interface {
+	typ() Type
+	eval(env Env) (interface{}, error)
+}
+
+type literal struct {
+	v interface{}
+	t Type
+}
+
+func (n *literal) typ() Type                     { return n.t }
+func (n *literal) eval(Env) (interface{}, error) { return n.v, nil }
+
+type variable struct {
+	name string
+	t    Type
+}
+
+func (n *variable) typ() Type { return n.t }
+
+func (n *variable) eval(env Env) (interface{}, error) {
+	v, ok := env[n.name]
+	if !ok {
+		// 未提供的变量按零值处理
+		switch n.t {
+		case String:
+			return "", nil
+		case Number:
+			return float64(0), nil
+		case Bool:
+			return false, nil
+		}
+		return []string(nil), nil
+	}
+	switch x := v.(type) {
+	case int:
+		return float64(x), nil
+	case int64:
+		return float64(x), nil
+	case uint32:
+		return float64(x), nil
+	}
+	return v, nil
+}
+
+type list struct {
+	items []node
+}
+
+func (n *list) typ() Type { return List }
+
+func (n *list) eval(env Env) (interface{}, error) {
+	out := make([]string, 0, len(n.items))
+	for _, item := range n.items {
+		v, err := item.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, v.(string))
+	}
+	return out, nil
+}
+
+type not struct {
+	x node
+}
+
+func (n *not) typ() Type { return Bool }
+
+func (n *not) eval(env Env) (interface{}, error) {
+	v, err := n.x.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	return !v.(bool), nil
+}
+
+// logical 短路求值的 && 和 ||
+type logical struct {
+	and  bool
+	x, y node
+}
+
+func (n *logical) typ() Type { return Bool }
+
+func (n *logical) eval(env Env) (interface{}, error) {
+	v, err := n.x.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	if v.(bool) != n.and {
+		return v, nil
+	}
+	return n.y.eval(env)
+}
+
+type ternary struct {
+	cond, a, b node
+}
+
+func (n *ternary) typ() Type { return n.a.typ() }
+
+func (n *ternary) eval(env Env) (interface{}, error) {
+	c, err := n.cond.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	if c.(bool) {
+		return n.a.eval(env)
+	}
+	return n.b.eval(env)
+}
+
+type match struct {
+	x  node
+	re *regexp.Regexp
+}
+
+func (n *match) typ() Type { return Bool }
+
+func (n *match) eval(env Env) (interface{}, error) {
+	v, err := n.x.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	return n.re.MatchString(v.(string)), nil
+}
+
+// binary 二元运算，类型已在编译时检查
+type binary struct {
+	op   string
+	x, y node
+	t    Type
+}
+
+func (n *binary) typ() Type { return n.t }
+
+func (n *binary) eval(env Env) (interface{}, error) {
+	x, err := n.x.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	y, err := n.y.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==":
+		return x == y, nil
+	case "!=":
+		return x != y, nil
+	case "concat":
+		return toString(x) + toString(y), nil
+	case "contains":
+		if l, ok := x.([]string); ok {
+			return containsFold(l, y.(string)), nil
+		}
+		return strings.Contains(strings.ToLower(x.(string)), strings.ToLower(y.(string))), nil
+	case "in":
+		if l, ok := y.([]string); ok {
+			return containsFold(l, x.(string)), nil
+		}
+		return strings.Contains(strings.ToLower(y.(string)), strings.ToLower(x.(string))), nil
+	case "startsWith":
+		return strings.HasPrefix(strings.ToLower(x.(string)), strings.ToLower(y.(string))), nil
+	case "endsWith":
+		return strings.HasSuffix(strings.ToLower(x.(string)), strings.ToLower(y.(string))), nil
+	}
+
+	if xs, ok := x.(string); ok {
+		ys := y.(string)
+		switch n.op {
+		case "<":
+			return xs < ys, nil
+		case "<=":
+			return xs <= ys, nil
+		case ">":
+			return xs > ys, nil
+		case ">=":
+			return xs >= ys, nil
+		}
+	}
+
+	a, b := x.(float64), y.(float64)
+	switch n.op {
+	case "<":
+		return a < b, nil
+	case "<=":
+		return a <= b, nil
+	case ">":
+		return a > b, nil
+	case ">=":
+		return a >= b, nil
+	case "+":
+		return a + b, nil
+	case "-":
+		return a - b, nil
+	case "*":
+		return a * b, nil
+	case "/":
+		if b == 0 {
+			return nil, fmt.Errorf("除数为 0")
+		}
+		return a / b, nil
+	case "%":
+		if b == 0 {
+			return nil, fmt.Errorf("除数为 0")
+		}
+		return math.Mod(a, b), nil
+	}
+	return nil, fmt.Errorf("未知的运算符 %s", n.op)
+}
+
+// 函数参数的特殊类型
+const (
+	stringOrList Type = -1 // 字符串或列表
+	pattern      Type = -2 // 正则字符串字面量（编译时编译）
+)
+
+// function 内置函数
+type function struct {
+	params []Type
+	ret    Type
+	fn     func(args []interface{}, re *regexp.Regexp) interface{}
+}
+
+// functions 内置函数表
+var functions = map[string]*function{
+	"lower": {params: []Type{String}, ret: String, fn: func(a []interface{}, _ *regexp.Regexp) interface{} {
+		return strings.ToLower(a[0].(string))
+	}},
+	"upper": {params: []Type{String}, ret: String, fn: func(a []interface{}, _ *regexp.Regexp) interface{} {
+		return strings.ToUpper(a[0].(string))
+	}},
+	"trim": {params: []Type{String}, ret: String, fn: func(a []interface{}, _ *regexp.Regexp) interface{} {
+		return strings.TrimSpace(a[0].(string))
+	}},
+	"len": {params: []Type{stringOrList}, ret: Number, fn: func(a []interface{}, _ *regexp.Regexp) interface{} {
+		if l, ok := a[0].([]string); ok {
+			return float64(len(l))
+		}
+		return float64(len([]rune(a[0].(string))))
+	}},
+	"replace": {params: []Type{String, String, String}, ret: String, fn: func(a []interface{}, _ *regexp.Regexp) interface{} {
+		return strings.ReplaceAll(a[0].(string), a[1].(string), a[2].(string))
+	}},
+	"truncate": {params: []Type{String, Number}, ret: String, fn: func(a []interface{}, _ *regexp.Regexp) interface{} {
+		r := []rune(a[0].(string))
+		n := int(a[1].(float64))
+		if n < 0 || len(r) <= n {
+			return a[0]
+		}
+		return string(r[:n]) + "…"
+	}},
+	"join": {params: []Type{List, String}, ret: String, fn: func(a []interface{}, _ *regexp.Regexp) interface{} {
+		return strings.Join(a[0].([]string), a[1].(string))
+	}},
+	"first": {params: []Type{List}, ret: String, fn: func(a []interface{}, _ *regexp.Regexp) interface{} {
+		if l := a[0].([]string); len(l) > 0 {
+			return l[0]
+		}
+		return ""
+	}},
+	"default": {params: []Type{String, String}, ret: String, fn: func(a []interface{}, _ *regexp.Regexp) interface{} {
+		if a[0].(string) != "" {
+			return a[0]
+		}
+		return a[1]
+	}},
+	// find 返回第一处匹配，正则含分组时返回第一个分组，未匹配时返回空字符串
+	"find": {params: []Type{String, pattern}, ret: String, fn: func(a []interface{}, re *regexp.Regexp) interface{} {
+		m := re.FindStringSubmatch(a[0].(string))
+		switch {
+		case m == nil:
+			return ""
+		case len(m) > 1:
+			return m[1]
+		}
+		return m[0]
+	}},
+}
+
+type call struct {
+	name string
+	fn   *function
+	args []node
+	re   *regexp.Regexp // pattern 参数编译后的正则
+}
+
+func (n *call) typ() Type { return n.fn.ret }
+
+func (n *call) eval(env Env) (interface{}, error) {
+	args := make([]interface{}, len(n.args))
+	for i, arg := range n.args {
+		v, err := arg.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = v
+	}
+	return n.fn.fn(args, n.re), nil
+}
+
+// containsFold 列表中是否有与 s 相同（不区分大小写）的元素
+func containsFold(l []string, s string) bool {
+	for _, v := range l {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// toString 将值转为字符串：整数不带小数点，列表以逗号分隔
+func toString(v interface{}) string {
+	switch x := v.(type) {
+	case string:
+		return x
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(x)
+	case []string:
+		return strings.Join(x, ", ")
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,201 @@
+package expr
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var testVars = Vars{
+	"subject": String,
+	"from":    String,
+	"size":    Number,
+	"unread":  Bool,
+	"to":      List,
+	"missing": String,
+}
+
+var testEnv = Env{
+	"subject": "Your code is 123456",
+	"from":    "Alice@Example.com",
+	"size":    2048, // 整数类型的值按数字处理
+	"unread":  true,
+	"to":      []string{"a@example.com", "B@example.org"},
+}
+
+func TestEval(t *testing.T) {
+	tests := []struct {
+		src  string
+		want interface{}
+	}{
+		// 算术优先级和结合性
+		{"1 + 2 * 3", float64(7)},
+		{"(1 + 2) * 3", float64(9)},
+		{"10 - 4 - 3", float64(3)},
+		{"12 / 3 / 2", float64(2)},
+		{"-2 * 3", float64(-6)},
+		{"- 2 + 3", float64(1)},
+		{"--2", float64(2)},
+		{"7 % 4 + 1", float64(4)},
+		{"size / 1024", float64(2)},
+
+		// 比较低于算术，逻辑运算低于比较，&& 高于 ||，! 高于 &&
+		{"size > 1000 + 1000", true},
+		{"1 + 1 == 2", true},
+		{"true || false && false", true},
+		{"(true || false) && false", false},
+		{"!false && false", false},
+		{"!(false && false)", true},
+		{"not size > 4096", true},
+		{"unread and size < 100 or from endsWith 'example.com'", true},
+
+		// 字符串拼接和比较
+		{"'a' + 1 + 2", "a12"},
+		{"1 + 2 + 'a'", "3a"},
+		{"'abc' < 'abd'", true},
+		{"subject contains 'CODE'", true},
+		{"from startsWith 'alice'", true},
+		{"'b@example.org' in to", true},
+		{"to contains 'c@example.com'", false},
+		{"'code' in subject", true},
+		{"missing == ''", true},
+
+		// ?: 优先级最低、右结合，分支只求值选中的一个
+		{"size > 4096 ? 'big' : 'small'", "small"},
+		{"size > 4096 ? 'big' : size > 1024 ? 'medium' : 'small'", "medium"},
+		{"true || false ? 1 : 2", float64(1)},
+		{"false ? 1 : 2 + 3", float64(5)},
+		{"(unread ? 'new' : 'old') + ':' + subject", "new:Your code is 123456"},
+		{"false ? 1 / 0 : 0", float64(0)},
+		{"true ? (false ? 'a' : 'b') : 'c'", "b"},
+
+		// matches 不区分大小写，优先级同比较
+		{"subject matches '\\d{6}'", true},
+		{"subject matches 'YOUR CODE'", true},
+		{"subject matches '^code'", false},
+		{"!(subject matches 'code') || unread", true},
+		{"subject matches 'code' && size > 0", true},
+
+		// 函数和列表
+		{"find(subject, '(\\d{6})')", "123456"},
+		{"find(subject, 'xyz')", ""},
+		{"len(to)", float64(2)},
+		{"len('验证码')", float64(3)},
+		{"truncate(subject, 4)", "Your…"},
+		{"join(['x', lower('Y')], '-')", "x-y"},
+		{"default(missing, 'none')", "none"},
+		{"first(to)", "a@example.com"},
+	}
+	for _, tt := range tests {
+		p, err := Compile(tt.src, testVars)
+		if err != nil {
+			t.Errorf("%s: 编译失败: %v", tt.src, err)
+			continue
+		}
+		got, err := p.Eval(testEnv)
+		if err != nil {
+			t.Errorf("%s: 求值失败: %v", tt.src, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s = %#v，期望 %#v", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestType(t *testing.T) {
+	tests := []struct {
+		src  string
+		want Type
+	}{
+		{"size", Number},
+		{"subject + size", String},
+		{"size > 1 ? 'a' : 'b'", String},
+		{"unread ? size : 0", Number},
+		{"subject matches 'x'", Bool},
+		{"['a', subject]", List},
+		{"len(to) * 2", Number},
+	}
+	for _, tt := range tests {
+		p, err := Compile(tt.src, testVars)
+		if err != nil {
+			t.Errorf("%s: 编译失败: %v", tt.src, err)
+			continue
+		}
+		if got := p.Type(); got != tt.want {
+			t.Errorf("%s 的类型为%s，期望%s", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string // 错误信息应包含的内容
+	}{
+		// 类型检查
+		{"size + true", "+ 不能用于数字和布尔值"},
+		{"subject > 1", "> 不能用于字符串和数字"},
+		{"subject - 'a'", "- 不能用于字符串和字符串"},
+		{"subject && unread", "&& 不能用于字符串和布尔值"},
+		{"!size", "! 不能用于数字"},
+		{"-subject", "- 不能用于字符串"},
+		{"to == to", "== 不能用于列表和列表"},
+		{"size contains 'a'", "contains 不能用于数字和字符串"},
+		{"size ? 1 : 2", "?: 的条件应为布尔值，实际为数字"},
+		{"unread ? 1 : 'a'", "?: 两个分支的类型不同（数字和字符串）"},
+		{"[size]", "列表元素应为字符串，实际为数字"},
+		{"size matches 'a'", "matches 不能用于数字"},
+		{"lower(size)", "lower 的第 1 个参数应为字符串，实际为数字"},
+		{"len(unread)", "len 的第 1 个参数应为字符串或列表，实际为布尔值"},
+		{"find(subject, from)", "find 的第 2 个参数应为正则字符串"},
+		{"truncate(subject)", "truncate 需要 2 个参数，实际为 1 个"},
+
+		// 语法
+		{"(1 + 2", "缺少 \")\""},
+		{"1 +", "表达式不完整"},
+		{"1 2", "多余的 \"2\""},
+		{"1 == 2 == 3", "多余的 \"==\""},
+		{"unread ? 1", "缺少 \":\""},
+		{"unread ? 1 ; 2", "无效的字符 ';'"},
+		{"subject matches from", "matches 后应为正则字符串"},
+		{"subject matches '('", "正则无效"},
+		{"find(subject, '(')", "find 的正则无效"},
+		{"'abc", "字符串缺少结束引号"},
+		{"unknown", "未知的变量 unknown"},
+		{"foo(1)", "未知的函数 foo"},
+		{"1..2", "数字无效"},
+		{")", "意外的 \")\""},
+		{"['a' 'b']", "应为 \",\"，实际为 \"'b'\""},
+	}
+	for _, tt := range tests {
+		_, err := Compile(tt.src, testVars)
+		if err == nil {
+			t.Errorf("%s: 应编译失败", tt.src)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: 错误 %q 不包含 %q", tt.src, err, tt.want)
+		}
+	}
+}
+
+func TestErrorPosition(t *testing.T) {
+	// 位置按字符计算，中文字符串算一个字符
+	_, err := Compile("'主题' + true", testVars)
+	if err == nil || !strings.HasPrefix(err.Error(), "表达式第 6 个字符") {
+		t.Fatalf("错误位置不正确: %v", err)
+	}
+}
+
+func TestEvalErrors(t *testing.T) {
+	for _, src := range []string{"size / 0", "size % (1 - 1)"} {
+		p, err := Compile(src, testVars)
+		if err != nil {
+			t.Fatalf("%s: 编译失败: %v", src, err)
+		}
+		if _, err := p.Eval(testEnv); err == nil || !strings.Contains(err.Error(), "除数为 0") {
+			t.Errorf("%s: 期望除数为 0 的错误，实际为 %v", src, err)
+		}
+	}
+}
@@ -0,0 +1,562 @@
+package expr
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Type 表达式的值类型
+type Type int
+
+// 值类型：求值结果分别为 string / float64 / bool / []string
+const (
+	String Type = iota + 1
+	Number
+	Bool
+	List // 字符串列表
+)
+
+// String 返回类型名称（用于错误信息）
+func (t Type) String() string {
+	switch t {
+	case String:
+		return "字符串"
+	case Number:
+		return "数字"
+	case Bool:
+		return "布尔值"
+	case List:
+		return "列表"
+	case stringOrList:
+		return "字符串或列表"
+	case pattern:
+		return "正则字符串"
+	}
+	return "未知类型"
+}
+
+// Vars 表达式可用的变量及其类型
+type Vars map[string]Type
+
+// Program 编译后的表达式：编译时检查变量、函数和类型，求值时不会因类型错误失败
+type Program struct {
+	src  string
+	root node
+}
+
+// Compile 编译表达式，vars 为可用的变量
+func Compile(src string, vars Vars) (*Program, error) {
+	toks, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{toks: toks, vars: vars}
+	root, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, p.errorf(t, "多余的 %q", t.text)
+	}
+	return &Program{src: src, root: root}, nil
+}
+
+// Type 返回表达式结果的类型
+func (p *Program) Type() Type {
+	return p.root.typ()
+}
+
+// Source 返回表达式原文
+func (p *Program) Source() string {
+	return p.src
+}
+
+// token 词法单元
+type token struct {
+	kind tokKind
+	text string
+	pos  int // 在表达式中的字符位置（从 0 开始）
+	str  string
+	num  float64
+}
+
+type tokKind int
+
+const (
+	tokEOF tokKind = iota
+	tokIdent
+	tokString
+	tokNumber
+	tokOp
+)
+
+// operators 运算符和标点，较长的在前
+var operators = []string{
+	"&&", "||", "==", "!=", "<=", ">=",
+	"<", ">", "!", "+", "-", "*", "/", "%", "(", ")", ",", "[", "]", "?", ":",
+}
+
+// lex 将表达式切分为词法单元
+func lex(src string) ([]token, error) {
+	var toks []token
+	pos := 0 // 字符位置
+	for i := 0; i < len(src); {
+		r, size := utf8.DecodeRuneInString(src[i:])
+		switch {
+		case unicode.IsSpace(r):
+			i += size
+			pos++
+		case r == '"' || r == '\'':
+			s, n, err := lexString(src[i:], r)
+			if err != nil {
+				return nil, fmt.Errorf("表达式第 %d 个字符: %v", pos+1, err)
+			}
+			toks = append(toks, token{kind: tokString, text: src[i : i+n], pos: pos, str: s})
+			pos += utf8.RuneCountInString(src[i : i+n])
+			i += n
+		case r >= '0' && r <= '9':
+			j := i
+			for j < len(src) && (src[j] >= '0' && src[j] <= '9' || src[j] == '.') {
+				j++
+			}
+			f, err := strconv.ParseFloat(src[i:j], 64)
+			if err != nil {
+				return nil, fmt.Errorf("表达式第 %d 个字符: 数字无效 %q", pos+1, src[i:j])
+			}
+			toks = append(toks, token{kind: tokNumber, text: src[i:j], pos: pos, num: f})
+			pos += j - i
+			i = j
+		case r == '_' || unicode.IsLetter(r):
+			j := i
+			for j < len(src) {
+				c, n := utf8.DecodeRuneInString(src[j:])
+				if c != '_' && !unicode.IsLetter(c) && !unicode.IsDigit(c) {
+					break
+				}
+				j += n
+			}
+			toks = append(toks, token{kind: tokIdent, text: src[i:j], pos: pos})
+			pos += utf8.RuneCountInString(src[i:j])
+			i = j
+		default:
+			op := ""
+			for _, o := range operators {
+				if strings.HasPrefix(src[i:], o) {
+					op = o
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("表达式第 %d 个字符: 无效的字符 %q", pos+1, r)
+			}
+			toks = append(toks, token{kind: tokOp, text: op, pos: pos})
+			pos += len(op)
+			i += len(op)
+		}
+	}
+	return append(toks, token{kind: tokEOF, pos: pos}), nil
+}
+
+// lexString 读取引号括起的字符串，返回内容和占用的字节数
+// 支持 \" \' \\ \n \t 转义，其他反斜杠原样保留（方便书写正则，如 '\d{6}'）
+func lexString(s string, quote rune) (string, int, error) {
+	var b strings.Builder
+	for i := 1; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == quote:
+			return b.String(), i + size, nil
+		case r == '\\' && i+1 < len(s):
+			switch s[i+1] {
+			case '"', '\'', '\\':
+				b.WriteByte(s[i+1])
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			default:
+				b.WriteByte('\\')
+				b.WriteByte(s[i+1])
+			}
+			i += 2
+		default:
+			b.WriteRune(r)
+			i += size
+		}
+	}
+	return "", 0, fmt.Errorf("字符串缺少结束引号")
+}
+
+// parser 递归下降解析，同时进行类型检查
+// 优先级从低到高：?: → ||/or → &&/and → !/not → 比较和字符串运算 → +/- → */% → 负号 → 字面量、变量、函数调用、括号、列表
+type parser struct {
+	toks []token
+	i    int
+	vars Vars
+}
+
+func (p *parser) peek() token {
+	return p.toks[p.i]
+}
+
+func (p *parser) next() token {
+	t := p.toks[p.i]
+	if t.kind != tokEOF {
+		p.i++
+	}
+	return t
+}
+
+// accept 下一个词法单元为指定的运算符或关键字时读取并返回true
+func (p *parser) accept(texts ...string) (token, bool) {
+	t := p.peek()
+	if t.kind != tokOp && t.kind != tokIdent {
+		return t, false
+	}
+	for _, s := range texts {
+		if t.text == s {
+			return p.next(), true
+		}
+	}
+	return t, false
+}
+
+func (p *parser) expect(text string) error {
+	if _, ok := p.accept(text); !ok {
+		t := p.peek()
+		if t.kind == tokEOF {
+			return p.errorf(t, "缺少 %q", text)
+		}
+		return p.errorf(t, "应为 %q，实际为 %q", text, t.text)
+	}
+	return nil
+}
+
+func (p *parser) errorf(t token, format string, args ...interface{}) error {
+	return fmt.Errorf("表达式第 %d 个字符: %s", t.pos+1, fmt.Sprintf(format, args...))
+}
+
+// typeError 运算数类型不符
+func (p *parser) typeError(t token, x, y node) error {
+	if y == nil {
+		return p.errorf(t, "%s 不能用于%s", t.text, x.typ())
+	}
+	return p.errorf(t, "%s 不能用于%s和%s", t.text, x.typ(), y.typ())
+}
+
+func (p *parser) parseExpr() (node, error) {
+	cond, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	q, ok := p.accept("?")
+	if !ok {
+		return cond, nil
+	}
+	if cond.typ() != Bool {
+		return nil, p.errorf(q, "?: 的条件应为布尔值，实际为%s", cond.typ())
+	}
+	a, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(":"); err != nil {
+		return nil, err
+	}
+	b, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if a.typ() != b.typ() {
+		return nil, p.errorf(q, "?: 两个分支的类型不同（%s和%s）", a.typ(), b.typ())
+	}
+	return &ternary{cond: cond, a: a, b: b}, nil
+}
+
+func (p *parser) parseOr() (node, error) {
+	x, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t, ok := p.accept("||", "or")
+		if !ok {
+			return x, nil
+		}
+		y, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		if x.typ() != Bool || y.typ() != Bool {
+			return nil, p.typeError(t, x, y)
+		}
+		x = &logical{and: false, x: x, y: y}
+	}
+}
+
+func (p *parser) parseAnd() (node, error) {
+	x, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t, ok := p.accept("&&", "and")
+		if !ok {
+			return x, nil
+		}
+		y, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		if x.typ() != Bool || y.typ() != Bool {
+			return nil, p.typeError(t, x, y)
+		}
+		x = &logical{and: true, x: x, y: y}
+	}
+}
+
+func (p *parser) parseNot() (node, error) {
+	t, ok := p.accept("!", "not")
+	if !ok {
+		return p.parseCompare()
+	}
+	x, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	if x.typ() != Bool {
+		return nil, p.typeError(t, x, nil)
+	}
+	return &not{x: x}, nil
+}
+
+// compareOps 比较和字符串运算符（不可连用）
+var compareOps = []string{"==", "!=", "<", "<=", ">", ">=", "contains", "startsWith", "endsWith", "matches", "in"}
+
+func (p *parser) parseCompare() (node, error) {
+	x, err := p.parseAdd()
+	if err != nil {
+		return nil, err
+	}
+	t, ok := p.accept(compareOps...)
+	if !ok {
+		return x, nil
+	}
+	if t.text == "matches" {
+		pat := p.peek()
+		if pat.kind != tokString {
+			return nil, p.errorf(pat, "matches 后应为正则字符串")
+		}
+		p.next()
+		if x.typ() != String {
+			return nil, p.typeError(t, x, nil)
+		}
+		re, err := regexp.Compile("(?i)" + pat.str)
+		if err != nil {
+			return nil, p.errorf(pat, "正则无效: %v", err)
+		}
+		return &match{x: x, re: re}, nil
+	}
+
+	y, err := p.parseAdd()
+	if err != nil {
+		return nil, err
+	}
+	xt, yt := x.typ(), y.typ()
+	valid := false
+	switch t.text {
+	case "==", "!=":
+		valid = xt == yt && xt != List
+	case "<", "<=", ">", ">=":
+		valid = xt == yt && (xt == Number || xt == String)
+	case "contains":
+		valid = yt == String && (xt == String || xt == List)
+	case "in":
+		valid = xt == String && (yt == String || yt == List)
+	case "startsWith", "endsWith":
+		valid = xt == String && yt == String
+	}
+	if !valid {
+		return nil, p.typeError(t, x, y)
+	}
+	return &binary{op: t.text, x: x, y: y, t: Bool}, nil
+}
+
+func (p *parser) parseAdd() (node, error) {
+	x, err := p.parseMul()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t, ok := p.accept("+", "-")
+		if !ok {
+			return x, nil
+		}
+		y, err := p.parseMul()
+		if err != nil {
+			return nil, err
+		}
+		xt, yt := x.typ(), y.typ()
+		switch {
+		case xt == Number && yt == Number:
+			x = &binary{op: t.text, x: x, y: y, t: Number}
+		case t.text == "+" && (xt == String || yt == String) && xt != List && yt != List && xt != Bool && yt != Bool:
+			// 字符串拼接，数字自动转为字符串
+			x = &binary{op: "concat", x: x, y: y, t: String}
+		default:
+			return nil, p.typeError(t, x, y)
+		}
+	}
+}
+
+func (p *parser) parseMul() (node, error) {
+	x, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t, ok := p.accept("*", "/", "%")
+		if !ok {
+			return x, nil
+		}
+		y, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if x.typ() != Number || y.typ() != Number {
+			return nil, p.typeError(t, x, y)
+		}
+		x = &binary{op: t.text, x: x, y: y, t: Number}
+	}
+}
+
+func (p *parser) parseUnary() (node, error) {
+	t, ok := p.accept("-")
+	if !ok {
+		return p.parsePrimary()
+	}
+	x, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	if x.typ() != Number {
+		return nil, p.typeError(t, x, nil)
+	}
+	return &binary{op: "-", x: &literal{v: float64(0), t: Number}, y: x, t: Number}, nil
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokString:
+		return &literal{v: t.str, t: String}, nil
+	case tokNumber:
+		return &literal{v: t.num, t: Number}, nil
+	case tokEOF:
+		return nil, p.errorf(t, "表达式不完整")
+	case tokOp:
+		switch t.text {
+		case "(":
+			x, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return x, nil
+		case "[":
+			return p.parseList()
+		}
+		return nil, p.errorf(t, "意外的 %q", t.text)
+	}
+
+	switch t.text {
+	case "true", "false":
+		return &literal{v: t.text == "true", t: Bool}, nil
+	}
+	if _, ok := p.accept("("); ok {
+		return p.parseCall(t)
+	}
+	typ, ok := p.vars[t.text]
+	if !ok {
+		return nil, p.errorf(t, "未知的变量 %s", t.text)
+	}
+	return &variable{name: t.text, t: typ}, nil
+}
+
+// parseList 解析字符串列表字面量，如 ["a", "b"]
+func (p *parser) parseList() (node, error) {
+	l := &list{}
+	if _, ok := p.accept("]"); ok {
+		return l, nil
+	}
+	for {
+		t := p.peek()
+		x, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if x.typ() != String {
+			return nil, p.errorf(t, "列表元素应为字符串，实际为%s", x.typ())
+		}
+		l.items = append(l.items, x)
+		if _, ok := p.accept("]"); ok {
+			return l, nil
+		}
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// parseCall 解析函数调用（已读取函数名和左括号）并检查参数
+func (p *parser) parseCall(name token) (node, error) {
+	fn, ok := functions[name.text]
+	if !ok {
+		return nil, p.errorf(name, "未知的函数 %s", name.text)
+	}
+	c := &call{name: name.text, fn: fn}
+	if _, ok := p.accept(")"); !ok {
+		for {
+			arg, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			c.args = append(c.args, arg)
+			if _, ok := p.accept(")"); ok {
+				break
+			}
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if len(c.args) != len(fn.params) {
+		return nil, p.errorf(name, "%s 需要 %d 个参数，实际为 %d 个", name.text, len(fn.params), len(c.args))
+	}
+	for i, arg := range c.args {
+		want := fn.params[i]
+		if want == stringOrList && (arg.typ() == String || arg.typ() == List) || want == arg.typ() {
+			continue
+		}
+		if want == pattern {
+			lit, ok := arg.(*literal)
+			if !ok || lit.t != String {
+				return nil, p.errorf(name, "%s 的第 %d 个参数应为正则字符串", name.text, i+1)
+			}
+			re, err := regexp.Compile("(?i)" + lit.v.(string))
+			if err != nil {
+				return nil, p.errorf(name, "%s 的正则无效: %v", name.text, err)
+			}
+			c.re = re
+			continue
+		}
+		return nil, p.errorf(name, "%s 的第 %d 个参数应为%s，实际为%s", name.text, i+1, want, arg.typ())
+	}
+	return c, nil
+}
@@ -0,0 +1,237 @@
+package handoff
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 交接使用的环境变量
+const (
+	envHandoff   = "MAIL_RECEIVER_HANDOFF"           // 非空表示由旧进程启动
+	envListeners = "MAIL_RECEIVER_HANDOFF_LISTENERS" // 继承的监听地址（逗号分隔），依次对应 fd 4、5…
+)
+
+// 新进程中的文件描述符：fd 3 为就绪通知管道，fd 4 为旧进程退出通知管道，之后为继承的监听套接字
+const (
+	readyFD         = 3
+	releaseFD       = 4
+	firstListenerFD = 5
+)
+
+var (
+	mu        sync.Mutex
+	listeners []listener                  // 当前进程打开的监听套接字，交接时传给新进程
+	inherited map[string]*net.TCPListener // 从旧进程继承、尚未被使用的监听套接字
+	loaded    bool
+
+	// releaseW 旧进程退出通知管道的写端，交接成功后保持打开，进程退出时由系统关闭，新进程读到 EOF
+	releaseW *os.File
+)
+
+// listener 按配置中的监听地址记录的套接字（实际地址可能与配置写法不同，如 ":8080"）
+type listener struct {
+	addr string
+	ln   *net.TCPListener
+}
+
+// Inherited 当前进程是否由旧进程交接启动
+func Inherited() bool {
+	return os.Getenv(envHandoff) != ""
+}
+
+// Listen 监听 TCP 地址：交接启动时复用旧进程的监听套接字（不中断HTTP服务），并记录以便下次交接
+func Listen(addr string) (net.Listener, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	loadInherited()
+	if ln, ok := inherited[addr]; ok {
+		delete(inherited, addr)
+		listeners = append(listeners, listener{addr, ln})
+		return ln, nil
+	}
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	if tcp, ok := ln.(*net.TCPListener); ok {
+		listeners = append(listeners, listener{addr, tcp})
+	}
+	return ln, nil
+}
+
+// loadInherited 解析从旧进程继承的监听套接字（只执行一次）
+func loadInherited() {
+	if loaded {
+		return
+	}
+	loaded = true
+	inherited = make(map[string]*net.TCPListener)
+	if !Inherited() || os.Getenv(envListeners) == "" {
+		return
+	}
+	for i, addr := range strings.Split(os.Getenv(envListeners), ",") {
+		f := os.NewFile(uintptr(firstListenerFD+i), "listener:"+addr)
+		ln, err := net.FileListener(f)
+		f.Close()
+		if err != nil {
+			continue
+		}
+		if tcp, ok := ln.(*net.TCPListener); ok {
+			inherited[addr] = tcp
+		}
+	}
+}
+
+// Ready 通知旧进程新进程已就绪，旧进程随后退出；非交接启动时不做任何事
+func Ready() {
+	if !Inherited() {
+		return
+	}
+	f := os.NewFile(readyFD, "handoff-ready")
+	f.Write([]byte{1})
+	f.Close()
+
+	// systemd 下将主进程切换为新进程（需要服务设置 NotifyAccess=all）
+	notifySystemd("MAINPID=" + strconv.Itoa(os.Getpid()))
+}
+
+// Released 返回旧进程退出（或崩溃）后关闭的通道，新进程据此在旧进程停止获取邮件后再开始获取；非交接启动时返回nil
+func Released() <-chan struct{} {
+	if !Inherited() {
+		return nil
+	}
+	done := make(chan struct{})
+	go func() {
+		f := os.NewFile(releaseFD, "handoff-release")
+		defer f.Close()
+		buf := make([]byte, 1)
+		for {
+			if _, err := f.Read(buf); err != nil {
+				break
+			}
+		}
+		close(done)
+	}()
+	return done
+}
+
+// Start 以相同的参数启动新进程并传递监听套接字，等待新进程就绪
+// 超时或新进程提前退出时返回错误，旧进程应继续运行
+func Start(timeout time.Duration) (pid int, err error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return 0, fmt.Errorf("获取程序路径失败: %w", err)
+	}
+
+	readyR, readyW, err := os.Pipe()
+	if err != nil {
+		return 0, fmt.Errorf("创建就绪通知管道失败: %w", err)
+	}
+	defer readyR.Close()
+	releaseR, relW, err := os.Pipe()
+	if err != nil {
+		readyW.Close()
+		return 0, fmt.Errorf("创建退出通知管道失败: %w", err)
+	}
+
+	files := []*os.File{readyW, releaseR}
+	var addrs []string
+	mu.Lock()
+	for _, l := range listeners {
+		f, err := l.ln.File()
+		if err != nil {
+			mu.Unlock()
+			closeAll(files)
+			relW.Close()
+			return 0, fmt.Errorf("复制监听套接字失败: %w", err)
+		}
+		files = append(files, f)
+		addrs = append(addrs, l.addr)
+	}
+	mu.Unlock()
+
+	cmd := exec.Command(exe, os.Args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	cmd.ExtraFiles = files
+	cmd.Env = append(filterEnv(os.Environ()), envHandoff+"=1", envListeners+"="+strings.Join(addrs, ","))
+	err = cmd.Start()
+	// 子进程已持有副本，关闭本进程中的描述符（就绪管道写端关闭后子进程退出时读端会收到 EOF）
+	closeAll(files)
+	if err != nil {
+		relW.Close()
+		return 0, fmt.Errorf("启动新进程失败: %w", err)
+	}
+
+	ready := make(chan error, 1)
+	go func() {
+		buf := make([]byte, 1)
+		if _, err := readyR.Read(buf); err != nil {
+			ready <- errors.New("新进程未就绪即退出")
+			return
+		}
+		ready <- nil
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case err := <-ready:
+		if err != nil {
+			relW.Close()
+			cmd.Wait()
+			return 0, err
+		}
+	case <-timer.C:
+		relW.Close()
+		cmd.Process.Kill()
+		cmd.Wait()
+		return 0, fmt.Errorf("等待新进程就绪超时 (%v)", timeout)
+	}
+	releaseW = relW
+
+	pid = cmd.Process.Pid
+	cmd.Process.Release()
+	return pid, nil
+}
+
+// filterEnv 去掉上一次交接留下的环境变量
+func filterEnv(env []string) []string {
+	var out []string
+	for _, kv := range env {
+		if strings.HasPrefix(kv, envHandoff+"=") || strings.HasPrefix(kv, envListeners+"=") {
+			continue
+		}
+		out = append(out, kv)
+	}
+	return out
+}
+
+// closeAll 关闭文件列表
+func closeAll(files []*os.File) {
+	for _, f := range files {
+		f.Close()
+	}
+}
+
+// notifySystemd 向 systemd 发送状态通知（未在 systemd 下运行时忽略）
+func notifySystemd(state string) {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return
+	}
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	conn.Write([]byte(state))
+}
@@ -5,6 +5,8 @@ import (
 	"log"
 	"net/http"
 	"time"
+
+	"mail-receiver/clock"
 )
 
 // Heartbeat 心跳检测器
@@ -13,6 +15,7 @@ type Heartbeat struct {
 	interval    time.Duration
 	accountName string
 	client      *http.Client
+	clock       clock.Clock
 }
 
 // New 创建心跳检测器
@@ -24,9 +27,15 @@ func New(url string, intervalSeconds int, accountName string) *Heartbeat {
 		client: &http.Client{
 			Timeout: 10 * time.Second,
 		},
+		clock: clock.Real,
 	}
 }
 
+// SetClock 设置时间来源（模拟运行和测试时快进时间）
+func (h *Heartbeat) SetClock(c clock.Clock) {
+	h.clock = clock.Or(c)
+}
+
 // Start 启动心跳检测
 func (h *Heartbeat) Start() {
 	if h.url == "" {
@@ -41,7 +50,7 @@ func (h *Heartbeat) Start() {
 
 		for {
 			// 等待间隔后发送下一次心跳
-			time.Sleep(h.interval)
+			h.clock.Sleep(h.interval)
 			h.sendHeartbeat()
 		}
 	}()
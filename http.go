@@ -0,0 +1,42 @@
+package main
+
+import (
+	"mail-receiver/config"
+	"mail-receiver/httpserver"
+	"mail-receiver/metrics"
+	"mail-receiver/receiver"
+)
+
+// startHTTP 启动内置HTTP服务（状态和管理接口、网页查看邮件）和 Prometheus 指标接口
+// 指标监听地址与 http.listen 相同时共用同一个服务
+func startHTTP(app *config.AppConfig, recv *receiver.Receiver) error {
+	var srv *httpserver.Server
+	if app.HTTP.Listen != "" {
+		srv = httpserver.New(app.HTTP)
+		admin := newAdminHandler(recv, app.HTTP.Token)
+		srv.Handle("/api/status", admin)
+		srv.Handle("/api/accounts", admin)
+		srv.Handle("/api/accounts/", admin)
+		srv.Handle("/api/reload", admin)
+		if app.MailView.Enabled {
+			srv.Handle("/mail/", newMailViewHandler(recv, app.MailView.RemoteImages))
+		}
+	}
+
+	if mc := app.Metrics; mc.Listen != "" {
+		if srv != nil && mc.Listen == app.HTTP.Listen {
+			srv.Handle(mc.Path, metrics.Handler())
+		} else {
+			ms := httpserver.New(config.HTTPConfig{Listen: mc.Listen})
+			ms.Handle(mc.Path, metrics.Handler())
+			if err := ms.Start(); err != nil {
+				return err
+			}
+		}
+	}
+
+	if srv != nil {
+		return srv.Start()
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package httpserver
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"time"
+
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+
+	"mail-receiver/config"
+	"mail-receiver/handoff"
+)
+
+// ACME 验证方式
+const (
+	ChallengeHTTP01    = "http-01"
+	ChallengeTLSALPN01 = "tls-alpn-01"
+)
+
+// Server 内置HTTP服务，支持静态证书或 ACME 自动申请证书
+type Server struct {
+	cfg config.HTTPConfig
+	mux *http.ServeMux
+}
+
+// New 创建HTTP服务
+func New(cfg config.HTTPConfig) *Server {
+	return &Server{cfg: cfg, mux: http.NewServeMux()}
+}
+
+// Handle 注册处理器
+func (s *Server) Handle(pattern string, h http.Handler) {
+	s.mux.Handle(pattern, h)
+}
+
+// HandleFunc 注册处理函数
+func (s *Server) HandleFunc(pattern string, h func(http.ResponseWriter, *http.Request)) {
+	s.mux.HandleFunc(pattern, h)
+}
+
+// Start 在后台启动监听，监听失败时返回错误
+func (s *Server) Start() error {
+	ln, err := handoff.Listen(s.cfg.Listen)
+	if err != nil {
+		return fmt.Errorf("HTTP 服务监听 %s 失败: %w", s.cfg.Listen, err)
+	}
+
+	srv := &http.Server{
+		Handler:           s.mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	tc := s.cfg.TLS
+	switch {
+	case len(tc.ACME.Domains) > 0:
+		manager := s.acmeManager()
+		srv.TLSConfig = manager.TLSConfig()
+		// tls-alpn-01 直接在 TLS 监听上完成验证；http-01 需要额外在 80 端口响应验证请求，其余请求重定向到 HTTPS
+		if tc.ACME.Challenge == ChallengeHTTP01 {
+			if err := serveHTTPChallenge(tc.ACME.HTTPListen, manager); err != nil {
+				ln.Close()
+				return err
+			}
+		}
+		log.Printf("HTTP 服务已启动: https://%s (ACME: %v)", s.cfg.Listen, tc.ACME.Domains)
+		go serve(srv, tls.NewListener(ln, srv.TLSConfig))
+	case tc.CertFile != "" || tc.KeyFile != "":
+		cert, err := tls.LoadX509KeyPair(tc.CertFile, tc.KeyFile)
+		if err != nil {
+			ln.Close()
+			return fmt.Errorf("加载 HTTP 服务证书失败: %w", err)
+		}
+		srv.TLSConfig = &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+		log.Printf("HTTP 服务已启动: https://%s", s.cfg.Listen)
+		go serve(srv, tls.NewListener(ln, srv.TLSConfig))
+	default:
+		if !isLoopback(ln.Addr()) {
+			log.Printf("警告: HTTP 服务监听在非本机地址 %s 且未启用 TLS，邮件内容将以明文传输", s.cfg.Listen)
+		}
+		log.Printf("HTTP 服务已启动: http://%s", s.cfg.Listen)
+		go serve(srv, ln)
+	}
+	return nil
+}
+
+// acmeManager 创建 ACME 证书管理器
+func (s *Server) acmeManager() *autocert.Manager {
+	ac := s.cfg.TLS.ACME
+	m := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(ac.Domains...),
+		Cache:      autocert.DirCache(ac.CacheDir),
+		Email:      ac.Email,
+	}
+	if ac.DirectoryURL != "" {
+		m.Client = &acme.Client{DirectoryURL: ac.DirectoryURL}
+	}
+	return m
+}
+
+// serveHTTPChallenge 启动 http-01 验证服务
+func serveHTTPChallenge(addr string, m *autocert.Manager) error {
+	ln, err := handoff.Listen(addr)
+	if err != nil {
+		return fmt.Errorf("ACME http-01 验证监听 %s 失败: %w", addr, err)
+	}
+	srv := &http.Server{
+		Handler:           m.HTTPHandler(nil),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go serve(srv, ln)
+	return nil
+}
+
+// serve 运行HTTP服务直到出错
+func serve(srv *http.Server, ln net.Listener) {
+	if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Printf("HTTP 服务异常退出: %v", err)
+	}
+}
+
+// isLoopback 判断监听地址是否只在本机可访问
+func isLoopback(addr net.Addr) bool {
+	tcp, ok := addr.(*net.TCPAddr)
+	return ok && tcp.IP.IsLoopback()
+}
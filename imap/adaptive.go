@@ -0,0 +1,110 @@
+package imap
+
+import (
+	"sync"
+	"time"
+)
+
+// PollScheduler 轮询间隔调度器
+type PollScheduler interface {
+	// Next 返回下一次轮询前的等待时间
+	Next(now time.Time) time.Duration
+	// Observe 记录一次新邮件到达
+	Observe(t time.Time)
+}
+
+// fixedInterval 固定轮询间隔
+type fixedInterval time.Duration
+
+// FixedInterval 创建固定间隔的调度器
+func FixedInterval(d time.Duration) PollScheduler {
+	return fixedInterval(d)
+}
+
+func (f fixedInterval) Next(time.Time) time.Duration { return time.Duration(f) }
+func (f fixedInterval) Observe(time.Time)            {}
+
+// AdaptiveInterval 自适应轮询间隔
+// 按小时统计历史到信频率：来信多的时段缩短间隔，少的时段（如夜间）延长间隔
+type AdaptiveInterval struct {
+	mu        sync.Mutex
+	base      time.Duration
+	min       time.Duration
+	max       time.Duration
+	hours     [24]float64 // 每小时的到信计数（带衰减）
+	lastDecay time.Time
+}
+
+// 每天对历史计数衰减一次，使统计逐步跟随最新的来信规律
+const adaptiveDailyDecay = 0.9
+
+// NewAdaptiveInterval 创建自适应调度器，base 为无历史数据时使用的间隔
+func NewAdaptiveInterval(base, min, max time.Duration) *AdaptiveInterval {
+	if min > max {
+		min, max = max, min
+	}
+	return &AdaptiveInterval{
+		base: base,
+		min:  min,
+		max:  max,
+	}
+}
+
+// Next 根据当前小时的历史到信频率计算轮询间隔
+func (a *AdaptiveInterval) Next(now time.Time) time.Duration {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.decay(now)
+
+	var peak float64
+	for _, v := range a.hours {
+		if v > peak {
+			peak = v
+		}
+	}
+
+	// 没有历史数据时使用基础间隔
+	if peak == 0 {
+		return a.clamp(a.base)
+	}
+
+	// 频率越高间隔越短：ratio=1 时取 min，ratio=0 时取 max
+	ratio := a.hours[now.Hour()] / peak
+	d := a.max - time.Duration(ratio*float64(a.max-a.min))
+	return a.clamp(d)
+}
+
+// Observe 记录一次新邮件到达
+func (a *AdaptiveInterval) Observe(t time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.decay(t)
+	a.hours[t.Hour()]++
+}
+
+// decay 按天衰减历史计数
+func (a *AdaptiveInterval) decay(now time.Time) {
+	if a.lastDecay.IsZero() {
+		a.lastDecay = now
+		return
+	}
+	for now.Sub(a.lastDecay) >= 24*time.Hour {
+		for i := range a.hours {
+			a.hours[i] *= adaptiveDailyDecay
+		}
+		a.lastDecay = a.lastDecay.Add(24 * time.Hour)
+	}
+}
+
+// clamp 将间隔限制在 [min, max] 范围内
+func (a *AdaptiveInterval) clamp(d time.Duration) time.Duration {
+	if d < a.min {
+		return a.min
+	}
+	if d > a.max {
+		return a.max
+	}
+	return d
+}
@@ -0,0 +1,142 @@
+package imap
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"log"
+	"mime"
+	"strings"
+
+	"github.com/emersion/go-message"
+	"github.com/emersion/go-message/mail"
+	"github.com/emersion/go-message/textproto"
+)
+
+// Bounce 退信（RFC 3464 投递状态通知，multipart/report; report-type=delivery-status）中的投递结果
+type Bounce struct {
+	ReportingMTA      string             `json:"reporting_mta,omitempty"`       // 生成退信的服务器
+	Recipients        []*BounceRecipient `json:"recipients"`                    // 投递失败或延迟的收件人
+	OriginalMessageID string             `json:"original_message_id,omitempty"` // 原邮件的 Message-ID
+	OriginalSubject   string             `json:"original_subject,omitempty"`    // 原邮件的主题
+}
+
+// BounceRecipient 单个收件人的投递状态
+type BounceRecipient struct {
+	Recipient  string `json:"recipient"`            // 收件人地址（Final-Recipient，缺失时为 Original-Recipient）
+	Action     string `json:"action"`               // failed（投递失败）/ delayed（延迟投递）
+	Status     string `json:"status"`               // 状态码，如 5.1.1
+	Diagnostic string `json:"diagnostic,omitempty"` // 对方服务器的诊断信息，如 "550 5.1.1 User unknown"
+}
+
+// isDeliveryReport 邮件是否为投递状态通知
+func isDeliveryReport(h message.Header) bool {
+	t, params, err := h.ContentType()
+	return err == nil && t == "multipart/report" && strings.EqualFold(params["report-type"], "delivery-status")
+}
+
+// failed 是否有投递失败或延迟的收件人（成功投递的通知不算退信）
+func (b *Bounce) failed() bool {
+	return len(b.Recipients) > 0
+}
+
+// readPart 读取投递状态通知中的状态部分和退回的原邮件，返回 false 表示按普通邮件部分处理（如说明文字）
+func (b *Bounce) readPart(part *mail.Part, accountName string, depth int) bool {
+	t, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+	switch t {
+	case "message/delivery-status", "message/global-delivery-status":
+		data, err := io.ReadAll(part.Body)
+		if err != nil {
+			log.Printf("[%s] 读取投递状态失败: %v", accountName, err)
+			return true
+		}
+		b.parseStatus(data)
+	case "text/rfc822-headers", "message/global-headers":
+		h, err := textproto.ReadHeader(bufio.NewReader(part.Body))
+		if err != nil {
+			return true
+		}
+		b.setOriginal(mail.Header{Header: message.Header{Header: h}})
+	case "message/rfc822", "message/global":
+		// 退回的原邮件只取主题和 Message-ID
+		if depth >= maxEmbeddedDepth {
+			return true
+		}
+		orig := &EmailMessage{}
+		if err := parseEntity(part.Body, orig, accountName, depth+1, ParseOptions{}); err != nil {
+			return true
+		}
+		if b.OriginalSubject == "" {
+			b.OriginalSubject = orig.Subject
+		}
+		if b.OriginalMessageID == "" {
+			b.OriginalMessageID = orig.MessageID
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+// setOriginal 从原邮件头取主题和 Message-ID
+func (b *Bounce) setOriginal(h mail.Header) {
+	if subject, err := h.Subject(); err == nil && b.OriginalSubject == "" {
+		b.OriginalSubject = subject
+	}
+	if id, err := h.MessageID(); err == nil && id != "" && b.OriginalMessageID == "" {
+		b.OriginalMessageID = "<" + id + ">"
+	}
+}
+
+// parseStatus 解析 message/delivery-status：第一段为邮件级字段，之后每段为一个收件人的字段
+func (b *Bounce) parseStatus(data []byte) {
+	data = bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+	for i, block := range strings.Split(strings.TrimSpace(string(data)), "\n\n") {
+		fields := statusFields(block)
+		if i == 0 {
+			b.ReportingMTA = typedValue(fields["reporting-mta"])
+			continue
+		}
+		action := strings.ToLower(fields["action"])
+		if action != "failed" && action != "delayed" {
+			continue
+		}
+		r := &BounceRecipient{
+			Recipient:  typedValue(fields["final-recipient"]),
+			Action:     action,
+			Status:     strings.Fields(fields["status"] + " ")[0],
+			Diagnostic: typedValue(fields["diagnostic-code"]),
+		}
+		if r.Recipient == "" {
+			r.Recipient = typedValue(fields["original-recipient"])
+		}
+		b.Recipients = append(b.Recipients, r)
+	}
+}
+
+// statusFields 解析一段 "名称: 值" 形式的字段（名称小写），合并折行
+func statusFields(block string) map[string]string {
+	fields := make(map[string]string)
+	var last string
+	for _, line := range strings.Split(block, "\n") {
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && last != "" {
+			fields[last] += " " + strings.TrimSpace(line)
+			continue
+		}
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		last = strings.ToLower(strings.TrimSpace(name))
+		fields[last] = strings.TrimSpace(value)
+	}
+	return fields
+}
+
+// typedValue 去掉 "rfc822; user@example.com"、"smtp; 550 ..." 中的类型前缀
+func typedValue(s string) string {
+	if _, v, ok := strings.Cut(s, ";"); ok {
+		return strings.TrimSpace(v)
+	}
+	return strings.TrimSpace(s)
+}
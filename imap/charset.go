@@ -0,0 +1,40 @@
+package imap
+
+import (
+	"mime"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/emersion/go-imap"
+	"github.com/emersion/go-message/charset"
+	htmlcharset "golang.org/x/net/html/charset"
+	"golang.org/x/text/encoding/simplifiedchinese"
+)
+
+func init() {
+	// 导入 go-message/charset 后正文和邮件头按声明的字符集（GBK、GB18030、Big5、ISO-8859-x、Shift_JIS 等）转为 UTF-8，
+	// 信封中的主题和发件人名称同样处理
+	imap.CharsetReader = charset.Reader
+}
+
+// decodeText 正文不是有效的 UTF-8 时（未声明字符集、声明的字符集不支持或与实际不符）转码：
+// HTML 按 <meta charset> 声明，否则按 GB18030（兼容 GBK/GB2312）尝试，仍无法解码时原样返回
+func decodeText(body []byte, html bool) string {
+	if utf8.Valid(body) {
+		return string(body)
+	}
+	if html {
+		if enc, name, _ := htmlcharset.DetermineEncoding(body, "text/html"); name != "windows-1252" && name != "utf-8" {
+			if out, err := enc.NewDecoder().Bytes(body); err == nil {
+				return string(out)
+			}
+		}
+	}
+	if out, err := simplifiedchinese.GB18030.NewDecoder().Bytes(body); err == nil && !strings.ContainsRune(string(out), utf8.RuneError) {
+		return string(out)
+	}
+	return string(body)
+}
+
+// headerDecoder 解码 RFC 2047 编码的邮件头，支持非 UTF-8 字符集
+var headerDecoder = &mime.WordDecoder{CharsetReader: charset.Reader}
@@ -2,12 +2,20 @@ package imap
 
 import (
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"log"
-	"time"
+	"sort"
+	"strings"
+	"sync"
 
 	"github.com/emersion/go-imap"
 	"github.com/emersion/go-imap/client"
+	"github.com/emersion/go-imap/commands"
+	"github.com/emersion/go-imap/responses"
+	"github.com/emersion/go-sasl"
+
+	"mail-receiver/clock"
 )
 
 // Client IMAP客户端封装
@@ -21,11 +29,70 @@ type Client struct {
 	accountName  string
 	idleTimeout  int
 	supportsIDLE bool
+	capabilities []string     // 服务器声明的能力列表（连接时记录）
+	capsMu       sync.Mutex   // 保护 capabilities（服务器可能在未标记响应中更新）
+	capsLogged   bool         // 是否已输出过能力列表（每个账号只输出一次）
+	tokenSource  *TokenSource // 可选，使用 XOAUTH2 认证
+	readOnly     bool         // 只读模式：使用 EXAMINE 打开文件夹，禁止修改邮箱
+	security     string       // 连接加密方式，默认 SecurityTLS
+	tlsConfig    *tls.Config  // 可选，自定义TLS选项
+	clock        clock.Clock  // 轮询计时的时间来源
+
+	special       map[string]string // 特殊用途属性 → 文件夹名称
+	specialLogged bool
+
+	watcher       *updateWatcher // 当前连接的未标记更新接收
+	noticeHandler func(Notice)   // 可选，服务器提示（ALERT/BYE）回调
 }
 
+// 连接加密方式
+const (
+	SecurityTLS      = "tls"      // 隐式TLS（通常为 993 端口）
+	SecuritySTARTTLS = "starttls" // 明文连接后升级为TLS（通常为 143 端口）
+	SecurityNone     = "none"     // 不加密，仅用于本机或测试服务器
+)
+
+// ErrReadOnly 只读模式下尝试修改邮箱
+var ErrReadOnly = errors.New("只读模式下禁止修改邮箱")
+
+// ErrAuthFailed 服务器拒绝了登录凭据（用户名、密码或访问令牌错误），可用 errors.Is 判断
+var ErrAuthFailed = errors.New("认证被拒绝")
+
+// 表示凭据被拒绝的登录响应码（RFC 5530），其他 NO/BAD 响应（如服务器临时不可用）按普通错误重试
+const (
+	codeAuthenticationFailed = "AUTHENTICATIONFAILED"
+	codeAuthorizationFailed  = "AUTHORIZATIONFAILED"
+	codeExpired              = "EXPIRED"
+)
+
+// 监控模式
+const (
+	ModeIDLE = "idle"
+	ModePoll = "poll"
+)
+
 // MonitorResult 监控结果
 type MonitorResult struct {
 	UpdateCh <-chan error // 更新通知通道，接收错误或nil（有新邮件）
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	ack      chan struct{}
+}
+
+// Ack 收到新邮件通知并处理完成后调用：轮询模式在此之前不再使用连接，避免与上层的 FETCH/STORE 交错
+func (m *MonitorResult) Ack() {
+	select {
+	case m.ack <- struct{}{}:
+	default:
+	}
+}
+
+// Stop 停止监控并等待监控协程退出，之后可以在同一连接上执行其他命令
+func (m *MonitorResult) Stop() {
+	m.stopOnce.Do(func() { close(m.stop) })
+	for range m.UpdateCh {
+	}
 }
 
 // logWriter 自定义日志写入器，将 go-imap 的错误日志转发到标准日志
@@ -52,19 +119,49 @@ func NewClient(server string, port int, username, password, accountName string,
 		password:    password,
 		accountName: accountName,
 		idleTimeout: idleTimeout,
+		clock:       clock.Real,
 	}
 }
 
+// SetClock 设置轮询、IDLE 超时和访问令牌过期检查的时间来源（模拟运行和测试时快进时间）
+func (c *Client) SetClock(clk clock.Clock) {
+	c.clock = clock.Or(clk)
+	if c.tokenSource != nil {
+		c.tokenSource.SetClock(c.clock)
+	}
+}
+
+// SetReadOnly 设置只读模式，开启后不会发送 STORE/MOVE/EXPUNGE/APPEND 等修改命令
+func (c *Client) SetReadOnly(readOnly bool) {
+	c.readOnly = readOnly
+}
+
+// SetSecurity 设置连接加密方式（tls/starttls/none）
+func (c *Client) SetSecurity(security string) {
+	c.security = security
+}
+
 // Connect 连接到IMAP服务器
 func (c *Client) Connect() error {
 	addr := fmt.Sprintf("%s:%d", c.server, c.port)
 
 	var err error
-	// 默认使用TLS连接
-	tlsConfig := &tls.Config{
-		ServerName: c.server,
+	tlsConfig := c.newTLSConfig()
+	switch c.security {
+	case SecuritySTARTTLS:
+		if c.client, err = client.Dial(addr); err != nil {
+			return fmt.Errorf("连接失败: %w", err)
+		}
+		if err = c.client.StartTLS(tlsConfig); err != nil {
+			c.client.Logout()
+			return fmt.Errorf("STARTTLS 失败: %w", err)
+		}
+	case SecurityNone:
+		c.client, err = client.Dial(addr)
+	default:
+		// 默认使用TLS连接
+		c.client, err = client.DialTLS(addr, tlsConfig)
 	}
-	c.client, err = client.DialTLS(addr, tlsConfig)
 
 	if err != nil {
 		return fmt.Errorf("连接失败: %w", err)
@@ -73,21 +170,159 @@ func (c *Client) Connect() error {
 	// 设置自定义错误日志写入器，使错误日志格式与其他日志一致
 	c.client.ErrorLog = log.New(&logWriter{accountName: c.accountName}, "", 0)
 
-	// 创建IDLE客户端并检查支持
+	// 创建IDLE客户端，登录后再检查支持（登录前的能力列表来自服务器问候，无需额外请求）
 	c.idleClient = NewIdleClient(c.client, c.accountName, c.idleTimeout)
-	c.supportsIDLE = c.idleClient.CheckIDLESupport()
+	c.idleClient.readOnly = c.readOnly
+	c.idleClient.clock = c.clock
+
+	// 接收服务器主动发送的更新（ALERT/BYE 提示、IDLE 通知）
+	c.watchUpdates()
 
 	return nil
 }
 
+// refreshCapabilities 从服务器获取并记录能力列表
+func (c *Client) refreshCapabilities() {
+	caps, err := c.client.Capability()
+	if err != nil {
+		log.Printf("[%s] 获取服务器能力失败: %v", c.accountName, err)
+		return
+	}
+
+	var list []string
+	for name, ok := range caps {
+		if ok {
+			list = append(list, name)
+		}
+	}
+	sort.Strings(list)
+
+	c.capsMu.Lock()
+	c.capabilities = list
+	c.capsMu.Unlock()
+}
+
+// Capabilities 返回服务器声明的能力列表
+func (c *Client) Capabilities() []string {
+	c.capsMu.Lock()
+	defer c.capsMu.Unlock()
+	return append([]string(nil), c.capabilities...)
+}
+
+// MonitorMode 返回当前选择的监控模式（idle/poll）
+func (c *Client) MonitorMode() string {
+	if c.supportsIDLE && c.idleClient != nil {
+		return ModeIDLE
+	}
+	return ModePoll
+}
+
+// SetOAuth2 设置 OAuth2 令牌源，登录时改用 XOAUTH2 认证；令牌过期时间按客户端的时间来源计算
+func (c *Client) SetOAuth2(ts *TokenSource) {
+	c.tokenSource = ts
+	ts.SetClock(c.clock)
+}
+
 // Login 登录到IMAP服务器
 func (c *Client) Login() error {
-	if err := c.client.Login(c.username, c.password); err != nil {
+	if c.tokenSource != nil {
+		if err := c.loginOAuth2(); err != nil {
+			return err
+		}
+	} else if err := c.loginPassword(); err != nil {
 		return fmt.Errorf("登录失败: %w", err)
 	}
+
+	// 登录后能力可能变化（如登录后才声明 IDLE），重新获取；能力列表每个账号只输出一次
+	c.refreshCapabilities()
+	if !c.capsLogged {
+		c.capsLogged = true
+		log.Printf("[%s] 服务器能力: %s", c.accountName, strings.Join(c.Capabilities(), " "))
+	}
+	c.supportsIDLE = c.idleClient.CheckIDLESupport()
+	if !c.supportsIDLE {
+		log.Printf("[%s] 服务器未声明 IDLE 能力，将使用轮询模式", c.accountName)
+	}
+
+	// 访问令牌到期前结束IDLE，以便重新连接认证
+	if c.tokenSource != nil {
+		c.idleClient.SetDeadline(c.tokenSource.Expiry())
+	}
 	return nil
 }
 
+// loginPassword 使用密码登录：服务器支持 SASL-IR 和 AUTH=PLAIN 时改用 AUTHENTICATE PLAIN，
+// 凭据随命令一次发送，密码含特殊字符时也不需要等待字面量续行
+func (c *Client) loginPassword() error {
+	if ok, _ := c.client.Support("SASL-IR"); ok {
+		if ok, _ := c.client.SupportAuth(sasl.Plain); ok {
+			return c.authenticate(sasl.NewPlainClient("", c.username, c.password))
+		}
+	}
+	if ok, _ := c.client.Support("LOGINDISABLED"); ok {
+		return client.ErrLoginDisabled
+	}
+	return c.execLogin(&commands.Login{Username: c.username, Password: c.password}, nil)
+}
+
+// authenticate 执行 AUTHENTICATE 命令，服务器支持 SASL-IR 时初始响应随命令发送
+func (c *Client) authenticate(auth sasl.Client) error {
+	mech, ir, err := auth.Start()
+	if err != nil {
+		return err
+	}
+	cmd := &commands.Authenticate{Mechanism: mech}
+	res := &responses.Authenticate{
+		Mechanism:       auth,
+		InitialResponse: ir,
+		RepliesCh:       make(chan []byte, 10),
+	}
+	if ok, _ := c.client.Support("SASL-IR"); ok {
+		cmd.InitialResponse, res.InitialResponse = ir, nil
+	}
+	return c.execLogin(cmd, res)
+}
+
+// execLogin 执行登录命令，响应码表示凭据被拒绝时包装为 ErrAuthFailed
+// go-imap 的 Login/Authenticate 只返回响应文本、丢弃响应码，因此直接执行命令
+func (c *Client) execLogin(cmd imap.Commander, h responses.Handler) error {
+	status, err := c.client.Execute(cmd, h)
+	if err != nil {
+		return err
+	}
+	if err := status.Err(); err != nil {
+		switch status.Code {
+		case codeAuthenticationFailed, codeAuthorizationFailed, codeExpired:
+			return fmt.Errorf("%w: [%s] %v", ErrAuthFailed, status.Code, err)
+		}
+		return err
+	}
+	c.client.SetState(imap.AuthenticatedState, nil)
+	return nil
+}
+
+// loginOAuth2 使用 XOAUTH2 认证，令牌被拒绝时强制刷新后重试一次
+func (c *Client) loginOAuth2() error {
+	var lastErr error
+	for attempt := 0; attempt < 2; attempt++ {
+		token, err := c.tokenSource.Token()
+		if err != nil {
+			return fmt.Errorf("登录失败: %w", err)
+		}
+
+		if lastErr = c.authenticate(newXoauth2Client(c.username, token)); lastErr == nil {
+			return nil
+		}
+		c.tokenSource.Invalidate()
+	}
+	return fmt.Errorf("登录失败 (XOAUTH2): %w", lastErr)
+}
+
+// tokenExpired 检查 OAuth2 访问令牌是否已过期（会话需要重新认证）
+func (c *Client) tokenExpired() bool {
+	return c.tokenSource != nil && !c.clock.Now().Before(c.tokenSource.Expiry())
+}
+
 // ListFolders 列出所有可用的邮箱文件夹
 func (c *Client) ListFolders() ([]string, error) {
 	mailboxes := make(chan *imap.MailboxInfo, 10)
@@ -110,34 +345,83 @@ func (c *Client) ListFolders() ([]string, error) {
 }
 
 // SelectFolder 选择文件夹
+// SELECT 不与后续命令流水线发送：go-imap 客户端在 SELECT 完成后才进入已选中状态，之前无法发送 SEARCH/FETCH；
+// 重连时减少的往返来自 SASL-IR 登录以及复用特殊文件夹
 func (c *Client) SelectFolder(folder string) (*imap.MailboxStatus, error) {
-	mbox, err := c.client.Select(folder, false)
+	mbox, err := c.client.Select(folder, c.readOnly)
 	if err != nil {
 		return nil, fmt.Errorf("选择文件夹 %s 失败: %w", folder, err)
 	}
 	return mbox, nil
 }
 
-// FetchMessages 获取邮件
-func (c *Client) FetchMessages(folder string, limit uint32, markAsRead bool) ([]*imap.Message, error) {
+// Unselect 取消选择当前文件夹并回到已认证状态（不删除标记为删除的邮件），服务器不支持 UNSELECT 时返回错误
+func (c *Client) Unselect() error {
+	if c.client == nil {
+		return fmt.Errorf("客户端未连接")
+	}
+	if err := c.client.Unselect(); err != nil {
+		return fmt.Errorf("取消选择文件夹失败: %w", err)
+	}
+	return nil
+}
+
+// HasNewMessages 用 STATUS 检查文件夹中是否可能有需要处理的新邮件（UID 大于 lastUID 且存在未读邮件），不切换当前文件夹
+// STATUS 不应用于已选中的文件夹，此时先 UNSELECT；服务器不支持 UNSELECT 或没有处理进度时返回 true，由调用方直接获取
+func (c *Client) HasNewMessages(folder string, uidValidity, lastUID uint32) (bool, error) {
+	if c.client == nil {
+		return false, fmt.Errorf("客户端未连接")
+	}
+	if uidValidity == 0 {
+		return true, nil
+	}
+	if mbox := c.client.Mailbox(); mbox != nil && mbox.Name == folder {
+		if err := c.Unselect(); err != nil {
+			return true, nil
+		}
+	}
+	st, err := c.client.Status(folder, []imap.StatusItem{imap.StatusUidNext, imap.StatusUidValidity, imap.StatusUnseen})
+	if err != nil {
+		return false, fmt.Errorf("查询文件夹 %s 状态失败: %w", folder, err)
+	}
+	if st.UidValidity != uidValidity {
+		return true, nil
+	}
+	return st.UidNext > lastUID+1 && st.Unseen > 0, nil
+}
+
+// FetchMessages 获取未读邮件，返回邮件列表和文件夹的 UIDVALIDITY
+// sinceUID 大于0且 uidValidity 与服务器一致时，只获取UID大于 sinceUID 的邮件（按UID从小到大取 limit 封）；
+// 否则获取最新的 limit 封未读邮件
+func (c *Client) FetchMessages(folder string, limit uint32, markAsRead bool, uidValidity, sinceUID uint32) ([]*imap.Message, uint32, error) {
 	mbox, err := c.SelectFolder(folder)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
+	}
+
+	// UIDVALIDITY 变化时旧的进度失效
+	if mbox.UidValidity != uidValidity {
+		sinceUID = 0
 	}
 
 	// 如果邮箱为空，直接返回
 	if mbox.Messages == 0 {
-		return nil, nil
+		return nil, mbox.UidValidity, nil
 	}
 
 	// 尝试搜索未读邮件
 	criteria := imap.NewSearchCriteria()
 	criteria.WithoutFlags = []string{imap.SeenFlag}
+	if sinceUID > 0 {
+		criteria.Uid = new(imap.SeqSet)
+		criteria.Uid.AddRange(sinceUID+1, 0) // sinceUID+1:*
+	}
 
 	var seqset *imap.SeqSet
 	var useFallback bool
 
-	ids, err := c.client.Search(criteria)
+	// 按UID搜索和获取，避免序列号在两次命令之间因其他客户端删除邮件而错位
+	ids, err := c.client.UidSearch(criteria)
 	if err != nil {
 		// 某些服务器（如阿里云）不支持 WithoutFlags，改用序列号范围获取
 		// 静默处理，稍后会在需要时输出日志
@@ -151,12 +435,26 @@ func (c *Client) FetchMessages(folder string, limit uint32, markAsRead bool) ([]
 			seqset.AddRange(1, mbox.Messages)
 		}
 	} else {
+		// sinceUID+1:* 在没有更大的UID时匹配最大的UID（即 sinceUID 本身），需要去掉
+		kept := ids[:0]
+		for _, id := range ids {
+			if id > sinceUID {
+				kept = append(kept, id)
+			}
+		}
+		ids = kept
 		if len(ids) == 0 {
-			return nil, nil
+			return nil, mbox.UidValidity, nil
 		}
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
 
 		if limit > 0 && uint32(len(ids)) > limit {
-			ids = ids[len(ids)-int(limit):]
+			if sinceUID > 0 {
+				// 有处理进度时按顺序从旧到新处理，剩余的留到下一轮
+				ids = ids[:limit]
+			} else {
+				ids = ids[len(ids)-int(limit):]
+			}
 		}
 
 		seqset = new(imap.SeqSet)
@@ -166,18 +464,7 @@ func (c *Client) FetchMessages(folder string, limit uint32, markAsRead bool) ([]
 	}
 
 	// 设置要获取的邮件部分
-	items := []imap.FetchItem{
-		imap.FetchEnvelope,
-		imap.FetchFlags,
-		imap.FetchInternalDate,
-		imap.FetchRFC822Size,
-		imap.FetchUid,
-		"BODY.PEEK[]", // 使用PEEK避免自动标记为已读
-	}
-
-	if markAsRead {
-		items[5] = "BODY[]" // 不使用PEEK，会自动标记为已读
-	}
+	items := c.fetchItems(markAsRead)
 
 	// 创建消息通道（使用合适的缓冲大小）
 	channelSize := len(ids)
@@ -191,7 +478,11 @@ func (c *Client) FetchMessages(folder string, limit uint32, markAsRead bool) ([]
 	done := make(chan error, 1)
 
 	go func() {
-		done <- c.client.Fetch(seqset, items, messages)
+		if useFallback {
+			done <- c.client.Fetch(seqset, items, messages)
+		} else {
+			done <- c.client.UidFetch(seqset, items, messages)
+		}
 	}()
 
 	var result []*imap.Message
@@ -209,7 +500,7 @@ func (c *Client) FetchMessages(folder string, limit uint32, markAsRead bool) ([]
 					break
 				}
 			}
-			if isUnread {
+			if isUnread && msg.Uid > sinceUID {
 				result = append(result, msg)
 			}
 		} else {
@@ -220,51 +511,128 @@ func (c *Client) FetchMessages(folder string, limit uint32, markAsRead bool) ([]
 	if err := <-done; err != nil {
 		// 如果备用方法也失败了，才输出错误日志
 		if useFallback {
-			return nil, fmt.Errorf("获取邮件失败（标准搜索和备用方法均失败）: %w", err)
+			return nil, 0, fmt.Errorf("获取邮件失败（标准搜索和备用方法均失败）: %w", err)
 		}
-		return nil, fmt.Errorf("获取邮件失败: %w", err)
+		return nil, 0, fmt.Errorf("获取邮件失败: %w", err)
 	}
 
+	// 按UID从小到大排序，便于按顺序记录处理进度
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Uid < result[j].Uid
+	})
+
 	// 如果使用了备用方法，静默处理并应用limit
 	if ids == nil {
 		// 应用limit限制
 		if limit > 0 && uint32(len(result)) > limit {
-			result = result[len(result)-int(limit):]
+			if sinceUID > 0 {
+				result = result[:limit]
+			} else {
+				result = result[len(result)-int(limit):]
+			}
 		}
 	}
 
+	return result, mbox.UidValidity, nil
+}
+
+// fetchItems 获取邮件时请求的部分，markAsRead 为 false 时使用 PEEK 不改变已读状态
+func (c *Client) fetchItems(markAsRead bool) []imap.FetchItem {
+	items := []imap.FetchItem{
+		imap.FetchEnvelope,
+		imap.FetchFlags,
+		imap.FetchInternalDate,
+		imap.FetchRFC822Size,
+		imap.FetchUid,
+		"BODY.PEEK[]", // 使用PEEK避免自动标记为已读
+	}
+
+	if markAsRead && !c.readOnly {
+		items[5] = "BODY[]" // 不使用PEEK，会自动标记为已读
+	}
+	// Gmail 的同一封邮件出现在多个标签（如 INBOX 和 [Gmail]/All Mail）中，获取 X-GM-MSGID 用于识别
+	if ok, _ := c.client.Support("X-GM-EXT-1"); ok {
+		items = append(items, fetchGmailMsgID)
+	}
+	return items
+}
+
+// FetchUnread 按UID获取当前选中文件夹中仍未读的邮件（不改变已读状态），已读或已删除的不返回；结果按UID从小到大排序
+func (c *Client) FetchUnread(uids []uint32) ([]*imap.Message, error) {
+	if c.client == nil {
+		return nil, fmt.Errorf("客户端未连接")
+	}
+	if len(uids) == 0 {
+		return nil, nil
+	}
+	seqset := new(imap.SeqSet)
+	seqset.AddNum(uids...)
+
+	messages := make(chan *imap.Message, len(uids))
+	done := make(chan error, 1)
+	go func() {
+		done <- c.client.UidFetch(seqset, c.fetchItems(false), messages)
+	}()
+	var result []*imap.Message
+	for msg := range messages {
+		seen := false
+		for _, flag := range msg.Flags {
+			if flag == imap.SeenFlag {
+				seen = true
+				break
+			}
+		}
+		if !seen {
+			result = append(result, msg)
+		}
+	}
+	if err := <-done; err != nil {
+		return nil, fmt.Errorf("获取邮件失败: %w", err)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Uid < result[j].Uid
+	})
 	return result, nil
 }
 
 // IdleWithFallback 使用IDLE或轮询监听新邮件
-func (c *Client) IdleWithFallback(folder string, pollInterval time.Duration) *MonitorResult {
+func (c *Client) IdleWithFallback(folder string, scheduler PollScheduler) *MonitorResult {
 	updateCh := make(chan error)
+	stop := make(chan struct{})
+	ack := make(chan struct{}, 1)
 
 	go func() {
 		defer close(updateCh)
 
 		// 根据服务器支持情况选择IDLE或轮询
-		if c.supportsIDLE && c.idleClient != nil {
-			c.idleMode(folder, updateCh)
+		if c.MonitorMode() == ModeIDLE {
+			c.idleMode(folder, updateCh, stop)
 		} else {
-			c.pollMode(folder, pollInterval, updateCh)
+			c.pollMode(folder, scheduler, updateCh, stop, ack)
 		}
 	}()
 
 	return &MonitorResult{
 		UpdateCh: updateCh,
+		stop:     stop,
+		ack:      ack,
 	}
 }
 
 // idleMode IDLE模式监听
-func (c *Client) idleMode(folder string, updateCh chan<- error) {
+func (c *Client) idleMode(folder string, updateCh chan<- error, stop <-chan struct{}) {
 	log.Printf("[%s] 使用 IDLE 模式监控文件夹: %s", c.accountName, folder)
 
 	// 使用IDLE客户端监控
-	idleUpdateCh := c.idleClient.MonitorWithIDLE(folder)
+	idleUpdateCh := c.idleClient.MonitorWithIDLE(folder, stop)
 
 	hasUpdate, ok := <-idleUpdateCh
 	if !ok {
+		select {
+		case <-stop:
+			return
+		default:
+		}
 		// IDLE监控结束（idle.go中已输出详细日志）
 		updateCh <- fmt.Errorf("IDLE 已结束")
 		return
@@ -277,8 +645,9 @@ func (c *Client) idleMode(folder string, updateCh chan<- error) {
 	}
 }
 
-// pollMode 轮询模式
-func (c *Client) pollMode(folder string, interval time.Duration, updateCh chan<- error) {
+// pollMode 轮询模式，每次通知新邮件后等待 ack（上层处理完成）再继续轮询
+func (c *Client) pollMode(folder string, scheduler PollScheduler, updateCh chan<- error, stop <-chan struct{}, ack <-chan struct{}) {
+	interval := scheduler.Next(c.clock.Now())
 	log.Printf("[%s] 使用轮询模式监控文件夹: %s (间隔: %v)", c.accountName, folder, interval)
 
 	var lastMessageCount uint32
@@ -288,10 +657,22 @@ func (c *Client) pollMode(folder string, interval time.Duration, updateCh chan<-
 		lastMessageCount = mbox.Messages
 	}
 
-	ticker := time.NewTicker(interval)
-	defer ticker.Stop()
+	timer := c.clock.NewTimer(interval)
+	defer timer.Stop()
+
+	// 在同一连接上持续轮询，每次检测到变化都发送一次通知
+	for {
+		select {
+		case <-stop:
+			return
+		case <-timer.C():
+		}
+
+		if c.tokenExpired() {
+			updateCh <- fmt.Errorf("访问令牌已过期，重新认证")
+			return
+		}
 
-	for range ticker.C {
 		mbox, err := c.SelectFolder(folder)
 		if err != nil {
 			updateCh <- err
@@ -300,37 +681,145 @@ func (c *Client) pollMode(folder string, interval time.Duration, updateCh chan<-
 
 		if mbox.Messages != lastMessageCount {
 			log.Printf("[%s] 检测到新邮件 (数量: %d → %d)", c.accountName, lastMessageCount, mbox.Messages)
-			updateCh <- nil // 通知有更新
-			return
+			if mbox.Messages > lastMessageCount {
+				scheduler.Observe(c.clock.Now())
+			}
+			lastMessageCount = mbox.Messages
+			// 通知有更新，等待上层处理完成后继续轮询，处理期间连接由上层使用
+			select {
+			case updateCh <- nil:
+			case <-stop:
+				return
+			}
+			select {
+			case <-ack:
+			case <-stop:
+				return
+			}
 		}
+
+		// 按调度器计算下一次轮询间隔
+		timer.Reset(scheduler.Next(c.clock.Now()))
 	}
 }
 
 // Logout 登出并关闭连接
 func (c *Client) Logout() error {
 	if c.client != nil {
+		if c.watcher != nil {
+			c.watcher.expectBye()
+		}
 		return c.client.Logout()
 	}
 	return nil
 }
 
-// MarkAsRead 标记邮件为已读
-func (c *Client) MarkAsRead(uid uint32) error {
+// MarkAsRead 批量标记邮件为已读（单条 UID STORE 命令）
+func (c *Client) MarkAsRead(uids ...uint32) error {
+	if err := c.storeFlags(imap.AddFlags, []interface{}{imap.SeenFlag}, uids); err != nil {
+		return fmt.Errorf("标记邮件为已读失败: %w", err)
+	}
+	return nil
+}
+
+// FetchFlags 获取当前文件夹中指定邮件的标志，已删除的邮件不在结果中
+func (c *Client) FetchFlags(uids ...uint32) (map[uint32][]string, error) {
+	result := make(map[uint32][]string, len(uids))
+	if len(uids) == 0 {
+		return result, nil
+	}
+	seqset := new(imap.SeqSet)
+	seqset.AddNum(uids...)
+
+	messages := make(chan *imap.Message, 10)
+	done := make(chan error, 1)
+	go func() {
+		done <- c.client.UidFetch(seqset, []imap.FetchItem{imap.FetchUid, imap.FetchFlags}, messages)
+	}()
+	for msg := range messages {
+		result[msg.Uid] = msg.Flags
+	}
+	if err := <-done; err != nil {
+		return nil, fmt.Errorf("获取邮件标志失败: %w", err)
+	}
+	return result, nil
+}
+
+// SetKeyword 批量添加或移除自定义关键字
+func (c *Client) SetKeyword(keyword string, add bool, uids ...uint32) error {
+	var op imap.FlagsOp = imap.RemoveFlags
+	if add {
+		op = imap.AddFlags
+	}
+	if err := c.storeFlags(op, []interface{}{keyword}, uids); err != nil {
+		return fmt.Errorf("设置关键字 %s 失败: %w", keyword, err)
+	}
+	return nil
+}
+
+// Move 批量移动邮件到目标文件夹（服务器不支持 MOVE 时使用 COPY+STORE+EXPUNGE）
+func (c *Client) Move(dest string, uids ...uint32) error {
 	if c.client == nil {
 		return fmt.Errorf("客户端未连接")
 	}
+	if c.readOnly {
+		return ErrReadOnly
+	}
+	if len(uids) == 0 {
+		return nil
+	}
 
 	seqSet := new(imap.SeqSet)
-	seqSet.AddNum(uid)
+	seqSet.AddNum(uids...)
+	if err := c.client.UidMove(seqSet, dest); err != nil {
+		return fmt.Errorf("移动邮件到 %s 失败: %w", dest, err)
+	}
+	return nil
+}
 
-	item := imap.FormatFlagsOp(imap.AddFlags, true)
-	flags := []interface{}{imap.SeenFlag}
+// SearchKeyword 搜索当前已选择文件夹中带有指定关键字的邮件UID
+func (c *Client) SearchKeyword(keyword string) ([]uint32, error) {
+	if c.client == nil {
+		return nil, fmt.Errorf("客户端未连接")
+	}
 
-	if err := c.client.UidStore(seqSet, item, flags, nil); err != nil {
-		return fmt.Errorf("标记邮件为已读失败: %w", err)
+	criteria := imap.NewSearchCriteria()
+	criteria.WithFlags = []string{keyword}
+
+	uids, err := c.client.UidSearch(criteria)
+	if err != nil {
+		return nil, fmt.Errorf("搜索关键字 %s 失败: %w", keyword, err)
+	}
+	return uids, nil
+}
+
+// storeFlags 对一组UID执行 UID STORE
+func (c *Client) storeFlags(op imap.FlagsOp, flags []interface{}, uids []uint32) error {
+	if c.client == nil {
+		return fmt.Errorf("客户端未连接")
+	}
+	if c.readOnly {
+		return ErrReadOnly
+	}
+	if len(uids) == 0 {
+		return nil
 	}
 
-	return nil
+	seqSet := new(imap.SeqSet)
+	seqSet.AddNum(uids...)
+
+	item := imap.FormatFlagsOp(op, true)
+	return c.client.UidStore(seqSet, item, flags, nil)
+}
+
+// SupportsKeywords 检查文件夹是否允许设置自定义关键字（PERMANENTFLAGS 含 \*）
+func SupportsKeywords(mbox *imap.MailboxStatus) bool {
+	for _, f := range mbox.PermanentFlags {
+		if f == imap.TryCreateFlag {
+			return true
+		}
+	}
+	return false
 }
 
 // IsConnected 检查是否已连接
@@ -0,0 +1,111 @@
+package imap
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/emersion/go-imap"
+)
+
+// Folder 文件夹树中的一个节点
+type Folder struct {
+	Name       string        `json:"name"`             // 完整路径，即配置 folders 时使用的名称
+	Delimiter  string        `json:"delimiter"`        // 层级分隔符
+	Attributes []string      `json:"attributes"`       // LIST 返回的属性（如 \Noselect、\Junk）
+	Status     *FolderStatus `json:"status,omitempty"` // 邮件数和未读数，未查询或无法选择时为空
+	Children   []*Folder     `json:"children,omitempty"`
+}
+
+// FolderStatus 文件夹的邮件数和未读数（STATUS 命令）
+type FolderStatus struct {
+	Messages uint32 `json:"messages"`
+	Unseen   uint32 `json:"unseen"`
+}
+
+// Selectable 文件夹是否可以选择（不是仅用于分层的文件夹）
+func (f *Folder) Selectable() bool {
+	for _, attr := range f.Attributes {
+		if strings.EqualFold(attr, imap.NoSelectAttr) || strings.EqualFold(attr, "\\NonExistent") {
+			return false
+		}
+	}
+	return true
+}
+
+// FolderTree 列出所有文件夹并按层级组织，withStatus 为 true 时对每个可选择的文件夹查询邮件数和未读数
+func (c *Client) FolderTree(withStatus bool) ([]*Folder, error) {
+	if c.client == nil {
+		return nil, fmt.Errorf("客户端未连接")
+	}
+	boxes, err := c.listMailboxes("LIST")
+	if err != nil {
+		return nil, fmt.Errorf("列出文件夹失败: %w", err)
+	}
+
+	nodes := make(map[string]*Folder, len(boxes))
+	for _, m := range boxes {
+		nodes[m.Name] = &Folder{Name: m.Name, Delimiter: m.Delimiter, Attributes: m.Attributes}
+	}
+	names := make([]string, 0, len(nodes))
+	for name := range nodes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	if withStatus {
+		items := []imap.StatusItem{imap.StatusMessages, imap.StatusUnseen}
+		for _, name := range names {
+			f := nodes[name]
+			if !f.Selectable() {
+				continue
+			}
+			st, err := c.client.Status(name, items)
+			if err != nil {
+				continue
+			}
+			f.Status = &FolderStatus{Messages: st.Messages, Unseen: st.Unseen}
+		}
+	}
+
+	// 按分隔符挂到上级文件夹下；上级未在 LIST 中返回时补一个不可选择的节点
+	var roots []*Folder
+	var attach func(f *Folder)
+	attach = func(f *Folder) {
+		i := -1
+		if f.Delimiter != "" {
+			i = strings.LastIndex(f.Name, f.Delimiter)
+		}
+		if i <= 0 {
+			roots = append(roots, f)
+			return
+		}
+		parentName := f.Name[:i]
+		parent, ok := nodes[parentName]
+		if !ok {
+			parent = &Folder{Name: parentName, Delimiter: f.Delimiter, Attributes: []string{imap.NoSelectAttr}}
+			nodes[parentName] = parent
+			attach(parent)
+		}
+		parent.Children = append(parent.Children, f)
+	}
+	for _, name := range names {
+		attach(nodes[name])
+	}
+	sortFolders(roots)
+	return roots, nil
+}
+
+// sortFolders 按名称排序（INBOX 在最前）
+func sortFolders(folders []*Folder) {
+	sort.Slice(folders, func(i, j int) bool {
+		a, b := folders[i].Name, folders[j].Name
+		if strings.EqualFold(a, "INBOX") != strings.EqualFold(b, "INBOX") {
+			return strings.EqualFold(a, "INBOX")
+		}
+		return a < b
+	})
+	for _, f := range folders {
+		sortFolders(f.Children)
+	}
+}
@@ -1,6 +1,7 @@
 package imap
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"strings"
@@ -8,8 +9,14 @@ import (
 
 	idle "github.com/emersion/go-imap-idle"
 	"github.com/emersion/go-imap/client"
+
+	"mail-receiver/clock"
+	"mail-receiver/metrics"
 )
 
+// errStopped 上层主动停止监控
+var errStopped = errors.New("监控已停止")
+
 // IdleClient IDLE客户端封装
 type IdleClient struct {
 	client       *client.Client
@@ -17,6 +24,10 @@ type IdleClient struct {
 	accountName  string
 	idleTimeout  time.Duration
 	supportsIDLE bool
+	deadline     time.Time // 可选，会话必须结束的时间（如访问令牌过期）
+	readOnly     bool      // 使用 EXAMINE 打开文件夹
+	watcher      *updateWatcher
+	clock        clock.Clock // IDLE 超时和会话截止的时间来源
 }
 
 // NewIdleClient 创建IDLE客户端
@@ -26,9 +37,15 @@ func NewIdleClient(c *client.Client, accountName string, idleTimeoutMinutes int)
 		idleClient:  idle.NewClient(c),
 		accountName: accountName,
 		idleTimeout: time.Duration(idleTimeoutMinutes) * time.Minute,
+		clock:       clock.Real,
 	}
 }
 
+// SetDeadline 设置会话截止时间，IDLE 会在此之前结束
+func (ic *IdleClient) SetDeadline(t time.Time) {
+	ic.deadline = t
+}
+
 // isConnectionError 检查是否是连接错误
 func isConnectionError(err error) bool {
 	if err == nil {
@@ -43,29 +60,36 @@ func isConnectionError(err error) bool {
 		strings.Contains(errMsg, "aborted")
 }
 
-// CheckIDLESupport 检查服务器是否支持IDLE扩展
-// go-imap-idle会自动处理IDLE支持检测，默认假设支持
+// CheckIDLESupport 根据服务器 CAPABILITY 响应检查是否支持IDLE扩展
+// 登录后服务器可能会更新能力列表，需要在登录后再次调用
 func (ic *IdleClient) CheckIDLESupport() bool {
-	ic.supportsIDLE = true
-	return true
+	ok, err := ic.client.Support("IDLE")
+	if err != nil {
+		log.Printf("[%s] 检查 IDLE 支持失败: %v", ic.accountName, err)
+		ok = false
+	}
+	ic.supportsIDLE = ok
+	return ok
 }
 
 // MonitorWithIDLE 使用IDLE监控邮箱（一次性模式）
-// IDLE超时或发生任何错误都会关闭通道，让上层重新建立连接
-func (ic *IdleClient) MonitorWithIDLE(folder string) <-chan bool {
+// IDLE超时或发生任何错误都会关闭通道，让上层重新建立连接；stop 关闭时结束IDLE并关闭通道，连接仍可继续使用
+func (ic *IdleClient) MonitorWithIDLE(folder string, stop <-chan struct{}) <-chan bool {
 	updateCh := make(chan bool)
 
 	go func() {
 		defer close(updateCh)
 
 		// 选择文件夹并启动IDLE
-		if _, err := ic.client.Select(folder, false); err != nil {
+		if _, err := ic.client.Select(folder, ic.readOnly); err != nil {
 			return
 		}
 
-		hasUpdate, err := ic.runIDLE(updateCh)
+		hasUpdate, err := ic.runIDLE(folder, updateCh, stop)
 
-		if err != nil {
+		if errors.Is(err, errStopped) {
+			return
+		} else if err != nil {
 			// 发生错误
 			if isConnectionError(err) {
 				log.Printf("[%s] 连接断开，重新建立连接", ic.accountName)
@@ -78,6 +102,7 @@ func (ic *IdleClient) MonitorWithIDLE(folder string) <-chan bool {
 		} else {
 			// 正常超时
 			log.Printf("[%s] IDLE 超时 (%v)，重新建立连接", ic.accountName, ic.idleTimeout)
+			metrics.IdleTimeouts.Inc(ic.accountName)
 		}
 	}()
 
@@ -85,7 +110,7 @@ func (ic *IdleClient) MonitorWithIDLE(folder string) <-chan bool {
 }
 
 // runIDLE 执行IDLE命令，返回(是否有更新, 错误)
-func (ic *IdleClient) runIDLE(updateCh chan<- bool) (bool, error) {
+func (ic *IdleClient) runIDLE(folder string, updateCh chan<- bool, stop <-chan struct{}) (bool, error) {
 	// 创建停止通道
 	idleStop := make(chan struct{})
 	var idleStopClosed bool
@@ -98,7 +123,7 @@ func (ic *IdleClient) runIDLE(updateCh chan<- bool) (bool, error) {
 
 	// 创建更新通道
 	updates := make(chan client.Update, 10)
-	ic.client.Updates = updates
+	defer ic.watcher.listen(updates, folder)()
 
 	// 启动IDLE协程
 	idleDone := make(chan error, 1)
@@ -106,14 +131,26 @@ func (ic *IdleClient) runIDLE(updateCh chan<- bool) (bool, error) {
 		idleDone <- ic.idleClient.Idle(idleStop)
 	}()
 
-	// 设置超时（使用配置的超时时间）
-	timeout := time.NewTimer(ic.idleTimeout)
+	// 设置超时（使用配置的超时时间，不超过会话截止时间）
+	wait := ic.idleTimeout
+	if !ic.deadline.IsZero() {
+		if untilDeadline := ic.deadline.Sub(ic.clock.Now()); untilDeadline < wait {
+			wait = untilDeadline
+		}
+	}
+	timeout := ic.clock.NewTimer(wait)
 	defer timeout.Stop()
 
 	// 等待更新
 	for {
 		select {
-		case <-timeout.C:
+		case <-stop:
+			// 上层停止监控
+			closeIdleStop()
+			<-idleDone
+			return false, errStopped
+
+		case <-timeout.C():
 			// 超时，停止IDLE
 			closeIdleStop()
 			<-idleDone
@@ -1,35 +1,102 @@
 package imap
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"log"
-	"mime"
 	"strings"
 	"time"
 
 	"github.com/emersion/go-imap"
+	"github.com/emersion/go-message"
 	"github.com/emersion/go-message/mail"
+
+	"mail-receiver/contacts"
+	"mail-receiver/mailauth"
+	"mail-receiver/smime"
 )
 
 // EmailMessage 邮件消息结构
 type EmailMessage struct {
-	UID            uint32
-	SeqNum         uint32
-	Subject        string
-	From           []string
-	To             []string
-	CC             []string
-	Date           time.Time
-	Size           uint32
-	Flags          []string
-	Body           string
-	HTMLBody       string
-	HasAttachments bool // 是否含有附件
-}
-
-// ParseMessage 解析IMAP消息
-func ParseMessage(msg *imap.Message, accountName string) (*EmailMessage, error) {
+	UID                 uint32
+	SeqNum              uint32
+	MessageID           string
+	GmailMsgID          string // Gmail 的 X-GM-MSGID，同一封邮件在各标签（文件夹）中相同；其他服务器为空
+	Subject             string
+	From                []string
+	To                  []string
+	CC                  []string
+	Date                time.Time
+	Size                uint32
+	Flags               []string
+	Body                string
+	HTMLBody            string
+	HasAttachments      bool              // 是否含有附件
+	ListUnsubscribe     string            // List-Unsubscribe 头（原始值）
+	ListUnsubscribePost string            // List-Unsubscribe-Post 头（原始值）
+	Attachments         []*Attachment     // 附件内容（含内嵌邮件中的附件）
+	Embedded            []*Embedded       // 内嵌的邮件（message/rfc822，如以附件形式转发的原邮件）
+	InlineImages        []*Attachment     // 内联图片（HTML 正文通过 cid: 引用），不计入附件
+	Bounce              *Bounce           // 退信（投递状态通知）中的失败信息，不是退信时为nil
+	Auth                *mailauth.Verdict // 发件人真实性检查结果，未启用检查时为nil
+	SMIME               *smime.Result     // S/MIME 解密和签名验证结果，不是 S/MIME 邮件或未启用时为nil
+	Raw                 []byte            // 原始邮件内容（SMTP 转发时使用）
+
+	FromAddress string            // 第一个发件人的邮箱地址（不含名称）
+	Contact     *contacts.Contact // 发件人对应的联系人（未匹配时为nil）
+	Tags        []string          // 分类标签（如 invoice/otp/alert/newsletter）
+}
+
+// fetchGmailMsgID Gmail 扩展（X-GM-EXT-1）的邮件ID
+const fetchGmailMsgID imap.FetchItem = "X-GM-MSGID"
+
+// Attachment 邮件附件
+type Attachment struct {
+	Filename    string
+	ContentType string
+	ContentID   string // Content-ID（不含尖括号），内联图片通过 cid: 引用
+	Size        int64  // 解码后的字节数
+	Data        []byte // 附件内容，未读取（ParseOptions.AttachmentData 为 false）或超过大小限制时为nil
+}
+
+// ParseOptions 解析邮件的选项
+type ParseOptions struct {
+	AttachmentData    bool  // 是否读取附件和内联图片的内容（保存附件时需要），否则只记录大小
+	MaxAttachmentSize int64 // 读取内容的单个附件最大字节数，超过时只记录大小（0 不限制）
+}
+
+// Embedded 内嵌邮件的邮件头和正文，多层内嵌时按出现顺序展开
+type Embedded struct {
+	Subject  string
+	From     string
+	Date     time.Time
+	Body     string
+	HTMLBody string
+}
+
+// maxEmbeddedDepth 内嵌邮件最多解析的层数
+const maxEmbeddedDepth = 5
+
+// ParseError 邮件正文解析失败，Email 中有信封信息和出错前已解析的正文、附件
+type ParseError struct {
+	Email *EmailMessage
+	Raw   []byte // 原始邮件内容
+	Err   error
+}
+
+// Error 实现 error
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("解析邮件正文失败: %v", e.Err)
+}
+
+// Unwrap 返回解析错误
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// ParseMessage 解析IMAP消息，正文无法解析时返回 *ParseError
+func ParseMessage(msg *imap.Message, accountName string, opts ParseOptions) (*EmailMessage, error) {
 	if msg == nil {
 		return nil, fmt.Errorf("消息为空")
 	}
@@ -41,15 +108,23 @@ func ParseMessage(msg *imap.Message, accountName string) (*EmailMessage, error)
 		Flags:  msg.Flags,
 	}
 
+	if v, ok := msg.Items[fetchGmailMsgID]; ok {
+		email.GmailMsgID, _ = imap.ParseString(v)
+	}
+
 	// 解析信封信息
 	if msg.Envelope != nil {
 		email.Subject = msg.Envelope.Subject
 		email.Date = msg.Envelope.Date
+		email.MessageID = msg.Envelope.MessageId
 
 		// 解析发件人
 		for _, addr := range msg.Envelope.From {
 			email.From = append(email.From, formatAddress(addr))
 		}
+		if len(msg.Envelope.From) > 0 && msg.Envelope.From[0] != nil {
+			email.FromAddress = msg.Envelope.From[0].Address()
+		}
 
 		// 解析收件人
 		for _, addr := range msg.Envelope.To {
@@ -64,21 +139,52 @@ func ParseMessage(msg *imap.Message, accountName string) (*EmailMessage, error)
 
 	// 解析邮件正文
 	for _, literal := range msg.Body {
-		if literal != nil {
-			if err := parseBody(literal, email, accountName); err != nil {
-				log.Printf("[%s] 解析邮件正文失败: %v", accountName, err)
-			}
+		if literal == nil {
+			continue
+		}
+		raw, err := io.ReadAll(literal)
+		if err == nil {
+			email.Raw = raw
+			err = parseBody(bytes.NewReader(raw), email, accountName, opts)
+		}
+		if err != nil {
+			// 保留出错前已解析的正文和附件（如只有最后一个附件损坏）
+			return nil, &ParseError{Email: email, Raw: raw, Err: err}
 		}
 	}
 
 	return email, nil
 }
 
+// ParseContent 用新的内容（如 S/MIME 解密后的 MIME 实体）重新解析正文和附件，信封信息不变，Raw 仍为原始邮件
+func (e *EmailMessage) ParseContent(content []byte, accountName string, opts ParseOptions) error {
+	parsed := *e
+	parsed.resetContent()
+	if err := parseBody(bytes.NewReader(content), &parsed, accountName, opts); err != nil {
+		return err
+	}
+	*e = parsed
+	return nil
+}
+
+// resetContent 清空解析得到的正文和附件
+func (e *EmailMessage) resetContent() {
+	e.Body, e.HTMLBody = "", ""
+	e.HasAttachments, e.Attachments, e.Embedded, e.InlineImages = false, nil, nil, nil
+	e.Bounce = nil
+}
+
 // parseBody 解析邮件正文
-func parseBody(r io.Reader, email *EmailMessage, accountName string) error {
-	// 创建邮件阅读器
+func parseBody(r io.Reader, email *EmailMessage, accountName string, opts ParseOptions) error {
+	return parseEntity(r, email, accountName, 0, opts)
+}
+
+// parseEntity 解析邮件正文，depth 为内嵌邮件的层数
+// 嵌套的 multipart 由 mail.Reader 展开，message/rfc822 部分递归解析为内嵌邮件
+func parseEntity(r io.Reader, email *EmailMessage, accountName string, depth int, opts ParseOptions) error {
+	// 创建邮件阅读器（字符集不支持时仍可读取，正文由 decodeText 兜底转码）
 	mr, err := mail.CreateReader(r)
-	if err != nil {
+	if err != nil && !message.IsUnknownCharset(err) {
 		return fmt.Errorf("创建邮件读取器失败: %w", err)
 	}
 	defer mr.Close()
@@ -91,6 +197,31 @@ func parseBody(r io.Reader, email *EmailMessage, accountName string) error {
 	if date, err := header.Date(); err == nil && email.Date.IsZero() {
 		email.Date = date
 	}
+	if id, err := header.MessageID(); err == nil && id != "" && email.MessageID == "" {
+		email.MessageID = "<" + id + ">"
+	}
+	// 没有信封时（如内嵌邮件）从邮件头取发件人
+	if addrs, err := header.AddressList("From"); err == nil && len(email.From) == 0 {
+		for _, addr := range addrs {
+			email.From = append(email.From, formatMailAddress(addr))
+		}
+		if len(addrs) > 0 {
+			email.FromAddress = addrs[0].Address
+		}
+	}
+	// 重新解析解密后的内容时保留外层邮件头的值
+	if v := header.Get("List-Unsubscribe"); v != "" {
+		email.ListUnsubscribe = v
+	}
+	if v := header.Get("List-Unsubscribe-Post"); v != "" {
+		email.ListUnsubscribePost = v
+	}
+
+	// 投递状态通知（退信）的状态和退回的原邮件不作为正文或附件
+	var bounce *Bounce
+	if isDeliveryReport(header.Header) {
+		bounce = &Bounce{}
+	}
 
 	// 遍历邮件各部分
 	for {
@@ -98,14 +229,33 @@ func parseBody(r io.Reader, email *EmailMessage, accountName string) error {
 		if err == io.EOF {
 			break
 		}
-		if err != nil {
+		if err != nil && !message.IsUnknownCharset(err) {
 			return fmt.Errorf("读取邮件部分失败: %w", err)
 		}
+		if bounce != nil && bounce.readPart(part, accountName, depth) {
+			continue
+		}
 
 		switch h := part.Header.(type) {
 		case *mail.InlineHeader:
 			// 处理内联内容（正文）
 			contentType, _, _ := h.ContentType()
+			if strings.HasPrefix(contentType, "image/") {
+				data, size, err := readAttachment(part.Body, opts)
+				if err != nil {
+					log.Printf("[%s] 读取内联图片失败: %v", accountName, err)
+					continue
+				}
+				_, params, _ := h.ContentDisposition()
+				email.InlineImages = append(email.InlineImages, &Attachment{
+					Filename:    params["filename"],
+					ContentType: contentType,
+					ContentID:   contentID(h.Get("Content-Id")),
+					Size:        size,
+					Data:        data,
+				})
+				continue
+			}
 			body, err := io.ReadAll(part.Body)
 			if err != nil {
 				log.Printf("[%s] 读取邮件正文失败: %v", accountName, err)
@@ -114,22 +264,161 @@ func parseBody(r io.Reader, email *EmailMessage, accountName string) error {
 
 			switch {
 			case strings.HasPrefix(contentType, "text/plain"):
-				email.Body = string(body)
+				email.Body = decodeText(body, false)
 			case strings.HasPrefix(contentType, "text/html"):
-				email.HTMLBody = string(body)
+				email.HTMLBody = decodeText(body, true)
+			case isEmbeddedMessage(contentType):
+				// 内联的转发邮件
+				email.addEmbedded(body, accountName, depth, opts)
 			}
 
 		case *mail.AttachmentHeader:
+			filename, _ := h.Filename()
+			contentType, _, _ := h.ContentType()
+			var data []byte
+			var size int64
+			var err error
+			if isEmbeddedMessage(contentType) {
+				// 内嵌邮件需要完整读取后解析
+				data, err = io.ReadAll(part.Body)
+				size = int64(len(data))
+			} else {
+				data, size, err = readAttachment(part.Body, opts)
+			}
+			// 未声明为附件、带 Content-ID 的图片（multipart/related 中 HTML 正文引用的图片）为内联图片
+			if disp, _, _ := h.ContentDisposition(); disp != "attachment" && strings.HasPrefix(contentType, "image/") && h.Get("Content-Id") != "" {
+				if err != nil {
+					log.Printf("[%s] 读取内联图片失败: %v", accountName, err)
+					continue
+				}
+				email.InlineImages = append(email.InlineImages, &Attachment{
+					Filename:    filename,
+					ContentType: contentType,
+					ContentID:   contentID(h.Get("Content-Id")),
+					Size:        size,
+					Data:        data,
+				})
+				continue
+			}
+
+			// S/MIME 签名（smime.p7s）不是附件
+			if contentType == "application/pkcs7-signature" || contentType == "application/x-pkcs7-signature" {
+				continue
+			}
+
 			// 标记邮件含有附件
 			email.HasAttachments = true
-			// 跳过附件内容
-			io.Copy(io.Discard, part.Body)
+			if err != nil {
+				log.Printf("[%s] 读取邮件附件失败: %v", accountName, err)
+				continue
+			}
+			if isEmbeddedMessage(contentType) {
+				if inner := email.addEmbedded(data, accountName, depth, opts); inner != nil && filename == "" {
+					filename = embeddedFilename(inner.Subject)
+				}
+				if !opts.AttachmentData || (opts.MaxAttachmentSize > 0 && size > opts.MaxAttachmentSize) {
+					data = nil
+				}
+			}
+			email.Attachments = append(email.Attachments, &Attachment{
+				Filename:    filename,
+				ContentType: contentType,
+				Size:        size,
+				Data:        data,
+			})
 		}
 	}
 
+	if bounce != nil && bounce.failed() {
+		email.Bounce = bounce
+	}
 	return nil
 }
 
+// readAttachment 读取附件内容，返回内容和解码后的字节数
+// 不需要内容或超过大小限制时只统计字节数，不保存在内存中
+func readAttachment(r io.Reader, opts ParseOptions) ([]byte, int64, error) {
+	if !opts.AttachmentData {
+		n, err := io.Copy(io.Discard, r)
+		return nil, n, err
+	}
+	if opts.MaxAttachmentSize <= 0 {
+		data, err := io.ReadAll(r)
+		return data, int64(len(data)), err
+	}
+	data, err := io.ReadAll(io.LimitReader(r, opts.MaxAttachmentSize+1))
+	if err != nil || int64(len(data)) <= opts.MaxAttachmentSize {
+		return data, int64(len(data)), err
+	}
+	n, err := io.Copy(io.Discard, r)
+	return nil, int64(len(data)) + n, err
+}
+
+// isEmbeddedMessage 是否为内嵌邮件（message/rfc822，或 RFC 6532 的 message/global）
+func isEmbeddedMessage(contentType string) bool {
+	return contentType == "message/rfc822" || contentType == "message/global"
+}
+
+// addEmbedded 解析内嵌邮件：邮件头和正文加入 Embedded，其中的附件加入外层邮件的附件；解析失败或层数过多时返回nil
+func (e *EmailMessage) addEmbedded(raw []byte, accountName string, depth int, opts ParseOptions) *EmailMessage {
+	if depth >= maxEmbeddedDepth {
+		return nil
+	}
+	inner := &EmailMessage{}
+	if err := parseEntity(bytes.NewReader(raw), inner, accountName, depth+1, opts); err != nil {
+		log.Printf("[%s] 解析内嵌邮件失败: %v", accountName, err)
+		return nil
+	}
+	var from string
+	if len(inner.From) > 0 {
+		from = inner.From[0]
+	}
+	e.Embedded = append(e.Embedded, &Embedded{
+		Subject:  inner.Subject,
+		From:     from,
+		Date:     inner.Date,
+		Body:     inner.Body,
+		HTMLBody: inner.HTMLBody,
+	})
+	e.Embedded = append(e.Embedded, inner.Embedded...)
+	e.InlineImages = append(e.InlineImages, inner.InlineImages...)
+	if len(inner.Attachments) > 0 {
+		e.HasAttachments = true
+		e.Attachments = append(e.Attachments, inner.Attachments...)
+	}
+	return inner
+}
+
+// contentID 去掉 Content-ID 的尖括号和空白
+func contentID(s string) string {
+	return strings.Trim(strings.TrimSpace(s), "<>")
+}
+
+// embeddedFilename 没有文件名的内嵌邮件附件以主题命名
+func embeddedFilename(subject string) string {
+	name := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`/\:*?"<>|`, r) || r < 0x20 {
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(subject))
+	if name == "" {
+		name = "message"
+	}
+	return name + ".eml"
+}
+
+// DisplayFrom 返回推送中展示的发件人，匹配到联系人时使用联系人名称
+func (e *EmailMessage) DisplayFrom() string {
+	if e.Contact != nil && e.Contact.Name != "" {
+		return fmt.Sprintf("%s (%s)", e.Contact.Name, e.FromAddress)
+	}
+	if len(e.From) > 0 {
+		return e.From[0]
+	}
+	return ""
+}
+
 // formatAddress 格式化邮件地址
 func formatAddress(addr *imap.Address) string {
 	if addr == nil {
@@ -152,10 +441,17 @@ func formatAddress(addr *imap.Address) string {
 	return email
 }
 
+// formatMailAddress 格式化邮件头中的地址，格式同 formatAddress
+func formatMailAddress(addr *mail.Address) string {
+	if addr.Name != "" {
+		return fmt.Sprintf("%s (%s)", addr.Name, addr.Address)
+	}
+	return addr.Address
+}
+
 // decodeRFC2047 解码RFC2047编码的字符串（用于处理中文等非ASCII字符）
 func decodeRFC2047(s string) (string, error) {
-	dec := new(mime.WordDecoder)
-	decoded, err := dec.DecodeHeader(s)
+	decoded, err := headerDecoder.DecodeHeader(s)
 	if err != nil {
 		return s, err
 	}
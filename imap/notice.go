@@ -0,0 +1,183 @@
+package imap
+
+import (
+	"log"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/emersion/go-imap"
+	"github.com/emersion/go-imap/client"
+)
+
+// 服务器提示类型
+const (
+	NoticeAlert = "alert" // 未标记的 [ALERT] 响应，要求展示给用户
+	NoticeBye   = "bye"   // 服务器主动断开连接（如停机维护）
+)
+
+// Notice 服务器主动发送的提示
+type Notice struct {
+	Type string // NoticeAlert / NoticeBye
+	Text string
+}
+
+// SetNoticeHandler 设置服务器提示（ALERT/BYE）的回调，在接收协程中调用，不应阻塞
+func (c *Client) SetNoticeHandler(fn func(Notice)) {
+	c.noticeHandler = fn
+}
+
+// ByeReason 返回当前连接上服务器断开连接时给出的原因，没有时返回空
+func (c *Client) ByeReason() string {
+	if c.watcher == nil {
+		return ""
+	}
+	c.watcher.mu.Lock()
+	defer c.watcher.mu.Unlock()
+	return c.watcher.bye
+}
+
+// updateWatcher 接收连接上的所有未标记更新：处理 ALERT/BYE/CAPABILITY，其余转发给当前的监听者（IDLE）
+type updateWatcher struct {
+	mu        sync.Mutex
+	listener  chan<- client.Update
+	bye       string          // 服务器断开连接的原因
+	loggedOut bool            // 已主动发送 LOGOUT，之后的 BYE 是正常响应
+	folder    string          // 当前监听者 IDLE 的文件夹
+	changed   map[string]bool // IDLE 期间收到邮件状态变化（FETCH）或删除（EXPUNGE）的文件夹
+}
+
+// watchUpdates 为新连接启动更新接收协程，连接关闭后退出
+func (c *Client) watchUpdates() {
+	w := &updateWatcher{}
+	c.watcher = w
+	if c.idleClient != nil {
+		c.idleClient.watcher = w
+	}
+
+	// 不使用缓冲：进入IDLE前（SELECT/SEARCH 等命令期间）收到的更新不会积压到IDLE开始后才转发
+	updates := make(chan client.Update)
+	cl := c.client
+	cl.Updates = updates
+	go func() {
+		for {
+			select {
+			case u := <-updates:
+				c.handleUpdate(w, u)
+			case <-cl.LoggedOut():
+				return
+			}
+		}
+	}()
+}
+
+// handleUpdate 处理一条未标记更新
+func (c *Client) handleUpdate(w *updateWatcher, u client.Update) {
+	su, ok := u.(*client.StatusUpdate)
+	if !ok || su.Status == nil {
+		w.forward(u)
+		return
+	}
+
+	resp := su.Status
+	switch {
+	case resp.Type == imap.StatusRespBye:
+		w.mu.Lock()
+		expected := w.loggedOut
+		if !expected {
+			w.bye = resp.Info
+		}
+		w.mu.Unlock()
+		if !expected {
+			c.notify(Notice{Type: NoticeBye, Text: resp.Info})
+		}
+	case resp.Code == imap.CodeAlert:
+		c.notify(Notice{Type: NoticeAlert, Text: resp.Info})
+	case resp.Code == imap.CodeCapability:
+		c.updateCapabilities(resp.Arguments)
+	}
+	// 其他状态响应（如 IDLE 期间的 "* OK Still here" 保活）不代表邮箱变化，不转发
+}
+
+// notify 调用提示回调，未设置回调时只记录日志
+func (c *Client) notify(n Notice) {
+	if c.noticeHandler != nil {
+		c.noticeHandler(n)
+		return
+	}
+	log.Printf("[%s] 服务器提示 (%s): %s", c.accountName, n.Type, n.Text)
+}
+
+// updateCapabilities 服务器在未标记响应中更新能力列表时记录变化
+func (c *Client) updateCapabilities(args []interface{}) {
+	var caps []string
+	for _, arg := range args {
+		if name, ok := arg.(string); ok {
+			caps = append(caps, name)
+		}
+	}
+	sort.Strings(caps)
+
+	c.capsMu.Lock()
+	changed := strings.Join(caps, " ") != strings.Join(c.capabilities, " ")
+	c.capabilities = caps
+	c.capsMu.Unlock()
+	if changed {
+		log.Printf("[%s] 服务器能力变化: %s", c.accountName, strings.Join(caps, " "))
+	}
+}
+
+// listen 设置当前的更新监听者及其 IDLE 的文件夹，返回取消函数
+func (w *updateWatcher) listen(ch chan<- client.Update, folder string) func() {
+	w.mu.Lock()
+	w.listener = ch
+	w.folder = folder
+	w.mu.Unlock()
+	return func() {
+		w.mu.Lock()
+		if w.listener == ch {
+			w.listener = nil
+		}
+		w.mu.Unlock()
+	}
+}
+
+// forward 转发给当前监听者，没有监听者或监听者繁忙时丢弃（下次获取邮件时会重新同步）
+func (w *updateWatcher) forward(u client.Update) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.listener == nil {
+		return
+	}
+	switch u.(type) {
+	case *client.MessageUpdate, *client.ExpungeUpdate:
+		// 只统计 IDLE 期间的更新，自己执行 STORE 等命令时的响应不计入
+		if w.changed == nil {
+			w.changed = make(map[string]bool)
+		}
+		w.changed[w.folder] = true
+	}
+	select {
+	case w.listener <- u:
+	default:
+	}
+}
+
+// TakeMessageChanges 返回 IDLE 监控 folder 期间是否收到过邮件状态变化或删除通知，并清除该状态
+func (c *Client) TakeMessageChanges(folder string) bool {
+	if c.watcher == nil {
+		return false
+	}
+	c.watcher.mu.Lock()
+	defer c.watcher.mu.Unlock()
+	changed := c.watcher.changed[folder]
+	delete(c.watcher.changed, folder)
+	return changed
+}
+
+// expectBye 主动退出前调用，之后收到的 BYE 不再作为服务器提示
+func (w *updateWatcher) expectBye() {
+	w.mu.Lock()
+	w.loggedOut = true
+	w.mu.Unlock()
+}
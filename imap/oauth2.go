@@ -0,0 +1,158 @@
+package imap
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/emersion/go-sasl"
+
+	"mail-receiver/clock"
+)
+
+// 常见服务商的令牌接口
+const (
+	GoogleTokenURL    = "https://oauth2.googleapis.com/token"
+	MicrosoftTokenURL = "https://login.microsoftonline.com/common/oauth2/v2.0/token"
+)
+
+// tokenRefreshMargin 访问令牌提前刷新的时间余量
+const tokenRefreshMargin = 5 * time.Minute
+
+// TokenSource 使用 refresh token 获取并缓存 OAuth2 访问令牌
+type TokenSource struct {
+	clientID     string
+	clientSecret string
+	refreshToken string
+	tokenURL     string
+	httpClient   *http.Client
+	clock        clock.Clock // 令牌过期时间的时间来源
+
+	mu          sync.Mutex
+	accessToken string
+	expiry      time.Time
+}
+
+// NewTokenSource 创建令牌源，tokenURL 留空时根据服务器地址推断（Gmail / Office 365）
+func NewTokenSource(clientID, clientSecret, refreshToken, tokenURL, server string) (*TokenSource, error) {
+	if tokenURL == "" {
+		switch {
+		case strings.Contains(server, "gmail") || strings.Contains(server, "google"):
+			tokenURL = GoogleTokenURL
+		case strings.Contains(server, "outlook") || strings.Contains(server, "office365"):
+			tokenURL = MicrosoftTokenURL
+		default:
+			return nil, fmt.Errorf("无法推断服务器 %s 的令牌接口，请配置 token_url", server)
+		}
+	}
+	if clientID == "" || refreshToken == "" {
+		return nil, fmt.Errorf("OAuth2 配置缺少 client_id 或 refresh_token")
+	}
+
+	return &TokenSource{
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		refreshToken: refreshToken,
+		tokenURL:     tokenURL,
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+		clock: clock.Real,
+	}, nil
+}
+
+// SetClock 设置令牌过期时间的时间来源（模拟运行和测试时快进时间）
+func (ts *TokenSource) SetClock(clk clock.Clock) {
+	ts.clock = clock.Or(clk)
+}
+
+// Token 返回有效的访问令牌，即将过期时自动刷新
+func (ts *TokenSource) Token() (string, error) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	if ts.accessToken != "" && ts.expiry.Sub(ts.clock.Now()) > tokenRefreshMargin {
+		return ts.accessToken, nil
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "refresh_token")
+	form.Set("client_id", ts.clientID)
+	form.Set("refresh_token", ts.refreshToken)
+	if ts.clientSecret != "" {
+		form.Set("client_secret", ts.clientSecret)
+	}
+
+	resp, err := ts.httpClient.PostForm(ts.tokenURL, form)
+	if err != nil {
+		return "", fmt.Errorf("刷新访问令牌失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		AccessToken  string `json:"access_token"`
+		ExpiresIn    int    `json:"expires_in"`
+		RefreshToken string `json:"refresh_token"`
+		Error        string `json:"error"`
+		Description  string `json:"error_description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("解析令牌响应失败: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK || result.AccessToken == "" {
+		err := fmt.Errorf("刷新访问令牌失败: [%d] %s %s", resp.StatusCode, result.Error, result.Description)
+		// 令牌接口拒绝（refresh token 失效或被撤销、客户端凭据错误）需要重新授权，5xx 为临时故障
+		if resp.StatusCode >= 400 && resp.StatusCode < 500 {
+			return "", fmt.Errorf("%w: %v", ErrAuthFailed, err)
+		}
+		return "", err
+	}
+
+	ts.accessToken = result.AccessToken
+	ts.expiry = ts.clock.Now().Add(time.Duration(result.ExpiresIn) * time.Second)
+	// 部分服务商（如微软）会轮换 refresh token
+	if result.RefreshToken != "" {
+		ts.refreshToken = result.RefreshToken
+	}
+
+	return ts.accessToken, nil
+}
+
+// Expiry 返回当前访问令牌的过期时间
+func (ts *TokenSource) Expiry() time.Time {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	return ts.expiry
+}
+
+// Invalidate 丢弃缓存的访问令牌，下次调用 Token 时强制刷新
+func (ts *TokenSource) Invalidate() {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	ts.accessToken = ""
+}
+
+// xoauth2Client XOAUTH2 SASL 客户端
+type xoauth2Client struct {
+	username string
+	token    string
+}
+
+// newXoauth2Client 创建 XOAUTH2 认证客户端
+func newXoauth2Client(username, token string) sasl.Client {
+	return &xoauth2Client{username: username, token: token}
+}
+
+func (a *xoauth2Client) Start() (mech string, ir []byte, err error) {
+	resp := "user=" + a.username + "\x01auth=Bearer " + a.token + "\x01\x01"
+	return "XOAUTH2", []byte(resp), nil
+}
+
+func (a *xoauth2Client) Next(challenge []byte) ([]byte, error) {
+	// 认证失败时服务器会返回包含错误详情的JSON，按协议回复空响应以结束认证
+	return []byte{}, nil
+}
@@ -0,0 +1,213 @@
+package imap
+
+import (
+	"bytes"
+	"net"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"mail-receiver/clock"
+
+	"github.com/emersion/go-imap/backend/memory"
+	"github.com/emersion/go-imap/server"
+)
+
+// commandLog 记录服务器收发的数据，统计客户端发送的命令
+type commandLog struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (l *commandLog) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.buf.Write(p)
+}
+
+// selectCommand 客户端发送的 SELECT / EXAMINE 命令（服务器的响应以 OK/NO/BAD 开头，不会匹配）
+var selectCommand = regexp.MustCompile(`(?m)^\S+ (?:SELECT|EXAMINE) `)
+
+// selects 返回客户端已发送的 SELECT / EXAMINE 次数
+func (l *commandLog) selects() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(selectCommand.FindAllIndex(l.buf.Bytes(), -1))
+}
+
+// startTestServer 在本机启动内存 IMAP 服务器（用户 username/password，INBOX 中有一封邮件）
+func startTestServer(t *testing.T) (*memory.Backend, int, *commandLog) {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("无法监听本机端口: %v", err)
+	}
+	be := memory.New()
+	s := server.New(be)
+	s.AllowInsecureAuth = true
+	cmds := &commandLog{}
+	s.Debug = cmds
+	go s.Serve(l)
+	t.Cleanup(func() { s.Close() })
+	return be, l.Addr().(*net.TCPAddr).Port, cmds
+}
+
+// connectTestClient 连接并登录测试服务器
+func connectTestClient(t *testing.T, port int, fake *clock.Fake) *Client {
+	t.Helper()
+	c := NewClient("127.0.0.1", port, "username", "password", "test", 0)
+	c.SetSecurity(SecurityNone)
+	c.SetClock(fake)
+	if err := c.Connect(); err != nil {
+		t.Fatalf("连接失败: %v", err)
+	}
+	t.Cleanup(func() { c.Logout() })
+	if err := c.Login(); err != nil {
+		t.Fatalf("登录失败: %v", err)
+	}
+	return c
+}
+
+// appendTestMessage 向 INBOX 添加一封邮件
+func appendTestMessage(t *testing.T, be *memory.Backend) {
+	t.Helper()
+	user, err := be.Login(nil, "username", "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mbox, err := user.GetMailbox("INBOX")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := "From: a@example.org\r\nSubject: test\r\n\r\nhello"
+	if err := mbox.CreateMessage(nil, time.Now(), bytes.NewBufferString(body)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// waitTimers 等待被测代码创建 n 个定时器后再推进时间
+func waitTimers(t *testing.T, c *clock.Fake, n int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for c.Waiting() < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("等待定时器超时：当前 %d 个，期望 %d 个", c.Waiting(), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestPollModeInterval(t *testing.T) {
+	be, port, _ := startTestServer(t)
+	fake := clock.NewFake(time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC))
+	c := connectTestClient(t, port, fake)
+
+	updateCh := make(chan error, 1)
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		c.pollMode("INBOX", FixedInterval(time.Minute), updateCh, stop, make(chan struct{}))
+		close(done)
+	}()
+	defer func() {
+		close(stop)
+		<-done
+	}()
+
+	// 没有新邮件时不通知
+	waitTimers(t, fake, 1)
+	fake.Advance(time.Minute)
+	waitTimers(t, fake, 1)
+	select {
+	case err := <-updateCh:
+		t.Fatalf("没有新邮件却收到了通知: %v", err)
+	default:
+	}
+
+	// 新邮件在下一次轮询（间隔到期）时才发现
+	appendTestMessage(t, be)
+	fake.Advance(59 * time.Second)
+	select {
+	case err := <-updateCh:
+		t.Fatalf("轮询间隔未到就收到了通知: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	fake.Advance(time.Second)
+	select {
+	case err := <-updateCh:
+		if err != nil {
+			t.Fatalf("轮询失败: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("轮询间隔到期后没有发现新邮件")
+	}
+}
+
+func TestPollWaitsForAck(t *testing.T) {
+	be, port, cmds := startTestServer(t)
+	fake := clock.NewFake(time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC))
+	c := connectTestClient(t, port, fake)
+	// 内存服务器支持 IDLE，按不支持处理以使用轮询模式
+	c.supportsIDLE = false
+
+	monitor := c.IdleWithFallback("INBOX", FixedInterval(time.Minute))
+	defer monitor.Stop()
+	waitTimers(t, fake, 1)
+
+	appendTestMessage(t, be)
+	fake.Advance(time.Minute)
+	select {
+	case err := <-monitor.UpdateCh:
+		if err != nil {
+			t.Fatalf("轮询失败: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("没有收到新邮件通知")
+	}
+
+	// 上层处理期间（未 Ack）轮询不能再发送 SELECT，否则会与上层的 FETCH/STORE 交错
+	before := cmds.selects()
+	for i := 0; i < 5; i++ {
+		fake.Advance(time.Minute)
+		time.Sleep(20 * time.Millisecond)
+	}
+	if n := cmds.selects(); n != before {
+		t.Fatalf("处理期间轮询发送了 %d 次 SELECT", n-before)
+	}
+	if n := fake.Waiting(); n != 0 {
+		t.Fatalf("处理期间轮询定时器仍在等待 (%d)", n)
+	}
+
+	// 处理完成后恢复轮询
+	monitor.Ack()
+	waitTimers(t, fake, 1)
+	fake.Advance(time.Minute)
+	deadline := time.Now().Add(2 * time.Second)
+	for cmds.selects() == before {
+		if time.Now().After(deadline) {
+			t.Fatalf("Ack 后没有继续轮询")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestAdaptiveIntervalFollowsArrivals(t *testing.T) {
+	a := NewAdaptiveInterval(5*time.Minute, time.Minute, 30*time.Minute)
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	if d := a.Next(day.Add(9 * time.Hour)); d != 5*time.Minute {
+		t.Fatalf("没有历史数据时间隔 %v，期望基础间隔 5m", d)
+	}
+
+	// 来信集中在 9 点：9 点取最小间隔，夜间取最大间隔
+	for i := 0; i < 10; i++ {
+		a.Observe(day.Add(9*time.Hour + time.Duration(i)*time.Minute))
+	}
+	if d := a.Next(day.Add(9*time.Hour + 30*time.Minute)); d != time.Minute {
+		t.Fatalf("来信高峰时段间隔 %v，期望 1m", d)
+	}
+	if d := a.Next(day.Add(23 * time.Hour)); d != 30*time.Minute {
+		t.Fatalf("无来信时段间隔 %v，期望 30m", d)
+	}
+}
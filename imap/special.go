@@ -0,0 +1,200 @@
+package imap
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/emersion/go-imap"
+	"github.com/emersion/go-imap/responses"
+)
+
+// 特殊用途文件夹（RFC 6154 SPECIAL-USE 属性）
+const (
+	SpecialAll     = imap.AllAttr
+	SpecialArchive = imap.ArchiveAttr
+	SpecialDrafts  = imap.DraftsAttr
+	SpecialFlagged = imap.FlaggedAttr
+	SpecialJunk    = imap.JunkAttr
+	SpecialSent    = imap.SentAttr
+	SpecialTrash   = imap.TrashAttr
+)
+
+// specialAttrs 识别的特殊用途属性（按日志输出顺序）
+var specialAttrs = []string{SpecialJunk, SpecialSent, SpecialArchive, SpecialTrash, SpecialDrafts, SpecialAll, SpecialFlagged}
+
+// xlistAttrs Gmail 旧版 XLIST 扩展的属性名 → SPECIAL-USE 属性
+var xlistAttrs = map[string]string{
+	"\\AllMail": SpecialAll,
+	"\\Spam":    SpecialJunk,
+	"\\Starred": SpecialFlagged,
+}
+
+// specialNames 服务器不支持 SPECIAL-USE/XLIST 时按常见文件夹名称匹配（小写）
+var specialNames = map[string][]string{
+	SpecialJunk:    {"junk", "spam", "bulk mail", "junk e-mail", "junk email", "垃圾邮件", "垃圾箱", "广告邮件"},
+	SpecialSent:    {"sent", "sent items", "sent messages", "sent mail", "已发送", "已发送邮件"},
+	SpecialArchive: {"archive", "archives", "归档", "存档"},
+	SpecialTrash:   {"trash", "deleted items", "deleted messages", "已删除", "已删除邮件"},
+	SpecialDrafts:  {"drafts", "draft", "草稿箱", "草稿"},
+}
+
+// DiscoverSpecialFolders 识别特殊用途文件夹（垃圾邮件、已发送、归档等）
+// 优先使用 SPECIAL-USE，其次 XLIST，最后按常见名称匹配；识别成功后重连时复用结果，不再发送 LIST
+func (c *Client) DiscoverSpecialFolders() error {
+	if c.client == nil {
+		return fmt.Errorf("客户端未连接")
+	}
+	if c.special != nil {
+		return nil
+	}
+
+	var (
+		boxes []*imap.MailboxInfo
+		err   error
+	)
+	caps := c.Capabilities()
+	if hasCapability(caps, "XLIST") && !hasCapability(caps, "SPECIAL-USE") {
+		boxes, err = c.listMailboxes("XLIST")
+	} else {
+		boxes, err = c.listMailboxes("LIST")
+	}
+	if err != nil {
+		return fmt.Errorf("识别特殊文件夹失败: %w", err)
+	}
+
+	special := make(map[string]string)
+	for _, m := range boxes {
+		for _, attr := range m.Attributes {
+			if mapped, ok := xlistAttrs[attr]; ok {
+				attr = mapped
+			}
+			if _, exists := special[attr]; !exists && isSpecialAttr(attr) {
+				special[attr] = m.Name
+			}
+		}
+	}
+
+	// 按名称补全未通过属性识别的文件夹
+	for attr, names := range specialNames {
+		if _, ok := special[attr]; ok {
+			continue
+		}
+		for _, m := range boxes {
+			if matchFolderName(m.Name, m.Delimiter, names) {
+				special[attr] = m.Name
+				break
+			}
+		}
+	}
+
+	if !c.specialLogged && len(special) > 0 {
+		c.specialLogged = true
+		var parts []string
+		for _, attr := range specialAttrs {
+			if name, ok := special[attr]; ok {
+				parts = append(parts, attr+"="+name)
+			}
+		}
+		log.Printf("[%s] 特殊文件夹: %s", c.accountName, strings.Join(parts, " "))
+	}
+	c.special = special
+	return nil
+}
+
+// SpecialFolder 返回特殊用途文件夹的实际名称
+func (c *Client) SpecialFolder(attr string) (string, bool) {
+	name, ok := c.special[attr]
+	return name, ok
+}
+
+// ResolveFolder 解析配置中的文件夹名：以 \ 开头的特殊用途名称（如 \Junk、\Archive）
+// 解析为服务器上的实际文件夹，无法识别时原样返回
+func (c *Client) ResolveFolder(folder string) string {
+	if !strings.HasPrefix(folder, "\\") {
+		return folder
+	}
+	for attr, name := range c.special {
+		if strings.EqualFold(attr, folder) {
+			return name
+		}
+	}
+	return folder
+}
+
+// listMailboxes 执行 LIST 或 XLIST 命令
+func (c *Client) listMailboxes(command string) ([]*imap.MailboxInfo, error) {
+	ch := make(chan *imap.MailboxInfo, 10)
+	done := make(chan error, 1)
+	go func() {
+		cmd := &imap.Command{Name: command, Arguments: []interface{}{"", "*"}}
+		status, err := c.client.Execute(cmd, &listHandler{name: command, mailboxes: ch})
+		close(ch)
+		if err == nil {
+			err = status.Err()
+		}
+		done <- err
+	}()
+
+	var boxes []*imap.MailboxInfo
+	for m := range ch {
+		boxes = append(boxes, m)
+	}
+	return boxes, <-done
+}
+
+// listHandler 解析 LIST/XLIST 响应
+type listHandler struct {
+	name      string
+	mailboxes chan<- *imap.MailboxInfo
+}
+
+func (h *listHandler) Handle(resp imap.Resp) error {
+	name, fields, ok := imap.ParseNamedResp(resp)
+	if !ok || name != h.name {
+		return responses.ErrUnhandled
+	}
+	mbox := &imap.MailboxInfo{}
+	if err := mbox.Parse(fields); err != nil {
+		return err
+	}
+	h.mailboxes <- mbox
+	return nil
+}
+
+// matchFolderName 按文件夹名称最后一级匹配（如 "[Gmail]/Spam"、"INBOX.Junk"）
+func matchFolderName(name, delimiter string, candidates []string) bool {
+	leaf := name
+	if delimiter != "" {
+		if i := strings.LastIndex(name, delimiter); i >= 0 {
+			leaf = name[i+len(delimiter):]
+		}
+	}
+	leaf = strings.ToLower(leaf)
+	for _, c := range candidates {
+		if leaf == c {
+			return true
+		}
+	}
+	return false
+}
+
+// isSpecialAttr 检查是否为识别的特殊用途属性
+func isSpecialAttr(attr string) bool {
+	for _, a := range specialAttrs {
+		if a == attr {
+			return true
+		}
+	}
+	return false
+}
+
+// hasCapability 检查能力列表
+func hasCapability(caps []string, name string) bool {
+	for _, c := range caps {
+		if strings.EqualFold(c, name) {
+			return true
+		}
+	}
+	return false
+}
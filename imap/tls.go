@@ -0,0 +1,76 @@
+package imap
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"os"
+)
+
+// TLSOptions IMAP 连接的TLS选项
+type TLSOptions struct {
+	CAFile             string // 可选，校验服务器证书的CA证书（如企业内部CA）
+	CertFile           string // 可选，客户端证书
+	KeyFile            string // 可选，客户端私钥
+	MinVersion         string // 可选，最低TLS版本：1.0 / 1.1 / 1.2 / 1.3
+	InsecureSkipVerify bool   // 不校验服务器证书（仅用于测试）
+}
+
+// tlsVersions 配置中的版本号 → TLS 常量
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// SetTLS 设置 TLS/STARTTLS 连接使用的TLS选项
+func (c *Client) SetTLS(opts TLSOptions) error {
+	cfg := &tls.Config{InsecureSkipVerify: opts.InsecureSkipVerify}
+
+	if opts.MinVersion != "" {
+		v, ok := tlsVersions[opts.MinVersion]
+		if !ok {
+			return fmt.Errorf("TLS 最低版本无效: %s (可选: 1.0/1.1/1.2/1.3)", opts.MinVersion)
+		}
+		cfg.MinVersion = v
+	}
+
+	if opts.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(opts.CertFile, opts.KeyFile)
+		if err != nil {
+			return fmt.Errorf("加载IMAP客户端证书失败: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	if opts.CAFile != "" {
+		pem, err := os.ReadFile(opts.CAFile)
+		if err != nil {
+			return fmt.Errorf("读取IMAP CA证书失败: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("IMAP CA证书无效: %s", opts.CAFile)
+		}
+		cfg.RootCAs = pool
+	}
+
+	if opts.InsecureSkipVerify {
+		log.Printf("[%s] 警告: 已关闭服务器证书校验", c.accountName)
+	}
+
+	c.tlsConfig = cfg
+	return nil
+}
+
+// newTLSConfig 返回本次连接使用的TLS配置
+func (c *Client) newTLSConfig() *tls.Config {
+	if c.tlsConfig == nil {
+		return &tls.Config{ServerName: c.server}
+	}
+	cfg := c.tlsConfig.Clone()
+	cfg.ServerName = c.server
+	return cfg
+}
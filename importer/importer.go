@@ -0,0 +1,109 @@
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// 导入来源
+const (
+	SourceCSV         = "csv"
+	SourceThunderbird = "thunderbird"
+)
+
+// 连接加密方式（与 config 中的取值一致）
+const (
+	securityTLS      = "tls"
+	securitySTARTTLS = "starttls"
+	securityNone     = "none"
+)
+
+// Account 导入的账号配置，只包含连接所需的字段，其他选项使用默认值
+type Account struct {
+	Server   string   `json:"server"`
+	Port     int      `json:"port"`
+	Security string   `json:"security,omitempty"`
+	Username string   `json:"username"`
+	Password string   `json:"password"`
+	AuthType string   `json:"auth_type,omitempty"`
+	Folders  []string `json:"folders"`
+}
+
+// Result 导入结果
+type Result struct {
+	Accounts map[string]*Account
+	Warnings []string // 需要手动补充或确认的项目
+}
+
+// add 按名称添加账号，名称重复时追加序号
+func (r *Result) add(name string, acc *Account) {
+	if r.Accounts == nil {
+		r.Accounts = make(map[string]*Account)
+	}
+	unique := name
+	for i := 2; r.Accounts[unique] != nil; i++ {
+		unique = fmt.Sprintf("%s-%d", name, i)
+	}
+	r.Accounts[unique] = acc
+}
+
+// warn 记录需要手动处理的项目
+func (r *Result) warn(format string, args ...interface{}) {
+	r.Warnings = append(r.Warnings, fmt.Sprintf(format, args...))
+}
+
+// FromCSV 从 CSV 导入账号，每行为 name,email,password,server（server 可写作 host:port，默认 993 端口 TLS）
+// 第一行为表头（以 name 开头）时跳过，以 # 开头的行为注释
+func FromCSV(r io.Reader) (*Result, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	result := &Result{}
+	for line := 1; ; line++ {
+		rec, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("解析 CSV 失败: %w", err)
+		}
+		if line == 1 && strings.EqualFold(strings.TrimSpace(rec[0]), "name") {
+			continue
+		}
+		if len(rec) < 4 {
+			return nil, fmt.Errorf("CSV 第 %d 行格式无效，应为 name,email,password,server", line)
+		}
+
+		name, email, password, server := strings.TrimSpace(rec[0]), strings.TrimSpace(rec[1]), rec[2], strings.TrimSpace(rec[3])
+		if email == "" || server == "" {
+			return nil, fmt.Errorf("CSV 第 %d 行缺少 email 或 server", line)
+		}
+		if name == "" {
+			name = email
+		}
+
+		acc := &Account{Server: server, Port: 993, Security: securityTLS, Username: email, Password: password, Folders: []string{"INBOX"}}
+		if host, port, err := net.SplitHostPort(server); err == nil {
+			n, err := strconv.Atoi(port)
+			if err != nil {
+				return nil, fmt.Errorf("CSV 第 %d 行端口无效: %s", line, port)
+			}
+			acc.Server, acc.Port = host, n
+			if n == 143 {
+				acc.Security = securitySTARTTLS
+			}
+		}
+		if password == "" {
+			result.warn("账号 %s 未填写密码", name)
+		}
+		result.add(name, acc)
+	}
+	return result, nil
+}
@@ -0,0 +1,176 @@
+package importer
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// prefPattern prefs.js 中的一行配置：user_pref("key", value);
+var prefPattern = regexp.MustCompile(`^user_pref\("([^"]+)",\s*(.*)\);\s*$`)
+
+// Thunderbird socketType 取值
+const (
+	tbSocketPlain    = 0
+	tbSocketSTARTTLS = 2
+	tbSocketSSL      = 3
+)
+
+// tbAuthOAuth2 Thunderbird authMethod 中的 OAuth2
+const tbAuthOAuth2 = 10
+
+// FromThunderbird 从 Thunderbird 配置目录（或其中的 prefs.js）导入 IMAP 账号
+// path 为空时在默认位置查找配置目录；Thunderbird 保存的密码已加密，需要手动填写
+func FromThunderbird(path string) (*Result, error) {
+	if path == "" {
+		profile, err := defaultThunderbirdProfile()
+		if err != nil {
+			return nil, err
+		}
+		path = profile
+	}
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		path = filepath.Join(path, "prefs.js")
+	}
+
+	prefs, err := readPrefs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// mail.server.<id>.<key> 按服务器分组
+	servers := make(map[string]map[string]string)
+	for key, value := range prefs {
+		rest, ok := strings.CutPrefix(key, "mail.server.")
+		if !ok {
+			continue
+		}
+		id, field, ok := strings.Cut(rest, ".")
+		if !ok {
+			continue
+		}
+		if servers[id] == nil {
+			servers[id] = make(map[string]string)
+		}
+		servers[id][field] = value
+	}
+
+	ids := make([]string, 0, len(servers))
+	for id := range servers {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	result := &Result{}
+	for _, id := range ids {
+		s := servers[id]
+		switch s["type"] {
+		case "imap":
+		case "pop3":
+			result.warn("跳过 POP3 账号 %s（只支持 IMAP）", s["name"])
+			continue
+		default:
+			// 本地文件夹、RSS 等
+			continue
+		}
+
+		host, username := s["hostname"], s["userName"]
+		if host == "" || username == "" {
+			continue
+		}
+		name := s["name"]
+		if name == "" {
+			name = username
+		}
+
+		acc := &Account{Server: host, Username: username, Folders: []string{"INBOX"}}
+		socket, _ := strconv.Atoi(s["socketType"])
+		switch socket {
+		case tbSocketSSL:
+			acc.Security, acc.Port = securityTLS, 993
+		case tbSocketSTARTTLS:
+			acc.Security, acc.Port = securitySTARTTLS, 143
+		case tbSocketPlain:
+			acc.Security, acc.Port = securityNone, 143
+			result.warn("账号 %s 使用未加密连接，请确认", name)
+		}
+		if port, err := strconv.Atoi(s["port"]); err == nil && port > 0 {
+			acc.Port = port
+		}
+		if auth, _ := strconv.Atoi(s["authMethod"]); auth == tbAuthOAuth2 {
+			acc.AuthType = "xoauth2"
+			result.warn("账号 %s 使用 OAuth2 登录，请补充 oauth2 配置", name)
+		} else {
+			result.warn("账号 %s 需要填写密码（Thunderbird 保存的密码已加密，无法导入）", name)
+		}
+		result.add(name, acc)
+	}
+	if len(result.Accounts) == 0 {
+		return nil, fmt.Errorf("%s 中没有 IMAP 账号", path)
+	}
+	return result, nil
+}
+
+// readPrefs 读取 prefs.js 中的字符串和数字配置
+func readPrefs(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("打开 Thunderbird 配置失败: %w", err)
+	}
+	defer f.Close()
+
+	prefs := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		m := prefPattern.FindStringSubmatch(strings.TrimSpace(scanner.Text()))
+		if m == nil {
+			continue
+		}
+		value := m[2]
+		if s, err := strconv.Unquote(value); err == nil {
+			value = s
+		}
+		prefs[m[1]] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取 Thunderbird 配置失败: %w", err)
+	}
+	return prefs, nil
+}
+
+// defaultThunderbirdProfile 在当前系统的默认位置查找 Thunderbird 配置目录，找到多个时要求手动指定
+func defaultThunderbirdProfile() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("获取用户目录失败: %w", err)
+	}
+	var root string
+	switch runtime.GOOS {
+	case "windows":
+		root = filepath.Join(os.Getenv("APPDATA"), "Thunderbird", "Profiles")
+	case "darwin":
+		root = filepath.Join(home, "Library", "Thunderbird", "Profiles")
+	default:
+		root = filepath.Join(home, ".thunderbird")
+	}
+
+	matches, _ := filepath.Glob(filepath.Join(root, "*", "prefs.js"))
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("未在 %s 找到 Thunderbird 配置目录，请使用 -input 指定", root)
+	case 1:
+		return filepath.Dir(matches[0]), nil
+	}
+	var dirs []string
+	for _, m := range matches {
+		dirs = append(dirs, filepath.Dir(m))
+	}
+	return "", fmt.Errorf("找到多个 Thunderbird 配置目录，请使用 -input 指定: %s", strings.Join(dirs, ", "))
+}
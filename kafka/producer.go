@@ -0,0 +1,337 @@
+package kafka
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math/big"
+	"net"
+	"strings"
+	"time"
+)
+
+// SASL 认证方式
+const (
+	MechanismPlain       = "PLAIN"
+	MechanismSCRAMSHA256 = "SCRAM-SHA-256"
+	MechanismSCRAMSHA512 = "SCRAM-SHA-512"
+)
+
+// Options 生产者配置
+type Options struct {
+	Brokers  []string      // 初始连接的 broker 地址（host:port），依次尝试
+	ClientID string        // 默认 mail-receiver
+	TLS      *tls.Config   // 可选，设置后使用 TLS 连接
+	SASL     SASL          // 可选，SASL 认证
+	Acks     int16         // -1 等待所有同步副本确认（默认应设为 -1）/ 1 只等待 leader / 0 不等待
+	Timeout  time.Duration // 连接、请求超时，默认 10 秒
+}
+
+// SASL 认证配置
+type SASL struct {
+	Mechanism string // PLAIN / SCRAM-SHA-256 / SCRAM-SHA-512，为空不认证
+	Username  string
+	Password  string
+}
+
+// Producer 精简的 Kafka 生产者：每次发送时查询元数据并连接分区 leader，只支持单条记录写入
+type Producer struct {
+	opts Options
+}
+
+// NewProducer 创建生产者
+func NewProducer(opts Options) (*Producer, error) {
+	if len(opts.Brokers) == 0 {
+		return nil, fmt.Errorf("Kafka 缺少 broker 地址")
+	}
+	switch strings.ToUpper(opts.SASL.Mechanism) {
+	case "":
+	case MechanismPlain, MechanismSCRAMSHA256, MechanismSCRAMSHA512:
+		opts.SASL.Mechanism = strings.ToUpper(opts.SASL.Mechanism)
+	default:
+		return nil, fmt.Errorf("Kafka SASL 认证方式无效: %s (可选: PLAIN/SCRAM-SHA-256/SCRAM-SHA-512)", opts.SASL.Mechanism)
+	}
+	switch opts.Acks {
+	case -1, 0, 1:
+	default:
+		return nil, fmt.Errorf("Kafka acks 无效: %d (可选: -1/0/1)", opts.Acks)
+	}
+	if opts.ClientID == "" {
+		opts.ClientID = "mail-receiver"
+	}
+	if opts.Timeout == 0 {
+		opts.Timeout = 10 * time.Second
+	}
+	return &Producer{opts: opts}, nil
+}
+
+// Send 写入一条记录：有 key 时按 key 哈希选择分区（与 Java 客户端一致），否则随机选择
+func (p *Producer) Send(topic string, key, value []byte, ts time.Time) error {
+	bootstrap, err := p.bootstrap()
+	if err != nil {
+		return err
+	}
+	defer bootstrap.close()
+
+	var req encoder
+	req.int32(1)
+	req.string(topic)
+	body, err := bootstrap.roundTrip(apiMetadata, versionMetadata, req.b)
+	if err != nil {
+		return fmt.Errorf("查询 Kafka 元数据失败: %w", err)
+	}
+	brokers, parts, err := decodeMetadata(body, topic)
+	if err != nil {
+		return fmt.Errorf("查询 Kafka 元数据失败: %w", err)
+	}
+	if len(parts) == 0 {
+		return fmt.Errorf("Kafka 主题 %s 没有分区", topic)
+	}
+
+	part := parts[0]
+	idx := int32(p.partitionFor(key, len(parts)))
+	for _, cand := range parts {
+		if cand.id == idx {
+			part = cand
+			break
+		}
+	}
+	lb, ok := brokers[part.leader]
+	if !ok {
+		return fmt.Errorf("Kafka 主题 %s 分区 %d 暂无 leader", topic, part.id)
+	}
+
+	leader := bootstrap
+	if lb.addr != bootstrap.addr {
+		if leader, err = p.dial(lb.addr); err != nil {
+			return err
+		}
+		defer leader.close()
+	}
+
+	req = encoder{}
+	req.nullString("") // transactional_id
+	req.int16(p.opts.Acks)
+	req.int32(int32(p.opts.Timeout / time.Millisecond))
+	req.int32(1)
+	req.string(topic)
+	req.int32(1)
+	req.int32(part.id)
+	req.bytes(recordBatch(key, value, ts))
+
+	if p.opts.Acks == 0 {
+		// acks=0 时 broker 不返回响应
+		return leader.send(apiProduce, versionProduce, req.b)
+	}
+	body, err = leader.roundTrip(apiProduce, versionProduce, req.b)
+	if err != nil {
+		return fmt.Errorf("写入 Kafka 失败: %w", err)
+	}
+	if err := decodeProduce(body); err != nil {
+		return fmt.Errorf("写入 Kafka 失败: %w", err)
+	}
+	return nil
+}
+
+// partitionFor 选择分区序号
+func (p *Producer) partitionFor(key []byte, n int) int {
+	if len(key) > 0 {
+		return int(murmur2(key)&0x7fffffff) % n
+	}
+	r, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0
+	}
+	return int(r.Int64())
+}
+
+// bootstrap 依次连接配置的 broker，返回第一个可用的连接
+func (p *Producer) bootstrap() (*conn, error) {
+	var lastErr error
+	for _, addr := range p.opts.Brokers {
+		c, err := p.dial(addr)
+		if err == nil {
+			return c, nil
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// dial 连接 broker 并完成 SASL 认证
+func (p *Producer) dial(addr string) (*conn, error) {
+	dialer := &net.Dialer{Timeout: p.opts.Timeout}
+	var nc net.Conn
+	var err error
+	if p.opts.TLS != nil {
+		cfg := p.opts.TLS.Clone()
+		if cfg.ServerName == "" {
+			if host, _, err := net.SplitHostPort(addr); err == nil {
+				cfg.ServerName = host
+			}
+		}
+		nc, err = tls.DialWithDialer(dialer, "tcp", addr, cfg)
+	} else {
+		nc, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("连接 Kafka broker %s 失败: %w", addr, err)
+	}
+
+	c := &conn{addr: addr, nc: nc, r: bufio.NewReader(nc), clientID: p.opts.ClientID, timeout: p.opts.Timeout}
+	if p.opts.SASL.Mechanism != "" {
+		if err := c.authenticate(p.opts.SASL); err != nil {
+			nc.Close()
+			return nil, fmt.Errorf("Kafka broker %s 认证失败: %w", addr, err)
+		}
+	}
+	return c, nil
+}
+
+// conn 与一个 broker 的连接
+type conn struct {
+	addr          string
+	nc            net.Conn
+	r             *bufio.Reader
+	clientID      string
+	timeout       time.Duration
+	correlationID int32
+}
+
+// send 发送请求，不等待响应
+func (c *conn) send(apiKey, version int16, body []byte) error {
+	c.correlationID++
+	var e encoder
+	e.int32(0) // 长度，最后填写
+	e.int16(apiKey)
+	e.int16(version)
+	e.int32(c.correlationID)
+	e.string(c.clientID)
+	e.b = append(e.b, body...)
+	binary.BigEndian.PutUint32(e.b, uint32(len(e.b)-4))
+
+	c.nc.SetWriteDeadline(time.Now().Add(c.timeout))
+	if _, err := c.nc.Write(e.b); err != nil {
+		return fmt.Errorf("发送请求失败: %w", err)
+	}
+	return nil
+}
+
+// roundTrip 发送请求并读取响应，返回去掉响应头后的内容
+func (c *conn) roundTrip(apiKey, version int16, body []byte) ([]byte, error) {
+	if err := c.send(apiKey, version, body); err != nil {
+		return nil, err
+	}
+
+	c.nc.SetReadDeadline(time.Now().Add(c.timeout))
+	var size [4]byte
+	if _, err := io.ReadFull(c.r, size[:]); err != nil {
+		return nil, fmt.Errorf("读取响应失败: %w", err)
+	}
+	n := binary.BigEndian.Uint32(size[:])
+	if n < 4 || n > 64<<20 {
+		return nil, fmt.Errorf("响应长度无效: %d", n)
+	}
+	resp := make([]byte, n)
+	if _, err := io.ReadFull(c.r, resp); err != nil {
+		return nil, fmt.Errorf("读取响应失败: %w", err)
+	}
+	if id := int32(binary.BigEndian.Uint32(resp)); id != c.correlationID {
+		return nil, fmt.Errorf("响应不匹配 (correlation id %d，期望 %d)", id, c.correlationID)
+	}
+	return resp[4:], nil
+}
+
+// close 关闭连接
+func (c *conn) close() {
+	if c != nil {
+		c.nc.Close()
+	}
+}
+
+// authenticate 通过 SaslHandshake v1 + SaslAuthenticate 完成认证
+func (c *conn) authenticate(s SASL) error {
+	var req encoder
+	req.string(s.Mechanism)
+	body, err := c.roundTrip(apiSaslHandshake, versionSaslHandshake, req.b)
+	if err != nil {
+		return err
+	}
+	d := &decoder{b: body}
+	if err := kafkaError(d.int16()); err != nil {
+		return err
+	}
+
+	exchange := func(msg []byte) ([]byte, error) {
+		var req encoder
+		req.bytes(msg)
+		body, err := c.roundTrip(apiSaslAuthenticate, versionSaslAuthenticate, req.b)
+		if err != nil {
+			return nil, err
+		}
+		d := &decoder{b: body}
+		code := d.int16()
+		msgText := d.string()
+		data := d.bytes()
+		if err := kafkaError(code); err != nil {
+			if msgText != "" {
+				return nil, fmt.Errorf("%w: %s", err, msgText)
+			}
+			return nil, err
+		}
+		return data, d.err
+	}
+
+	if s.Mechanism == MechanismPlain {
+		_, err := exchange([]byte("\x00" + s.Username + "\x00" + s.Password))
+		return err
+	}
+	return scramAuthenticate(s, exchange)
+}
+
+// recordBatch 编码只含一条记录的 RecordBatch（magic 2，不压缩）
+func recordBatch(key, value []byte, ts time.Time) []byte {
+	var rec []byte
+	rec = append(rec, 0)              // attributes
+	rec = binary.AppendVarint(rec, 0) // timestampDelta
+	rec = binary.AppendVarint(rec, 0) // offsetDelta
+	rec = appendVarBytes(rec, key)    // key
+	rec = appendVarBytes(rec, value)  // value
+	rec = binary.AppendVarint(rec, 0) // headers
+	record := binary.AppendVarint(nil, int64(len(rec)))
+	record = append(record, rec...)
+
+	ms := ts.UnixMilli()
+	var tail encoder
+	tail.int16(0) // attributes
+	tail.int32(0) // lastOffsetDelta
+	tail.int64(ms)
+	tail.int64(ms)
+	tail.int64(-1) // producerId
+	tail.int16(-1) // producerEpoch
+	tail.int32(-1) // baseSequence
+	tail.int32(1)  // 记录数
+	tail.b = append(tail.b, record...)
+
+	var e encoder
+	e.int64(0)                              // baseOffset
+	e.int32(int32(4 + 1 + 4 + len(tail.b))) // batchLength：partitionLeaderEpoch 之后的长度
+	e.int32(-1)                             // partitionLeaderEpoch
+	e.int8(2)                               // magic
+	e.b = binary.BigEndian.AppendUint32(e.b, crc32.Checksum(tail.b, crc32.MakeTable(crc32.Castagnoli)))
+	e.b = append(e.b, tail.b...)
+	return e.b
+}
+
+// appendVarBytes 追加 varint 长度前缀的字节，nil 编码为 -1
+func appendVarBytes(b, v []byte) []byte {
+	if v == nil {
+		return binary.AppendVarint(b, -1)
+	}
+	b = binary.AppendVarint(b, int64(len(v)))
+	return append(b, v...)
+}
@@ -0,0 +1,281 @@
+package kafka
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// request broker 收到的请求
+type request struct {
+	apiKey, version int16
+	frame           []byte // 完整的请求（含长度前缀）
+	body            []byte // 请求头之后的内容
+}
+
+// fakeBroker 在本机模拟 broker：记录收到的请求，按接口返回 respond 的结果
+type fakeBroker struct {
+	addr    string
+	port    int32
+	respond func(r request) []byte
+
+	mu       sync.Mutex
+	requests []request
+}
+
+func startFakeBroker(t *testing.T, respond func(r request) []byte) *fakeBroker {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("无法监听本机端口: %v", err)
+	}
+	t.Cleanup(func() { l.Close() })
+	b := &fakeBroker{addr: l.Addr().String(), port: int32(l.Addr().(*net.TCPAddr).Port), respond: respond}
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go b.serve(c)
+		}
+	}()
+	return b
+}
+
+func (b *fakeBroker) serve(c net.Conn) {
+	defer c.Close()
+	for {
+		var size [4]byte
+		if _, err := io.ReadFull(c, size[:]); err != nil {
+			return
+		}
+		frame := make([]byte, 4+binary.BigEndian.Uint32(size[:]))
+		copy(frame, size[:])
+		if _, err := io.ReadFull(c, frame[4:]); err != nil {
+			return
+		}
+		d := &decoder{b: frame[4:]}
+		r := request{apiKey: d.int16(), version: d.int16(), frame: frame}
+		correlationID := d.int32()
+		d.string() // client_id
+		r.body = d.b
+		b.mu.Lock()
+		b.requests = append(b.requests, r)
+		b.mu.Unlock()
+
+		resp := b.respond(r)
+		if resp == nil {
+			continue
+		}
+		var e encoder
+		e.int32(int32(4 + len(resp)))
+		e.int32(correlationID)
+		e.b = append(e.b, resp...)
+		if _, err := c.Write(e.b); err != nil {
+			return
+		}
+	}
+}
+
+// received 返回收到的请求
+func (b *fakeBroker) received() []request {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]request(nil), b.requests...)
+}
+
+// produceOK Produce v3 的成功响应
+func produceOK(topic string, part int32) []byte {
+	var e encoder
+	e.int32(1)
+	e.string(topic)
+	e.int32(1)
+	e.int32(part)
+	e.int16(0)
+	e.int64(42) // base_offset
+	e.int64(-1) // log_append_time
+	e.int32(0)  // throttle_time_ms
+	return e.b
+}
+
+func TestProduceRequestGolden(t *testing.T) {
+	var b *fakeBroker
+	b = startFakeBroker(t, func(r request) []byte {
+		switch r.apiKey {
+		case apiMetadata:
+			return metadataResponse("127.0.0.1", b.port, "mail", 0)
+		case apiProduce:
+			return produceOK("mail", 1)
+		}
+		t.Errorf("未预期的请求 %d", r.apiKey)
+		return nil
+	})
+
+	p, err := NewProducer(Options{Brokers: []string{b.addr}, ClientID: "test", Acks: -1, Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Send("mail", []byte("key"), []byte("value"), time.UnixMilli(1700000000000)); err != nil {
+		t.Fatalf("写入失败: %v", err)
+	}
+
+	reqs := b.received()
+	if len(reqs) != 2 || reqs[0].apiKey != apiMetadata || reqs[1].apiKey != apiProduce {
+		t.Fatalf("收到的请求 %+v，期望 Metadata 和 Produce", reqs)
+	}
+	if want := unhex(t, "00 00 00 01 00 04 6d 61 69 6c"); !bytes.Equal(reqs[0].body, want) {
+		t.Errorf("Metadata 请求 % x，期望 % x", reqs[0].body, want)
+	}
+
+	// key 的 murmur2 哈希对 2 个分区取模为 1
+	header := stripComments(`
+		00 00 00 78               请求长度 120
+		00 00 00 03               Produce v3
+		00 00 00 02               correlation_id
+		00 04 74 65 73 74         client_id
+		ff ff                     transactional_id（null）
+		ff ff                     acks -1
+		00 00 13 88               timeout_ms 5000
+		00 00 00 01  00 04 6d 61 69 6c
+		00 00 00 01  00 00 00 01  分区 1
+		00 00 00 4c               RecordBatch 长度 76`)
+	want := append(unhex(t, header), unhex(t, stripComments(goldenBatch))...)
+	if !bytes.Equal(reqs[1].frame, want) {
+		t.Errorf("Produce 请求\n% x\n期望\n% x", reqs[1].frame, want)
+	}
+}
+
+func TestProduceErrors(t *testing.T) {
+	var b *fakeBroker
+	b = startFakeBroker(t, func(r request) []byte {
+		if r.apiKey == apiMetadata {
+			return metadataResponse("127.0.0.1", b.port, "mail", 0)
+		}
+		var e encoder
+		e.int32(1)
+		e.string("mail")
+		e.int32(1)
+		e.int32(0)
+		e.int16(29) // 无权写入主题
+		e.int64(-1)
+		e.int64(-1)
+		e.int32(0)
+		return e.b
+	})
+	p, err := NewProducer(Options{Brokers: []string{b.addr}, Acks: 1, Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = p.Send("mail", nil, []byte("value"), time.Now())
+	if err == nil || err.Error() != "写入 Kafka 失败: 无权写入主题 (错误码 29)" {
+		t.Errorf("错误 %v", err)
+	}
+	if err := p.Send("other", nil, []byte("value"), time.Now()); err == nil || !strings.Contains(err.Error(), "主题 other 不存在") {
+		t.Errorf("主题不存在时错误 %v", err)
+	}
+}
+
+func TestProduceAcksZero(t *testing.T) {
+	var b *fakeBroker
+	b = startFakeBroker(t, func(r request) []byte {
+		if r.apiKey == apiMetadata {
+			return metadataResponse("127.0.0.1", b.port, "mail", 0)
+		}
+		// acks=0 时 broker 不返回响应
+		return nil
+	})
+	p, err := NewProducer(Options{Brokers: []string{b.addr}, Acks: 0, Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Send("mail", nil, []byte("value"), time.Now()); err != nil {
+		t.Fatalf("写入失败: %v", err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for len(b.received()) < 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("broker 没有收到 Produce 请求")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSASLPlain(t *testing.T) {
+	var b *fakeBroker
+	b = startFakeBroker(t, func(r request) []byte {
+		var e encoder
+		switch r.apiKey {
+		case apiSaslHandshake:
+			e.int16(0)
+			e.int32(1)
+			e.string(MechanismPlain)
+		case apiSaslAuthenticate:
+			d := &decoder{b: r.body}
+			if auth := d.bytes(); string(auth) == "\x00alice\x00secret" {
+				e.int16(0)
+				e.nullString("")
+			} else {
+				e.int16(58)
+				e.string("Authentication failed: Invalid username or password")
+			}
+			e.bytes(nil)
+			e.int64(0) // session_lifetime_ms
+		case apiMetadata:
+			return metadataResponse("127.0.0.1", b.port, "mail", 0)
+		case apiProduce:
+			return produceOK("mail", 0)
+		}
+		return e.b
+	})
+
+	opts := Options{Brokers: []string{b.addr}, Acks: -1, Timeout: 5 * time.Second,
+		SASL: SASL{Mechanism: "plain", Username: "alice", Password: "secret"}}
+	p, err := NewProducer(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Send("mail", nil, []byte("value"), time.Now()); err != nil {
+		t.Fatalf("写入失败: %v", err)
+	}
+	reqs := b.received()
+	if reqs[0].apiKey != apiSaslHandshake || reqs[0].version != versionSaslHandshake || string(reqs[0].body) != "\x00\x05PLAIN" {
+		t.Errorf("第一个请求 %+v，期望 SaslHandshake", reqs[0])
+	}
+
+	opts.SASL.Password = "wrong"
+	p, _ = NewProducer(opts)
+	err = p.Send("mail", nil, []byte("value"), time.Now())
+	if err == nil || !strings.Contains(err.Error(), "SASL 认证失败 (错误码 58): Authentication failed") {
+		t.Errorf("密码错误时返回 %v", err)
+	}
+}
+
+func TestNewProducerOptions(t *testing.T) {
+	tests := []struct {
+		opts Options
+		err  string
+	}{
+		{Options{}, "Kafka 缺少 broker 地址"},
+		{Options{Brokers: []string{"k:9092"}, SASL: SASL{Mechanism: "GSSAPI"}}, "Kafka SASL 认证方式无效: GSSAPI (可选: PLAIN/SCRAM-SHA-256/SCRAM-SHA-512)"},
+		{Options{Brokers: []string{"k:9092"}, Acks: 2}, "Kafka acks 无效: 2 (可选: -1/0/1)"},
+	}
+	for _, tt := range tests {
+		if _, err := NewProducer(tt.opts); err == nil || err.Error() != tt.err {
+			t.Errorf("NewProducer(%+v) 错误 %v，期望 %q", tt.opts, err, tt.err)
+		}
+	}
+
+	p, err := NewProducer(Options{Brokers: []string{"k:9092"}, SASL: SASL{Mechanism: "scram-sha-512"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.opts.SASL.Mechanism != MechanismSCRAMSHA512 || p.opts.ClientID != "mail-receiver" || p.opts.Timeout != 10*time.Second {
+		t.Errorf("默认值 %+v", p.opts)
+	}
+}
@@ -0,0 +1,274 @@
+package kafka
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// 使用的 Kafka 协议接口及版本
+const (
+	apiProduce          = 0
+	apiMetadata         = 3
+	apiSaslHandshake    = 17
+	apiSaslAuthenticate = 36
+
+	versionProduce          = 3 // RecordBatch（magic 2），Kafka 0.11 起支持，4.0 起为最低版本
+	versionMetadata         = 1
+	versionSaslHandshake    = 1
+	versionSaslAuthenticate = 0
+)
+
+// errorMessages 常见错误码的说明
+var errorMessages = map[int16]string{
+	2:  "消息校验失败",
+	3:  "主题或分区不存在",
+	5:  "分区暂无 leader",
+	6:  "请求的 broker 不是分区 leader",
+	7:  "请求超时",
+	10: "消息过大",
+	19: "同步副本不足",
+	29: "无权写入主题",
+	33: "不支持的 SASL 认证方式",
+	35: "不支持的协议版本",
+	58: "SASL 认证失败",
+}
+
+// kafkaError 将错误码转换为错误，0 返回nil
+func kafkaError(code int16) error {
+	if code == 0 {
+		return nil
+	}
+	if msg, ok := errorMessages[code]; ok {
+		return fmt.Errorf("%s (错误码 %d)", msg, code)
+	}
+	return fmt.Errorf("错误码 %d", code)
+}
+
+// encoder 请求编码
+type encoder struct {
+	b []byte
+}
+
+func (e *encoder) int8(v int8)   { e.b = append(e.b, byte(v)) }
+func (e *encoder) int16(v int16) { e.b = binary.BigEndian.AppendUint16(e.b, uint16(v)) }
+func (e *encoder) int32(v int32) { e.b = binary.BigEndian.AppendUint32(e.b, uint32(v)) }
+func (e *encoder) int64(v int64) { e.b = binary.BigEndian.AppendUint64(e.b, uint64(v)) }
+
+func (e *encoder) string(s string) {
+	e.int16(int16(len(s)))
+	e.b = append(e.b, s...)
+}
+
+// nullString 空字符串编码为 null
+func (e *encoder) nullString(s string) {
+	if s == "" {
+		e.int16(-1)
+		return
+	}
+	e.string(s)
+}
+
+func (e *encoder) bytes(v []byte) {
+	e.int32(int32(len(v)))
+	e.b = append(e.b, v...)
+}
+
+// errShortResponse 响应内容不完整
+var errShortResponse = errors.New("响应内容不完整")
+
+// decoder 响应解码，出错后的读取都返回零值，最后检查 err
+type decoder struct {
+	b   []byte
+	err error
+}
+
+func (d *decoder) take(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if n < 0 || len(d.b) < n {
+		d.err = errShortResponse
+		return nil
+	}
+	v := d.b[:n]
+	d.b = d.b[n:]
+	return v
+}
+
+func (d *decoder) int8() int8 {
+	if v := d.take(1); v != nil {
+		return int8(v[0])
+	}
+	return 0
+}
+
+func (d *decoder) int16() int16 {
+	if v := d.take(2); v != nil {
+		return int16(binary.BigEndian.Uint16(v))
+	}
+	return 0
+}
+
+func (d *decoder) int32() int32 {
+	if v := d.take(4); v != nil {
+		return int32(binary.BigEndian.Uint32(v))
+	}
+	return 0
+}
+
+func (d *decoder) int64() int64 {
+	if v := d.take(8); v != nil {
+		return int64(binary.BigEndian.Uint64(v))
+	}
+	return 0
+}
+
+// string 读取字符串，null 返回空字符串
+func (d *decoder) string() string {
+	n := d.int16()
+	if n < 0 {
+		return ""
+	}
+	return string(d.take(int(n)))
+}
+
+func (d *decoder) bytes() []byte {
+	n := d.int32()
+	if n < 0 {
+		return nil
+	}
+	return d.take(int(n))
+}
+
+// array 读取数组长度，null 返回0
+func (d *decoder) array() int {
+	n := d.int32()
+	if n < 0 || d.err != nil {
+		return 0
+	}
+	// 每个元素至少占1字节，避免异常长度导致大量循环
+	if int(n) > len(d.b) {
+		d.err = errShortResponse
+		return 0
+	}
+	return int(n)
+}
+
+// broker 元数据中的 broker 地址
+type broker struct {
+	id   int32
+	addr string
+}
+
+// partition 元数据中的分区
+type partition struct {
+	id     int32
+	leader int32
+}
+
+// decodeMetadata 解析 Metadata v1 响应，返回 broker 列表和指定主题的分区
+func decodeMetadata(body []byte, topic string) (map[int32]broker, []partition, error) {
+	d := &decoder{b: body}
+	brokers := make(map[int32]broker)
+	for i, n := 0, d.array(); i < n; i++ {
+		id := d.int32()
+		host := d.string()
+		port := d.int32()
+		d.string() // rack
+		brokers[id] = broker{id: id, addr: fmt.Sprintf("%s:%d", host, port)}
+	}
+	d.int32() // controller_id
+
+	var parts []partition
+	var topicErr error
+	found := false
+	for i, n := 0, d.array(); i < n; i++ {
+		code := d.int16()
+		name := d.string()
+		d.int8() // is_internal
+		for j, m := 0, d.array(); j < m; j++ {
+			d.int16() // 分区错误码，leader 为 -1 时判断
+			p := partition{id: d.int32(), leader: d.int32()}
+			for k, r := 0, d.array(); k < r; k++ {
+				d.int32() // replicas
+			}
+			for k, r := 0, d.array(); k < r; k++ {
+				d.int32() // isr
+			}
+			if name == topic {
+				parts = append(parts, p)
+			}
+		}
+		if name == topic {
+			found = true
+			topicErr = kafkaError(code)
+		}
+	}
+	if d.err != nil {
+		return nil, nil, fmt.Errorf("解析元数据失败: %w", d.err)
+	}
+	if !found {
+		return nil, nil, fmt.Errorf("主题 %s 不存在", topic)
+	}
+	if topicErr != nil {
+		return nil, nil, fmt.Errorf("主题 %s 不可用: %w", topic, topicErr)
+	}
+	return brokers, parts, nil
+}
+
+// decodeProduce 解析 Produce v3 响应，返回分区的错误
+func decodeProduce(body []byte) error {
+	d := &decoder{b: body}
+	var result error
+	for i, n := 0, d.array(); i < n; i++ {
+		d.string() // topic
+		for j, m := 0, d.array(); j < m; j++ {
+			d.int32() // partition
+			if err := kafkaError(d.int16()); err != nil && result == nil {
+				result = err
+			}
+			d.int64() // base_offset
+			d.int64() // log_append_time
+		}
+	}
+	if d.err != nil {
+		return fmt.Errorf("解析写入响应失败: %w", d.err)
+	}
+	return result
+}
+
+// murmur2 与 Java 客户端默认分区器相同的哈希，相同的 key 总是写入相同的分区
+func murmur2(data []byte) uint32 {
+	const (
+		seed = 0x9747b28c
+		m    = 0x5bd1e995
+		r    = 24
+	)
+	length := len(data)
+	h := uint32(seed) ^ uint32(length)
+	for i := 0; i+4 <= length; i += 4 {
+		k := binary.LittleEndian.Uint32(data[i:])
+		k *= m
+		k ^= k >> r
+		k *= m
+		h *= m
+		h ^= k
+	}
+	tail := data[length&^3:]
+	switch len(tail) {
+	case 3:
+		h ^= uint32(tail[2]) << 16
+		fallthrough
+	case 2:
+		h ^= uint32(tail[1]) << 8
+		fallthrough
+	case 1:
+		h ^= uint32(tail[0])
+		h *= m
+	}
+	h ^= h >> 13
+	h *= m
+	h ^= h >> 15
+	return h
+}
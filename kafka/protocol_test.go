@@ -0,0 +1,172 @@
+package kafka
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+)
+
+// unhex 解码带空格的十六进制
+func unhex(t testing.TB, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(strings.Join(strings.Fields(s), ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// goldenBatch key 为 "key"、value 为 "value"、时间为 1700000000000 毫秒的 RecordBatch
+const goldenBatch = `
+00 00 00 00 00 00 00 00   baseOffset
+00 00 00 40               batchLength 64
+ff ff ff ff               partitionLeaderEpoch
+02                        magic
+db 5e 9c dd               CRC-32C
+00 00                     attributes
+00 00 00 00               lastOffsetDelta
+00 00 01 8b cf e5 68 00   firstTimestamp
+00 00 01 8b cf e5 68 00   maxTimestamp
+ff ff ff ff ff ff ff ff   producerId
+ff ff                     producerEpoch
+ff ff ff ff               baseSequence
+00 00 00 01               记录数
+1c 00 00 00               记录长度 14、attributes、timestampDelta、offsetDelta
+06 6b 65 79               key
+0a 76 61 6c 75 65         value
+00                        headers
+`
+
+// stripComments 去掉每行第一个非十六进制字段之后的注释
+func stripComments(s string) string {
+	var out []string
+	for _, line := range strings.Split(s, "\n") {
+		for _, f := range strings.Fields(line) {
+			if _, err := hex.DecodeString(f); err != nil || len(f) != 2 {
+				break
+			}
+			out = append(out, f)
+		}
+	}
+	return strings.Join(out, " ")
+}
+
+func TestRecordBatchGolden(t *testing.T) {
+	got := recordBatch([]byte("key"), []byte("value"), time.UnixMilli(1700000000000))
+	if want := unhex(t, stripComments(goldenBatch)); !bytes.Equal(got, want) {
+		t.Fatalf("RecordBatch 编码\n% x\n期望\n% x", got, want)
+	}
+
+	// 没有 key 时编码为 -1
+	batch := recordBatch(nil, []byte("v"), time.UnixMilli(0))
+	if rec := batch[len(batch)-8:]; !bytes.Equal(rec, unhex(t, "0e 00 00 00 01 02 76 00")) {
+		t.Errorf("无 key 的记录编码 % x", rec)
+	}
+}
+
+func TestDecodeProduce(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		err  string
+	}{
+		{"写入成功", `
+			00 00 00 01  00 04 6d 61 69 6c
+			00 00 00 01  00 00 00 00  00 00  00 00 00 00 00 00 00 2a  ff ff ff ff ff ff ff ff
+			00 00 00 00`, ""},
+		{"不是分区 leader", `
+			00 00 00 01  00 04 6d 61 69 6c
+			00 00 00 01  00 00 00 02  00 06  ff ff ff ff ff ff ff ff  ff ff ff ff ff ff ff ff
+			00 00 00 00`, "请求的 broker 不是分区 leader (错误码 6)"},
+		{"未知错误码", `
+			00 00 00 01  00 04 6d 61 69 6c
+			00 00 00 01  00 00 00 00  03 e7  ff ff ff ff ff ff ff ff  ff ff ff ff ff ff ff ff
+			00 00 00 00`, "错误码 999"},
+		{"响应不完整", `00 00 00 01  00 04 6d 61 69 6c  00 00 00 01  00 00`, "响应内容不完整"},
+		{"数组长度超出响应", `7f ff ff ff`, "响应内容不完整"},
+	}
+	for _, tt := range tests {
+		err := decodeProduce(unhex(t, tt.body))
+		if tt.err == "" {
+			if err != nil {
+				t.Errorf("%s: %v", tt.name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: 错误 %v，期望包含 %q", tt.name, err, tt.err)
+		}
+	}
+}
+
+// metadataResponse 编码 Metadata v1 响应：一个 broker，主题 topic 的分区 0 和 1 由它负责
+func metadataResponse(host string, port int32, topic string, code int16) []byte {
+	var e encoder
+	e.int32(1)
+	e.int32(1) // node_id
+	e.string(host)
+	e.int32(port)
+	e.nullString("") // rack
+	e.int32(1)       // controller_id
+	e.int32(1)
+	e.int16(code)
+	e.string(topic)
+	e.int8(0) // is_internal
+	e.int32(2)
+	for id := int32(0); id < 2; id++ {
+		e.int16(0)
+		e.int32(id)
+		e.int32(1) // leader
+		e.int32(1)
+		e.int32(1) // replicas
+		e.int32(1)
+		e.int32(1) // isr
+	}
+	return e.b
+}
+
+func TestDecodeMetadata(t *testing.T) {
+	body := metadataResponse("kafka.local", 9092, "mail", 0)
+	brokers, parts, err := decodeMetadata(body, "mail")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := brokers[1]; b.addr != "kafka.local:9092" {
+		t.Errorf("broker 地址 %q", b.addr)
+	}
+	if len(parts) != 2 || parts[1] != (partition{id: 1, leader: 1}) {
+		t.Errorf("分区 %+v", parts)
+	}
+
+	if _, _, err := decodeMetadata(body, "other"); err == nil || err.Error() != "主题 other 不存在" {
+		t.Errorf("其他主题: %v", err)
+	}
+	if _, _, err := decodeMetadata(metadataResponse("kafka.local", 9092, "mail", 3), "mail"); err == nil || !strings.Contains(err.Error(), "主题或分区不存在") {
+		t.Errorf("主题错误码: %v", err)
+	}
+	if _, _, err := decodeMetadata(body[:len(body)-3], "mail"); err == nil || !strings.Contains(err.Error(), "响应内容不完整") {
+		t.Errorf("截断的响应: %v", err)
+	}
+}
+
+func TestMurmur2(t *testing.T) {
+	// Kafka Java 客户端 Utils.murmur2 的测试数据
+	tests := []struct {
+		key  string
+		want int32
+	}{
+		{"21", -973932308},
+		{"foobar", -790332482},
+		{"a-little-bit-long-string", -985981536},
+		{"a-little-bit-longer-string", -1486304829},
+		{"lkjh234lh9fiuh90y23oiuhsafujhadof229phr9h19h89h8", -58897971},
+		{"abc", 479470107},
+	}
+	for _, tt := range tests {
+		if got := int32(murmur2([]byte(tt.key))); got != tt.want {
+			t.Errorf("murmur2(%q) = %d，期望 %d", tt.key, got, tt.want)
+		}
+	}
+}
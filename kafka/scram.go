@@ -0,0 +1,99 @@
+package kafka
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"hash"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// scramAuthenticate SCRAM-SHA-256 / SCRAM-SHA-512 认证（RFC 5802），exchange 发送一条客户端消息并返回服务端消息
+func scramAuthenticate(s SASL, exchange func([]byte) ([]byte, error)) error {
+	nonce := make([]byte, 24)
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("生成随机数失败: %w", err)
+	}
+	return scramExchange(s, base64.RawStdEncoding.EncodeToString(nonce), exchange)
+}
+
+// scramExchange 使用指定的客户端随机数完成 SCRAM 消息交换
+func scramExchange(s SASL, clientNonce string, exchange func([]byte) ([]byte, error)) error {
+	newHash := sha256.New
+	if s.Mechanism == MechanismSCRAMSHA512 {
+		newHash = sha512.New
+	}
+
+	user := strings.NewReplacer("=", "=3D", ",", "=2C").Replace(s.Username)
+	clientFirstBare := "n=" + user + ",r=" + clientNonce
+
+	serverFirst, err := exchange([]byte("n,," + clientFirstBare))
+	if err != nil {
+		return err
+	}
+	attrs := scramAttributes(string(serverFirst))
+	serverNonce, saltB64, iterText := attrs["r"], attrs["s"], attrs["i"]
+	if !strings.HasPrefix(serverNonce, clientNonce) {
+		return fmt.Errorf("SCRAM 服务端随机数无效")
+	}
+	salt, err := base64.StdEncoding.DecodeString(saltB64)
+	if err != nil {
+		return fmt.Errorf("SCRAM 盐值无效: %w", err)
+	}
+	iterations, err := strconv.Atoi(iterText)
+	if err != nil || iterations <= 0 {
+		return fmt.Errorf("SCRAM 迭代次数无效: %s", iterText)
+	}
+
+	salted := pbkdf2.Key([]byte(s.Password), salt, iterations, newHash().Size(), newHash)
+	clientKey := hmacSum(newHash, salted, "Client Key")
+	stored := newHash()
+	stored.Write(clientKey)
+	storedKey := stored.Sum(nil)
+
+	clientFinalBare := "c=biws,r=" + serverNonce
+	authMessage := clientFirstBare + "," + string(serverFirst) + "," + clientFinalBare
+	signature := hmacSum(newHash, storedKey, authMessage)
+	proof := make([]byte, len(clientKey))
+	for i := range clientKey {
+		proof[i] = clientKey[i] ^ signature[i]
+	}
+
+	serverFinal, err := exchange([]byte(clientFinalBare + ",p=" + base64.StdEncoding.EncodeToString(proof)))
+	if err != nil {
+		return err
+	}
+	final := scramAttributes(string(serverFinal))
+	if e := final["e"]; e != "" {
+		return fmt.Errorf("SCRAM 认证失败: %s", e)
+	}
+	expected := hmacSum(newHash, hmacSum(newHash, salted, "Server Key"), authMessage)
+	if got, err := base64.StdEncoding.DecodeString(final["v"]); err != nil || !hmac.Equal(got, expected) {
+		return fmt.Errorf("SCRAM 服务端签名校验失败")
+	}
+	return nil
+}
+
+// scramAttributes 解析 "k=v,k=v" 形式的 SCRAM 消息
+func scramAttributes(msg string) map[string]string {
+	attrs := make(map[string]string)
+	for _, part := range strings.Split(msg, ",") {
+		if len(part) >= 2 && part[1] == '=' {
+			attrs[part[:1]] = part[2:]
+		}
+	}
+	return attrs
+}
+
+// hmacSum 计算 HMAC
+func hmacSum(newHash func() hash.Hash, key []byte, msg string) []byte {
+	h := hmac.New(newHash, key)
+	h.Write([]byte(msg))
+	return h.Sum(nil)
+}
@@ -0,0 +1,131 @@
+package kafka
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// RFC 7677 第 3 节 SCRAM-SHA-256 的示例交换（用户 user，密码 pencil）
+const (
+	rfc7677ClientNonce = "rOprNGfwEbeRWgbNEkqO"
+	rfc7677ClientFirst = "n,,n=user,r=rOprNGfwEbeRWgbNEkqO"
+	rfc7677ServerFirst = "r=rOprNGfwEbeRWgbNEkqO%hvYDpWUa2RaTCAfuxFIlj)hNlF$k0,s=W22ZaJ0SNY7soEsUEjb6gQ==,i=4096"
+	rfc7677ClientFinal = "c=biws,r=rOprNGfwEbeRWgbNEkqO%hvYDpWUa2RaTCAfuxFIlj)hNlF$k0,p=dHzbZapWIk4jUhN+Ute9ytag9zjfMHgsqmmiz7AndVQ="
+	rfc7677ServerFinal = "v=6rriTRBi23WpRR/wtup+mMhUZUn/dB5nLTJRsjl95G4="
+)
+
+// scriptedServer 按顺序检查客户端消息并返回预设的服务端消息
+func scriptedServer(t *testing.T, steps ...[2]string) func([]byte) ([]byte, error) {
+	i := 0
+	return func(msg []byte) ([]byte, error) {
+		if i >= len(steps) {
+			t.Fatalf("多余的客户端消息: %s", msg)
+		}
+		step := steps[i]
+		i++
+		if string(msg) != step[0] {
+			t.Errorf("第 %d 条客户端消息 %q，期望 %q", i, msg, step[0])
+		}
+		return []byte(step[1]), nil
+	}
+}
+
+func TestSCRAMSHA256Vector(t *testing.T) {
+	s := SASL{Mechanism: MechanismSCRAMSHA256, Username: "user", Password: "pencil"}
+	exchange := scriptedServer(t,
+		[2]string{rfc7677ClientFirst, rfc7677ServerFirst},
+		[2]string{rfc7677ClientFinal, rfc7677ServerFinal},
+	)
+	if err := scramExchange(s, rfc7677ClientNonce, exchange); err != nil {
+		t.Fatalf("认证失败: %v", err)
+	}
+}
+
+func TestSCRAMServerErrors(t *testing.T) {
+	s := SASL{Mechanism: MechanismSCRAMSHA256, Username: "user", Password: "pencil"}
+	tests := []struct {
+		name        string
+		serverFirst string
+		serverFinal string
+		err         string
+	}{
+		{"服务端签名错误", rfc7677ServerFirst, "v=" + base64.StdEncoding.EncodeToString(make([]byte, 32)), "服务端签名校验失败"},
+		{"缺少服务端签名", rfc7677ServerFirst, "", "服务端签名校验失败"},
+		{"服务端返回错误", rfc7677ServerFirst, "e=invalid-proof", "SCRAM 认证失败: invalid-proof"},
+		{"随机数不以客户端随机数开头", strings.Replace(rfc7677ServerFirst, "r=rOpr", "r=xOpr", 1), "", "服务端随机数无效"},
+		{"盐值无效", strings.Replace(rfc7677ServerFirst, "s=W22Z", "s=!!", 1), "", "盐值无效"},
+		{"迭代次数无效", strings.Replace(rfc7677ServerFirst, "i=4096", "i=0", 1), "", "迭代次数无效"},
+	}
+	for _, tt := range tests {
+		replies := [][]byte{[]byte(tt.serverFirst), []byte(tt.serverFinal)}
+		exchange := func([]byte) ([]byte, error) {
+			reply := replies[0]
+			replies = replies[1:]
+			return reply, nil
+		}
+		err := scramExchange(s, rfc7677ClientNonce, exchange)
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: 错误 %v，期望包含 %q", tt.name, err, tt.err)
+		}
+	}
+}
+
+// scramSHA512Server 按 RFC 5802 校验客户端证明的 SCRAM-SHA-512 服务端
+func scramSHA512Server(t *testing.T, user, password string) func([]byte) ([]byte, error) {
+	salt := []byte("0123456789abcdef")
+	salted := pbkdf2.Key([]byte(password), salt, 4096, sha512.Size, sha512.New)
+	var clientFirstBare, serverFirst string
+	return func(msg []byte) ([]byte, error) {
+		if clientFirstBare == "" {
+			bare, ok := strings.CutPrefix(string(msg), "n,,")
+			if !ok {
+				t.Fatalf("客户端首条消息缺少 GS2 头: %s", msg)
+			}
+			attrs := scramAttributes(bare)
+			if attrs["n"] != user {
+				t.Errorf("用户名 %q，期望 %q", attrs["n"], user)
+			}
+			clientFirstBare = bare
+			serverFirst = "r=" + attrs["r"] + "server,s=" + base64.StdEncoding.EncodeToString(salt) + ",i=4096"
+			return []byte(serverFirst), nil
+		}
+
+		final := string(msg)
+		i := strings.LastIndex(final, ",p=")
+		authMessage := clientFirstBare + "," + serverFirst + "," + final[:i]
+		proof, err := base64.StdEncoding.DecodeString(final[i+3:])
+		if err != nil {
+			return nil, err
+		}
+		clientKey := hmacSum(sha512.New, salted, "Client Key")
+		storedKey := sha512.Sum512(clientKey)
+		signature := hmacSum(sha512.New, storedKey[:], authMessage)
+		for j := range proof {
+			proof[j] ^= signature[j]
+		}
+		if !hmac.Equal(proof, clientKey) {
+			return []byte("e=invalid-proof"), nil
+		}
+		serverSig := hmacSum(sha512.New, hmacSum(sha512.New, salted, "Server Key"), authMessage)
+		return []byte("v=" + base64.StdEncoding.EncodeToString(serverSig)), nil
+	}
+}
+
+func TestSCRAMSHA512(t *testing.T) {
+	// 用户名中的 = 和 , 需要转义
+	s := SASL{Mechanism: MechanismSCRAMSHA512, Username: "a=b,c", Password: "secret"}
+	if err := scramAuthenticate(s, scramSHA512Server(t, "a=3Db=2Cc", "secret")); err != nil {
+		t.Fatalf("认证失败: %v", err)
+	}
+
+	s.Password = "wrong"
+	err := scramAuthenticate(s, scramSHA512Server(t, "a=3Db=2Cc", "secret"))
+	if err == nil || err.Error() != "SCRAM 认证失败: invalid-proof" {
+		t.Fatalf("密码错误时返回 %v，期望服务端拒绝", err)
+	}
+}
@@ -0,0 +1,352 @@
+package mailauth
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxSignatures 每封邮件最多验证的 DKIM 签名数
+const maxSignatures = 5
+
+// minRSAKeyBits RSA 公钥的最小长度（RFC 8301）
+const minRSAKeyBits = 1024
+
+// errNoKey 域名未发布公钥或公钥已撤销
+var errNoKey = errors.New("未找到 DKIM 公钥")
+
+// DKIMResult 单个 DKIM 签名的验证结果
+type DKIMResult struct {
+	Domain   string // 签名域名（d=）
+	Selector string // 选择器（s=）
+	Pass     bool
+	Err      error // 验证失败的原因
+}
+
+// signature 解析后的 DKIM-Signature
+type signature struct {
+	raw        string // 原始邮件头（含名称，用于计算签名）
+	algorithm  string // rsa-sha256 / ed25519-sha256
+	sig        []byte // b=
+	bodyHash   []byte // bh=
+	domain     string // d=
+	selector   string // s=
+	headers    []string
+	headerC    string // 邮件头规范化方式：simple / relaxed
+	bodyC      string // 正文规范化方式
+	bodyLength int64  // l=，-1 表示整个正文
+	expires    int64  // x=，0 表示不过期
+}
+
+// VerifyDKIM 验证邮件中的 DKIM 签名（RFC 6376），lookup 用于查询 <selector>._domainkey.<domain> 的 TXT 记录
+func VerifyDKIM(raw []byte, lookup Lookup) []*DKIMResult {
+	header, body := splitMessage(raw)
+	fields := headerFields(header)
+
+	var results []*DKIMResult
+	for _, f := range fields {
+		if !strings.EqualFold(fieldName(f), "DKIM-Signature") {
+			continue
+		}
+		if len(results) == maxSignatures {
+			break
+		}
+		sig, err := parseSignature(f)
+		res := &DKIMResult{}
+		if err == nil {
+			res.Domain, res.Selector = sig.domain, sig.selector
+			err = sig.verify(fields, body, lookup)
+		}
+		res.Pass, res.Err = err == nil, err
+		results = append(results, res)
+	}
+	return results
+}
+
+// splitMessage 拆分邮件头和正文，换行统一为 CRLF
+func splitMessage(raw []byte) (header, body []byte) {
+	if !bytes.Contains(raw, []byte("\r\n")) {
+		raw = bytes.ReplaceAll(raw, []byte("\n"), []byte("\r\n"))
+	}
+	if i := bytes.Index(raw, []byte("\r\n\r\n")); i >= 0 {
+		return raw[:i+2], raw[i+4:]
+	}
+	return raw, nil
+}
+
+// headerFields 按顺序拆分邮件头字段，折行保留在字段内，每个字段以 CRLF 结尾
+func headerFields(header []byte) []string {
+	var fields []string
+	for _, line := range strings.SplitAfter(string(header), "\r\n") {
+		if line == "" {
+			continue
+		}
+		if (line[0] == ' ' || line[0] == '\t') && len(fields) > 0 {
+			fields[len(fields)-1] += line
+			continue
+		}
+		fields = append(fields, line)
+	}
+	return fields
+}
+
+// fieldName 邮件头字段的名称
+func fieldName(field string) string {
+	name, _, _ := strings.Cut(field, ":")
+	return strings.TrimSpace(name)
+}
+
+// parseTags 解析 "tag=value; tag=value" 形式的标签列表
+func parseTags(s string) map[string]string {
+	tags := make(map[string]string)
+	for _, part := range strings.Split(s, ";") {
+		name, value, ok := strings.Cut(part, "=")
+		if !ok {
+			continue
+		}
+		tags[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+	return tags
+}
+
+// removeSpace 去掉所有空白（b=、bh=、p= 等 base64 值可以折行）
+func removeSpace(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '\t', '\r', '\n':
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// parseSignature 解析 DKIM-Signature 邮件头
+func parseSignature(field string) (*signature, error) {
+	_, value, _ := strings.Cut(field, ":")
+	tags := parseTags(value)
+	if tags["v"] != "1" {
+		return nil, fmt.Errorf("不支持的 DKIM 版本: %q", tags["v"])
+	}
+	for _, t := range []string{"a", "b", "bh", "d", "h", "s"} {
+		if tags[t] == "" {
+			return nil, fmt.Errorf("DKIM 签名缺少 %s=", t)
+		}
+	}
+
+	s := &signature{
+		raw:        field,
+		algorithm:  strings.ToLower(tags["a"]),
+		domain:     strings.ToLower(strings.TrimSuffix(tags["d"], ".")),
+		selector:   tags["s"],
+		headerC:    "simple",
+		bodyC:      "simple",
+		bodyLength: -1,
+	}
+	var err error
+	if s.sig, err = base64.StdEncoding.DecodeString(removeSpace(tags["b"])); err != nil {
+		return nil, fmt.Errorf("DKIM 签名 b= 无效: %w", err)
+	}
+	if s.bodyHash, err = base64.StdEncoding.DecodeString(removeSpace(tags["bh"])); err != nil {
+		return nil, fmt.Errorf("DKIM 签名 bh= 无效: %w", err)
+	}
+	for _, h := range strings.Split(tags["h"], ":") {
+		if h = strings.TrimSpace(h); h != "" {
+			s.headers = append(s.headers, h)
+		}
+	}
+	signsFrom := false
+	for _, h := range s.headers {
+		signsFrom = signsFrom || strings.EqualFold(h, "From")
+	}
+	if !signsFrom {
+		return nil, fmt.Errorf("DKIM 签名未包含 From 邮件头")
+	}
+	if c := strings.ToLower(tags["c"]); c != "" {
+		hc, bc, ok := strings.Cut(c, "/")
+		s.headerC = hc
+		if ok {
+			s.bodyC = bc
+		}
+	}
+	for _, c := range []string{s.headerC, s.bodyC} {
+		if c != "simple" && c != "relaxed" {
+			return nil, fmt.Errorf("不支持的 DKIM 规范化方式: %s", c)
+		}
+	}
+	if l := tags["l"]; l != "" {
+		if s.bodyLength, err = strconv.ParseInt(l, 10, 64); err != nil || s.bodyLength < 0 {
+			return nil, fmt.Errorf("DKIM 签名 l= 无效: %s", l)
+		}
+	}
+	if x := tags["x"]; x != "" {
+		if s.expires, err = strconv.ParseInt(x, 10, 64); err != nil {
+			return nil, fmt.Errorf("DKIM 签名 x= 无效: %s", x)
+		}
+	}
+	return s, nil
+}
+
+// hashFunc 签名算法对应的摘要算法，按 RFC 8301 不再接受 rsa-sha1
+func (s *signature) hashFunc() (crypto.Hash, func() hash.Hash, error) {
+	switch s.algorithm {
+	case "rsa-sha256", "ed25519-sha256":
+		return crypto.SHA256, sha256.New, nil
+	case "rsa-sha1":
+		return 0, nil, fmt.Errorf("不安全的 DKIM 签名算法: rsa-sha1（RFC 8301）")
+	}
+	return 0, nil, fmt.Errorf("不支持的 DKIM 签名算法: %s", s.algorithm)
+}
+
+// verify 验证正文摘要和签名
+func (s *signature) verify(fields []string, body []byte, lookup Lookup) error {
+	if s.expires > 0 && time.Now().Unix() > s.expires {
+		return fmt.Errorf("DKIM 签名已过期")
+	}
+	hashID, newHash, err := s.hashFunc()
+	if err != nil {
+		return err
+	}
+
+	// 正文摘要
+	canon := canonicalBody(body, s.bodyC == "relaxed")
+	if s.bodyLength >= 0 {
+		if s.bodyLength > int64(len(canon)) {
+			return fmt.Errorf("DKIM 签名 l= 超过正文长度")
+		}
+		canon = canon[:s.bodyLength]
+	}
+	h := newHash()
+	h.Write(canon)
+	if !bytes.Equal(h.Sum(nil), s.bodyHash) {
+		return fmt.Errorf("正文摘要不匹配（邮件正文被修改）")
+	}
+
+	// 邮件头摘要：按 h= 的顺序，同名邮件头从下往上依次取用
+	h = newHash()
+	relaxed := s.headerC == "relaxed"
+	used := make(map[int]bool)
+	for _, name := range s.headers {
+		for i := len(fields) - 1; i >= 0; i-- {
+			if !used[i] && strings.EqualFold(fieldName(fields[i]), name) {
+				used[i] = true
+				h.Write([]byte(canonicalHeader(fields[i], relaxed)))
+				break
+			}
+		}
+	}
+	self := canonicalHeader(signatureValue.ReplaceAllString(s.raw, "$1"), relaxed)
+	h.Write([]byte(strings.TrimSuffix(self, "\r\n")))
+	digest := h.Sum(nil)
+
+	key, err := s.publicKey(lookup)
+	if err != nil {
+		return err
+	}
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		if err := rsa.VerifyPKCS1v15(k, hashID, digest, s.sig); err != nil {
+			return fmt.Errorf("签名不匹配")
+		}
+	case ed25519.PublicKey:
+		if !ed25519.Verify(k, digest, s.sig) {
+			return fmt.Errorf("签名不匹配")
+		}
+	}
+	return nil
+}
+
+// signatureValue DKIM-Signature 中 b= 的值（计算签名时置空）
+var signatureValue = regexp.MustCompile(`([:;]\s*b\s*=)[^;]*`)
+
+// publicKey 查询并解析签名域名的公钥
+func (s *signature) publicKey(lookup Lookup) (crypto.PublicKey, error) {
+	name := s.selector + "._domainkey." + s.domain
+	txts, err := lookup(name)
+	if err != nil {
+		return nil, fmt.Errorf("查询 DKIM 公钥 %s 失败: %w", name, err)
+	}
+	tags := parseTags(strings.Join(txts, ""))
+	if v := tags["v"]; v != "" && v != "DKIM1" {
+		return nil, fmt.Errorf("DKIM 公钥 %s 版本无效: %s", name, v)
+	}
+	p := removeSpace(tags["p"])
+	if p == "" {
+		return nil, errNoKey
+	}
+	data, err := base64.StdEncoding.DecodeString(p)
+	if err != nil {
+		return nil, fmt.Errorf("DKIM 公钥 %s 无效: %w", name, err)
+	}
+
+	switch k := strings.ToLower(tags["k"]); {
+	case k == "ed25519":
+		if !strings.HasPrefix(s.algorithm, "ed25519-") || len(data) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("DKIM 公钥 %s 与签名算法不符", name)
+		}
+		return ed25519.PublicKey(data), nil
+	case k != "" && k != "rsa":
+		return nil, fmt.Errorf("不支持的 DKIM 公钥类型: %s", k)
+	}
+	if !strings.HasPrefix(s.algorithm, "rsa-") {
+		return nil, fmt.Errorf("DKIM 公钥 %s 与签名算法不符", name)
+	}
+	var key *rsa.PublicKey
+	if k, err := x509.ParsePKIXPublicKey(data); err == nil {
+		rk, ok := k.(*rsa.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("DKIM 公钥 %s 不是 RSA 公钥", name)
+		}
+		key = rk
+	} else if key, err = x509.ParsePKCS1PublicKey(data); err != nil {
+		return nil, fmt.Errorf("DKIM 公钥 %s 无效: %w", name, err)
+	}
+	if n := key.N.BitLen(); n < minRSAKeyBits {
+		return nil, fmt.Errorf("DKIM 公钥 %s 过短（%d 位，至少 %d 位）", name, n, minRSAKeyBits)
+	}
+	return key, nil
+}
+
+// wsp 连续的空白
+var wsp = regexp.MustCompile(`[ \t]+`)
+
+// canonicalHeader 规范化邮件头字段：simple 原样保留；relaxed 名称小写、去掉折行、合并空白
+func canonicalHeader(field string, relaxed bool) string {
+	if !relaxed {
+		return field
+	}
+	name, value, _ := strings.Cut(field, ":")
+	value = strings.NewReplacer("\r\n", "", "\n", "").Replace(value)
+	value = strings.TrimSpace(wsp.ReplaceAllString(value, " "))
+	return strings.ToLower(strings.TrimSpace(name)) + ":" + value + "\r\n"
+}
+
+// canonicalBody 规范化正文：去掉末尾的空行；relaxed 时去掉行尾空白、合并行内空白，空正文为空
+func canonicalBody(body []byte, relaxed bool) []byte {
+	lines := strings.Split(string(body), "\r\n")
+	if relaxed {
+		for i, line := range lines {
+			lines[i] = strings.TrimRight(wsp.ReplaceAllString(line, " "), " ")
+		}
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) == 0 {
+		if relaxed {
+			return nil
+		}
+		return []byte("\r\n")
+	}
+	return []byte(strings.Join(lines, "\r\n") + "\r\n")
+}
@@ -0,0 +1,233 @@
+package mailauth
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestCanonicalBody(t *testing.T) {
+	tests := []struct {
+		body    string
+		relaxed bool
+		want    string
+	}{
+		// RFC 6376 3.4.5 的示例
+		{" C \r\nD \t E\r\n\r\n\r\n", false, " C \r\nD \t E\r\n"},
+		{" C \r\nD \t E\r\n\r\n\r\n", true, " C\r\nD E\r\n"},
+		// 空正文：simple 为一个 CRLF，relaxed 为空
+		{"", false, "\r\n"},
+		{"", true, ""},
+		{"\r\n\r\n", false, "\r\n"},
+		{"\r\n\r\n", true, ""},
+		// 缺少最后的换行时补上
+		{"abc", false, "abc\r\n"},
+		{"abc  ", true, "abc\r\n"},
+		// 中间的空行保留
+		{"a\r\n\r\nb\r\n", true, "a\r\n\r\nb\r\n"},
+		{"a\r\n \r\nb\r\n\t\r\n", true, "a\r\n\r\nb\r\n"},
+	}
+	for _, tt := range tests {
+		if got := string(canonicalBody([]byte(tt.body), tt.relaxed)); got != tt.want {
+			t.Errorf("canonicalBody(%q, %v) = %q，期望 %q", tt.body, tt.relaxed, got, tt.want)
+		}
+	}
+}
+
+func TestCanonicalHeader(t *testing.T) {
+	tests := []struct {
+		field   string
+		relaxed bool
+		want    string
+	}{
+		// RFC 6376 3.4.5 的示例
+		{"A: X\r\n", true, "a:X\r\n"},
+		{"B : Y\t\r\n\tZ  \r\n", true, "b:Y Z\r\n"},
+		{"B : Y\t\r\n\tZ  \r\n", false, "B : Y\t\r\n\tZ  \r\n"},
+		{"Subject:   Is  dinner\r\n ready?\r\n", true, "subject:Is dinner ready?\r\n"},
+	}
+	for _, tt := range tests {
+		if got := canonicalHeader(tt.field, tt.relaxed); got != tt.want {
+			t.Errorf("canonicalHeader(%q, %v) = %q，期望 %q", tt.field, tt.relaxed, got, tt.want)
+		}
+	}
+}
+
+// rfc8463Message RFC 8463 附录 A.3 中 Ed25519 签名的示例邮件
+const rfc8463Message = `DKIM-Signature: v=1; a=ed25519-sha256; c=relaxed/relaxed;
+ d=football.example.com; i=@football.example.com;
+ q=dns/txt; s=brisbane; t=1528637909; h=from : to :
+ subject : date : message-id : from : subject : date;
+ bh=2jUSOH9NhtVGCQWNr9BrIAPreKQjO6Sn7XIkfJVOzv8=;
+ b=/gCrinpcQOoIfuHNQIbq4pgh9kyIK3AQUdt9OdqQehSwhEIug4D11Bus
+ Fa3bT3FY5OsU7ZbnKELq+eXdp1Q1Dw==
+From: Joe SixPack <joe@football.example.com>
+To: Suzie Q <suzie@shopping.example.net>
+Subject: Is dinner ready?
+Date: Fri, 11 Jul 2003 21:00:37 -0700 (PDT)
+Message-ID: <20030712040037.46341.5F8J@football.example.com>
+
+Hi.
+
+We lost the game.  Are you hungry yet?
+
+Joe.
+`
+
+// staticLookup 返回固定 TXT 记录的查询，并检查查询的名称
+func staticLookup(t *testing.T, name, txt string) Lookup {
+	return func(n string) ([]string, error) {
+		if n != name {
+			t.Errorf("查询了 %s，期望 %s", n, name)
+		}
+		return []string{txt}, nil
+	}
+}
+
+func TestVerifyDKIMEd25519Vector(t *testing.T) {
+	lookup := staticLookup(t, "brisbane._domainkey.football.example.com",
+		"v=DKIM1; k=ed25519; p=11qYAYKxCrfVS/7TyWQHOg7hcvPapiMlrwIaaPcHURo=")
+	tests := []struct {
+		name    string
+		message string
+		pass    bool
+		err     string
+	}{
+		{"原始邮件", rfc8463Message, true, ""},
+		// relaxed 规范化忽略行内空白的变化
+		{"正文空白变化", strings.Replace(rfc8463Message, "the game.  Are", "the game. Are", 1), true, ""},
+		{"正文被修改", strings.Replace(rfc8463Message, "Hi.", "Hello.", 1), false, "正文摘要不匹配"},
+		{"主题被修改", strings.Replace(rfc8463Message, "Is dinner ready?", "Is lunch ready?", 1), false, "签名不匹配"},
+		// h= 中重复列出的 Subject 要求不能再添加同名邮件头
+		{"添加主题", strings.Replace(rfc8463Message, "Date:", "Subject: urgent\nDate:", 1), false, "签名不匹配"},
+	}
+	for _, tt := range tests {
+		raw := strings.ReplaceAll(tt.message, "\n", "\r\n")
+		results := VerifyDKIM([]byte(raw), lookup)
+		if len(results) != 1 {
+			t.Fatalf("%s: 验证了 %d 个签名，期望 1 个", tt.name, len(results))
+		}
+		res := results[0]
+		if res.Domain != "football.example.com" || res.Selector != "brisbane" {
+			t.Errorf("%s: 签名域名 %s、选择器 %s 不正确", tt.name, res.Domain, res.Selector)
+		}
+		if res.Pass != tt.pass {
+			t.Errorf("%s: 验证结果 %v，期望 %v（%v）", tt.name, res.Pass, tt.pass, res.Err)
+		}
+		if tt.err != "" && (res.Err == nil || !strings.Contains(res.Err.Error(), tt.err)) {
+			t.Errorf("%s: 错误 %v 不包含 %q", tt.name, res.Err, tt.err)
+		}
+	}
+}
+
+// rsaBody 测试邮件的正文，simple 规范化后即为原文
+const rsaBody = "Hello,\r\n\r\nThis is a test.\r\n"
+
+// signRSA 按 RFC 6376 用 simple/simple 规范化签名：待签名数据为 From、Subject 原样拼接，
+// 再加上 b= 为空、不含最后换行的 DKIM-Signature
+func signRSA(t *testing.T, key *rsa.PrivateKey, algorithm string) string {
+	t.Helper()
+	headers := "From: Alice <alice@example.com>\r\nSubject: Test\r\n"
+	bh := sha256.Sum256([]byte(rsaBody))
+	sigHeader := "DKIM-Signature: v=1; a=" + algorithm + "; c=simple/simple; d=example.com; s=sel;\r\n" +
+		"\th=From:Subject; bh=" + base64.StdEncoding.EncodeToString(bh[:]) + "; b="
+	digest := sha256.Sum256([]byte(headers + sigHeader))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sigHeader + base64.StdEncoding.EncodeToString(sig) + "\r\n" + headers + "\r\n" + rsaBody
+}
+
+// rsaKeyRecord 生成 DKIM 公钥记录
+func rsaKeyRecord(t *testing.T, pub *rsa.PublicKey) string {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return "v=DKIM1; k=rsa; p=" + base64.StdEncoding.EncodeToString(der)
+}
+
+func TestVerifyDKIMRSA(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lookup := staticLookup(t, "sel._domainkey.example.com", rsaKeyRecord(t, &key.PublicKey))
+
+	raw := signRSA(t, key, "rsa-sha256")
+	if res := VerifyDKIM([]byte(raw), lookup); len(res) != 1 || !res[0].Pass {
+		t.Fatalf("RSA-SHA256 签名未通过验证: %+v", res[0])
+	}
+
+	// 折行中的空白在 simple 规范化下不能改变
+	changed := strings.Replace(raw, "\r\n\th=", "\r\n h=", 1)
+	if res := VerifyDKIM([]byte(changed), lookup); res[0].Pass {
+		t.Errorf("修改了 DKIM-Signature 的折行仍通过验证")
+	}
+
+	// RFC 8301：不接受 rsa-sha1
+	sha1 := strings.Replace(raw, "a=rsa-sha256", "a=rsa-sha1", 1)
+	if res := VerifyDKIM([]byte(sha1), lookup); res[0].Pass || res[0].Err == nil || !strings.Contains(res[0].Err.Error(), "rsa-sha1") {
+		t.Errorf("rsa-sha1 签名应被拒绝: %+v", res[0])
+	}
+}
+
+func TestVerifyDKIMShortKey(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw := signRSA(t, key, "rsa-sha256")
+
+	// RFC 8301：短于 1024 位的公钥在验证签名之前即被拒绝，模数只需长度正确
+	n := new(big.Int).Lsh(big.NewInt(1), 511)
+	n.Add(n, big.NewInt(1))
+	short := &rsa.PublicKey{N: n, E: 65537}
+	lookup := staticLookup(t, "sel._domainkey.example.com", rsaKeyRecord(t, short))
+	res := VerifyDKIM([]byte(raw), lookup)
+	if res[0].Pass || res[0].Err == nil || !strings.Contains(res[0].Err.Error(), "过短（512 位，至少 1024 位）") {
+		t.Errorf("512 位公钥应被拒绝: %+v", res[0])
+	}
+}
+
+func TestParseAuthResults(t *testing.T) {
+	raw := []byte("Authentication-Results: mx.example.net;\r\n" +
+		"\tspf=pass (sender IP is 192.0.2.1) smtp.mailfrom=example.com;\r\n" +
+		"\tdkim=fail header.d=example.com; dkim=pass header.d=example.com;\r\n" +
+		"\tdmarc=pass (p=reject) header.from=example.com\r\n" +
+		"Authentication-Results: mx.example.net; spf=fail; dmarc=fail\r\n" +
+		"From: alice@example.com\r\n" +
+		"\r\n" +
+		"body\r\n")
+
+	// 未配置 authserv_id 时不读取（发件人可以伪造该邮件头）
+	if res := ParseAuthResults(raw, ""); res != nil {
+		t.Errorf("未配置 authserv_id 时读取了 %+v", res)
+	}
+	if res := ParseAuthResults(raw, "mx.other.net"); res != nil {
+		t.Errorf("没有该服务器的邮件头时读取了 %+v", res)
+	}
+
+	// 只使用最上面一条，不合并下方可能伪造的邮件头；同一方法有一个 pass 即为 pass
+	res := ParseAuthResults(raw, "MX.example.net")
+	want := AuthResults{AuthServID: "mx.example.net", SPF: "pass", DKIM: "pass", DMARC: "pass"}
+	if res == nil || *res != want {
+		t.Errorf("读取结果 %+v，期望 %+v", res, want)
+	}
+
+	// 伪造的邮件头在自己的收件服务器添加的邮件头下方
+	forged := []byte("Authentication-Results: mx.example.net; dmarc=fail\r\n" +
+		"Authentication-Results: evil.example.org; dmarc=pass\r\n" +
+		"\r\n")
+	if res := ParseAuthResults(forged, "mx.example.net"); res == nil || res.DMARC != "fail" {
+		t.Errorf("读取了伪造的邮件头: %+v", res)
+	}
+}
@@ -0,0 +1,158 @@
+package mailauth
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultTimeout DNS 查询的默认超时
+const defaultTimeout = 5 * time.Second
+
+// keyCacheTTL DKIM 公钥的缓存时间
+const keyCacheTTL = time.Hour
+
+// maxCachedKeys 缓存的公钥数超过该值时清理过期的记录
+const maxCachedKeys = 1000
+
+// Lookup 查询域名的 TXT 记录
+type Lookup func(name string) ([]string, error)
+
+// Verdict 邮件的真实性检查结果
+type Verdict struct {
+	DKIM       string       `json:"dkim"`                  // 本地验证 DKIM 签名的结果：pass / fail / none（没有签名）
+	DKIMDomain string       `json:"dkim_domain,omitempty"` // 通过验证且与发件人域名一致的签名域名
+	DKIMError  string       `json:"dkim_error,omitempty"`  // 验证失败的原因
+	Results    *AuthResults `json:"results,omitempty"`     // 收件服务器记录的 SPF/DKIM/DMARC 结果
+}
+
+// DKIMPass 是否有通过验证且签名域名与发件人域名一致（与 DMARC 相同的宽松对齐，子域名也算一致）的 DKIM 签名
+func (v *Verdict) DKIMPass() bool {
+	return v.DKIM == "pass"
+}
+
+// Suspicious 是否可能为冒充的邮件：DKIM 验证失败或没有与发件人一致的签名，且收件服务器没有记录 DMARC 通过，
+// 或收件服务器记录 DMARC/SPF 失败
+func (v *Verdict) Suspicious() bool {
+	if r := v.Results; r != nil {
+		if r.DMARC == "fail" || (r.SPF == "fail" && !v.DKIMPass()) {
+			return true
+		}
+		if r.DMARC == "pass" {
+			return false
+		}
+	}
+	return !v.DKIMPass()
+}
+
+// Indicator 推送中显示的真实性标识，如 "✅ DKIM 通过 (example.com)、SPF pass、DMARC pass"
+func (v *Verdict) Indicator() string {
+	var parts []string
+	switch v.DKIM {
+	case "pass":
+		parts = append(parts, "DKIM 通过 ("+v.DKIMDomain+")")
+	case "fail":
+		parts = append(parts, "DKIM 未通过")
+	default:
+		parts = append(parts, "无 DKIM 签名")
+	}
+	if r := v.Results; r != nil {
+		if r.SPF != "" {
+			parts = append(parts, "SPF "+r.SPF)
+		}
+		if r.DMARC != "" {
+			parts = append(parts, "DMARC "+r.DMARC)
+		}
+	}
+	mark := "✅ "
+	if v.Suspicious() {
+		mark = "⚠️ "
+	}
+	return mark + strings.Join(parts, "、")
+}
+
+// Verifier 邮件真实性检查：验证 DKIM 签名，读取收件服务器记录的 SPF/DMARC 结果
+type Verifier struct {
+	authservID string
+	lookup     Lookup
+
+	mu    sync.Mutex
+	cache map[string]*cachedKey
+}
+
+// cachedKey 缓存的 DKIM 公钥查询结果
+type cachedKey struct {
+	txts    []string
+	err     error
+	expires time.Time
+}
+
+// NewVerifier 创建真实性检查，authservID 见 ParseAuthResults，timeout 为 DNS 查询超时（0 使用默认值）
+func NewVerifier(authservID string, timeout time.Duration) *Verifier {
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	v := &Verifier{authservID: authservID, cache: make(map[string]*cachedKey)}
+	v.lookup = func(name string) ([]string, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		return net.DefaultResolver.LookupTXT(ctx, name)
+	}
+	return v
+}
+
+// Verify 检查邮件，fromAddress 为发件人地址（用于判断签名域名是否一致）
+func (v *Verifier) Verify(raw []byte, fromAddress string) *Verdict {
+	verdict := &Verdict{DKIM: "none", Results: ParseAuthResults(raw, v.authservID)}
+	_, from, _ := strings.Cut(strings.ToLower(fromAddress), "@")
+
+	for _, res := range VerifyDKIM(raw, v.cachedLookup) {
+		if res.Pass && aligned(from, res.Domain) {
+			verdict.DKIM, verdict.DKIMDomain, verdict.DKIMError = "pass", res.Domain, ""
+			break
+		}
+		verdict.DKIM = "fail"
+		if res.Err != nil {
+			verdict.DKIMError = res.Err.Error()
+		} else {
+			verdict.DKIMError = "签名域名 " + res.Domain + " 与发件人不一致"
+		}
+	}
+	return verdict
+}
+
+// cachedLookup 查询 TXT 记录，结果（包括失败）缓存一段时间，避免同一发件域名的邮件重复查询
+func (v *Verifier) cachedLookup(name string) ([]string, error) {
+	v.mu.Lock()
+	if c, ok := v.cache[name]; ok && time.Now().Before(c.expires) {
+		v.mu.Unlock()
+		return c.txts, c.err
+	}
+	v.mu.Unlock()
+
+	txts, err := v.lookup(name)
+	ttl := keyCacheTTL
+	if err != nil {
+		// 查询失败（如超时）只短暂缓存
+		ttl = time.Minute
+	}
+	v.mu.Lock()
+	now := time.Now()
+	if len(v.cache) >= maxCachedKeys {
+		for k, c := range v.cache {
+			if now.After(c.expires) {
+				delete(v.cache, k)
+			}
+		}
+	}
+	v.cache[name] = &cachedKey{txts: txts, err: err, expires: now.Add(ttl)}
+	v.mu.Unlock()
+	return txts, err
+}
+
+// aligned 签名域名与发件人域名是否一致（相同或发件人域名为其子域名）
+func aligned(from, domain string) bool {
+	return from != "" && (from == domain || strings.HasSuffix(from, "."+domain))
+}
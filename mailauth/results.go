@@ -0,0 +1,82 @@
+package mailauth
+
+import (
+	"strings"
+)
+
+// AuthResults 收件服务器在 Authentication-Results 邮件头（RFC 8601）中记录的验证结果，未记录的方法为空字符串
+type AuthResults struct {
+	AuthServID string `json:"authserv_id,omitempty"` // 添加该邮件头的服务器
+	SPF        string `json:"spf,omitempty"`         // pass / fail / softfail / neutral / none / temperror / permerror
+	DKIM       string `json:"dkim,omitempty"`
+	DMARC      string `json:"dmarc,omitempty"`
+}
+
+// ParseAuthResults 从邮件头中读取 authservID 服务器添加的 Authentication-Results，没有时返回nil
+// 发件人可以伪造 Authentication-Results，收件服务器不一定删除已有的同名邮件头，因此未配置 authservID 时不读取，
+// 也不合并多条邮件头
+func ParseAuthResults(raw []byte, authservID string) *AuthResults {
+	if authservID == "" {
+		return nil
+	}
+	header, _ := splitMessage(raw)
+	for _, f := range headerFields(header) {
+		if !strings.EqualFold(fieldName(f), "Authentication-Results") {
+			continue
+		}
+		_, value, _ := strings.Cut(f, ":")
+		parts := strings.Split(stripComments(value), ";")
+		id := strings.Fields(parts[0])
+		if len(id) == 0 {
+			continue
+		}
+		if !strings.EqualFold(id[0], authservID) {
+			continue
+		}
+
+		res := &AuthResults{AuthServID: id[0]}
+		for _, part := range parts[1:] {
+			method, result, ok := strings.Cut(strings.TrimSpace(part), "=")
+			if !ok {
+				continue
+			}
+			result = strings.ToLower(strings.Fields(result + " ")[0])
+			// 同一方法有多个结果（如多个 DKIM 签名）时，有一个 pass 即为 pass
+			switch strings.ToLower(strings.TrimSpace(method)) {
+			case "spf":
+				res.SPF = mergeResult(res.SPF, result)
+			case "dkim":
+				res.DKIM = mergeResult(res.DKIM, result)
+			case "dmarc":
+				res.DMARC = mergeResult(res.DMARC, result)
+			}
+		}
+		return res
+	}
+	return nil
+}
+
+// mergeResult 合并同一方法的多个结果
+func mergeResult(prev, result string) string {
+	if prev == "" || result == "pass" {
+		return result
+	}
+	return prev
+}
+
+// stripComments 去掉邮件头中括号内的注释（可嵌套）
+func stripComments(s string) string {
+	var b strings.Builder
+	depth := 0
+	for _, r := range s {
+		switch {
+		case r == '(':
+			depth++
+		case r == ')' && depth > 0:
+			depth--
+		case depth == 0:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,172 @@
+package mailer
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 连接加密方式
+const (
+	SecurityTLS      = "tls"      // 隐式TLS（通常为 465 端口）
+	SecuritySTARTTLS = "starttls" // 明文连接后升级为TLS（通常为 587 端口）
+	SecurityNone     = "none"     // 不加密，仅用于本机中继
+)
+
+// defaultTimeout 连接和发送的默认超时
+const defaultTimeout = 30 * time.Second
+
+// Options SMTP 发送配置
+type Options struct {
+	Host     string
+	Port     int    // 默认按加密方式：tls 465，starttls 587，none 25
+	Username string // 可选，设置后使用 PLAIN 认证
+	Password string
+	Security string        // tls / starttls（默认）/ none
+	TLS      *tls.Config   // 可选，nil 时使用系统根证书
+	Timeout  time.Duration // 默认 30 秒
+}
+
+// Validate 检查配置并补全默认端口
+func (o *Options) Validate() error {
+	if o.Host == "" {
+		return fmt.Errorf("SMTP 缺少 host")
+	}
+	switch o.Security {
+	case "":
+		o.Security = SecuritySTARTTLS
+	case SecurityTLS, SecuritySTARTTLS, SecurityNone:
+	default:
+		return fmt.Errorf("SMTP 加密方式无效: %s (可选: tls/starttls/none)", o.Security)
+	}
+	if o.Port == 0 {
+		switch o.Security {
+		case SecurityTLS:
+			o.Port = 465
+		case SecuritySTARTTLS:
+			o.Port = 587
+		default:
+			o.Port = 25
+		}
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = defaultTimeout
+	}
+	return nil
+}
+
+// Send 通过 SMTP 发送一封邮件，msg 为完整的 RFC 5322 邮件（含邮件头）
+func Send(o Options, from string, to []string, msg []byte) error {
+	addr := net.JoinHostPort(o.Host, strconv.Itoa(o.Port))
+	tlsConfig := o.TLS
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{}
+	} else {
+		tlsConfig = tlsConfig.Clone()
+	}
+	if tlsConfig.ServerName == "" {
+		tlsConfig.ServerName = o.Host
+	}
+
+	dialer := &net.Dialer{Timeout: o.Timeout}
+	var conn net.Conn
+	var err error
+	if o.Security == SecurityTLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("连接 SMTP 服务器失败: %w", err)
+	}
+	conn.SetDeadline(time.Now().Add(o.Timeout))
+
+	c, err := smtp.NewClient(conn, o.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("SMTP 握手失败: %w", err)
+	}
+	defer c.Close()
+
+	if o.Security == SecuritySTARTTLS {
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("SMTP 服务器不支持 STARTTLS")
+		}
+		if err := c.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("SMTP STARTTLS 失败: %w", err)
+		}
+	}
+	if o.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", o.Username, o.Password, o.Host)); err != nil {
+			return fmt.Errorf("SMTP 认证失败: %w", err)
+		}
+	}
+	if err := c.Mail(from); err != nil {
+		return fmt.Errorf("SMTP 发件人被拒绝: %w", err)
+	}
+	for _, rcpt := range to {
+		if err := c.Rcpt(rcpt); err != nil {
+			return fmt.Errorf("SMTP 收件人 %s 被拒绝: %w", rcpt, err)
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("SMTP 发送失败: %w", err)
+	}
+	if _, err := w.Write(msg); err != nil {
+		w.Close()
+		return fmt.Errorf("SMTP 发送失败: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("SMTP 发送失败: %w", err)
+	}
+	return c.Quit()
+}
+
+// EncodeHeader 编码含非 ASCII 字符的邮件头（如主题）
+func EncodeHeader(s string) string {
+	return mime.QEncoding.Encode("utf-8", s)
+}
+
+// FormatAddress 生成 "名称 <地址>" 形式的地址，名称按需编码
+func FormatAddress(name, addr string) string {
+	if name == "" {
+		return "<" + addr + ">"
+	}
+	return mime.QEncoding.Encode("utf-8", name) + " <" + addr + ">"
+}
+
+// MessageID 生成新的 Message-ID，domain 取自发件人地址
+func MessageID(from string) string {
+	domain := "mail-receiver"
+	if i := strings.LastIndex(from, "@"); i >= 0 && i < len(from)-1 {
+		domain = from[i+1:]
+	}
+	b := make([]byte, 12)
+	rand.Read(b)
+	return "<" + hex.EncodeToString(b) + "@" + domain + ">"
+}
+
+// Header 按顺序写入的邮件头
+type Header struct {
+	buf bytes.Buffer
+}
+
+// Add 添加一个邮件头，值中的换行会被去掉（防止头注入）
+func (h *Header) Add(key, value string) {
+	value = strings.NewReplacer("\r", "", "\n", "").Replace(value)
+	h.buf.WriteString(key + ": " + value + "\r\n")
+}
+
+// Bytes 返回邮件头内容（不含分隔的空行）
+func (h *Header) Bytes() []byte {
+	return h.buf.Bytes()
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"errors"
+	"html/template"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"mail-receiver/content"
+	"mail-receiver/receiver"
+	"mail-receiver/storage"
+)
+
+// mailViewPage 邮件查看页面
+var mailViewPage = template.Must(template.New("mail").Parse(`<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Record.Subject}}</title>
+<style>
+body { margin: 0; font-family: -apple-system, "Segoe UI", "PingFang SC", "Microsoft YaHei", sans-serif; color: #222; background: #f4f4f4; }
+.mail { max-width: 860px; margin: 0 auto; background: #fff; min-height: 100vh; }
+.header { padding: 16px 20px; border-bottom: 1px solid #e5e5e5; }
+.header h1 { font-size: 20px; margin: 0 0 12px; word-break: break-word; }
+.header table { font-size: 14px; border-collapse: collapse; }
+.header th { color: #888; font-weight: normal; text-align: left; padding: 2px 12px 2px 0; vertical-align: top; white-space: nowrap; }
+.header td { word-break: break-all; }
+.notice { padding: 8px 20px; background: #fff8e1; font-size: 14px; border-bottom: 1px solid #e5e5e5; }
+.body { padding: 20px; overflow-x: auto; word-wrap: break-word; }
+.body img { max-width: 100%; height: auto; }
+.text-body { white-space: pre-wrap; font-family: inherit; margin: 0; }
+.blocked-image { color: #888; font-size: 12px; }
+</style>
+</head>
+<body>
+<div class="mail">
+<div class="header">
+<h1>{{.Record.Subject}}</h1>
+<table>
+<tr><th>发件人</th><td>{{.Record.From}}</td></tr>
+{{if .Record.To}}<tr><th>收件人</th><td>{{range $i, $a := .Record.To}}{{if $i}}, {{end}}{{$a}}{{end}}</td></tr>{{end}}
+{{if .Record.CC}}<tr><th>抄送</th><td>{{range $i, $a := .Record.CC}}{{if $i}}, {{end}}{{$a}}{{end}}</td></tr>{{end}}
+{{if not .Record.Date.IsZero}}<tr><th>时间</th><td>{{.Record.Date.Format "2006-01-02 15:04:05"}}</td></tr>{{end}}
+<tr><th>账号</th><td>{{.Record.Account}} / {{.Record.Folder}}</td></tr>
+{{if .Record.Attachments}}<tr><th>附件</th><td>{{range $i, $a := .Record.Attachments}}{{if $i}}, {{end}}{{$a}}{{end}}</td></tr>{{end}}
+</table>
+</div>
+{{if .Blocked}}<div class="notice">为保护隐私，远程图片未加载。<a href="?images=1">显示图片</a></div>{{end}}
+<div class="body">{{.Body}}</div>
+</div>
+</body>
+</html>
+`))
+
+// mailViewHandler 网页查看邮件：GET /mail/{id}
+// 链接ID由密钥签名，持有链接即可查看（推送中的链接无法附带访问令牌）；
+// 正文经白名单清理后显示，并以 CSP 禁止脚本和表单，远程图片默认不加载（?images=1 加载）
+type mailViewHandler struct {
+	recv         *receiver.Receiver
+	remoteImages bool
+}
+
+// newMailViewHandler 创建邮件查看页面
+func newMailViewHandler(recv *receiver.Receiver, remoteImages bool) *mailViewHandler {
+	return &mailViewHandler{recv: recv, remoteImages: remoteImages}
+}
+
+func (h *mailViewHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "请求方法不支持", http.StatusMethodNotAllowed)
+		return
+	}
+	id := strings.TrimPrefix(r.URL.Path, "/mail/")
+	rec, err := h.recv.LookupMail(id)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			http.NotFound(w, r)
+			return
+		}
+		log.Printf("读取邮件失败: %v", err)
+		http.Error(w, "读取邮件失败", http.StatusInternalServerError)
+		return
+	}
+
+	images := h.remoteImages
+	if v, err := strconv.ParseBool(r.URL.Query().Get("images")); err == nil {
+		images = v
+	}
+	var body string
+	blocked := false
+	if rec.HTMLBody != "" {
+		body, blocked = content.SanitizeHTML(rec.HTMLBody, images)
+	} else {
+		body = content.TextToHTML(rec.Body)
+	}
+
+	imgSrc := "data:"
+	if images {
+		imgSrc += " https: http:"
+	}
+	header := w.Header()
+	header.Set("Content-Type", "text/html; charset=utf-8")
+	header.Set("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'; img-src "+imgSrc+
+		"; form-action 'none'; base-uri 'none'; frame-ancestors 'none'; sandbox allow-popups allow-popups-to-escape-sandbox")
+	// 不向邮件中的链接泄露查看地址
+	header.Set("Referrer-Policy", "no-referrer")
+	header.Set("X-Content-Type-Options", "nosniff")
+	header.Set("Cache-Control", "private, no-store")
+
+	err = mailViewPage.Execute(w, struct {
+		Record  *storage.Record
+		Body    template.HTML
+		Blocked bool
+	}{rec, template.HTML(body), blocked})
+	if err != nil {
+		log.Printf("输出邮件页面失败: %v", err)
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
 	"os/signal"
@@ -10,12 +11,59 @@ import (
 	"mail-receiver/receiver"
 )
 
+// configFile 默认配置文件路径
+const configFile = "config.json"
+
 func main() {
 	// 初始化日志
 	log.SetFlags(log.LstdFlags | log.Lshortfile)
 
+	// 配置档案（--profile <名称>），之后的路径都相对档案目录
+	args, err := selectProfile(os.Args[1:])
+	if err != nil {
+		log.Fatalf("选择配置档案失败: %v", err)
+	}
+
+	// 子命令
+	if len(args) > 0 && args[0] == "report" {
+		if err := runReport(args[1:]); err != nil {
+			log.Fatalf("生成报表失败: %v", err)
+		}
+		return
+	}
+	if len(args) > 0 && args[0] == "reprocess" {
+		if err := runReprocess(args[1:]); err != nil {
+			log.Fatalf("重新评估邮件失败: %v", err)
+		}
+		return
+	}
+	if len(args) > 0 && args[0] == "migrate" {
+		if err := runMigrate(args[1:]); err != nil {
+			log.Fatalf("升级配置失败: %v", err)
+		}
+		return
+	}
+	if len(args) > 0 && args[0] == "folders" {
+		if err := runFolders(args[1:]); err != nil {
+			log.Fatalf("列出文件夹失败: %v", err)
+		}
+		return
+	}
+	if len(args) > 0 && args[0] == "dead-letter" {
+		if err := runDeadLetter(args[1:]); err != nil {
+			log.Fatalf("处理推送失败记录失败: %v", err)
+		}
+		return
+	}
+	if len(args) > 0 && args[0] == "import" {
+		if err := runImport(args[1:]); err != nil {
+			log.Fatalf("导入账号失败: %v", err)
+		}
+		return
+	}
+
 	// 加载配置
-	cfg, err := config.LoadConfig("config.json")
+	cfg, err := config.LoadConfig(configFile)
 	if err != nil {
 		log.Fatalf("加载配置失败: %v", err)
 	}
@@ -24,21 +72,44 @@ func main() {
 
 	// 创建接收器
 	recv := receiver.NewReceiver(cfg)
+	holdUntilReleased(recv)
 
 	// 启动接收器
-	if err := recv.Start(); err != nil {
+	if err := recv.Start(context.Background()); err != nil {
 		log.Fatalf("启动接收器失败: %v", err)
 	}
 
 	// 启动心跳检测
 	recv.StartHeartbeat()
 
-	// 设置信号处理
+	// 启动内置HTTP服务和指标接口
+	if err := startHTTP(&cfg.App, recv); err != nil {
+		log.Fatalf("启动HTTP服务失败: %v", err)
+	}
+
+	// 配置文件修改后自动重新加载
+	if cfg.App.WatchConfig {
+		watchConfig(recv)
+	}
+
+	// 升级交接启动时，完成连接后通知旧进程退出
+	notifyReady(recv)
+
+	// 设置信号处理：SIGHUP 重新加载配置，SIGUSR2 交接给新版本程序，其他信号退出
 	sigCh := make(chan os.Signal, 1)
-	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	signal.Notify(sigCh, append([]os.Signal{syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP}, upgradeSignals...)...)
 
-	// 等待退出信号
-	sig := <-sigCh
-	log.Printf("收到信号: %v，立即退出", sig)
-	os.Exit(0)
+	for sig := range sigCh {
+		if sig == syscall.SIGHUP {
+			reloadConfig(recv, "收到 SIGHUP")
+			continue
+		}
+		if isUpgradeSignal(sig) {
+			upgrade(recv)
+			continue
+		}
+		log.Printf("收到信号: %v，立即退出", sig)
+		recv.FlushRetries()
+		os.Exit(0)
+	}
 }
@@ -0,0 +1,23 @@
+package metrics
+
+// 邮件接收相关指标（按账号区分）
+var (
+	EmailsFetched = NewCounterVec("mail_receiver_emails_fetched_total", "获取到的邮件数", "account")
+	EmailsPushed  = NewCounterVec("mail_receiver_emails_pushed_total", "推送成功的邮件数", "account")
+	PushFailures  = NewCounterVec("mail_receiver_push_failures_total", "推送失败次数", "account")
+	Reconnects    = NewCounterVec("mail_receiver_imap_reconnects_total", "IMAP 重新连接次数", "account")
+	IdleTimeouts  = NewCounterVec("mail_receiver_idle_timeouts_total", "IDLE 超时次数", "account")
+
+	PushDuration = NewHistogramVec("mail_receiver_push_duration_seconds", "推送请求耗时（秒）", "account",
+		[]float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30})
+
+	// ReconnectDuration 重新连接到可以获取邮件（连接、登录、识别文件夹）的耗时
+	ReconnectDuration = NewHistogramVec("mail_receiver_imap_reconnect_duration_seconds", "IMAP 重新连接耗时（秒）", "account",
+		[]float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30})
+
+	// DeliveryLatency 邮件 Date 头到推送送达的延迟
+	DeliveryLatency = NewSummaryVec("mail_receiver_delivery_latency_seconds", "邮件发出到推送送达的延迟（秒）", "account",
+		[]float64{0.5, 0.9, 0.99}, 1000)
+
+	LastFetch = NewGaugeVec("mail_receiver_last_fetch_timestamp_seconds", "最近一次成功获取邮件的时间（Unix 秒）", "account")
+)
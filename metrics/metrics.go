@@ -0,0 +1,276 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// collector 可输出为 Prometheus 文本格式的指标
+type collector interface {
+	write(w io.Writer)
+}
+
+var (
+	registryMu sync.Mutex
+	registry   []collector
+)
+
+// register 注册指标到默认注册表
+func register(c collector) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry = append(registry, c)
+}
+
+// Handler 返回输出所有指标的 HTTP 处理器（Prometheus 文本格式）
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		registryMu.Lock()
+		list := append([]collector(nil), registry...)
+		registryMu.Unlock()
+		for _, c := range list {
+			c.write(w)
+		}
+	})
+}
+
+// vec 按单个标签区分的一组数值
+type vec struct {
+	name   string
+	help   string
+	kind   string
+	label  string
+	mu     sync.Mutex
+	values map[string]float64
+}
+
+func (v *vec) write(w io.Writer) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", v.name, v.help, v.name, v.kind)
+	for _, key := range sortedKeys(v.values) {
+		fmt.Fprintf(w, "%s{%s=\"%s\"} %s\n", v.name, v.label, escape(key), formatFloat(v.values[key]))
+	}
+}
+
+// CounterVec 计数器
+type CounterVec struct{ vec }
+
+// NewCounterVec 创建并注册计数器
+func NewCounterVec(name, help, label string) *CounterVec {
+	c := &CounterVec{vec{name: name, help: help, kind: "counter", label: label, values: make(map[string]float64)}}
+	register(c)
+	return c
+}
+
+// Inc 计数加一
+func (c *CounterVec) Inc(label string) {
+	c.Add(label, 1)
+}
+
+// Add 计数增加 n
+func (c *CounterVec) Add(label string, n float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.values[label] += n
+}
+
+// GaugeVec 仪表盘（可增可减的当前值）
+type GaugeVec struct{ vec }
+
+// NewGaugeVec 创建并注册仪表盘
+func NewGaugeVec(name, help, label string) *GaugeVec {
+	g := &GaugeVec{vec{name: name, help: help, kind: "gauge", label: label, values: make(map[string]float64)}}
+	register(g)
+	return g
+}
+
+// Set 设置当前值
+func (g *GaugeVec) Set(label string, v float64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.values[label] = v
+}
+
+// HistogramVec 直方图
+type HistogramVec struct {
+	name    string
+	help    string
+	label   string
+	buckets []float64
+	mu      sync.Mutex
+	series  map[string]*histogram
+}
+
+// histogram 单个标签值的直方图数据
+type histogram struct {
+	counts []uint64 // 与 buckets 对应的累计计数
+	count  uint64
+	sum    float64
+}
+
+// NewHistogramVec 创建并注册直方图，buckets 为升序的上界
+func NewHistogramVec(name, help, label string, buckets []float64) *HistogramVec {
+	h := &HistogramVec{name: name, help: help, label: label, buckets: buckets, series: make(map[string]*histogram)}
+	register(h)
+	return h
+}
+
+// Observe 记录一次观测值
+func (h *HistogramVec) Observe(label string, v float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s, ok := h.series[label]
+	if !ok {
+		s = &histogram{counts: make([]uint64, len(h.buckets))}
+		h.series[label] = s
+	}
+	for i, upper := range h.buckets {
+		if v <= upper {
+			s.counts[i]++
+		}
+	}
+	s.count++
+	s.sum += v
+}
+
+func (h *HistogramVec) write(w io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", h.name, h.help, h.name)
+	keys := make([]string, 0, len(h.series))
+	for k := range h.series {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		s := h.series[key]
+		lv := escape(key)
+		for i, upper := range h.buckets {
+			fmt.Fprintf(w, "%s_bucket{%s=\"%s\",le=\"%s\"} %d\n", h.name, h.label, lv, formatFloat(upper), s.counts[i])
+		}
+		fmt.Fprintf(w, "%s_bucket{%s=\"%s\",le=\"+Inf\"} %d\n", h.name, h.label, lv, s.count)
+		fmt.Fprintf(w, "%s_sum{%s=\"%s\"} %s\n", h.name, h.label, lv, formatFloat(s.sum))
+		fmt.Fprintf(w, "%s_count{%s=\"%s\"} %d\n", h.name, h.label, lv, s.count)
+	}
+}
+
+// sortedKeys 返回排序后的键
+func sortedKeys(m map[string]float64) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// escape 转义标签值
+func escape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+// formatFloat 按 Prometheus 文本格式输出数值
+func formatFloat(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// SummaryVec 摘要：按最近的观测值计算分位数
+type SummaryVec struct {
+	name      string
+	help      string
+	label     string
+	quantiles []float64
+	window    int
+	mu        sync.Mutex
+	series    map[string]*summary
+}
+
+// summary 单个标签值最近的观测值（环形缓冲）
+type summary struct {
+	samples []float64
+	next    int
+	count   uint64
+	sum     float64
+}
+
+// NewSummaryVec 创建并注册摘要，window 为参与分位数计算的最近观测值数量
+func NewSummaryVec(name, help, label string, quantiles []float64, window int) *SummaryVec {
+	s := &SummaryVec{name: name, help: help, label: label, quantiles: quantiles, window: window, series: make(map[string]*summary)}
+	register(s)
+	return s
+}
+
+// Observe 记录一次观测值
+func (s *SummaryVec) Observe(label string, v float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sm, ok := s.series[label]
+	if !ok {
+		sm = &summary{}
+		s.series[label] = sm
+	}
+	if len(sm.samples) < s.window {
+		sm.samples = append(sm.samples, v)
+	} else {
+		sm.samples[sm.next] = v
+		sm.next = (sm.next + 1) % s.window
+	}
+	sm.count++
+	sm.sum += v
+}
+
+// Quantile 返回标签值最近观测值的分位数，没有数据时返回 false
+func (s *SummaryVec) Quantile(label string, q float64) (float64, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sm, ok := s.series[label]
+	if !ok || len(sm.samples) == 0 {
+		return 0, false
+	}
+	return quantile(sm.samples, q), true
+}
+
+func (s *SummaryVec) write(w io.Writer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s summary\n", s.name, s.help, s.name)
+	keys := make([]string, 0, len(s.series))
+	for k := range s.series {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		sm := s.series[key]
+		lv := escape(key)
+		for _, q := range s.quantiles {
+			fmt.Fprintf(w, "%s{%s=\"%s\",quantile=\"%s\"} %s\n", s.name, s.label, lv, formatFloat(q), formatFloat(quantile(sm.samples, q)))
+		}
+		fmt.Fprintf(w, "%s_sum{%s=\"%s\"} %s\n", s.name, s.label, lv, formatFloat(sm.sum))
+		fmt.Fprintf(w, "%s_count{%s=\"%s\"} %d\n", s.name, s.label, lv, sm.count)
+	}
+}
+
+// quantile 计算分位数（最近秩法）
+func quantile(samples []float64, q float64) float64 {
+	sorted := append([]float64(nil), samples...)
+	sort.Float64s(sorted)
+	i := int(math.Ceil(q*float64(len(sorted)))) - 1
+	if i < 0 {
+		i = 0
+	}
+	if i >= len(sorted) {
+		i = len(sorted) - 1
+	}
+	return sorted[i]
+}
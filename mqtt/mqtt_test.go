@@ -0,0 +1,257 @@
+package mqtt
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// unhex 解码带空格的十六进制
+func unhex(t testing.TB, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(strings.Join(strings.Fields(s), ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestRemainingLength(t *testing.T) {
+	// MQTT 3.1.1 第 2.2.3 节各字节数的边界值
+	tests := []struct {
+		n       int
+		encoded string
+	}{
+		{0, "00"},
+		{127, "7f"},
+		{128, "80 01"},
+		{16383, "ff 7f"},
+		{16384, "80 80 01"},
+		{2097151, "ff ff 7f"},
+		{2097152, "80 80 80 01"},
+		{268435455, "ff ff ff 7f"},
+	}
+	for _, tt := range tests {
+		want := unhex(t, tt.encoded)
+		if got := appendLength(nil, tt.n); !bytes.Equal(got, want) {
+			t.Errorf("appendLength(%d) = % x，期望 % x", tt.n, got, want)
+		}
+		n, err := readLength(bytes.NewReader(want))
+		if err != nil || n != tt.n {
+			t.Errorf("readLength(% x) = %d (%v)，期望 %d", want, n, err, tt.n)
+		}
+	}
+
+	if _, err := readLength(bytes.NewReader(unhex(t, "ff ff ff ff 01"))); err == nil || err.Error() != "剩余长度无效" {
+		t.Errorf("超过 4 字节时返回 %v", err)
+	}
+	if _, err := readLength(bytes.NewReader(unhex(t, "80"))); err != io.EOF {
+		t.Errorf("内容不完整时返回 %v", err)
+	}
+}
+
+// packet broker 收到的控制报文
+type packet struct {
+	header byte
+	body   []byte
+}
+
+// readPacket 读取一个完整的控制报文（保留固定报头中的标志位）
+func readPacket(r *bufio.Reader) (packet, error) {
+	header, err := r.ReadByte()
+	if err != nil {
+		return packet{}, err
+	}
+	n, err := readLength(r)
+	if err != nil {
+		return packet{}, err
+	}
+	body := make([]byte, n)
+	_, err = io.ReadFull(r, body)
+	return packet{header, body}, err
+}
+
+// fakeBroker 在本机模拟代理：回复 CONNACK 后把之后收到的报文交给 serve，serve 返回要回复的数据
+func fakeBroker(t *testing.T, connack byte, serve func(p packet) []byte) (string, <-chan packet) {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("无法监听本机端口: %v", err)
+	}
+	received := make(chan packet, 16)
+	done := make(chan struct{})
+	t.Cleanup(func() {
+		l.Close()
+		<-done
+	})
+	go func() {
+		defer close(done)
+		defer close(received)
+		nc, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer nc.Close()
+		r := bufio.NewReader(nc)
+		connect, err := readPacket(r)
+		if err != nil {
+			return
+		}
+		received <- connect
+		nc.Write([]byte{packetConnack << 4, 2, 0, connack})
+		for {
+			p, err := readPacket(r)
+			if err != nil {
+				return
+			}
+			received <- p
+			if serve != nil {
+				if reply := serve(p); reply != nil {
+					nc.Write(reply)
+				}
+			}
+		}
+	}()
+	return l.Addr().String(), received
+}
+
+func TestConnectPacket(t *testing.T) {
+	addr, received := fakeBroker(t, 0, nil)
+	c, err := Dial(Options{Broker: "mqtt://u:p@" + addr, ClientID: "test", Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatalf("连接失败: %v", err)
+	}
+	c.Close()
+
+	connect := <-received
+	// 协议名 MQTT、级别 4、标志（用户名、密码、clean session）、keep alive 60，
+	// 之后是客户端ID、用户名和密码
+	want := unhex(t, "00 04 4d 51 54 54  04  c2  00 3c  00 04 74 65 73 74  00 01 75  00 01 70")
+	if connect.header != 0x10 || !bytes.Equal(connect.body, want) {
+		t.Errorf("CONNECT %02x % x，期望 10 % x", connect.header, connect.body, want)
+	}
+	if disconnect := <-received; disconnect.header != 0xe0 || len(disconnect.body) != 0 {
+		t.Errorf("DISCONNECT %02x % x", disconnect.header, disconnect.body)
+	}
+}
+
+func TestConnectRefused(t *testing.T) {
+	tests := []struct {
+		code byte
+		err  string
+	}{
+		{4, "MQTT 代理拒绝连接: 用户名或密码错误"},
+		{9, "MQTT 代理拒绝连接: 返回码 9"},
+	}
+	for _, tt := range tests {
+		addr, _ := fakeBroker(t, tt.code, nil)
+		if _, err := Dial(Options{Broker: "tcp://" + addr, Timeout: 5 * time.Second}); err == nil || err.Error() != tt.err {
+			t.Errorf("返回码 %d 时错误 %v，期望 %q", tt.code, err, tt.err)
+		}
+	}
+}
+
+func TestPublishPacket(t *testing.T) {
+	addr, received := fakeBroker(t, 0, nil)
+	c, err := Dial(Options{Broker: "mqtt://" + addr, ClientID: "test", Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatalf("连接失败: %v", err)
+	}
+	<-received
+	defer c.Close()
+
+	if err := c.Publish("a/b", []byte("hi"), 0, true); err != nil {
+		t.Fatalf("发布失败: %v", err)
+	}
+	// QoS 0 没有报文ID；固定报头 0x31 = PUBLISH + retain
+	p := <-received
+	if want := unhex(t, "00 03 61 2f 62  68 69"); p.header != 0x31 || !bytes.Equal(p.body, want) {
+		t.Errorf("PUBLISH %02x % x，期望 31 % x", p.header, p.body, want)
+	}
+
+	// 剩余长度需要两个字节的消息
+	payload := bytes.Repeat([]byte("x"), 200)
+	if err := c.Publish("a/b", payload, 0, false); err != nil {
+		t.Fatalf("发布失败: %v", err)
+	}
+	if p := <-received; p.header != 0x30 || len(p.body) != 205 || !bytes.Equal(p.body[5:], payload) {
+		t.Errorf("PUBLISH %02x，内容 %d 字节", p.header, len(p.body))
+	}
+
+	if err := c.Publish("a/b", nil, 3, false); err == nil || err.Error() != "MQTT QoS 无效: 3 (可选: 0/1/2)" {
+		t.Errorf("QoS 3 时返回 %v", err)
+	}
+}
+
+func TestPublishQoS1(t *testing.T) {
+	// 第 1 条消息按报文ID确认，第 2 条回复其他报文ID的 PUBACK
+	acks := 0
+	addr, received := fakeBroker(t, 0, func(p packet) []byte {
+		if p.header>>4 != packetPublish {
+			return nil
+		}
+		acks++
+		id := p.body[5:7]
+		if acks == 2 {
+			id = []byte{0, 9}
+		}
+		return append([]byte{packetPuback << 4, 2}, id...)
+	})
+	c, err := Dial(Options{Broker: "mqtt://" + addr, ClientID: "test", Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatalf("连接失败: %v", err)
+	}
+	<-received
+	defer c.Close()
+
+	if err := c.Publish("a/b", []byte("hi"), 1, false); err != nil {
+		t.Fatalf("发布失败: %v", err)
+	}
+	// 固定报头 0x32 = PUBLISH + QoS 1，主题之后是报文ID 1
+	p := <-received
+	if want := unhex(t, "00 03 61 2f 62  00 01  68 69"); p.header != 0x32 || !bytes.Equal(p.body, want) {
+		t.Errorf("PUBLISH %02x % x，期望 32 % x", p.header, p.body, want)
+	}
+
+	err = c.Publish("a/b", []byte("hi"), 1, false)
+	if err == nil || err.Error() != "MQTT 代理确认无效 (报文类型 4)" {
+		t.Errorf("报文ID不匹配时返回 %v", err)
+	}
+	if p := <-received; !bytes.Equal(p.body[5:7], []byte{0, 2}) {
+		t.Errorf("第 2 条消息报文ID % x，期望 00 02", p.body[5:7])
+	}
+}
+
+func TestPublishQoS2(t *testing.T) {
+	addr, received := fakeBroker(t, 0, func(p packet) []byte {
+		switch p.header >> 4 {
+		case packetPublish:
+			return append([]byte{packetPubrec << 4, 2}, p.body[5:7]...)
+		case packetPubrel:
+			return append([]byte{packetPubcomp << 4, 2}, p.body...)
+		}
+		return nil
+	})
+	c, err := Dial(Options{Broker: "mqtt://" + addr, ClientID: "test", Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatalf("连接失败: %v", err)
+	}
+	<-received
+	defer c.Close()
+
+	if err := c.Publish("a/b", []byte("hi"), 2, false); err != nil {
+		t.Fatalf("发布失败: %v", err)
+	}
+	if p := <-received; p.header != 0x34 {
+		t.Errorf("PUBLISH 固定报头 %02x，期望 34", p.header)
+	}
+	// PUBREL 的固定报头标志位必须为 0010
+	if p := <-received; p.header != 0x62 || !bytes.Equal(p.body, []byte{0, 1}) {
+		t.Errorf("PUBREL %02x % x，期望 62 00 01", p.header, p.body)
+	}
+}
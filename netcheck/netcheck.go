@@ -0,0 +1,135 @@
+package netcheck
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"mail-receiver/clock"
+)
+
+// Checker 出口网络检查：连接前探测网络是否可用，不可用时进入“网络中断”状态，
+// 所有账号暂停连接、等待恢复，不消耗各自的重试次数；多个账号共用探测结果
+type Checker struct {
+	targets  []string
+	timeout  time.Duration
+	interval time.Duration
+	ctx      context.Context
+	clock    clock.Clock
+
+	mu      sync.Mutex
+	checked time.Time     // 最近一次探测时间
+	down    chan struct{} // 网络中断时非nil，恢复时关闭
+	since   time.Time     // 网络中断的开始时间
+}
+
+// New 创建网络检查，targets 为 host:port（TCP 连接）或域名（DNS 解析），任一可达即视为网络可用
+// ctx 取消时停止后台探测；clk 为检查间隔的时间来源，nil 时使用系统时间
+func New(ctx context.Context, targets []string, timeout, interval time.Duration, clk clock.Clock) *Checker {
+	return &Checker{
+		targets:  targets,
+		timeout:  timeout,
+		interval: interval,
+		ctx:      ctx,
+		clock:    clock.Or(clk),
+	}
+}
+
+// Offline 返回网络是否中断；距离上次探测超过检查间隔时重新探测（探测期间其他账号等待同一结果）
+// 未启用时（nil）总是返回false
+func (c *Checker) Offline() bool {
+	if c == nil {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.down != nil {
+		return true
+	}
+	if c.clock.Since(c.checked) < c.interval {
+		return false
+	}
+	c.checked = c.clock.Now()
+	if err := c.probe(); err != nil {
+		c.down = make(chan struct{})
+		c.since = c.clock.Now()
+		log.Printf("网络不可用（%v），暂停所有账号的连接，每 %v 检查一次", err, c.interval)
+		go c.watch()
+		return true
+	}
+	return false
+}
+
+// Wait 网络中断时等待恢复，返回false表示等待被 wake 打断（如收到暂停、停止请求）
+func (c *Checker) Wait(wake <-chan struct{}) bool {
+	if !c.Offline() {
+		return true
+	}
+	c.mu.Lock()
+	down := c.down
+	c.mu.Unlock()
+	if down == nil {
+		return true
+	}
+	select {
+	case <-down:
+		return true
+	case <-wake:
+		return false
+	}
+}
+
+// watch 网络中断期间按检查间隔探测，恢复后唤醒等待的账号
+func (c *Checker) watch() {
+	for {
+		if clock.SleepContext(c.ctx, c.clock, c.interval) != nil {
+			return
+		}
+		if c.probe() != nil {
+			continue
+		}
+		c.mu.Lock()
+		log.Printf("网络已恢复（中断 %v），继续连接所有账号", c.clock.Since(c.since).Round(time.Second))
+		close(c.down)
+		c.down = nil
+		c.checked = c.clock.Now()
+		c.mu.Unlock()
+		return
+	}
+}
+
+// probe 依次探测各目标，任一可达时返回nil，否则返回最后一个错误
+func (c *Checker) probe() error {
+	var lastErr error
+	for _, target := range c.targets {
+		if lastErr = c.probeTarget(target); lastErr == nil {
+			return nil
+		}
+	}
+	return lastErr
+}
+
+// probeTarget 探测单个目标：含端口时建立 TCP 连接，否则解析域名
+func (c *Checker) probeTarget(target string) error {
+	ctx, cancel := context.WithTimeout(c.ctx, c.timeout)
+	defer cancel()
+
+	if _, _, err := net.SplitHostPort(target); err == nil {
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "tcp", target)
+		if err != nil {
+			return fmt.Errorf("连接 %s 失败: %w", target, err)
+		}
+		conn.Close()
+		return nil
+	}
+	host := strings.TrimSuffix(target, ".")
+	if _, err := net.DefaultResolver.LookupHost(ctx, host); err != nil {
+		return fmt.Errorf("解析 %s 失败: %w", host, err)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// envProfileRoot 配置档案根目录（默认 profiles）
+const envProfileRoot = "MAIL_RECEIVER_PROFILES"
+
+// selectProfile 解析开头的 --profile <名称> 参数并切换到配置档案目录 <根目录>/<名称>，返回其余参数
+// 配置文件和状态文件、存储、附件等相对路径都位于该目录中，不同档案互不影响
+func selectProfile(args []string) ([]string, error) {
+	if len(args) == 0 {
+		return args, nil
+	}
+	var name string
+	switch a := args[0]; {
+	case a == "--profile" || a == "-profile":
+		if len(args) < 2 {
+			return nil, fmt.Errorf("%s 缺少档案名称", a)
+		}
+		name, args = args[1], args[2:]
+	case strings.HasPrefix(a, "--profile=") || strings.HasPrefix(a, "-profile="):
+		_, name, _ = strings.Cut(a, "=")
+		args = args[1:]
+	default:
+		return args, nil
+	}
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return nil, fmt.Errorf("配置档案名称无效: %q", name)
+	}
+
+	root := os.Getenv(envProfileRoot)
+	if root == "" {
+		root = "profiles"
+	}
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("获取配置档案目录失败: %w", err)
+	}
+	dir := filepath.Join(root, name)
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("配置档案 %s 不存在: %s", name, dir)
+	}
+	if err := os.Chdir(dir); err != nil {
+		return nil, fmt.Errorf("切换到配置档案目录失败: %w", err)
+	}
+	// 升级交接时新进程继承当前目录和环境变量，使用绝对路径以找到同一目录
+	os.Setenv(envProfileRoot, root)
+
+	log.SetPrefix("[" + name + "] ")
+	log.Printf("使用配置档案 %s: %s", name, dir)
+	return args, nil
+}
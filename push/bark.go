@@ -0,0 +1,137 @@
+package push
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// defaultBarkServer Bark 官方服务器
+const defaultBarkServer = "https://api.day.app"
+
+// Bark 通知级别
+const (
+	barkLevelActive        = "active"
+	barkLevelTimeSensitive = "timeSensitive"
+	barkLevelPassive       = "passive"
+	barkLevelCritical      = "critical"
+)
+
+// barkLevels 推送优先级 → Bark 通知级别
+var barkLevels = map[string]string{
+	PriorityLow:    barkLevelPassive,
+	PriorityNormal: barkLevelActive,
+	PriorityHigh:   barkLevelTimeSensitive,
+	PriorityUrgent: barkLevelCritical,
+}
+
+func init() {
+	Register(TypeBark, newBarkPusher)
+}
+
+// TypeBark Bark（iOS）推送类型
+const TypeBark = "bark"
+
+// barkPusher Bark 推送
+type barkPusher struct {
+	opts barkOptions
+	http *HTTPClient
+}
+
+// barkOptions Bark 推送配置
+type barkOptions struct {
+	DeviceKey string `json:"device_key"`
+	Server    string `json:"server"` // 可选，自建服务器地址
+	Sound     string `json:"sound"`  // 可选，默认铃声（规则可覆盖）
+	Group     string `json:"group"`  // 可选，通知分组，默认为账号名
+	Icon      string `json:"icon"`   // 可选，通知图标URL
+	Level     string `json:"level"`  // 可选，默认通知级别（active/timeSensitive/passive/critical），规则优先级可覆盖
+	Volume    int    `json:"volume"` // 可选，critical 级别的音量（0-10）
+}
+
+// newBarkPusher 创建 Bark 推送后端
+func newBarkPusher(s *Settings) (Pusher, error) {
+	var opts barkOptions
+	if err := s.Decode(&opts); err != nil {
+		return nil, err
+	}
+	if opts.DeviceKey == "" {
+		return nil, fmt.Errorf("Bark 推送缺少 device_key")
+	}
+	switch opts.Level {
+	case "", barkLevelActive, barkLevelTimeSensitive, barkLevelPassive, barkLevelCritical:
+	default:
+		return nil, fmt.Errorf("Bark 通知级别无效: %s (可选: active/timeSensitive/passive/critical)", opts.Level)
+	}
+	if opts.Server == "" {
+		opts.Server = defaultBarkServer
+	}
+	opts.Server = strings.TrimRight(opts.Server, "/")
+	if opts.Group == "" {
+		opts.Group = s.AccountName
+	}
+	return &barkPusher{opts: opts, http: s.HTTP}, nil
+}
+
+// Push 实现 Pusher
+func (p *barkPusher) Push(title, msg string, meta *Meta) error {
+	params := map[string]interface{}{
+		"device_key": p.opts.DeviceKey,
+		"title":      title,
+		"body":       msg,
+		"group":      p.opts.Group,
+	}
+
+	sound, level := p.opts.Sound, p.opts.Level
+	if meta != nil {
+		if meta.Sound != "" {
+			sound = meta.Sound
+		}
+		if l, ok := barkLevels[meta.Priority]; ok {
+			level = l
+		}
+		if meta.ImageURL != "" {
+			params["image"] = meta.ImageURL
+		}
+		if meta.ViewURL != "" {
+			params["url"] = meta.ViewURL
+		}
+		if meta.Code != "" {
+			// 验证码：通知中可直接复制
+			params["copy"] = meta.Code
+		}
+	}
+	if sound != "" {
+		params["sound"] = sound
+	}
+	if level != "" {
+		params["level"] = level
+	}
+	if level == barkLevelCritical && p.opts.Volume > 0 {
+		params["volume"] = p.opts.Volume
+	}
+	if p.opts.Icon != "" {
+		params["icon"] = p.opts.Icon
+	}
+
+	payload, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("序列化推送消息失败: %w", err)
+	}
+
+	status, body, err := p.http.Post(p.opts.Server+"/push", "application/json; charset=utf-8", payload, nil)
+	if err != nil {
+		return err
+	}
+
+	var result struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+	json.Unmarshal(body, &result)
+	if status == http.StatusOK && result.Code == http.StatusOK {
+		return nil
+	}
+	return fmt.Errorf("Bark 推送失败: [%d] %s", status, result.Message)
+}
@@ -0,0 +1,176 @@
+package push
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"mail-receiver/imap"
+)
+
+// DeadLetterEntry 重试多次仍失败的推送，可用 dead-letter 子命令重新推送
+type DeadLetterEntry struct {
+	Time      time.Time `json:"time"` // 放弃重试的时间
+	Account   string    `json:"account"`
+	Target    string    `json:"target"` // 推送目标名称
+	Title     string    `json:"title"`
+	Message   string    `json:"message"`
+	Folder    string    `json:"folder,omitempty"`
+	Priority  string    `json:"priority,omitempty"`
+	Sound     string    `json:"sound,omitempty"`
+	ImageURL  string    `json:"image_url,omitempty"`
+	ViewURL   string    `json:"view_url,omitempty"`
+	MessageID string    `json:"message_id,omitempty"`
+	Subject   string    `json:"subject,omitempty"`
+	From      string    `json:"from,omitempty"`
+	Attempts  int       `json:"attempts"` // 已尝试次数
+	Error     string    `json:"error"`    // 最后一次失败的原因
+}
+
+// newDeadLetterEntry 根据推送内容生成记录
+func newDeadLetterEntry(target, title, msg string, meta *Meta, attempts int, err error) *DeadLetterEntry {
+	e := &DeadLetterEntry{
+		Time:     time.Now(),
+		Target:   target,
+		Title:    title,
+		Message:  msg,
+		Attempts: attempts,
+		Error:    err.Error(),
+	}
+	if meta != nil {
+		e.Account = meta.Account
+		e.Folder = meta.Folder
+		e.Priority = meta.Priority
+		e.Sound = meta.Sound
+		e.ImageURL = meta.ImageURL
+		e.ViewURL = meta.ViewURL
+		if email := meta.Email; email != nil {
+			e.MessageID = email.MessageID
+			e.Subject = email.Subject
+			e.From = email.FromAddress
+		}
+	}
+	return e
+}
+
+// Meta 还原推送附加信息；邮件只保留 Message-ID、主题和发件人
+func (e *DeadLetterEntry) Meta() *Meta {
+	meta := &Meta{
+		Account:  e.Account,
+		Folder:   e.Folder,
+		Priority: e.Priority,
+		Sound:    e.Sound,
+		ImageURL: e.ImageURL,
+		ViewURL:  e.ViewURL,
+	}
+	if e.MessageID != "" || e.Subject != "" {
+		meta.Email = &imap.EmailMessage{
+			MessageID:   e.MessageID,
+			Subject:     e.Subject,
+			FromAddress: e.From,
+		}
+		if e.From != "" {
+			meta.Email.From = []string{e.From}
+		}
+	}
+	return meta
+}
+
+// DeadLetter 推送失败记录文件（JSON Lines，追加写入）
+type DeadLetter struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewDeadLetter 创建推送失败记录文件，首次写入时创建
+func NewDeadLetter(path string) *DeadLetter {
+	return &DeadLetter{path: path}
+}
+
+// Write 追加一条记录
+func (d *DeadLetter) Write(e *DeadLetterEntry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("序列化推送失败记录失败: %w", err)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if dir := filepath.Dir(d.path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("创建推送失败记录目录失败: %w", err)
+		}
+	}
+	f, err := os.OpenFile(d.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("打开推送失败记录文件失败: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("写入推送失败记录失败: %w", err)
+	}
+	return nil
+}
+
+// ReadDeadLetters 读取推送失败记录文件，文件不存在时返回空列表
+func ReadDeadLetters(path string) ([]*DeadLetterEntry, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("打开推送失败记录文件失败: %w", err)
+	}
+	defer f.Close()
+
+	var entries []*DeadLetterEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var e DeadLetterEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("解析推送失败记录失败: %w", err)
+		}
+		entries = append(entries, &e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取推送失败记录文件失败: %w", err)
+	}
+	return entries, nil
+}
+
+// WriteDeadLetters 用给定的记录替换推送失败记录文件（先写临时文件再替换）
+func WriteDeadLetters(path string, entries []*DeadLetterEntry) error {
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("写入推送失败记录文件失败: %w", err)
+	}
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	for _, e := range entries {
+		if err := enc.Encode(e); err != nil {
+			f.Close()
+			os.Remove(tmp)
+			return fmt.Errorf("写入推送失败记录文件失败: %w", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return fmt.Errorf("写入推送失败记录文件失败: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("写入推送失败记录文件失败: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
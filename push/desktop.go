@@ -0,0 +1,110 @@
+package push
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// desktopTimeout 调用系统通知命令的超时时间
+const desktopTimeout = 10 * time.Second
+
+// desktopBodyLimit 通知正文的最大字符数，系统通知只显示开头几行
+const desktopBodyLimit = 300
+
+func init() {
+	Register(TypeDesktop, newDesktopPusher)
+}
+
+// TypeDesktop 本机桌面通知推送类型
+const TypeDesktop = "desktop"
+
+// desktopPusher 本机桌面通知：Windows 为 toast 通知，macOS 为 terminal-notifier 或 osascript，Linux 等为 notify-send
+type desktopPusher struct {
+	opts    desktopOptions
+	account string
+	notify  func(ctx context.Context, n *desktopNotification) error // 各平台的通知实现
+}
+
+// desktopOptions 桌面通知配置
+type desktopOptions struct {
+	AppName string `json:"app_name"` // 可选，应用名称（Linux 通知的来源），默认 mail-receiver
+	Icon    string `json:"icon"`     // 可选，通知图标（Linux 为图标名或文件路径，Windows 为图片路径，macOS 仅 terminal-notifier 支持）
+	Sound   string `json:"sound"`    // 可选，macOS 提示音名称（如 Glass），规则的 sound 可覆盖
+	Timeout int    `json:"timeout"`  // 可选，Linux 通知显示时间（秒），0 使用通知服务的默认值
+}
+
+// desktopNotification 一条桌面通知
+type desktopNotification struct {
+	Title    string
+	Subtitle string // 发件人，非邮件推送时为账号名
+	Body     string
+	Sound    string
+	Priority string
+	opts     *desktopOptions
+}
+
+// newDesktopPusher 创建桌面通知推送后端
+func newDesktopPusher(s *Settings) (Pusher, error) {
+	var opts desktopOptions
+	if err := s.Decode(&opts); err != nil {
+		return nil, err
+	}
+	if opts.Timeout < 0 {
+		return nil, fmt.Errorf("桌面通知显示时间无效: %d", opts.Timeout)
+	}
+	if opts.AppName == "" {
+		opts.AppName = "mail-receiver"
+	}
+	notify, err := desktopNotifier()
+	if err != nil {
+		return nil, err
+	}
+	return &desktopPusher{opts: opts, account: s.AccountName, notify: notify}, nil
+}
+
+// Push 实现 Pusher
+func (p *desktopPusher) Push(title, msg string, meta *Meta) error {
+	n := &desktopNotification{
+		Title:    title,
+		Subtitle: p.account,
+		Body:     truncateText(strings.TrimSpace(messageBody(msg)), desktopBodyLimit),
+		Sound:    p.opts.Sound,
+		Priority: PriorityNormal,
+		opts:     &p.opts,
+	}
+	if meta != nil {
+		if meta.Sound != "" {
+			n.Sound = meta.Sound
+		}
+		if meta.Priority != "" {
+			n.Priority = meta.Priority
+		}
+		if meta.Email != nil {
+			n.Subtitle = meta.Email.DisplayFrom()
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), desktopTimeout)
+	defer cancel()
+	if err := p.notify(ctx, n); err != nil {
+		return fmt.Errorf("桌面通知失败: %w", err)
+	}
+	return nil
+}
+
+// runNotifyCommand 运行通知命令，失败时带上命令输出
+func runNotifyCommand(cmd *exec.Cmd) error {
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		name := filepath.Base(cmd.Path)
+		if s := strings.TrimSpace(string(out)); s != "" {
+			return fmt.Errorf("%s: %w: %s", name, err, truncateText(s, 200))
+		}
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package push
+
+import (
+	"context"
+	"os/exec"
+)
+
+// desktopNotifier 已安装 terminal-notifier 时使用它（支持图标、勿扰模式下的紧急通知），否则使用系统自带的 osascript
+func desktopNotifier() (func(ctx context.Context, n *desktopNotification) error, error) {
+	if _, err := exec.LookPath("terminal-notifier"); err == nil {
+		return terminalNotifier, nil
+	}
+	return osascriptNotify, nil
+}
+
+// terminalNotifier 调用 terminal-notifier 发送通知
+func terminalNotifier(ctx context.Context, n *desktopNotification) error {
+	args := []string{"-title", n.Title, "-message", n.Body}
+	if n.Subtitle != "" {
+		args = append(args, "-subtitle", n.Subtitle)
+	}
+	if n.Sound != "" {
+		args = append(args, "-sound", n.Sound)
+	}
+	if n.opts.Icon != "" {
+		args = append(args, "-contentImage", n.opts.Icon)
+	}
+	if n.Priority == PriorityUrgent {
+		args = append(args, "-ignoreDnD")
+	}
+	return runNotifyCommand(exec.CommandContext(ctx, "terminal-notifier", args...))
+}
+
+// osascriptNotify 通过 AppleScript 的 display notification 发送通知
+// 标题和正文作为脚本参数传入，不拼接到脚本中，无需转义
+func osascriptNotify(ctx context.Context, n *desktopNotification) error {
+	script := "display notification (item 3 of argv) with title (item 1 of argv) subtitle (item 2 of argv)"
+	args := []string{n.Title, n.Subtitle, n.Body}
+	if n.Sound != "" {
+		script += " sound name (item 4 of argv)"
+		args = append(args, n.Sound)
+	}
+	args = append([]string{"-e", "on run argv", "-e", script, "-e", "end run"}, args...)
+	return runNotifyCommand(exec.CommandContext(ctx, "osascript", args...))
+}
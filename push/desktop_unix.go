@@ -0,0 +1,48 @@
+//go:build !windows && !darwin
+
+package push
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+)
+
+// notifySendUrgency 推送优先级 → notify-send 紧急程度（critical 通知不会自动消失）
+var notifySendUrgency = map[string]string{
+	PriorityLow:    "low",
+	PriorityNormal: "normal",
+	PriorityHigh:   "normal",
+	PriorityUrgent: "critical",
+}
+
+// desktopNotifier 使用 notify-send（libnotify）发送通知，需要图形会话（DISPLAY / DBUS_SESSION_BUS_ADDRESS）
+func desktopNotifier() (func(ctx context.Context, n *desktopNotification) error, error) {
+	if _, err := exec.LookPath("notify-send"); err != nil {
+		return nil, fmt.Errorf("桌面通知需要 notify-send（libnotify-bin / libnotify）: %w", err)
+	}
+	return notifySend, nil
+}
+
+// notifySend 调用 notify-send 发送通知
+func notifySend(ctx context.Context, n *desktopNotification) error {
+	urgency := notifySendUrgency[n.Priority]
+	if urgency == "" {
+		urgency = "normal"
+	}
+	args := []string{"-a", n.opts.AppName, "-u", urgency}
+	if n.opts.Icon != "" {
+		args = append(args, "-i", n.opts.Icon)
+	}
+	if n.opts.Timeout > 0 {
+		args = append(args, "-t", strconv.Itoa(n.opts.Timeout*1000))
+	}
+	body := n.Body
+	if n.Subtitle != "" {
+		body = n.Subtitle + "\n" + body
+	}
+	// "--" 之后的参数不作为选项解析，避免以 "-" 开头的标题被误认
+	args = append(args, "--", n.Title, body)
+	return runNotifyCommand(exec.CommandContext(ctx, "notify-send", args...))
+}
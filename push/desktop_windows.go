@@ -0,0 +1,71 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// powershellAppID 通过 PowerShell 的应用 ID 显示 toast，无需为本程序注册开始菜单快捷方式
+const powershellAppID = `{1AC14E77-02E7-4E5D-B744-2EB1AE5198B7}\WindowsPowerShell\v1.0\powershell.exe`
+
+// toastScript 显示环境变量中的 toast XML，内容不拼接到脚本中，避免注入
+const toastScript = `[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] | Out-Null
+[Windows.Data.Xml.Dom.XmlDocument, Windows.Data.Xml.Dom.XmlDocument, ContentType = WindowsRuntime] | Out-Null
+$xml = New-Object Windows.Data.Xml.Dom.XmlDocument
+$xml.LoadXml($env:MAIL_RECEIVER_TOAST)
+$toast = New-Object Windows.UI.Notifications.ToastNotification $xml
+[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier($env:MAIL_RECEIVER_TOAST_APP).Show($toast)`
+
+// desktopNotifier 通过 Windows PowerShell 调用 WinRT 显示 toast 通知（需要 Windows 10 及以上，以服务方式运行时没有桌面会话，不会显示）
+func desktopNotifier() (func(ctx context.Context, n *desktopNotification) error, error) {
+	if _, err := exec.LookPath("powershell.exe"); err != nil {
+		return nil, fmt.Errorf("桌面通知需要 Windows PowerShell: %w", err)
+	}
+	return toastNotify, nil
+}
+
+// toastNotify 显示 toast 通知
+func toastNotify(ctx context.Context, n *desktopNotification) error {
+	cmd := exec.CommandContext(ctx, "powershell.exe", "-NoProfile", "-NonInteractive", "-Command", toastScript)
+	cmd.Env = append(os.Environ(),
+		"MAIL_RECEIVER_TOAST="+toastXML(n),
+		"MAIL_RECEIVER_TOAST_APP="+powershellAppID,
+	)
+	return runNotifyCommand(cmd)
+}
+
+// toastXML 生成 toast 通知内容：高优先级通知停留更久，低优先级静音
+func toastXML(n *desktopNotification) string {
+	var b bytes.Buffer
+	text := func(s string) {
+		b.WriteString("<text>")
+		xml.EscapeText(&b, []byte(s))
+		b.WriteString("</text>")
+	}
+
+	b.WriteString("<toast")
+	if n.Priority == PriorityHigh || n.Priority == PriorityUrgent {
+		b.WriteString(` duration="long"`)
+	}
+	b.WriteString(`><visual><binding template="ToastGeneric">`)
+	text(n.Title)
+	if n.Subtitle != "" {
+		text(n.Subtitle)
+	}
+	text(n.Body)
+	if n.opts.Icon != "" {
+		b.WriteString(`<image placement="appLogoOverride" src="`)
+		xml.EscapeText(&b, []byte(n.opts.Icon))
+		b.WriteString(`"/>`)
+	}
+	b.WriteString("</binding></visual>")
+	if n.Priority == PriorityLow {
+		b.WriteString(`<audio silent="true"/>`)
+	}
+	b.WriteString("</toast>")
+	return b.String()
+}
@@ -0,0 +1,114 @@
+package push
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dingtalkMaxLength markdown 正文最大字符数（钉钉消息上限 20000 字节）
+const dingtalkMaxLength = 5000
+
+func init() {
+	Register(TypeDingTalk, newDingTalkPusher)
+}
+
+// TypeDingTalk 钉钉群机器人推送类型
+const TypeDingTalk = "dingtalk"
+
+// dingtalkPusher 钉钉群机器人推送（markdown 消息）
+type dingtalkPusher struct {
+	webhook string
+	secret  string
+	http    *HTTPClient
+}
+
+// dingtalkOptions 钉钉推送配置
+type dingtalkOptions struct {
+	Webhook    string `json:"webhook"`     // 机器人 Webhook 地址（含 access_token）
+	SignSecret string `json:"sign_secret"` // 可选，加签密钥（SEC 开头）
+}
+
+// newDingTalkPusher 创建钉钉群机器人推送后端
+func newDingTalkPusher(s *Settings) (Pusher, error) {
+	var opts dingtalkOptions
+	if err := s.Decode(&opts); err != nil {
+		return nil, err
+	}
+	if opts.Webhook == "" {
+		return nil, fmt.Errorf("钉钉推送缺少 webhook")
+	}
+	if _, err := url.Parse(opts.Webhook); err != nil {
+		return nil, fmt.Errorf("钉钉 webhook 无效: %w", err)
+	}
+	return &dingtalkPusher{webhook: opts.Webhook, secret: opts.SignSecret, http: s.HTTP}, nil
+}
+
+// Push 发送 markdown 消息，紧急优先级时 @所有人
+func (p *dingtalkPusher) Push(title, msg string, meta *Meta) error {
+	runes := []rune(msg)
+	if len(runes) > dingtalkMaxLength {
+		msg = string(runes[:dingtalkMaxLength]) + "…"
+	}
+
+	// 钉钉 markdown 中单个换行不会换行，行尾加两个空格
+	var text strings.Builder
+	text.WriteString("### " + title + "\n\n")
+	text.WriteString(strings.ReplaceAll(msg, "\n", "  \n"))
+	if meta != nil && meta.ImageURL != "" {
+		text.WriteString("\n\n![](" + meta.ImageURL + ")")
+	}
+
+	payload, err := json.Marshal(map[string]interface{}{
+		"msgtype": "markdown",
+		"markdown": map[string]string{
+			"title": title,
+			"text":  text.String(),
+		},
+		"at": map[string]bool{
+			"isAtAll": meta != nil && meta.Priority == PriorityUrgent,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("序列化推送消息失败: %w", err)
+	}
+
+	status, body, err := p.http.Post(p.signedURL(time.Now()), "application/json", payload, nil)
+	if err != nil {
+		return err
+	}
+
+	var result struct {
+		ErrCode int    `json:"errcode"`
+		ErrMsg  string `json:"errmsg"`
+	}
+	json.Unmarshal(body, &result)
+	if status == http.StatusOK && result.ErrCode == 0 {
+		return nil
+	}
+	return fmt.Errorf("钉钉推送失败: [%d] %d %s", status, result.ErrCode, result.ErrMsg)
+}
+
+// signedURL 配置了加签密钥时在地址后附加 timestamp 和 sign 参数
+func (p *dingtalkPusher) signedURL(now time.Time) string {
+	if p.secret == "" {
+		return p.webhook
+	}
+	timestamp := strconv.FormatInt(now.UnixMilli(), 10)
+	mac := hmac.New(sha256.New, []byte(p.secret))
+	mac.Write([]byte(timestamp + "\n" + p.secret))
+	sign := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	sep := "?"
+	if strings.Contains(p.webhook, "?") {
+		sep = "&"
+	}
+	return p.webhook + sep + "timestamp=" + timestamp + "&sign=" + url.QueryEscape(sign)
+}
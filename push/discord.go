@@ -0,0 +1,167 @@
+package push
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Discord embed 长度限制
+const (
+	discordTitleLength       = 256
+	discordDescriptionLength = 4096
+	discordFieldLength       = 1024
+	discordEmbedLength       = 6000 // 一条消息中 embed 的标题、描述、字段和页脚合计
+	discordFooterLength      = 16   // 为分段页脚（如 "2/3"）预留
+	discordEmbedColor        = 0x5865F2
+)
+
+func init() {
+	Register(TypeDiscord, newDiscordPusher)
+}
+
+// TypeDiscord Discord Webhook 推送类型
+const TypeDiscord = "discord"
+
+// discordPusher Discord Webhook 推送（embed 消息）
+type discordPusher struct {
+	opts discordOptions
+	http *HTTPClient
+}
+
+// discordOptions Discord 推送配置
+type discordOptions struct {
+	Webhook   string `json:"webhook"`    // Webhook 地址
+	Username  string `json:"username"`   // 可选，覆盖 Webhook 的显示名称
+	AvatarURL string `json:"avatar_url"` // 可选，覆盖 Webhook 的头像
+	Format    string `json:"format"`     // 可选，正文格式 text（默认）/markdown
+}
+
+// newDiscordPusher 创建 Discord 推送后端
+func newDiscordPusher(s *Settings) (Pusher, error) {
+	var opts discordOptions
+	if err := s.Decode(&opts); err != nil {
+		return nil, err
+	}
+	if opts.Webhook == "" {
+		return nil, fmt.Errorf("Discord 推送缺少 webhook")
+	}
+	if _, err := url.Parse(opts.Webhook); err != nil {
+		return nil, fmt.Errorf("Discord webhook 无效: %w", err)
+	}
+	if _, err := parseFormat(opts.Format); err != nil {
+		return nil, err
+	}
+	return &discordPusher{opts: opts, http: s.HTTP}, nil
+}
+
+// Push 发送 embed 消息：标题为主题，正文为描述，发件人/收件人/时间为字段
+// format 为 markdown 时 HTML 正文转为 Markdown；正文超过 4096 字符时拆分为多条消息，字段放在第一条；被限流（429）时按 Retry-After 等待后重试
+func (p *discordPusher) Push(title, msg string, meta *Meta) error {
+	body := msg
+	var fields []map[string]interface{}
+	var timestamp string
+	if meta != nil && meta.Email != nil {
+		email := meta.Email
+		body = messageBody(msg)
+		fields = append(fields, discordField("发件人", email.DisplayFrom()))
+		if len(email.To) > 0 {
+			fields = append(fields, discordField("收件人", strings.Join(email.To, "\n")))
+		}
+		fields = append(fields, discordField("时间", email.Date.Format("2006-01-02 15:04:05")))
+		if !email.Date.IsZero() {
+			timestamp = email.Date.UTC().Format(time.RFC3339)
+		}
+	}
+	if p.opts.Format == formatMarkdown {
+		if md, rest, ok := markdownBody(msg, meta, discordMarkdown); ok {
+			if meta.Email != nil {
+				rest = messageBody(rest)
+			}
+			body = md + escapeDiscord(rest)
+		}
+	}
+
+	// 第一条带标题和字段，正文长度扣除它们，保证合计不超过 6000 字符
+	title = truncateText(title, discordTitleLength)
+	firstLength := discordEmbedLength - discordFooterLength - len([]rune(title))
+	for _, f := range fields {
+		firstLength -= len([]rune(f["name"].(string))) + len([]rune(f["value"].(string)))
+	}
+	if firstLength > discordDescriptionLength {
+		firstLength = discordDescriptionLength
+	}
+	chunks := splitText(strings.TrimSpace(body), firstLength)
+	if len(chunks) > 1 {
+		chunks = append(chunks[:1], splitText(strings.Join(chunks[1:], ""), discordDescriptionLength)...)
+	}
+	for i, chunk := range chunks {
+		embed := map[string]interface{}{"color": discordEmbedColor}
+		if chunk != "" {
+			embed["description"] = chunk
+		}
+		if i == 0 {
+			embed["title"] = title
+			if len(fields) > 0 {
+				embed["fields"] = fields
+			}
+			if meta != nil && meta.ImageURL != "" {
+				embed["image"] = map[string]string{"url": meta.ImageURL}
+			}
+		}
+		if i == len(chunks)-1 && timestamp != "" {
+			embed["timestamp"] = timestamp
+		}
+		if len(chunks) > 1 {
+			embed["footer"] = map[string]string{"text": fmt.Sprintf("%d/%d", i+1, len(chunks))}
+		}
+		if err := p.send(embed); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// send 发送一条包含单个 embed 的消息
+func (p *discordPusher) send(embed map[string]interface{}) error {
+	params := map[string]interface{}{
+		"embeds": []interface{}{embed},
+		// 邮件内容中的 @everyone 等不触发提及
+		"allowed_mentions": map[string]interface{}{"parse": []string{}},
+	}
+	if p.opts.Username != "" {
+		params["username"] = p.opts.Username
+	}
+	if p.opts.AvatarURL != "" {
+		params["avatar_url"] = p.opts.AvatarURL
+	}
+	payload, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("序列化推送消息失败: %w", err)
+	}
+
+	status, body, err := p.http.Post(p.opts.Webhook, "application/json", payload, nil)
+	if err != nil {
+		return err
+	}
+	if status == http.StatusOK || status == http.StatusNoContent {
+		return nil
+	}
+
+	var result struct {
+		Message string `json:"message"`
+	}
+	json.Unmarshal(body, &result)
+	return fmt.Errorf("Discord 推送失败: [%d] %s", status, result.Message)
+}
+
+// discordField embed 字段
+func discordField(name, value string) map[string]interface{} {
+	if value == "" {
+		value = "-"
+	}
+	return map[string]interface{}{"name": name, "value": truncateText(value, discordFieldLength), "inline": true}
+}
@@ -0,0 +1,131 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+func init() {
+	Register(TypeExec, newExecPusher)
+}
+
+// TypeExec 外部命令类型
+const TypeExec = "exec"
+
+// 外部命令的默认超时和并发数
+const (
+	defaultExecTimeout     = 30 * time.Second
+	defaultExecConcurrency = 4
+)
+
+// execWaitDelay 超时结束命令后，等待其子进程关闭输出的最长时间
+const execWaitDelay = 5 * time.Second
+
+// execPusher 每封邮件运行一次外部命令，邮件以 Webhook 默认的 JSON 写入标准输入，
+// 退出码为 0 视为成功；用于不修改程序即可用脚本做任意处理
+type execPusher struct {
+	command string
+	args    []string
+	dir     string
+	env     []string
+	timeout time.Duration
+	slots   chan struct{} // 并发限制
+}
+
+// execOptions 外部命令配置
+type execOptions struct {
+	Command     string            `json:"command"`     // 可执行文件路径或 PATH 中的命令名（不经过 shell）
+	Args        []string          `json:"args"`        // 命令参数
+	Dir         string            `json:"dir"`         // 工作目录，默认为程序的当前目录
+	Env         map[string]string `json:"env"`         // 附加环境变量（继承程序的环境变量）
+	Timeout     int               `json:"timeout"`     // 超时（秒），默认 30
+	Concurrency int               `json:"concurrency"` // 同时运行的命令数，默认 4
+}
+
+// newExecPusher 创建外部命令推送后端
+func newExecPusher(s *Settings) (Pusher, error) {
+	var opts execOptions
+	if err := s.Decode(&opts); err != nil {
+		return nil, err
+	}
+	if opts.Command == "" {
+		return nil, fmt.Errorf("外部命令推送缺少 command")
+	}
+	if _, err := exec.LookPath(opts.Command); err != nil {
+		return nil, fmt.Errorf("外部命令无法执行: %w", err)
+	}
+	if opts.Dir != "" {
+		if info, err := os.Stat(opts.Dir); err != nil || !info.IsDir() {
+			return nil, fmt.Errorf("外部命令的工作目录无效: %s", opts.Dir)
+		}
+	}
+	if opts.Timeout < 0 || opts.Concurrency < 0 {
+		return nil, fmt.Errorf("外部命令的 timeout 和 concurrency 不能为负数")
+	}
+
+	p := &execPusher{
+		command: opts.Command,
+		args:    opts.Args,
+		dir:     opts.Dir,
+		timeout: defaultExecTimeout,
+		slots:   make(chan struct{}, defaultExecConcurrency),
+	}
+	if opts.Timeout > 0 {
+		p.timeout = time.Duration(opts.Timeout) * time.Second
+	}
+	if opts.Concurrency > 0 {
+		p.slots = make(chan struct{}, opts.Concurrency)
+	}
+	if len(opts.Env) > 0 {
+		p.env = os.Environ()
+		for k, v := range opts.Env {
+			p.env = append(p.env, k+"="+v)
+		}
+	}
+	return p, nil
+}
+
+// Push 实现 Pusher：达到并发上限时等待其他命令结束
+func (p *execPusher) Push(title, msg string, meta *Meta) error {
+	if meta == nil {
+		meta = &Meta{}
+	}
+	input, err := marshalPayload(title, msg, meta)
+	if err != nil {
+		return err
+	}
+
+	p.slots <- struct{}{}
+	defer func() { <-p.slots }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, p.command, p.args...)
+	cmd.Dir = p.dir
+	cmd.Env = p.env
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.WaitDelay = execWaitDelay
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+
+	err = cmd.Run()
+	name := filepath.Base(p.command)
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("外部命令 %s 超时（%v）", name, p.timeout)
+	}
+	if err != nil {
+		if s := strings.TrimSpace(out.String()); s != "" {
+			return fmt.Errorf("外部命令 %s 失败: %w: %s", name, err, truncateText(s, 200))
+		}
+		return fmt.Errorf("外部命令 %s 失败: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,201 @@
+package push
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// fanoutMaxTracked 记录部分目标推送失败的邮件数上限，超过时丢弃最早的记录
+const fanoutMaxTracked = 1000
+
+// Target 扇出推送的一个目标
+type Target struct {
+	Name     string
+	Pusher   Pusher
+	RuleOnly bool // 只推送规则指定到该目标的邮件，不作为默认推送目标
+}
+
+// TargetStatus 推送目标的累计结果
+type TargetStatus struct {
+	Name        string     `json:"name"`
+	Sent        uint64     `json:"sent"`
+	Failed      uint64     `json:"failed"`
+	LastError   string     `json:"last_error,omitempty"`
+	LastSuccess *time.Time `json:"last_success,omitempty"`
+}
+
+// Fanout 将每条推送并行发送到多个目标
+// 任一目标失败时返回错误；同一封邮件（按 Message-ID）重试时跳过已经成功的目标，避免重复通知
+type Fanout struct {
+	targets  []Target
+	defaults []int // 默认推送目标（非 RuleOnly）的下标
+
+	mu        sync.Mutex
+	status    []TargetStatus
+	delivered map[string][]bool // Message-ID → 各目标是否已成功，只记录部分失败的邮件
+	order     []string          // delivered 的记录顺序
+}
+
+// NewFanout 创建扇出推送
+func NewFanout(targets []Target) *Fanout {
+	f := &Fanout{
+		targets:   targets,
+		status:    make([]TargetStatus, len(targets)),
+		delivered: make(map[string][]bool),
+	}
+	for i, t := range targets {
+		f.status[i].Name = t.Name
+		if !t.RuleOnly {
+			f.defaults = append(f.defaults, i)
+		}
+	}
+	return f
+}
+
+// Len 返回推送目标数
+func (f *Fanout) Len() int {
+	return len(f.targets)
+}
+
+// HasDefault 是否有默认推送目标
+func (f *Fanout) HasDefault() bool {
+	return len(f.defaults) > 0
+}
+
+// Push 实现 Pusher，推送到所有默认目标
+func (f *Fanout) Push(title, msg string, meta *Meta) error {
+	return f.push(f.defaults, title, msg, meta)
+}
+
+// Select 返回只推送到指定名称目标的 Pusher，与 Fanout 共用各目标的结果统计
+func (f *Fanout) Select(names []string) (Pusher, error) {
+	var idx []int
+	for _, name := range names {
+		i := f.index(name)
+		if i < 0 {
+			var all []string
+			for _, t := range f.targets {
+				all = append(all, t.Name)
+			}
+			return nil, fmt.Errorf("推送目标不存在: %s (可选: %v)", name, all)
+		}
+		idx = append(idx, i)
+	}
+	return &fanoutSelection{f: f, idx: idx}, nil
+}
+
+// index 按名称查找目标下标，不存在时返回 -1
+func (f *Fanout) index(name string) int {
+	for i, t := range f.targets {
+		if t.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// fanoutSelection 推送到 Fanout 中的部分目标
+type fanoutSelection struct {
+	f   *Fanout
+	idx []int
+}
+
+// Push 实现 Pusher
+func (s *fanoutSelection) Push(title, msg string, meta *Meta) error {
+	return s.f.push(s.idx, title, msg, meta)
+}
+
+// push 并行推送到 idx 指定的目标
+func (f *Fanout) push(idx []int, title, msg string, meta *Meta) error {
+	var key string
+	if meta != nil && meta.Email != nil {
+		key = meta.Email.MessageID
+	}
+
+	f.mu.Lock()
+	done := f.delivered[key]
+	f.mu.Unlock()
+
+	errs := make([]error, len(f.targets))
+	var wg sync.WaitGroup
+	for _, i := range idx {
+		if done != nil && done[i] {
+			continue
+		}
+		wg.Add(1)
+		go func(i int, t Target) {
+			defer wg.Done()
+			errs[i] = t.Pusher.Push(title, msg, meta)
+		}(i, f.targets[i])
+	}
+	wg.Wait()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	now := time.Now()
+	ok := make([]bool, len(f.targets))
+	if done != nil {
+		copy(ok, done)
+	}
+	var failed []string
+	for _, i := range idx {
+		st := &f.status[i]
+		switch {
+		case done != nil && done[i]:
+		case errs[i] != nil:
+			st.Failed++
+			st.LastError = errs[i].Error()
+			failed = append(failed, fmt.Sprintf("%s: %v", st.Name, errs[i]))
+		default:
+			ok[i] = true
+			st.Sent++
+			st.LastError = ""
+			st.LastSuccess = &now
+		}
+	}
+
+	if len(failed) == 0 {
+		if done != nil {
+			f.forget(key)
+		}
+		return nil
+	}
+	if key != "" {
+		f.remember(key, ok)
+	}
+	return fmt.Errorf("%d 个推送目标中 %d 个失败: %s", len(idx), len(failed), strings.Join(failed, "; "))
+}
+
+// remember 记录邮件已成功的目标，调用方需持有锁
+func (f *Fanout) remember(key string, ok []bool) {
+	if _, exists := f.delivered[key]; !exists {
+		f.order = append(f.order, key)
+	}
+	f.delivered[key] = ok
+	for len(f.order) > fanoutMaxTracked {
+		delete(f.delivered, f.order[0])
+		f.order = f.order[1:]
+	}
+}
+
+// forget 所有目标都已成功，删除邮件的记录，调用方需持有锁
+func (f *Fanout) forget(key string) {
+	delete(f.delivered, key)
+	for i, k := range f.order {
+		if k == key {
+			f.order = append(f.order[:i], f.order[i+1:]...)
+			break
+		}
+	}
+}
+
+// Status 返回各目标的累计结果
+func (f *Fanout) Status() []TargetStatus {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	result := make([]TargetStatus, len(f.status))
+	copy(result, f.status)
+	return result
+}
@@ -0,0 +1,150 @@
+package push
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// feishuPreviewLength 卡片中正文预览的最大字符数
+const feishuPreviewLength = 2000
+
+// feishuTemplates 推送优先级 → 卡片标题颜色
+var feishuTemplates = map[string]string{
+	PriorityLow:    "grey",
+	PriorityNormal: "blue",
+	PriorityHigh:   "orange",
+	PriorityUrgent: "red",
+}
+
+func init() {
+	Register(TypeFeishu, newFeishuPusher)
+}
+
+// TypeFeishu 飞书 / Lark 自定义机器人推送类型
+const TypeFeishu = "feishu"
+
+// feishuPusher 飞书自定义机器人推送（消息卡片）
+type feishuPusher struct {
+	webhook string
+	secret  string
+	http    *HTTPClient
+}
+
+// feishuOptions 飞书推送配置
+type feishuOptions struct {
+	Webhook    string `json:"webhook"`     // 机器人 Webhook 地址（飞书或 Lark）
+	SignSecret string `json:"sign_secret"` // 可选，签名校验密钥
+}
+
+// newFeishuPusher 创建飞书自定义机器人推送后端
+func newFeishuPusher(s *Settings) (Pusher, error) {
+	var opts feishuOptions
+	if err := s.Decode(&opts); err != nil {
+		return nil, err
+	}
+	if opts.Webhook == "" {
+		return nil, fmt.Errorf("飞书推送缺少 webhook")
+	}
+	if _, err := url.Parse(opts.Webhook); err != nil {
+		return nil, fmt.Errorf("飞书 webhook 无效: %w", err)
+	}
+	return &feishuPusher{webhook: opts.Webhook, secret: opts.SignSecret, http: s.HTTP}, nil
+}
+
+// Push 发送消息卡片：标题为主题，发件人和时间为并排字段，正文为预览（超长截断）
+func (p *feishuPusher) Push(title, msg string, meta *Meta) error {
+	template := feishuTemplates[PriorityNormal]
+	body := msg
+	var elements []interface{}
+	if meta != nil {
+		if t, ok := feishuTemplates[meta.Priority]; ok {
+			template = t
+		}
+		if email := meta.Email; email != nil {
+			body = messageBody(msg)
+			elements = append(elements, map[string]interface{}{
+				"tag": "div",
+				"fields": []interface{}{
+					feishuField("发件人", email.DisplayFrom()),
+					feishuField("时间", email.Date.Format("2006-01-02 15:04:05")),
+				},
+			})
+		}
+	}
+	if body = strings.TrimSpace(body); body != "" {
+		elements = append(elements, map[string]interface{}{
+			"tag":  "div",
+			"text": feishuText("plain_text", truncateText(body, feishuPreviewLength)),
+		})
+	}
+
+	params := map[string]interface{}{
+		"msg_type": "interactive",
+		"card": map[string]interface{}{
+			"config": map[string]bool{"wide_screen_mode": true},
+			"header": map[string]interface{}{
+				"title":    feishuText("plain_text", title),
+				"template": template,
+			},
+			"elements": elements,
+		},
+	}
+	if p.secret != "" {
+		timestamp, sign := p.sign(time.Now())
+		params["timestamp"] = timestamp
+		params["sign"] = sign
+	}
+
+	payload, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("序列化推送消息失败: %w", err)
+	}
+
+	status, resp, err := p.http.Post(p.webhook, "application/json", payload, nil)
+	if err != nil {
+		return err
+	}
+
+	var result struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+	}
+	json.Unmarshal(resp, &result)
+	if status == http.StatusOK && result.Code == 0 {
+		return nil
+	}
+	return fmt.Errorf("飞书推送失败: [%d] %d %s", status, result.Code, result.Msg)
+}
+
+// sign 计算签名：以 "timestamp\nsecret" 为密钥对空字符串做 HMAC-SHA256
+func (p *feishuPusher) sign(now time.Time) (timestamp, sign string) {
+	timestamp = strconv.FormatInt(now.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(timestamp+"\n"+p.secret))
+	return timestamp, base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// feishuText 卡片文本对象
+func feishuText(tag, content string) map[string]string {
+	return map[string]string{"tag": tag, "content": content}
+}
+
+// feishuField 卡片中并排显示的字段
+func feishuField(name, value string) map[string]interface{} {
+	return map[string]interface{}{
+		"is_short": true,
+		"text":     feishuText("lark_md", "**"+name+"**\n"+escapeLarkMD(value)),
+	}
+}
+
+// escapeLarkMD 转义 lark_md 中会被解析的字符
+func escapeLarkMD(s string) string {
+	return strings.NewReplacer("<", "&lt;", ">", "&gt;", "*", "\\*", "_", "\\_", "~", "\\~", "[", "\\[", "]", "\\]").Replace(s)
+}
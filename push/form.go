@@ -0,0 +1,52 @@
+package push
+
+import (
+	"fmt"
+	"net/url"
+)
+
+func init() {
+	Register(TypeForm, newFormPusher)
+}
+
+// TypeForm 表单 Webhook 推送类型
+const TypeForm = "form"
+
+// formPusher 表单 Webhook 推送：向推送地址 POST title/msg 表单
+type formPusher struct {
+	url  string
+	http *HTTPClient
+}
+
+// formOptions 表单推送配置
+type formOptions struct {
+	URL string `json:"url"` // 推送地址
+}
+
+// newFormPusher 创建表单推送后端，未配置推送地址时返回nil（不推送）
+func newFormPusher(s *Settings) (Pusher, error) {
+	var opts formOptions
+	if err := s.Decode(&opts); err != nil {
+		return nil, err
+	}
+	if opts.URL == "" {
+		return nil, nil
+	}
+	return &formPusher{url: opts.URL, http: s.HTTP}, nil
+}
+
+// Push 实现 Pusher
+func (p *formPusher) Push(title, msg string, meta *Meta) error {
+	formData := url.Values{}
+	formData.Set("title", title)
+	formData.Set("msg", msg)
+
+	status, _, err := p.http.Post(p.url, "application/x-www-form-urlencoded", []byte(formData.Encode()), nil)
+	if err != nil {
+		return err
+	}
+	if status != 200 {
+		return fmt.Errorf("推送响应状态码 %d", status)
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package push
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// gotifyPriorities 推送优先级 → Gotify 优先级（0-10，Android 客户端 8 以上弹出通知并响铃）
+var gotifyPriorities = map[string]int{
+	PriorityLow:    2,
+	PriorityNormal: 5,
+	PriorityHigh:   8,
+	PriorityUrgent: 10,
+}
+
+func init() {
+	Register(TypeGotify, newGotifyPusher)
+}
+
+// TypeGotify Gotify 推送类型
+const TypeGotify = "gotify"
+
+// gotifyPusher Gotify 推送（自建服务器）
+type gotifyPusher struct {
+	opts gotifyOptions
+	http *HTTPClient
+}
+
+// gotifyOptions Gotify 推送配置
+type gotifyOptions struct {
+	Server   string `json:"server"`   // 服务器地址
+	Token    string `json:"token"`    // 应用令牌
+	Priority *int   `json:"priority"` // 可选，默认优先级（0-10），未设置时使用应用的默认优先级，规则优先级可覆盖
+}
+
+// newGotifyPusher 创建 Gotify 推送后端
+func newGotifyPusher(s *Settings) (Pusher, error) {
+	var opts gotifyOptions
+	if err := s.Decode(&opts); err != nil {
+		return nil, err
+	}
+	if opts.Server == "" || opts.Token == "" {
+		return nil, fmt.Errorf("Gotify 推送缺少 server 或 token")
+	}
+	if p := opts.Priority; p != nil && (*p < 0 || *p > 10) {
+		return nil, fmt.Errorf("Gotify 优先级无效: %d (可选: 0-10)", *p)
+	}
+	opts.Server = strings.TrimRight(opts.Server, "/")
+	return &gotifyPusher{opts: opts, http: s.HTTP}, nil
+}
+
+// Push 实现 Pusher
+func (p *gotifyPusher) Push(title, msg string, meta *Meta) error {
+	params := map[string]interface{}{
+		"title":   title,
+		"message": msg,
+	}
+
+	priority := p.opts.Priority
+	if meta != nil {
+		if n, ok := gotifyPriorities[meta.Priority]; ok {
+			priority = &n
+		}
+		if meta.ImageURL != "" {
+			params["extras"] = map[string]interface{}{
+				"client::notification": map[string]string{"bigImageUrl": meta.ImageURL},
+			}
+		}
+	}
+	if priority != nil {
+		params["priority"] = *priority
+	}
+
+	payload, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("序列化推送消息失败: %w", err)
+	}
+
+	// 令牌放在请求头中，避免出现在URL和错误信息里
+	header := http.Header{}
+	header.Set("X-Gotify-Key", p.opts.Token)
+	status, body, err := p.http.Post(p.opts.Server+"/message", "application/json", payload, header)
+	if err != nil {
+		return err
+	}
+	if status == http.StatusOK {
+		return nil
+	}
+
+	var result struct {
+		Error       string `json:"error"`
+		Description string `json:"errorDescription"`
+	}
+	json.Unmarshal(body, &result)
+	return fmt.Errorf("Gotify 推送失败: [%d] %s %s", status, result.Error, result.Description)
+}
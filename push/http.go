@@ -0,0 +1,138 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"mail-receiver/clock"
+)
+
+// HTTPClient 推送后端共用的HTTP客户端：请求体签名、429/503 限流处理、双向TLS
+type HTTPClient struct {
+	accountName string
+	client      *http.Client
+	signer      *signer // 可选，请求体签名
+	throttle    throttle
+	ctx         context.Context // 取消时不再等待限流结束
+	clock       clock.Clock     // 限流等待的时间来源
+
+	// 推送目标要求的附加请求头、Basic 认证和内容类型，覆盖后端的设置
+	header      http.Header
+	username    string
+	password    string
+	contentType string
+}
+
+// NewHTTPClient 创建推送HTTP客户端
+func NewHTTPClient(accountName string) *HTTPClient {
+	return &HTTPClient{
+		accountName: accountName,
+		client: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+		ctx:   context.Background(),
+		clock: clock.Real,
+	}
+}
+
+// SetWait 设置限流等待的取消信号和时间来源：ctx 取消（如程序退出）时不再等待，推送返回错误
+func (h *HTTPClient) SetWait(ctx context.Context, clk clock.Clock) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	h.ctx, h.clock = ctx, clock.Or(clk)
+}
+
+// SetSignature 设置 HMAC-SHA256 签名密钥和请求头名称（为空时使用默认请求头），timestamp 为 true 时签名包含时间戳
+func (h *HTTPClient) SetSignature(secret, header string, timestamp bool) {
+	h.signer = newSigner(secret, header, timestamp)
+}
+
+// SetHeaders 设置每个请求附加的请求头和内容类型（为空时使用后端的设置）
+func (h *HTTPClient) SetHeaders(headers map[string]string, contentType string) {
+	h.header = http.Header{}
+	for k, v := range headers {
+		h.header.Set(k, v)
+	}
+	h.contentType = contentType
+}
+
+// SetBasicAuth 设置 Basic 认证，用户名为空时不启用
+func (h *HTTPClient) SetBasicAuth(username, password string) {
+	h.username, h.password = username, password
+}
+
+// Post 发送POST请求，被限流时按 Retry-After 等待后重试，返回状态码和响应体
+func (h *HTTPClient) Post(url, contentType string, body []byte, header http.Header) (int, []byte, error) {
+	return h.Do(http.MethodPost, url, contentType, body, header)
+}
+
+// Do 使用指定方法发送请求，其余与 Post 相同
+func (h *HTTPClient) Do(method, url, contentType string, body []byte, header http.Header) (int, []byte, error) {
+	for attempt := 0; ; attempt++ {
+		if err := h.throttle.wait(h.ctx, h.clock); err != nil {
+			return 0, nil, fmt.Errorf("等待限流结束时取消: %w", err)
+		}
+
+		req, err := http.NewRequest(method, url, bytes.NewReader(body))
+		if err != nil {
+			return 0, nil, fmt.Errorf("创建推送请求失败: %w", err)
+		}
+		req.Header.Set("Content-Type", contentType)
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		h.applyHeaders(req)
+		h.signer.apply(req, body)
+
+		resp, err := h.client.Do(req)
+		if err != nil {
+			return 0, nil, fmt.Errorf("推送请求失败: %w", redactURLError(err))
+		}
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+		resp.Body.Close()
+
+		if _, throttled := h.throttle.check(resp, h.accountName, h.clock.Now()); throttled && attempt < maxThrottleRetries {
+			continue
+		}
+		return resp.StatusCode, respBody, nil
+	}
+}
+
+// redactURLError 去掉请求错误中URL的路径和查询参数，令牌常放在其中（如 Telegram 的 /bot<token>/、钉钉的 access_token）
+// 返回的 *url.Error 保留原错误，仍可判断超时等原因
+func redactURLError(err error) error {
+	var uerr *url.Error
+	if !errors.As(err, &uerr) {
+		return err
+	}
+	redacted := "(已隐藏)"
+	if u, perr := url.Parse(uerr.URL); perr == nil && u.Host != "" {
+		redacted = u.Scheme + "://" + u.Host + "/…"
+	}
+	return &url.Error{Op: uerr.Op, URL: redacted, Err: uerr.Err}
+}
+
+// ThrottledCount 返回累计收到限流响应（429/503）的次数
+func (h *HTTPClient) ThrottledCount() uint64 {
+	return h.throttle.count()
+}
+
+// applyHeaders 应用推送目标配置的请求头、Basic 认证和内容类型
+func (h *HTTPClient) applyHeaders(req *http.Request) {
+	for k, v := range h.header {
+		req.Header[k] = v
+	}
+	if h.username != "" {
+		req.SetBasicAuth(h.username, h.password)
+	}
+	if h.contentType != "" {
+		req.Header.Set("Content-Type", h.contentType)
+	}
+}
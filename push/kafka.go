@@ -0,0 +1,107 @@
+package push
+
+import (
+	"crypto/tls"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"mail-receiver/kafka"
+)
+
+func init() {
+	Register(TypeKafka, newKafkaPusher)
+}
+
+// TypeKafka Kafka 写入类型
+const TypeKafka = "kafka"
+
+// kafkaPusher 将邮件以 JSON 记录写入 Kafka 主题，key 为 Message-ID
+type kafkaPusher struct {
+	producer *kafka.Producer
+	topic    *template.Template
+}
+
+// kafkaOptions Kafka 推送配置
+type kafkaOptions struct {
+	Brokers  []string `json:"brokers"`  // broker 地址（host:port）
+	Topic    string   `json:"topic"`    // 主题（可使用 text/template 语法，如 mail.{{.Account}}）
+	Acks     *int     `json:"acks"`     // -1 所有同步副本确认（默认）/ 1 只等待 leader / 0 不等待
+	Security string   `json:"security"` // none（默认）/ tls
+	SASL     struct {
+		Mechanism string `json:"mechanism"` // PLAIN / SCRAM-SHA-256 / SCRAM-SHA-512
+		Username  string `json:"username"`
+		Password  string `json:"password"`
+	} `json:"sasl"`
+}
+
+// newKafkaPusher 创建 Kafka 推送后端
+func newKafkaPusher(s *Settings) (Pusher, error) {
+	var opts kafkaOptions
+	if err := s.Decode(&opts); err != nil {
+		return nil, err
+	}
+	if len(opts.Brokers) == 0 || opts.Topic == "" {
+		return nil, fmt.Errorf("Kafka 推送缺少 brokers 或 topic")
+	}
+	topic, err := template.New("topic").Option("missingkey=error").Parse(opts.Topic)
+	if err != nil {
+		return nil, fmt.Errorf("Kafka 主题模板无效: %w", err)
+	}
+
+	acks := -1
+	if opts.Acks != nil {
+		acks = *opts.Acks
+	}
+	var tlsConfig *tls.Config
+	switch opts.Security {
+	case "", "none":
+	case "tls":
+		// 客户端证书和 CA 使用 push.tls 中的设置
+		if tlsConfig = s.HTTP.TLSConfig(); tlsConfig == nil {
+			tlsConfig = &tls.Config{}
+		}
+	default:
+		return nil, fmt.Errorf("Kafka security 无效: %s (可选: none/tls)", opts.Security)
+	}
+
+	producer, err := kafka.NewProducer(kafka.Options{
+		Brokers:  opts.Brokers,
+		ClientID: "mail-receiver-" + s.AccountName,
+		TLS:      tlsConfig,
+		SASL: kafka.SASL{
+			Mechanism: opts.SASL.Mechanism,
+			Username:  opts.SASL.Username,
+			Password:  opts.SASL.Password,
+		},
+		Acks: int16(acks),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &kafkaPusher{producer: producer, topic: topic}, nil
+}
+
+// Push 实现 Pusher
+func (p *kafkaPusher) Push(title, msg string, meta *Meta) error {
+	if meta == nil {
+		meta = &Meta{}
+	}
+	var topic strings.Builder
+	if err := p.topic.Execute(&topic, newWebhookData(title, msg, meta)); err != nil {
+		return fmt.Errorf("生成 Kafka 主题失败: %w", err)
+	}
+	value, err := marshalPayload(title, msg, meta)
+	if err != nil {
+		return err
+	}
+
+	// 以 Message-ID 为 key，同一封邮件总是写入同一分区；系统告警等没有 key
+	var key []byte
+	if email := meta.Email; email != nil && email.MessageID != "" {
+		key = []byte(email.MessageID)
+	}
+	// 记录时间使用处理时间，邮件的 Date 可能与当前相差很大而被 broker 拒绝
+	return p.producer.Send(topic.String(), key, value, time.Now())
+}
@@ -0,0 +1,91 @@
+package push
+
+import (
+	"fmt"
+	"strings"
+
+	"mail-receiver/content"
+)
+
+// 推送正文格式（Telegram、Slack、Discord 的 format 选项）
+const (
+	formatText     = "text"     // 纯文本（默认）
+	formatMarkdown = "markdown" // HTML 正文转为渠道的 Markdown（粗体、链接、列表）
+)
+
+// RichText 推送正文的 HTML 来源：推送内容以 Text 开头，Markdown 格式的推送目标将这部分替换为 HTML 转换的 Markdown
+type RichText struct {
+	Text string // 推送内容开头的纯文本正文
+	HTML string
+}
+
+// parseFormat 检查正文格式，返回是否使用 Markdown
+func parseFormat(format string) (bool, error) {
+	switch format {
+	case "", formatText:
+		return false, nil
+	case formatMarkdown:
+		return true, nil
+	}
+	return false, fmt.Errorf("正文格式无效: %s (可选: text/markdown)", format)
+}
+
+// markdownBody 将推送内容拆分为 Markdown 正文和其余部分（附件提示、收件信息，未转义），没有 HTML 来源时 ok 为false
+func markdownBody(msg string, meta *Meta, style content.MarkdownStyle) (body, rest string, ok bool) {
+	if meta == nil || meta.Rich == nil || meta.Rich.HTML == "" || !strings.HasPrefix(msg, meta.Rich.Text) {
+		return "", "", false
+	}
+	body = content.HTMLToMarkdown(meta.Rich.HTML, style)
+	if body == "" {
+		return "", "", false
+	}
+	return body, msg[len(meta.Rich.Text):], true
+}
+
+// telegramMarkdown Telegram MarkdownV2
+var telegramMarkdown = content.MarkdownStyle{
+	Escape: escapeMarkdownV2,
+	Bold:   func(s string) string { return "*" + s + "*" },
+	Italic: func(s string) string { return "_" + s + "_" },
+	Code:   func(s string) string { return "`" + s + "`" },
+	Link: func(text, href string) string {
+		return "[" + text + "](" + strings.NewReplacer(`\`, `\\`, `)`, `\)`).Replace(href) + ")"
+	},
+}
+
+// slackMarkdown Slack mrkdwn
+var slackMarkdown = content.MarkdownStyle{
+	Escape: escapeSlack,
+	Bold:   func(s string) string { return "*" + s + "*" },
+	Italic: func(s string) string { return "_" + s + "_" },
+	Code:   func(s string) string { return "`" + s + "`" },
+	Link: func(text, href string) string {
+		return "<" + strings.NewReplacer("|", "%7C", ">", "%3E").Replace(href) + "|" + text + ">"
+	},
+}
+
+// discordMarkdown Discord（embed 描述支持 Markdown 和带文字的链接）
+var discordMarkdown = content.MarkdownStyle{
+	Escape: escapeDiscord,
+	Bold:   func(s string) string { return "**" + s + "**" },
+	Italic: func(s string) string { return "*" + s + "*" },
+	Code:   func(s string) string { return "`" + s + "`" },
+	Link: func(text, href string) string {
+		return "[" + text + "](" + strings.NewReplacer(")", "%29", " ", "%20").Replace(href) + ")"
+	},
+}
+
+// discordSpecial Discord Markdown 中需要转义的字符
+const discordSpecial = "\\*_~`|>[]#"
+
+// escapeDiscord 转义 Discord Markdown 特殊字符
+func escapeDiscord(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(discordSpecial, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
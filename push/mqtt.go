@@ -0,0 +1,105 @@
+package push
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"mail-receiver/mqtt"
+)
+
+func init() {
+	Register(TypeMQTT, newMQTTPusher)
+}
+
+// TypeMQTT MQTT 发布类型
+const TypeMQTT = "mqtt"
+
+// defaultMQTTTopic 默认主题模板
+const defaultMQTTTopic = "mail-receiver/{{.Account}}"
+
+// mqttPusher 将邮件以 JSON 发布到 MQTT 代理（如 Home Assistant 使用的 Mosquitto），每次推送建立一个连接
+type mqttPusher struct {
+	opts   mqtt.Options // ClientID 为前缀，每个连接加上随机后缀
+	topic  *template.Template
+	qos    byte
+	retain bool
+}
+
+// mqttOptions MQTT 推送配置
+type mqttOptions struct {
+	Broker   string `json:"broker"`    // mqtt://host:1883 或 mqtts://host:8883
+	Topic    string `json:"topic"`     // 主题模板（text/template 语法），默认 mail-receiver/{{.Account}}
+	QoS      int    `json:"qos"`       // 0（默认）/ 1 / 2
+	Retain   bool   `json:"retain"`    // 代理保留最后一条消息
+	ClientID string `json:"client_id"` // 客户端ID前缀，默认 mail-receiver-<账号>，每个连接加上随机后缀
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// newMQTTPusher 创建 MQTT 推送后端
+func newMQTTPusher(s *Settings) (Pusher, error) {
+	var opts mqttOptions
+	if err := s.Decode(&opts); err != nil {
+		return nil, err
+	}
+	if opts.Broker == "" {
+		return nil, fmt.Errorf("MQTT 推送缺少 broker")
+	}
+	if opts.QoS < 0 || opts.QoS > 2 {
+		return nil, fmt.Errorf("MQTT QoS 无效: %d (可选: 0/1/2)", opts.QoS)
+	}
+	if opts.Topic == "" {
+		opts.Topic = defaultMQTTTopic
+	}
+	topic, err := template.New("topic").Option("missingkey=error").Parse(opts.Topic)
+	if err != nil {
+		return nil, fmt.Errorf("MQTT 主题模板无效: %w", err)
+	}
+	if opts.ClientID == "" {
+		opts.ClientID = "mail-receiver-" + s.AccountName
+	}
+
+	return &mqttPusher{
+		opts: mqtt.Options{
+			Broker:   opts.Broker,
+			ClientID: opts.ClientID,
+			Username: opts.Username,
+			Password: opts.Password,
+			TLS:      s.HTTP.TLSConfig(),
+		},
+		topic:  topic,
+		qos:    byte(opts.QoS),
+		retain: opts.Retain,
+	}, nil
+}
+
+// Push 实现 Pusher
+func (p *mqttPusher) Push(title, msg string, meta *Meta) error {
+	if meta == nil {
+		meta = &Meta{}
+	}
+	var topic strings.Builder
+	if err := p.topic.Execute(&topic, newWebhookData(title, msg, meta)); err != nil {
+		return fmt.Errorf("生成 MQTT 主题失败: %w", err)
+	}
+	payload, err := marshalPayload(title, msg, meta)
+	if err != nil {
+		return err
+	}
+
+	// 并发推送（重试、多个文件夹）时各连接的客户端ID不能相同，否则代理会断开先建立的连接
+	opts := p.opts
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	opts.ClientID += "-" + hex.EncodeToString(suffix)
+
+	c, err := mqtt.Dial(opts)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	return c.Publish(topic.String(), payload, p.qos, p.retain)
+}
@@ -0,0 +1,167 @@
+package push
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"text/template"
+)
+
+// defaultNtfyServer ntfy 官方服务器
+const defaultNtfyServer = "https://ntfy.sh"
+
+// ntfyPriorities 推送优先级 → ntfy 优先级（1 最低，5 最高）
+var ntfyPriorities = map[string]int{
+	PriorityLow:    2,
+	PriorityNormal: 3,
+	PriorityHigh:   4,
+	PriorityUrgent: 5,
+}
+
+func init() {
+	Register(TypeNtfy, newNtfyPusher)
+}
+
+// TypeNtfy ntfy 推送类型
+const TypeNtfy = "ntfy"
+
+// ntfyPusher ntfy 推送（ntfy.sh 或自建服务器）
+type ntfyPusher struct {
+	opts  ntfyOptions
+	click *template.Template
+	http  *HTTPClient
+}
+
+// ntfyOptions ntfy 推送配置
+type ntfyOptions struct {
+	Server   string   `json:"server"`   // 可选，自建服务器地址
+	Topic    string   `json:"topic"`    // 主题
+	Token    string   `json:"token"`    // 可选，访问令牌（tk_ 开头）
+	Username string   `json:"username"` // 可选，用户名密码认证（与 token 二选一）
+	Password string   `json:"password"`
+	Priority int      `json:"priority"` // 可选，默认优先级（1-5），规则优先级可覆盖
+	Tags     []string `json:"tags"`     // 可选，附加标签（ntfy 会把 emoji 简码显示为图标）
+	Click    string   `json:"click"`    // 可选，点击通知打开的地址模板（如 "https://mail.example.com/#search/{{urlquery .MessageID}}"）
+}
+
+// ntfyClickData 点击地址模板可用的字段
+type ntfyClickData struct {
+	Account   string
+	Folder    string
+	Subject   string
+	From      string
+	MessageID string
+	UID       uint32
+}
+
+// newNtfyPusher 创建 ntfy 推送后端
+func newNtfyPusher(s *Settings) (Pusher, error) {
+	var opts ntfyOptions
+	if err := s.Decode(&opts); err != nil {
+		return nil, err
+	}
+	if opts.Topic == "" {
+		return nil, fmt.Errorf("ntfy 推送缺少 topic")
+	}
+	if opts.Priority < 0 || opts.Priority > 5 {
+		return nil, fmt.Errorf("ntfy 优先级无效: %d (可选: 1-5)", opts.Priority)
+	}
+	if opts.Server == "" {
+		opts.Server = defaultNtfyServer
+	}
+	opts.Server = strings.TrimRight(opts.Server, "/")
+
+	p := &ntfyPusher{opts: opts, http: s.HTTP}
+	if opts.Click != "" {
+		t, err := template.New("click").Option("missingkey=error").Parse(opts.Click)
+		if err != nil {
+			return nil, fmt.Errorf("ntfy 点击地址模板无效: %w", err)
+		}
+		p.click = t
+	}
+	return p, nil
+}
+
+// Push 实现 Pusher
+func (p *ntfyPusher) Push(title, msg string, meta *Meta) error {
+	params := map[string]interface{}{
+		"topic":   p.opts.Topic,
+		"title":   title,
+		"message": msg,
+	}
+
+	priority := p.opts.Priority
+	tags := append([]string(nil), p.opts.Tags...)
+	if meta != nil {
+		if n, ok := ntfyPriorities[meta.Priority]; ok {
+			priority = n
+		}
+		if meta.ImageURL != "" {
+			params["attach"] = meta.ImageURL
+		}
+		if meta.Email != nil {
+			tags = append(tags, meta.Email.Tags...)
+			if click := p.clickURL(meta); click != "" {
+				params["click"] = click
+			} else if meta.ViewURL != "" {
+				params["click"] = meta.ViewURL
+			}
+		}
+	}
+	if priority > 0 {
+		params["priority"] = priority
+	}
+	if len(tags) > 0 {
+		params["tags"] = tags
+	}
+
+	payload, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("序列化推送消息失败: %w", err)
+	}
+
+	header := http.Header{}
+	if p.opts.Token != "" {
+		header.Set("Authorization", "Bearer "+p.opts.Token)
+	} else if p.opts.Username != "" {
+		auth := base64.StdEncoding.EncodeToString([]byte(p.opts.Username + ":" + p.opts.Password))
+		header.Set("Authorization", "Basic "+auth)
+	}
+
+	status, body, err := p.http.Post(p.opts.Server, "application/json", payload, header)
+	if err != nil {
+		return err
+	}
+	if status == http.StatusOK {
+		return nil
+	}
+
+	var result struct {
+		Error string `json:"error"`
+	}
+	json.Unmarshal(body, &result)
+	return fmt.Errorf("ntfy 推送失败: [%d] %s", status, result.Error)
+}
+
+// clickURL 按模板生成点击地址，生成失败时不附带地址
+func (p *ntfyPusher) clickURL(meta *Meta) string {
+	if p.click == nil || meta.Email == nil {
+		return ""
+	}
+	email := meta.Email
+	var b strings.Builder
+	err := p.click.Execute(&b, &ntfyClickData{
+		Account:   meta.Account,
+		Folder:    meta.Folder,
+		Subject:   email.Subject,
+		From:      email.FromAddress,
+		MessageID: email.MessageID,
+		UID:       email.UID,
+	})
+	if err != nil {
+		return ""
+	}
+	return b.String()
+}
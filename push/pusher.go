@@ -2,69 +2,127 @@ package push
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
-	"net/http"
-	"net/url"
-	"time"
+	"sort"
+	"strings"
+	"sync"
+
+	"mail-receiver/imap"
 )
 
-// Pusher 推送器
-type Pusher struct {
-	url         string
-	accountName string
-	client      *http.Client
+// Meta 推送附加信息，供各推送后端按需使用
+type Meta struct {
+	Account  string
+	Folder   string
+	Email    *imap.EmailMessage // 关联的邮件，系统告警等非邮件推送时为nil
+	Sound    string             // 可选，通知铃声（如 Bark 的 sound），不支持的后端忽略
+	Priority string             // 可选，通知优先级（low/normal/high/urgent），由各后端映射为自己的级别
+	ImageURL string             // 可选，图片预览地址（如附件缩略图），支持图片的后端附在通知中
+	ViewURL  string             // 可选，网页查看完整邮件的地址，支持点击跳转的后端作为通知链接
+	Code     string             // 可选，从邮件中提取的验证码，支持复制的后端（如 Bark）可一键复制
+	Rich     *RichText          // 可选，正文的 HTML 来源，format 为 markdown 的后端转换后代替纯文本正文
 }
 
-// NewPusher 创建新的推送器
-func NewPusher(url string, accountName string) *Pusher {
-	return &Pusher{
-		url:         url,
-		accountName: accountName,
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+// 推送优先级
+const (
+	PriorityLow    = "low"
+	PriorityNormal = "normal"
+	PriorityHigh   = "high"
+	PriorityUrgent = "urgent"
+)
+
+// ValidPriority 检查优先级是否有效（空值表示使用后端默认）
+func ValidPriority(p string) bool {
+	switch p {
+	case "", PriorityLow, PriorityNormal, PriorityHigh, PriorityUrgent:
+		return true
 	}
+	return false
 }
 
-// Push 推送邮件信息
-func (p *Pusher) Push(title, msg string) (bool, error) {
-	if p.url == "" {
-		return false, nil
-	}
+// Pusher 推送后端
+type Pusher interface {
+	// Push 发送推送，成功返回nil
+	Push(title, msg string, meta *Meta) error
+}
 
-	// 构建表单数据
-	formData := url.Values{}
-	formData.Set("title", title)
-	formData.Set("msg", msg)
+// Settings 创建推送后端所需的配置
+type Settings struct {
+	AccountName string
+	Raw         json.RawMessage // 完整的 push 配置块，各后端从中解析自己的字段
+	HTTP        *HTTPClient     // 共用的HTTP客户端（签名、限流、TLS）
+}
 
-	// 发送POST请求（表单格式）
-	resp, err := p.client.PostForm(p.url, formData)
-	if err != nil {
-		return false, fmt.Errorf("推送请求失败: %w", err)
+// Decode 将 push 配置块解析到后端自己的配置结构
+func (s *Settings) Decode(v interface{}) error {
+	if len(s.Raw) == 0 {
+		return nil
 	}
-	defer resp.Body.Close()
+	if err := json.Unmarshal(s.Raw, v); err != nil {
+		return fmt.Errorf("解析推送配置失败: %w", err)
+	}
+	return nil
+}
+
+// Factory 推送后端构造函数
+type Factory func(s *Settings) (Pusher, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Factory)
+)
+
+// Register 注册推送后端，name 对应配置中的 push.type
+func Register(name string, f Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[name] = f
+}
 
-	// 检查状态码
-	if resp.StatusCode == 200 {
-		return true, nil
+// New 按类型创建推送后端
+func New(kind string, s *Settings) (Pusher, error) {
+	registryMu.RLock()
+	f, ok := registry[kind]
+	registryMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("不支持的推送类型: %s (可选: %v)", kind, Types())
 	}
+	return f(s)
+}
 
-	return false, nil
+// Types 返回已注册的推送类型
+func Types() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	var names []string
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
 }
 
 // BuildMessageContent 构建推送消息内容
-func BuildMessageContent(body, receiveTime, from string, to []string, hasAttachments bool) string {
+func BuildMessageContent(body, receiveTime, from string, to []string, hasAttachments bool, savedAttachments []string) string {
 	var content bytes.Buffer
 
 	content.WriteString(body)
 
-	// 如果有附件，在正文后追加提示
-	if hasAttachments {
+	// 如果有附件，在正文后追加已保存的路径或提示
+	if len(savedAttachments) > 0 {
+		if body != "" {
+			content.WriteString("\n\n")
+		}
+		content.WriteString("附件已保存:")
+		for _, path := range savedAttachments {
+			content.WriteString("\n" + path)
+		}
+	} else if hasAttachments {
 		content.WriteString("\n\n该邮件含有附件，请手动查看")
 	}
 
-	content.WriteString("\n\n")
-	content.WriteString("----------------------------\n")
+	content.WriteString(footerSeparator)
 	content.WriteString(fmt.Sprintf("收件时间: %s\n", receiveTime))
 	content.WriteString(fmt.Sprintf("发件人: %s\n", from))
 
@@ -77,3 +135,23 @@ func BuildMessageContent(body, receiveTime, from string, to []string, hasAttachm
 
 	return content.String()
 }
+
+// footerSeparator 正文与收件信息（时间、发件人、收件人）之间的分隔线
+const footerSeparator = "\n\n----------------------------\n"
+
+// messageBody 去掉 BuildMessageContent 追加的收件信息，供单独展示发件人和时间的后端使用
+func messageBody(msg string) string {
+	if i := strings.LastIndex(msg, footerSeparator); i >= 0 {
+		return msg[:i]
+	}
+	return msg
+}
+
+// truncateText 超过 limit 个字符时截断并加上省略号
+func truncateText(s string, limit int) string {
+	runes := []rune(s)
+	if len(runes) <= limit {
+		return s
+	}
+	return string(runes[:limit-1]) + "…"
+}
@@ -0,0 +1,131 @@
+package push
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Pushover 优先级
+const (
+	pushoverLowest    = -2
+	pushoverEmergency = 2 // 紧急：重复提醒直到用户确认或过期
+)
+
+// pushoverPriorities 推送优先级 → Pushover 优先级（-2 至 2）
+var pushoverPriorities = map[string]int{
+	PriorityLow:    -1,
+	PriorityNormal: 0,
+	PriorityHigh:   1,
+	PriorityUrgent: pushoverEmergency,
+}
+
+// Pushover 消息长度限制
+const (
+	pushoverTitleLimit   = 250
+	pushoverMessageLimit = 1024
+)
+
+func init() {
+	Register(TypePushover, newPushoverPusher)
+}
+
+// TypePushover Pushover 推送类型
+const TypePushover = "pushover"
+
+// pushoverAPI Pushover 消息接口
+const pushoverAPI = "https://api.pushover.net/1/messages.json"
+
+// pushoverPusher Pushover 推送
+type pushoverPusher struct {
+	opts pushoverOptions
+	http *HTTPClient
+}
+
+// pushoverOptions Pushover 推送配置
+type pushoverOptions struct {
+	User     string `json:"user"`     // 用户或群组 key
+	Token    string `json:"token"`    // 应用 API token
+	Device   string `json:"device"`   // 可选，只推送到指定设备（多个用逗号分隔）
+	Priority *int   `json:"priority"` // 可选，默认优先级（-2 至 2），规则优先级可覆盖
+	Sound    string `json:"sound"`    // 可选，默认提示音，规则铃声可覆盖
+	Retry    int    `json:"retry"`    // 紧急优先级的重复提醒间隔（秒，最少 30）
+	Expire   int    `json:"expire"`   // 紧急优先级的提醒持续时间（秒，最多 10800）
+}
+
+// newPushoverPusher 创建 Pushover 推送后端
+func newPushoverPusher(s *Settings) (Pusher, error) {
+	var opts pushoverOptions
+	if err := s.Decode(&opts); err != nil {
+		return nil, err
+	}
+	if opts.User == "" || opts.Token == "" {
+		return nil, fmt.Errorf("Pushover 推送缺少 user 或 token")
+	}
+	if p := opts.Priority; p != nil && (*p < pushoverLowest || *p > pushoverEmergency) {
+		return nil, fmt.Errorf("Pushover 优先级无效: %d (可选: -2 至 2)", *p)
+	}
+	if opts.Retry == 0 {
+		opts.Retry = 60
+	}
+	if opts.Expire == 0 {
+		opts.Expire = 3600
+	}
+	if opts.Retry < 30 {
+		return nil, fmt.Errorf("Pushover retry 不能少于 30 秒")
+	}
+	if opts.Expire > 10800 {
+		return nil, fmt.Errorf("Pushover expire 不能超过 10800 秒")
+	}
+	return &pushoverPusher{opts: opts, http: s.HTTP}, nil
+}
+
+// Push 实现 Pusher
+func (p *pushoverPusher) Push(title, msg string, meta *Meta) error {
+	form := url.Values{}
+	form.Set("token", p.opts.Token)
+	form.Set("user", p.opts.User)
+	form.Set("title", truncateText(title, pushoverTitleLimit))
+	form.Set("message", truncateText(msg, pushoverMessageLimit))
+	if p.opts.Device != "" {
+		form.Set("device", strings.ReplaceAll(p.opts.Device, " ", ""))
+	}
+
+	priority, sound := p.opts.Priority, p.opts.Sound
+	if meta != nil {
+		if n, ok := pushoverPriorities[meta.Priority]; ok {
+			priority = &n
+		}
+		if meta.Sound != "" {
+			sound = meta.Sound
+		}
+	}
+	if priority != nil {
+		form.Set("priority", strconv.Itoa(*priority))
+		if *priority == pushoverEmergency {
+			form.Set("retry", strconv.Itoa(p.opts.Retry))
+			form.Set("expire", strconv.Itoa(p.opts.Expire))
+		}
+	}
+	if sound != "" {
+		form.Set("sound", sound)
+	}
+
+	status, body, err := p.http.Post(pushoverAPI, "application/x-www-form-urlencoded", []byte(form.Encode()), nil)
+	if err != nil {
+		return err
+	}
+
+	var result struct {
+		Status int      `json:"status"`
+		Errors []string `json:"errors"`
+	}
+	json.Unmarshal(body, &result)
+	if status == http.StatusOK && result.Status == 1 {
+		return nil
+	}
+	return fmt.Errorf("Pushover 推送失败: [%d] %s", status, strings.Join(result.Errors, "; "))
+}
@@ -0,0 +1,76 @@
+package push
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// pushPlusAPI PushPlus 推送接口
+const pushPlusAPI = "https://www.pushplus.plus/send"
+
+func init() {
+	Register(TypePushPlus, newPushPlusPusher)
+}
+
+// TypePushPlus PushPlus 推送类型
+const TypePushPlus = "pushplus"
+
+// pushPlusPusher PushPlus（推送加）推送
+type pushPlusPusher struct {
+	opts pushPlusOptions
+	http *HTTPClient
+}
+
+// pushPlusOptions PushPlus 推送配置
+type pushPlusOptions struct {
+	Token    string `json:"token"`
+	Topic    string `json:"topic"`    // 可选，群组编码（一对多推送）
+	Template string `json:"template"` // 可选，消息模板（默认 txt，可选 html/markdown）
+	Channel  string `json:"channel"`  // 可选，发送渠道（默认 wechat，可选 webhook/mail/sms 等）
+}
+
+// newPushPlusPusher 创建 PushPlus 推送后端
+func newPushPlusPusher(s *Settings) (Pusher, error) {
+	var opts pushPlusOptions
+	if err := s.Decode(&opts); err != nil {
+		return nil, err
+	}
+	if opts.Token == "" {
+		return nil, fmt.Errorf("PushPlus 推送缺少 token")
+	}
+	if opts.Template == "" {
+		opts.Template = "txt"
+	}
+	return &pushPlusPusher{opts: opts, http: s.HTTP}, nil
+}
+
+// Push 实现 Pusher
+func (p *pushPlusPusher) Push(title, msg string, meta *Meta) error {
+	payload, err := json.Marshal(map[string]string{
+		"token":    p.opts.Token,
+		"title":    title,
+		"content":  msg,
+		"template": p.opts.Template,
+		"topic":    p.opts.Topic,
+		"channel":  p.opts.Channel,
+	})
+	if err != nil {
+		return fmt.Errorf("序列化推送消息失败: %w", err)
+	}
+
+	status, body, err := p.http.Post(pushPlusAPI, "application/json", payload, nil)
+	if err != nil {
+		return err
+	}
+
+	var result struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+	}
+	json.Unmarshal(body, &result)
+	if status == http.StatusOK && result.Code == 200 {
+		return nil
+	}
+	return fmt.Errorf("PushPlus 推送失败: [%d] %d %s", status, result.Code, result.Msg)
+}
@@ -0,0 +1,75 @@
+package push
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"mail-receiver/clock"
+)
+
+// RateLimit 推送限速（令牌桶）：每个周期最多推送 limit 条，允许 burst 条的突发；
+// 超出时按到达顺序排队等待，避免邮件风暴时机器人被封禁或 Webhook 被限流。可在多个推送器间共用
+type RateLimit struct {
+	interval time.Duration // 生成一个令牌的间隔
+	burst    int
+	clock    clock.Clock
+
+	mu  sync.Mutex
+	tat time.Time // 理论到达时间：令牌桶回满的时刻
+}
+
+// NewRateLimit 创建推送限速，burst 为 0 时等于 limit；clk 为nil时使用系统时间
+func NewRateLimit(limit int, period time.Duration, burst int, clk clock.Clock) *RateLimit {
+	if burst <= 0 {
+		burst = limit
+	}
+	return &RateLimit{
+		interval: period / time.Duration(limit),
+		burst:    burst,
+		clock:    clock.Or(clk),
+	}
+}
+
+// reserve 预留一个令牌，返回需要等待的时长
+func (l *RateLimit) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.clock.Now()
+	if l.tat.Before(now) {
+		l.tat = now
+	}
+	wait := l.tat.Sub(now) - time.Duration(l.burst-1)*l.interval
+	l.tat = l.tat.Add(l.interval)
+	return wait
+}
+
+// rateLimited 按限速推送的推送器
+type rateLimited struct {
+	ctx     context.Context
+	next    Pusher
+	limit   *RateLimit
+	account string
+	target  string
+}
+
+// RateLimited 为推送器加上限速，超出时阻塞等待；ctx 取消（如程序退出）时不再等待，推送返回错误
+func RateLimited(ctx context.Context, next Pusher, limit *RateLimit, account, target string) Pusher {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return &rateLimited{ctx: ctx, next: next, limit: limit, account: account, target: target}
+}
+
+// Push 实现 Pusher
+func (r *rateLimited) Push(title, msg string, meta *Meta) error {
+	if wait := r.limit.reserve(); wait > 0 {
+		log.Printf("[%s] 推送目标 %s 超过限速，%v 后推送: %s", r.account, r.target, wait.Round(time.Second), title)
+		if err := clock.SleepContext(r.ctx, r.limit.clock, wait); err != nil {
+			return fmt.Errorf("等待推送限速时取消: %w", err)
+		}
+	}
+	return r.next.Push(title, msg, meta)
+}
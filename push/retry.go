@@ -0,0 +1,249 @@
+package push
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"mail-receiver/clock"
+)
+
+// 重试队列默认值
+const (
+	defaultRetryInitialDelay = 30 * time.Second
+	defaultRetryMaxDelay     = time.Hour
+	defaultRetryQueueSize    = 1000
+)
+
+// errRetryClosed 程序退出时仍未推送成功
+var errRetryClosed = errors.New("程序退出时仍在等待重试")
+
+// RetryOptions 推送失败后的重试策略
+type RetryOptions struct {
+	MaxAttempts  int           // 最多尝试次数（含首次推送）
+	InitialDelay time.Duration // 首次重试间隔，之后每次翻倍
+	MaxDelay     time.Duration // 最大重试间隔
+	QueueSize    int           // 最多排队的推送数，队列满时直接返回失败
+	Clock        clock.Clock   // 可选，重试计时的时间来源（默认系统时间）
+	OnFull       func(error)   // 可选，队列已满、推送失败不再排队时调用（队列有空位前只调用一次）
+}
+
+// RetryQueue 推送失败时放入队列，在后台按指数退避重试，超过最多尝试次数后写入推送失败记录
+// 放入队列即视为推送成功（邮件按已推送处理），队列满时返回原错误
+type RetryQueue struct {
+	next    Pusher
+	opts    RetryOptions
+	account string
+	target  string
+	dead    *DeadLetter // 可选，nil 时只记录日志
+
+	mu      sync.Mutex
+	items   []*retryItem
+	running bool
+	closed  bool
+	full    bool // 队列已满且已调用 OnFull
+	wake    chan struct{}
+}
+
+// retryItem 等待重试的推送
+type retryItem struct {
+	title, msg string
+	meta       *Meta
+	attempts   int
+	next       time.Time
+	err        error
+}
+
+// NewRetryQueue 创建重试队列，dead 为nil时放弃的推送只记录日志
+func NewRetryQueue(next Pusher, opts RetryOptions, account, target string, dead *DeadLetter) *RetryQueue {
+	if opts.InitialDelay <= 0 {
+		opts.InitialDelay = defaultRetryInitialDelay
+	}
+	if opts.MaxDelay <= 0 {
+		opts.MaxDelay = defaultRetryMaxDelay
+	}
+	if opts.MaxDelay < opts.InitialDelay {
+		opts.MaxDelay = opts.InitialDelay
+	}
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = defaultRetryQueueSize
+	}
+	opts.Clock = clock.Or(opts.Clock)
+	return &RetryQueue{
+		next:    next,
+		opts:    opts,
+		account: account,
+		target:  target,
+		dead:    dead,
+		wake:    make(chan struct{}, 1),
+	}
+}
+
+// Push 实现 Pusher：立即推送一次，失败时放入重试队列
+func (q *RetryQueue) Push(title, msg string, meta *Meta) error {
+	err := q.next.Push(title, msg, meta)
+	if err == nil || q.opts.MaxAttempts <= 1 {
+		return err
+	}
+
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return err
+	}
+	if len(q.items) >= q.opts.QueueSize {
+		notify := !q.full && q.opts.OnFull != nil
+		q.full = true
+		q.mu.Unlock()
+		log.Printf("[%s] 推送目标 %s 的重试队列已满 (%d)，不再排队重试: %v", q.account, q.target, q.opts.QueueSize, err)
+		if notify {
+			q.opts.OnFull(err)
+		}
+		return err
+	}
+	defer q.mu.Unlock()
+	q.items = append(q.items, &retryItem{
+		title:    title,
+		msg:      msg,
+		meta:     meta,
+		attempts: 1,
+		next:     q.opts.Clock.Now().Add(q.opts.InitialDelay),
+		err:      err,
+	})
+	log.Printf("[%s] 推送目标 %s 推送失败，%v 后重试: %v", q.account, q.target, q.opts.InitialDelay, err)
+	if !q.running {
+		q.running = true
+		go q.run()
+	} else {
+		select {
+		case q.wake <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// Pending 返回等待重试的推送数
+func (q *RetryQueue) Pending() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.items)
+}
+
+// Close 停止重试，未完成的推送写入推送失败记录
+func (q *RetryQueue) Close() {
+	q.mu.Lock()
+	items := q.items
+	q.items = nil
+	q.closed = true
+	q.mu.Unlock()
+
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+	for _, item := range items {
+		q.giveUp(item, fmt.Errorf("%w（上次失败: %v）", errRetryClosed, item.err))
+	}
+}
+
+// run 按到期时间依次重试，队列为空时退出
+func (q *RetryQueue) run() {
+	for {
+		q.mu.Lock()
+		if q.closed || len(q.items) == 0 {
+			q.running = false
+			q.mu.Unlock()
+			return
+		}
+		item := q.items[0]
+		for _, it := range q.items[1:] {
+			if it.next.Before(item.next) {
+				item = it
+			}
+		}
+		wait := item.next.Sub(q.opts.Clock.Now())
+		q.mu.Unlock()
+
+		if wait > 0 {
+			timer := q.opts.Clock.NewTimer(wait)
+			select {
+			case <-timer.C():
+			case <-q.wake:
+				// 有新的推送入队或已关闭，重新选择最早到期的推送
+				timer.Stop()
+				continue
+			}
+		}
+
+		err := q.next.Push(item.title, item.msg, item.meta)
+
+		q.mu.Lock()
+		if !q.remove(item) {
+			// 已被 Close 取出并写入推送失败记录
+			q.mu.Unlock()
+			continue
+		}
+		item.attempts++
+		if err == nil || item.attempts >= q.opts.MaxAttempts {
+			// 推送成功或放弃后队列有了空位
+			q.full = false
+		}
+		if err == nil {
+			q.mu.Unlock()
+			log.Printf("[%s] 推送目标 %s 第 %d 次尝试推送成功: %s", q.account, q.target, item.attempts, item.title)
+			continue
+		}
+		item.err = err
+		if item.attempts >= q.opts.MaxAttempts {
+			q.mu.Unlock()
+			q.giveUp(item, err)
+			continue
+		}
+		delay := q.backoff(item.attempts)
+		item.next = q.opts.Clock.Now().Add(delay)
+		q.items = append(q.items, item)
+		q.mu.Unlock()
+		log.Printf("[%s] 推送目标 %s 第 %d 次推送失败，%v 后重试: %v", q.account, q.target, item.attempts, delay, err)
+	}
+}
+
+// remove 从队列中移除推送，调用方需持有锁；不在队列中时返回 false
+func (q *RetryQueue) remove(item *retryItem) bool {
+	for i, it := range q.items {
+		if it == item {
+			q.items = append(q.items[:i], q.items[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// backoff 第 attempts 次失败后的重试间隔
+func (q *RetryQueue) backoff(attempts int) time.Duration {
+	delay := q.opts.InitialDelay
+	for i := 1; i < attempts && delay < q.opts.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > q.opts.MaxDelay {
+		delay = q.opts.MaxDelay
+	}
+	return delay
+}
+
+// giveUp 放弃重试，写入推送失败记录
+func (q *RetryQueue) giveUp(item *retryItem, err error) {
+	log.Printf("[%s] 推送目标 %s 尝试 %d 次后放弃推送: %s: %v", q.account, q.target, item.attempts, item.title, err)
+	if q.dead == nil {
+		return
+	}
+	e := newDeadLetterEntry(q.target, item.title, item.msg, item.meta, item.attempts, err)
+	if e.Account == "" {
+		e.Account = q.account
+	}
+	if werr := q.dead.Write(e); werr != nil {
+		log.Printf("[%s] %v", q.account, werr)
+	}
+}
@@ -0,0 +1,150 @@
+package push
+
+import (
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"mail-receiver/clock"
+)
+
+// flakyPusher 前 fail 次推送失败，之后成功；每次推送都发送到 calls
+type flakyPusher struct {
+	mu    sync.Mutex
+	fail  int
+	calls chan int
+	n     int
+}
+
+func (p *flakyPusher) Push(title, msg string, meta *Meta) error {
+	p.mu.Lock()
+	p.n++
+	n := p.n
+	p.mu.Unlock()
+	p.calls <- n
+	if n <= p.fail {
+		return errors.New("推送失败")
+	}
+	return nil
+}
+
+// waitTimers 等待被测代码创建 n 个定时器后再推进时间
+func waitTimers(t *testing.T, c *clock.Fake, n int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for c.Waiting() < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("等待定时器超时：当前 %d 个，期望 %d 个", c.Waiting(), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// expectCall 期望在短时间内发生第 want 次推送
+func expectCall(t *testing.T, p *flakyPusher, want int) {
+	t.Helper()
+	select {
+	case n := <-p.calls:
+		if n != want {
+			t.Fatalf("第 %d 次推送，期望第 %d 次", n, want)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("等待第 %d 次推送超时", want)
+	}
+}
+
+// expectNoCall 期望没有发生推送
+func expectNoCall(t *testing.T, p *flakyPusher) {
+	t.Helper()
+	select {
+	case n := <-p.calls:
+		t.Fatalf("未到重试时间却发生了第 %d 次推送", n)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestRetryQueueBackoff(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	p := &flakyPusher{fail: 2, calls: make(chan int, 10)}
+	q := NewRetryQueue(p, RetryOptions{
+		MaxAttempts:  5,
+		InitialDelay: 30 * time.Second,
+		MaxDelay:     time.Hour,
+		Clock:        fake,
+	}, "test", "webhook", nil)
+	defer q.Close()
+
+	if err := q.Push("标题", "内容", nil); err != nil {
+		t.Fatalf("首次推送失败后应放入重试队列: %v", err)
+	}
+	expectCall(t, p, 1)
+	if n := q.Pending(); n != 1 {
+		t.Fatalf("等待重试的推送数 %d，期望 1", n)
+	}
+
+	// 首次重试在 InitialDelay 后
+	waitTimers(t, fake, 1)
+	fake.Advance(29 * time.Second)
+	expectNoCall(t, p)
+	fake.Advance(time.Second)
+	expectCall(t, p, 2)
+
+	// 再次失败后间隔翻倍
+	waitTimers(t, fake, 1)
+	fake.Advance(59 * time.Second)
+	expectNoCall(t, p)
+	fake.Advance(time.Second)
+	expectCall(t, p, 3)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for q.Pending() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("推送成功后仍有 %d 个等待重试", q.Pending())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestRetryQueueGiveUp(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	p := &flakyPusher{fail: 10, calls: make(chan int, 10)}
+	path := filepath.Join(t.TempDir(), "dead.jsonl")
+	q := NewRetryQueue(p, RetryOptions{
+		MaxAttempts:  2,
+		InitialDelay: time.Minute,
+		Clock:        fake,
+	}, "test", "webhook", NewDeadLetter(path))
+	defer q.Close()
+
+	if err := q.Push("标题", "内容", nil); err != nil {
+		t.Fatalf("首次推送失败后应放入重试队列: %v", err)
+	}
+	expectCall(t, p, 1)
+	waitTimers(t, fake, 1)
+	fake.Advance(time.Minute)
+	expectCall(t, p, 2)
+
+	// 达到最多尝试次数后写入推送失败记录
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		entries, err := ReadDeadLetters(path)
+		if err != nil {
+			t.Fatalf("读取推送失败记录失败: %v", err)
+		}
+		if len(entries) == 1 {
+			if entries[0].Attempts != 2 || entries[0].Title != "标题" {
+				t.Fatalf("推送失败记录内容错误: %+v", entries[0])
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("放弃重试后没有写入推送失败记录")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if n := q.Pending(); n != 0 {
+		t.Fatalf("放弃后仍有 %d 个等待重试", n)
+	}
+}
@@ -0,0 +1,84 @@
+package push
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// serverChanTitleLength 标题最大字符数
+const serverChanTitleLength = 32
+
+// sctpKey Server酱³ 的 SendKey（sctp{uid}t...），推送地址中包含 uid
+var sctpKey = regexp.MustCompile(`^sctp(\d+)t`)
+
+func init() {
+	Register(TypeServerChan, newServerChanPusher)
+}
+
+// TypeServerChan Server酱推送类型
+const TypeServerChan = "serverchan"
+
+// serverChanPusher Server酱（Turbo 版及 Server酱³）推送
+type serverChanPusher struct {
+	url  string
+	http *HTTPClient
+}
+
+// serverChanOptions Server酱推送配置
+type serverChanOptions struct {
+	Token string `json:"token"` // SendKey
+}
+
+// newServerChanPusher 创建Server酱推送后端
+func newServerChanPusher(s *Settings) (Pusher, error) {
+	var opts serverChanOptions
+	if err := s.Decode(&opts); err != nil {
+		return nil, err
+	}
+	if opts.Token == "" {
+		return nil, fmt.Errorf("Server酱推送缺少 token (SendKey)")
+	}
+
+	endpoint := "https://sctapi.ftqq.com/" + url.PathEscape(opts.Token) + ".send"
+	if m := sctpKey.FindStringSubmatch(opts.Token); m != nil {
+		endpoint = fmt.Sprintf("https://%s.push.ft07.com/send/%s.send", m[1], url.PathEscape(opts.Token))
+	}
+	return &serverChanPusher{url: endpoint, http: s.HTTP}, nil
+}
+
+// Push 发送消息，正文按 Markdown 展示
+func (p *serverChanPusher) Push(title, msg string, meta *Meta) error {
+	// 标题过长时截断，完整标题放在正文开头
+	if runes := []rune(title); len(runes) > serverChanTitleLength {
+		msg = title + "\n\n" + msg
+		title = string(runes[:serverChanTitleLength-1]) + "…"
+	}
+
+	// Markdown 中单个换行不会换行，行尾加两个空格
+	desp := strings.ReplaceAll(msg, "\n", "  \n")
+	if meta != nil && meta.ImageURL != "" {
+		desp += "\n\n![](" + meta.ImageURL + ")"
+	}
+
+	form := url.Values{}
+	form.Set("title", title)
+	form.Set("desp", desp)
+	status, body, err := p.http.Post(p.url, "application/x-www-form-urlencoded", []byte(form.Encode()), nil)
+	if err != nil {
+		return err
+	}
+
+	var result struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+	json.Unmarshal(body, &result)
+	if status == http.StatusOK && result.Code == 0 {
+		return nil
+	}
+	return fmt.Errorf("Server酱推送失败: [%d] %d %s", status, result.Code, result.Message)
+}
@@ -0,0 +1,77 @@
+package push
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// DefaultSignatureHeader 默认的签名请求头
+const DefaultSignatureHeader = "X-Signature-256"
+
+// TimestampHeader 带时间戳签名时发送时间戳（Unix 秒）的请求头
+const TimestampHeader = "X-Signature-Timestamp"
+
+// signer 使用 HMAC-SHA256 对请求体签名，接收方可据此验证推送来源
+type signer struct {
+	secret    []byte
+	header    string
+	timestamp bool // 签名内容包含时间戳，接收方可拒绝过期的请求（防重放）
+}
+
+// newSigner 创建签名器，secret 为空时返回nil（不签名）
+func newSigner(secret, header string, timestamp bool) *signer {
+	if secret == "" {
+		return nil
+	}
+	if header == "" {
+		header = DefaultSignatureHeader
+	}
+	return &signer{secret: []byte(secret), header: header, timestamp: timestamp}
+}
+
+// Sign 计算请求体签名，格式为 sha256=<hex>
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// SignTimestamped 计算带时间戳的签名，签名内容为 "<timestamp>.<body>"
+func SignTimestamped(secret, body []byte, timestamp string) string {
+	return Sign(secret, append([]byte(timestamp+"."), body...))
+}
+
+// Verify 校验请求体签名（接收方使用）
+func Verify(secret, body []byte, signature string) bool {
+	return hmac.Equal([]byte(signature), []byte(Sign(secret, body)))
+}
+
+// VerifyTimestamped 校验带时间戳的签名，时间戳与当前时间相差超过 tolerance 时视为无效
+func VerifyTimestamped(secret, body []byte, signature, timestamp string, tolerance time.Duration) bool {
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	if d := time.Since(time.Unix(ts, 0)); d > tolerance || d < -tolerance {
+		return false
+	}
+	return hmac.Equal([]byte(signature), []byte(SignTimestamped(secret, body, timestamp)))
+}
+
+// apply 为请求设置签名头
+func (s *signer) apply(req *http.Request, body []byte) {
+	if s == nil {
+		return
+	}
+	if !s.timestamp {
+		req.Header.Set(s.header, Sign(s.secret, body))
+		return
+	}
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set(TimestampHeader, ts)
+	req.Header.Set(s.header, SignTimestamped(s.secret, body, ts))
+}
@@ -0,0 +1,123 @@
+package push
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Slack Block Kit 长度限制
+const (
+	slackHeaderLength  = 150  // header 块纯文本
+	slackSectionLength = 3000 // section 块文本
+)
+
+func init() {
+	Register(TypeSlack, newSlackPusher)
+}
+
+// TypeSlack Slack Incoming Webhook 推送类型
+const TypeSlack = "slack"
+
+// slackPusher Slack Incoming Webhook 推送（Block Kit 消息）
+type slackPusher struct {
+	webhook  string
+	markdown bool
+	http     *HTTPClient
+}
+
+// slackOptions Slack 推送配置
+type slackOptions struct {
+	Webhook string `json:"webhook"` // Incoming Webhook 地址
+	Format  string `json:"format"`  // 可选，正文格式 text（默认）/markdown
+}
+
+// newSlackPusher 创建 Slack 推送后端
+func newSlackPusher(s *Settings) (Pusher, error) {
+	var opts slackOptions
+	if err := s.Decode(&opts); err != nil {
+		return nil, err
+	}
+	if opts.Webhook == "" {
+		return nil, fmt.Errorf("Slack 推送缺少 webhook")
+	}
+	if _, err := url.Parse(opts.Webhook); err != nil {
+		return nil, fmt.Errorf("Slack webhook 无效: %w", err)
+	}
+	markdown, err := parseFormat(opts.Format)
+	if err != nil {
+		return nil, err
+	}
+	return &slackPusher{webhook: opts.Webhook, markdown: markdown, http: s.HTTP}, nil
+}
+
+// Push 发送 Block Kit 消息：标题为 header，发件人和时间为字段，正文为 section（超长截断）
+func (p *slackPusher) Push(title, msg string, meta *Meta) error {
+	blocks := []map[string]interface{}{{
+		"type": "header",
+		"text": slackText("plain_text", truncateText(title, slackHeaderLength)),
+	}}
+
+	body := escapeSlack(msg)
+	if meta != nil && meta.Email != nil {
+		email := meta.Email
+		body = escapeSlack(messageBody(msg))
+		blocks = append(blocks, map[string]interface{}{
+			"type": "section",
+			"fields": []map[string]interface{}{
+				slackText("mrkdwn", "*发件人*\n"+escapeSlack(email.DisplayFrom())),
+				slackText("mrkdwn", "*时间*\n"+email.Date.Format("2006-01-02 15:04:05")),
+			},
+		})
+	}
+	if p.markdown {
+		if md, rest, ok := markdownBody(msg, meta, slackMarkdown); ok {
+			if meta.Email != nil {
+				rest = messageBody(rest)
+			}
+			body = md + escapeSlack(rest)
+		}
+	}
+	if body = strings.TrimSpace(body); body != "" {
+		blocks = append(blocks, map[string]interface{}{
+			"type": "section",
+			"text": slackText("mrkdwn", truncateText(body, slackSectionLength)),
+		})
+	}
+	if meta != nil && meta.ImageURL != "" {
+		blocks = append(blocks, map[string]interface{}{
+			"type":      "image",
+			"image_url": meta.ImageURL,
+			"alt_text":  title,
+		})
+	}
+
+	payload, err := json.Marshal(map[string]interface{}{
+		"text":   title, // 通知和不支持 Block Kit 的客户端显示的文本
+		"blocks": blocks,
+	})
+	if err != nil {
+		return fmt.Errorf("序列化推送消息失败: %w", err)
+	}
+
+	status, resp, err := p.http.Post(p.webhook, "application/json", payload, nil)
+	if err != nil {
+		return err
+	}
+	if status == http.StatusOK {
+		return nil
+	}
+	return fmt.Errorf("Slack 推送失败: [%d] %s", status, strings.TrimSpace(string(resp)))
+}
+
+// slackText Block Kit 文本对象
+func slackText(kind, text string) map[string]interface{} {
+	return map[string]interface{}{"type": kind, "text": text}
+}
+
+// escapeSlack 转义 Slack mrkdwn 中的控制字符
+func escapeSlack(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
@@ -0,0 +1,244 @@
+package push
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"github.com/emersion/go-message"
+	gomail "github.com/emersion/go-message/mail"
+	mtextproto "github.com/emersion/go-message/textproto"
+
+	"mail-receiver/mailer"
+)
+
+func init() {
+	Register(TypeSMTP, newSMTPPusher)
+}
+
+// TypeSMTP SMTP 转发类型
+const TypeSMTP = "smtp"
+
+// SMTP 转发方式
+const (
+	smtpModeAttach  = "attach"  // 原邮件作为 message/rfc822 附件转发（默认）
+	smtpModeRewrite = "rewrite" // 原样重新投递，只改写发件人（原发件人放入 Reply-To）
+)
+
+// rewriteDropHeaders 改写发件人后失效或不应保留的邮件头
+var rewriteDropHeaders = []string{
+	"Sender", "Return-Path", "Delivered-To", "Bcc",
+	"DKIM-Signature", "ARC-Seal", "ARC-Message-Signature", "ARC-Authentication-Results",
+}
+
+// smtpPusher 通过 SMTP 将邮件转发到其他地址（如把共享邮箱镜像到个人邮箱），每次推送建立一个连接
+// 没有原始邮件的通知（告警、合并推送）以纯文本邮件发送
+type smtpPusher struct {
+	opts          mailer.Options
+	from          string
+	to            []string
+	mode          string
+	fromPrefix    string
+	subjectPrefix string
+}
+
+// smtpOptions SMTP 转发配置
+type smtpOptions struct {
+	Host          string   `json:"host"`
+	Port          int      `json:"port"`     // 默认按加密方式：tls 465，starttls 587，none 25
+	Security      string   `json:"security"` // tls / starttls（默认）/ none
+	Username      string   `json:"username"`
+	Password      string   `json:"password"`
+	From          string   `json:"from"` // 发件人地址，默认为 username
+	To            []string `json:"to"`
+	Mode          string   `json:"mode"`           // attach（默认）/ rewrite
+	FromPrefix    string   `json:"from_prefix"`    // rewrite 模式下发件人名称前缀，如 "[共享] "
+	SubjectPrefix string   `json:"subject_prefix"` // 主题前缀，attach 模式默认 "Fwd: "
+}
+
+// newSMTPPusher 创建 SMTP 转发后端
+func newSMTPPusher(s *Settings) (Pusher, error) {
+	var opts smtpOptions
+	if err := s.Decode(&opts); err != nil {
+		return nil, err
+	}
+	mo := mailer.Options{
+		Host:     opts.Host,
+		Port:     opts.Port,
+		Username: opts.Username,
+		Password: opts.Password,
+		Security: opts.Security,
+		TLS:      s.HTTP.TLSConfig(),
+	}
+	if err := mo.Validate(); err != nil {
+		return nil, err
+	}
+	if opts.From == "" {
+		opts.From = opts.Username
+	}
+	if !strings.Contains(opts.From, "@") {
+		return nil, fmt.Errorf("SMTP 转发缺少发件人地址 from")
+	}
+	if len(opts.To) == 0 {
+		return nil, fmt.Errorf("SMTP 转发缺少收件人 to")
+	}
+	switch opts.Mode {
+	case "":
+		opts.Mode = smtpModeAttach
+		if opts.SubjectPrefix == "" {
+			opts.SubjectPrefix = "Fwd: "
+		}
+	case smtpModeAttach, smtpModeRewrite:
+	default:
+		return nil, fmt.Errorf("SMTP 转发方式无效: %s (可选: attach/rewrite)", opts.Mode)
+	}
+
+	return &smtpPusher{
+		opts:          mo,
+		from:          opts.From,
+		to:            opts.To,
+		mode:          opts.Mode,
+		fromPrefix:    opts.FromPrefix,
+		subjectPrefix: opts.SubjectPrefix,
+	}, nil
+}
+
+// Push 实现 Pusher
+func (p *smtpPusher) Push(title, msg string, meta *Meta) error {
+	var data []byte
+	var err error
+	switch {
+	case meta == nil || meta.Email == nil || len(meta.Email.Raw) == 0:
+		data, err = p.textMessage(title, msg)
+	case p.mode == smtpModeRewrite:
+		data, err = p.rewriteMessage(meta.Email.Raw)
+	default:
+		data, err = p.attachMessage(meta.Email.Subject, msg, meta.Email.FromAddress, meta.Email.Raw)
+	}
+	if err != nil {
+		return err
+	}
+	return mailer.Send(p.opts, p.from, p.to, data)
+}
+
+// header 生成转发邮件的公共邮件头
+func (p *smtpPusher) header(subject string) *mailer.Header {
+	h := &mailer.Header{}
+	h.Add("From", mailer.FormatAddress("", p.from))
+	h.Add("To", strings.Join(p.to, ", "))
+	h.Add("Subject", mailer.EncodeHeader(subject))
+	h.Add("Date", time.Now().Format(time.RFC1123Z))
+	h.Add("Message-ID", mailer.MessageID(p.from))
+	h.Add("MIME-Version", "1.0")
+	return h
+}
+
+// textMessage 生成纯文本邮件
+func (p *smtpPusher) textMessage(title, msg string) ([]byte, error) {
+	h := p.header(p.subjectPrefix + title)
+	h.Add("Content-Type", "text/plain; charset=utf-8")
+	h.Add("Content-Transfer-Encoding", "quoted-printable")
+
+	var b bytes.Buffer
+	b.Write(h.Bytes())
+	b.WriteString("\r\n")
+	qp := quotedprintable.NewWriter(&b)
+	if _, err := qp.Write([]byte(msg)); err != nil {
+		return nil, err
+	}
+	if err := qp.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// attachMessage 生成转发邮件：推送内容为正文，原邮件作为 message/rfc822 附件
+func (p *smtpPusher) attachMessage(subject, msg, replyTo string, raw []byte) ([]byte, error) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+
+	text, err := mw.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {"text/plain; charset=utf-8"},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	qp := quotedprintable.NewWriter(text)
+	if _, err := qp.Write([]byte(msg)); err != nil {
+		return nil, err
+	}
+	if err := qp.Close(); err != nil {
+		return nil, err
+	}
+
+	orig, err := mw.CreatePart(textproto.MIMEHeader{
+		"Content-Type":        {"message/rfc822"},
+		"Content-Disposition": {`attachment; filename="original.eml"`},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := orig.Write(raw); err != nil {
+		return nil, err
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+
+	h := p.header(p.subjectPrefix + subject)
+	if replyTo != "" {
+		h.Add("Reply-To", mailer.FormatAddress("", replyTo))
+	}
+	h.Add("Content-Type", "multipart/mixed; boundary="+mw.Boundary())
+
+	var b bytes.Buffer
+	b.Write(h.Bytes())
+	b.WriteString("\r\n")
+	b.Write(body.Bytes())
+	return b.Bytes(), nil
+}
+
+// rewriteMessage 原样重新投递原邮件：发件人改为转发地址（名称加前缀），原发件人放入 Reply-To
+// 去掉改写后签名失效的 DKIM/ARC 头，避免收件服务器判为伪造
+func (p *smtpPusher) rewriteMessage(raw []byte) ([]byte, error) {
+	r := bufio.NewReader(bytes.NewReader(raw))
+	th, err := mtextproto.ReadHeader(r)
+	if err != nil {
+		return nil, fmt.Errorf("解析原邮件头失败: %w", err)
+	}
+	h := gomail.Header{Header: message.Header{Header: th}}
+
+	name := ""
+	if from, err := h.AddressList("From"); err == nil && len(from) > 0 {
+		name = from[0].Name
+		if name == "" {
+			name = from[0].Address
+		}
+		if !h.Has("Reply-To") {
+			h.SetAddressList("Reply-To", from)
+		}
+	}
+	for _, k := range rewriteDropHeaders {
+		h.Del(k)
+	}
+	h.SetAddressList("From", []*gomail.Address{{Name: p.fromPrefix + name, Address: p.from}})
+	if p.subjectPrefix != "" {
+		subject, _ := h.Subject()
+		h.SetSubject(p.subjectPrefix + subject)
+	}
+
+	var b bytes.Buffer
+	if err := mtextproto.WriteHeader(&b, h.Header.Header); err != nil {
+		return nil, fmt.Errorf("生成转发邮件头失败: %w", err)
+	}
+	if _, err := r.WriteTo(&b); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
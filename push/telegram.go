@@ -0,0 +1,213 @@
+package push
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// telegramMessageLength Telegram 单条消息的字符数上限
+const telegramMessageLength = 4096
+
+// telegramChunkLength 拆分消息时每段转义后的字符数（含标题），为分段标记预留余量
+const telegramChunkLength = 4000
+
+// telegramTitleLength 拆分发送时标题最多保留的字符数
+const telegramTitleLength = 256
+
+// defaultTelegramAPI Telegram Bot API 地址
+const defaultTelegramAPI = "https://api.telegram.org"
+
+func init() {
+	Register(TypeTelegram, newTelegramPusher)
+}
+
+// TypeTelegram Telegram Bot 推送类型
+const TypeTelegram = "telegram"
+
+// telegramPusher Telegram Bot 推送
+type telegramPusher struct {
+	apiURL   string
+	botToken string
+	chatID   string
+	markdown bool
+	http     *HTTPClient
+}
+
+// telegramOptions Telegram 推送配置
+type telegramOptions struct {
+	BotToken string `json:"bot_token"`
+	ChatID   string `json:"chat_id"`
+	APIURL   string `json:"api_url"` // 可选，Bot API 地址（如反向代理）
+	Format   string `json:"format"`  // 可选，正文格式 text（默认）/markdown
+}
+
+// newTelegramPusher 创建 Telegram Bot 推送后端
+func newTelegramPusher(s *Settings) (Pusher, error) {
+	var opts telegramOptions
+	if err := s.Decode(&opts); err != nil {
+		return nil, err
+	}
+	if opts.BotToken == "" || opts.ChatID == "" {
+		return nil, fmt.Errorf("Telegram 推送缺少 bot_token 或 chat_id")
+	}
+	if opts.APIURL == "" {
+		opts.APIURL = defaultTelegramAPI
+	}
+	markdown, err := parseFormat(opts.Format)
+	if err != nil {
+		return nil, err
+	}
+	return &telegramPusher{
+		apiURL:   strings.TrimRight(opts.APIURL, "/"),
+		botToken: opts.BotToken,
+		chatID:   opts.ChatID,
+		markdown: markdown,
+		http:     s.HTTP,
+	}, nil
+}
+
+// Push 以 MarkdownV2 格式发送消息，超长时拆分为多条
+// format 为 markdown 时 HTML 正文转为 MarkdownV2 发送，超过单条消息长度时按纯文本拆分发送
+func (p *telegramPusher) Push(title, msg string, meta *Meta) error {
+	var texts []string
+	if p.markdown {
+		if body, rest, ok := markdownBody(msg, meta, telegramMarkdown); ok {
+			text := "*" + escapeMarkdownV2(title) + "*\n\n" + body + escapeMarkdownV2(rest)
+			if len([]rune(text)) <= telegramMessageLength {
+				texts = []string{text}
+			}
+		}
+	}
+	if texts == nil {
+		// 按转义后的长度拆分，转义会使特殊字符变为两个字符
+		header := "*" + escapeMarkdownV2(truncateText(title, telegramTitleLength)) + "*\n\n"
+		chunks := splitWeighted(msg, telegramChunkLength-len([]rune(header)), markdownV2Width)
+		for i, chunk := range chunks {
+			var text strings.Builder
+			if i == 0 {
+				text.WriteString(header)
+			}
+			text.WriteString(escapeMarkdownV2(chunk))
+			if len(chunks) > 1 {
+				text.WriteString(escapeMarkdownV2(fmt.Sprintf("\n(%d/%d)", i+1, len(chunks))))
+			}
+			texts = append(texts, text.String())
+		}
+	}
+
+	// 低优先级消息静默发送
+	silent := meta != nil && meta.Priority == PriorityLow
+	for _, text := range texts {
+		if err := p.send("sendMessage", map[string]interface{}{
+			"chat_id":                  p.chatID,
+			"text":                     text,
+			"parse_mode":               "MarkdownV2",
+			"disable_web_page_preview": true,
+			"disable_notification":     silent,
+		}); err != nil {
+			return err
+		}
+	}
+
+	// 图片预览（如附件缩略图）在正文之后静默发送，失败不影响推送结果
+	if meta != nil && meta.ImageURL != "" {
+		if err := p.send("sendPhoto", map[string]interface{}{
+			"chat_id":              p.chatID,
+			"photo":                meta.ImageURL,
+			"caption":              title,
+			"disable_notification": true,
+		}); err != nil {
+			log.Printf("[%s] 发送图片预览失败: %v", meta.Account, err)
+		}
+	}
+	return nil
+}
+
+// send 调用 Bot API 接口
+func (p *telegramPusher) send(method string, params map[string]interface{}) error {
+	payload, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("序列化推送消息失败: %w", err)
+	}
+
+	endpoint := fmt.Sprintf("%s/bot%s/%s", p.apiURL, p.botToken, method)
+	status, body, err := p.http.Post(endpoint, "application/json", payload, nil)
+	if err != nil {
+		return err
+	}
+
+	var result struct {
+		OK          bool   `json:"ok"`
+		Description string `json:"description"`
+	}
+	json.Unmarshal(body, &result)
+	if status == http.StatusOK && result.OK {
+		return nil
+	}
+	return fmt.Errorf("Telegram 推送失败: [%d] %s", status, result.Description)
+}
+
+// markdownV2Special MarkdownV2 中需要转义的字符
+const markdownV2Special = "_*[]()~`>#+-=|{}.!\\"
+
+// escapeMarkdownV2 转义 MarkdownV2 特殊字符
+func escapeMarkdownV2(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(markdownV2Special, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// markdownV2Width 字符转义为 MarkdownV2 后的长度
+func markdownV2Width(r rune) int {
+	if strings.ContainsRune(markdownV2Special, r) {
+		return 2
+	}
+	return 1
+}
+
+// splitText 按字符数拆分文本，尽量在换行处断开
+func splitText(s string, limit int) []string {
+	return splitWeighted(s, limit, func(rune) int { return 1 })
+}
+
+// splitWeighted 拆分文本，每段各字符的 width 之和不超过 limit，尽量在换行处断开
+func splitWeighted(s string, limit int, width func(rune) int) []string {
+	runes := []rune(s)
+	var chunks []string
+	for len(runes) > 0 {
+		// 本段最多容纳的字符数
+		n, total := 0, 0
+		for n < len(runes) && total+width(runes[n]) <= limit {
+			total += width(runes[n])
+			n++
+		}
+		if n == len(runes) {
+			break
+		}
+		if n == 0 {
+			n = 1
+		}
+		cut := n
+		// 在后半段中寻找最后一个换行符
+		for i := n - 1; i > n/2; i-- {
+			if runes[i] == '\n' {
+				cut = i + 1
+				break
+			}
+		}
+		chunks = append(chunks, string(runes[:cut]))
+		runes = runes[cut:]
+	}
+	if len(runes) > 0 || len(chunks) == 0 {
+		chunks = append(chunks, string(runes))
+	}
+	return chunks
+}
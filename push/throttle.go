@@ -0,0 +1,104 @@
+package push
+
+import (
+	"context"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"mail-receiver/clock"
+)
+
+const (
+	// defaultRetryAfter 响应未携带 Retry-After 时的默认等待时间
+	defaultRetryAfter = 30 * time.Second
+	// maxRetryAfter 单次等待的上限，避免异常的 Retry-After 长时间阻塞推送
+	maxRetryAfter = 5 * time.Minute
+	// maxThrottleRetries 单条推送因限流重试的最大次数
+	maxThrottleRetries = 3
+)
+
+// throttle 推送通道的限流状态：收到 429/503 后，该通道的后续推送都会等待到限流结束
+type throttle struct {
+	mu    sync.Mutex
+	until time.Time
+
+	throttled uint64 // 累计收到限流响应的次数
+}
+
+// wait 阻塞直到限流结束，ctx 取消时提前返回错误
+func (t *throttle) wait(ctx context.Context, clk clock.Clock) error {
+	t.mu.Lock()
+	d := t.until.Sub(clk.Now())
+	t.mu.Unlock()
+
+	return clock.SleepContext(ctx, clk, d)
+}
+
+// check 检查响应是否为限流，是则记录限流截止时间并返回需要等待的时长
+func (t *throttle) check(resp *http.Response, accountName string, now time.Time) (time.Duration, bool) {
+	// 服务端提前告知配额已用完（如 Discord 的 X-RateLimit-Remaining: 0），下一次请求等待到配额重置
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		if secs, err := strconv.ParseFloat(resp.Header.Get("X-RateLimit-Reset-After"), 64); err == nil && secs > 0 {
+			t.delay(seconds(secs), now)
+		}
+	}
+
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable {
+		return 0, false
+	}
+
+	d := parseRetryAfter(resp.Header.Get("Retry-After"), now)
+	atomic.AddUint64(&t.throttled, 1)
+
+	t.delay(d, now)
+
+	log.Printf("[%s] 推送被限流 (状态码 %d)，%v 后重试", accountName, resp.StatusCode, d)
+	return d, true
+}
+
+// delay 推迟后续推送，直到 now 之后 d
+func (t *throttle) delay(d time.Duration, now time.Time) {
+	if d > maxRetryAfter {
+		d = maxRetryAfter
+	}
+	t.mu.Lock()
+	if until := now.Add(d); until.After(t.until) {
+		t.until = until
+	}
+	t.mu.Unlock()
+}
+
+// count 返回累计限流次数
+func (t *throttle) count() uint64 {
+	return atomic.LoadUint64(&t.throttled)
+}
+
+// parseRetryAfter 解析 Retry-After 头（秒数或HTTP日期，秒数可以是小数）
+func parseRetryAfter(v string, now time.Time) time.Duration {
+	d := defaultRetryAfter
+	if v != "" {
+		if secs, err := strconv.ParseFloat(v, 64); err == nil && secs >= 0 {
+			d = seconds(secs)
+		} else if t, err := http.ParseTime(v); err == nil {
+			d = t.Sub(now)
+		}
+	}
+
+	if d < 0 {
+		d = 0
+	}
+	if d > maxRetryAfter {
+		d = maxRetryAfter
+	}
+	return d
+}
+
+// seconds 将秒数转换为时长（不超过 maxRetryAfter）
+func seconds(secs float64) time.Duration {
+	return time.Duration(math.Min(secs, maxRetryAfter.Seconds()) * float64(time.Second))
+}
@@ -0,0 +1,51 @@
+package push
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// SetClientTLS 为推送请求配置客户端证书（双向TLS），caFile 可选用于校验推送服务端证书
+func (h *HTTPClient) SetClientTLS(certFile, keyFile, caFile string) error {
+	if certFile == "" && caFile == "" {
+		return nil
+	}
+
+	tlsConfig := &tls.Config{}
+
+	if certFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return fmt.Errorf("加载推送客户端证书失败: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return fmt.Errorf("读取推送CA证书失败: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("推送CA证书无效: %s", caFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	h.client.Transport = transport
+	return nil
+}
+
+// TLSConfig 返回 SetClientTLS 设置的TLS配置，未设置时为nil（供 MQTT 等非HTTP后端复用）
+func (h *HTTPClient) TLSConfig() *tls.Config {
+	if t, ok := h.client.Transport.(*http.Transport); ok {
+		return t.TLSClientConfig
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package push
+
+import (
+	"fmt"
+	"strings"
+)
+
+// truncated 限制正文长度的推送器
+type truncated struct {
+	next  Pusher
+	limit int
+}
+
+// Truncated 为推送器加上正文长度限制（字符数）：推送内容中的正文超长时截断，时间、发件人等收件信息保留；
+// 推送信息中的邮件纯文本正文同样截断，超长的 HTML 正文去掉。limit 不大于 0 时原样返回
+func Truncated(next Pusher, limit int) Pusher {
+	if limit <= 0 {
+		return next
+	}
+	return &truncated{next: next, limit: limit}
+}
+
+// Push 实现 Pusher
+func (t *truncated) Push(title, msg string, meta *Meta) error {
+	body := messageBody(msg)
+	cut := TruncateBody(body, t.limit)
+	if cut == body && (meta == nil || meta.Email == nil || len(meta.Email.HTMLBody) <= t.limit && len([]rune(meta.Email.Body)) <= t.limit) {
+		return t.next.Push(title, msg, meta)
+	}
+	msg = cut + msg[len(body):]
+
+	// 推送信息由多个推送目标共用，修改副本
+	if meta != nil {
+		m := *meta
+		if cut != body {
+			// 正文已截断，不再使用 HTML 来源转换 Markdown
+			m.Rich = nil
+		}
+		if meta.Email != nil {
+			email := *meta.Email
+			email.Body = TruncateBody(email.Body, t.limit)
+			if len(email.HTMLBody) > t.limit {
+				email.HTMLBody = ""
+			}
+			m.Email = &email
+		}
+		meta = &m
+	}
+	return t.next.Push(title, msg, meta)
+}
+
+// TruncateBody 正文超过 limit 个字符时截断并注明剩余字符数：优先在后半段的最后一个换行处断开，
+// 其次在末尾附近的空格处断开（避免截断英文单词），都没有时按字符截断
+func TruncateBody(s string, limit int) string {
+	runes := []rune(s)
+	if limit <= 0 || len(runes) <= limit {
+		return s
+	}
+
+	cut := limit
+	if i := lastRune(runes[:limit], '\n', limit/2); i >= 0 {
+		cut = i
+	} else if i := lastRune(runes[:limit], ' ', limit-limit/5); i >= 0 {
+		cut = i
+	}
+	kept := strings.TrimRight(string(runes[:cut]), " \t\r\n")
+	return fmt.Sprintf("%s…[已截断，剩余 %d 字符]", kept, len(runes)-len([]rune(kept)))
+}
+
+// lastRune 返回 runes 中下标不小于 min 的最后一个 r 的位置，没有时返回 -1
+func lastRune(runes []rune, r rune, min int) int {
+	for i := len(runes) - 1; i >= min && i > 0; i-- {
+		if runes[i] == r {
+			return i
+		}
+	}
+	return -1
+}
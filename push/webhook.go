@@ -0,0 +1,252 @@
+package push
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"mail-receiver/imap"
+	"mail-receiver/mailauth"
+	"mail-receiver/smime"
+)
+
+func init() {
+	Register(TypeWebhook, newWebhookPusher)
+}
+
+// TypeWebhook JSON Webhook 推送类型
+const TypeWebhook = "webhook"
+
+// webhookPusher JSON Webhook 推送：请求体由模板生成，可自定义请求方法（请求头和认证使用通用的 headers / basic_auth）
+type webhookPusher struct {
+	url         string
+	method      string
+	contentType string
+	tmpl        *template.Template // 未配置模板时为nil，发送默认的 JSON 结构
+	http        *HTTPClient
+}
+
+// webhookOptions JSON Webhook 推送配置
+type webhookOptions struct {
+	URL          string `json:"url"`
+	Method       string `json:"method"`        // POST（默认）/ PUT / PATCH
+	ContentType  string `json:"content_type"`  // 默认 application/json
+	Template     string `json:"template"`      // 请求体模板（text/template 语法）
+	TemplateFile string `json:"template_file"` // 或从文件读取模板
+}
+
+// webhookData 请求体模板可用的数据
+type webhookData struct {
+	Title       string
+	Message     string
+	Account     string
+	Folder      string
+	Priority    string
+	Sound       string
+	ImageURL    string
+	ViewURL     string              // 网页查看完整邮件的地址（未启用时为空）
+	Code        string              // 提取的验证码（未启用或未找到时为空）
+	Email       *imap.EmailMessage  // 系统告警等非邮件推送时为nil
+	Attachments []webhookAttachment // 附件信息（不含内容）
+}
+
+// webhookAttachment 附件信息
+type webhookAttachment struct {
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+}
+
+// webhookPayload 未配置模板时发送的默认请求体
+type webhookPayload struct {
+	Title       string              `json:"title"`
+	Message     string              `json:"message"`
+	Account     string              `json:"account"`
+	Folder      string              `json:"folder,omitempty"`
+	Priority    string              `json:"priority,omitempty"`
+	ImageURL    string              `json:"image_url,omitempty"`
+	ViewURL     string              `json:"view_url,omitempty"`
+	Code        string              `json:"code,omitempty"`
+	Email       *webhookEmail       `json:"email,omitempty"`
+	Attachments []webhookAttachment `json:"attachments,omitempty"`
+}
+
+// webhookEmail 默认请求体中的邮件信息
+type webhookEmail struct {
+	UID       uint32            `json:"uid"`
+	MessageID string            `json:"message_id"`
+	Subject   string            `json:"subject"`
+	From      string            `json:"from"`
+	To        []string          `json:"to"`
+	CC        []string          `json:"cc,omitempty"`
+	Date      time.Time         `json:"date"`
+	Body      string            `json:"body"`
+	HTMLBody  string            `json:"html_body,omitempty"`
+	Tags      []string          `json:"tags,omitempty"`
+	Bounce    *imap.Bounce      `json:"bounce,omitempty"` // 退信的失败收件人和状态码
+	Auth      *mailauth.Verdict `json:"auth,omitempty"`   // 发件人真实性检查结果（DKIM/SPF/DMARC）
+	SMIME     *smime.Result     `json:"smime,omitempty"`  // S/MIME 解密和签名验证结果
+}
+
+// webhookFuncs 模板函数：json 将任意值编码为 JSON（字符串会加上引号并转义），用于安全地嵌入字段
+var webhookFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+// newWebhookPusher 创建 JSON Webhook 推送后端
+func newWebhookPusher(s *Settings) (Pusher, error) {
+	var opts webhookOptions
+	if err := s.Decode(&opts); err != nil {
+		return nil, err
+	}
+	if opts.URL == "" {
+		return nil, fmt.Errorf("Webhook 推送缺少 url")
+	}
+
+	method := strings.ToUpper(opts.Method)
+	switch method {
+	case "":
+		method = http.MethodPost
+	case http.MethodPost, http.MethodPut, http.MethodPatch:
+	default:
+		return nil, fmt.Errorf("Webhook 请求方法无效: %s (可选: POST/PUT/PATCH)", opts.Method)
+	}
+	if opts.ContentType == "" {
+		opts.ContentType = "application/json"
+	}
+
+	p := &webhookPusher{
+		url:         opts.URL,
+		method:      method,
+		contentType: opts.ContentType,
+		http:        s.HTTP,
+	}
+
+	text := opts.Template
+	if text == "" && opts.TemplateFile != "" {
+		data, err := os.ReadFile(opts.TemplateFile)
+		if err != nil {
+			return nil, fmt.Errorf("读取 Webhook 模板失败: %w", err)
+		}
+		text = string(data)
+	}
+	if text != "" {
+		t, err := template.New("webhook").Funcs(webhookFuncs).Option("missingkey=error").Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("Webhook 模板无效: %w", err)
+		}
+		p.tmpl = t
+	}
+	return p, nil
+}
+
+// Push 实现 Pusher
+func (p *webhookPusher) Push(title, msg string, meta *Meta) error {
+	if meta == nil {
+		meta = &Meta{}
+	}
+	payload, err := p.render(title, msg, meta)
+	if err != nil {
+		return err
+	}
+
+	status, body, err := p.http.Do(p.method, p.url, p.contentType, payload, nil)
+	if err != nil {
+		return err
+	}
+	if status >= 200 && status < 300 {
+		return nil
+	}
+	return fmt.Errorf("Webhook 推送失败: [%d] %s", status, truncateText(strings.TrimSpace(string(body)), 200))
+}
+
+// render 生成请求体：有模板时按模板生成（JSON 类型时校验结果），否则发送默认结构
+func (p *webhookPusher) render(title, msg string, meta *Meta) ([]byte, error) {
+	if p.tmpl == nil {
+		return marshalPayload(title, msg, meta)
+	}
+
+	var b strings.Builder
+	err := p.tmpl.Execute(&b, newWebhookData(title, msg, meta))
+	if err != nil {
+		return nil, fmt.Errorf("生成 Webhook 请求体失败: %w", err)
+	}
+	out := []byte(b.String())
+	if strings.Contains(p.contentType, "json") && !json.Valid(out) {
+		return nil, fmt.Errorf("Webhook 模板生成的内容不是有效的 JSON")
+	}
+	return out, nil
+}
+
+// newWebhookData 生成模板数据
+func newWebhookData(title, msg string, meta *Meta) *webhookData {
+	return &webhookData{
+		Title:       title,
+		Message:     msg,
+		Account:     meta.Account,
+		Folder:      meta.Folder,
+		Priority:    meta.Priority,
+		Sound:       meta.Sound,
+		ImageURL:    meta.ImageURL,
+		ViewURL:     meta.ViewURL,
+		Code:        meta.Code,
+		Email:       meta.Email,
+		Attachments: attachmentInfo(meta.Email),
+	}
+}
+
+// marshalPayload 生成默认的 JSON 请求体（Webhook 未配置模板时和 MQTT 消息使用）
+func marshalPayload(title, msg string, meta *Meta) ([]byte, error) {
+	payload := &webhookPayload{
+		Title:       title,
+		Message:     msg,
+		Account:     meta.Account,
+		Folder:      meta.Folder,
+		Priority:    meta.Priority,
+		ImageURL:    meta.ImageURL,
+		ViewURL:     meta.ViewURL,
+		Code:        meta.Code,
+		Attachments: attachmentInfo(meta.Email),
+	}
+	if email := meta.Email; email != nil {
+		payload.Email = &webhookEmail{
+			UID:       email.UID,
+			MessageID: email.MessageID,
+			Subject:   email.Subject,
+			From:      email.DisplayFrom(),
+			To:        email.To,
+			CC:        email.CC,
+			Date:      email.Date,
+			Body:      email.Body,
+			HTMLBody:  email.HTMLBody,
+			Tags:      email.Tags,
+			Bounce:    email.Bounce,
+			Auth:      email.Auth,
+			SMIME:     email.SMIME,
+		}
+	}
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("序列化推送消息失败: %w", err)
+	}
+	return b, nil
+}
+
+// attachmentInfo 邮件的附件信息（不含内容），email 为nil时返回nil
+func attachmentInfo(email *imap.EmailMessage) []webhookAttachment {
+	if email == nil {
+		return nil
+	}
+	var attachments []webhookAttachment
+	for _, a := range email.Attachments {
+		attachments = append(attachments, webhookAttachment{Filename: a.Filename, ContentType: a.ContentType, Size: a.Size})
+	}
+	return attachments
+}
@@ -0,0 +1,119 @@
+package receiver
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"mail-receiver/config"
+	"mail-receiver/push"
+)
+
+// 运维告警类型，同一账号同类告警按 alerts.interval 限制频率
+const (
+	alertStopped   = "stopped"    // 已达最大尝试次数，停止监控
+	alertUnhealthy = "unhealthy"  // 连续失败达到阈值
+	alertRecovered = "recovered"  // 异常后恢复连接
+	alertAuth      = "auth"       // 服务器拒绝登录凭据
+	alertDiskFull  = "disk_full"  // 本地存储空间不足
+	alertNotice    = "notice"     // 服务器主动发送的 ALERT/BYE 提示
+	alertSLA       = "sla"        // 推送延迟超过 SLA
+	alertRetryFull = "retry_full" // 推送重试队列已满
+)
+
+// severityLevels 告警级别的高低
+var severityLevels = map[string]int{
+	config.AlertInfo:     0,
+	config.AlertWarning:  1,
+	config.AlertCritical: 2,
+}
+
+// severityPriorities 告警级别对应的推送优先级
+var severityPriorities = map[string]string{
+	config.AlertInfo:     push.PriorityLow,
+	config.AlertWarning:  push.PriorityNormal,
+	config.AlertCritical: push.PriorityHigh,
+}
+
+// alerter 所有账号共用的运维告警策略：级别过滤、同类告警间隔和每小时上限
+type alerter struct {
+	pusher     push.Pusher // 单独的告警推送目标，nil 时使用账号的推送
+	minLevel   int
+	interval   time.Duration
+	maxPerHour int
+
+	mu   sync.Mutex
+	last map[string]time.Time // 账号+类型 → 最近推送时间
+	sent []time.Time          // 最近一小时内推送的时间
+}
+
+// newAlerter 按配置创建告警策略
+func newAlerter(cfg config.AlertsConfig, wait pushWait) (*alerter, error) {
+	a := &alerter{
+		minLevel:   severityLevels[cfg.MinSeverity],
+		interval:   time.Duration(cfg.Interval) * time.Second,
+		maxPerHour: cfg.MaxPerHour,
+		last:       make(map[string]time.Time),
+	}
+	if cfg.Push != nil {
+		p, err := newPusher(*cfg.Push, "alerts", push.NewHTTPClient("alerts"), wait)
+		if err != nil {
+			return nil, err
+		}
+		a.pusher = p
+	}
+	return a, nil
+}
+
+// wants 告警级别是否达到推送的最低级别
+func (a *alerter) wants(severity string) bool {
+	return severityLevels[severity] >= a.minLevel
+}
+
+// allow 检查告警是否超过频率限制，未超过时记录推送时间
+func (a *alerter) allow(account, kind string, now time.Time) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	key := account + "\x00" + kind
+	if at, ok := a.last[key]; ok && now.Sub(at) < a.interval {
+		return false
+	}
+	if a.maxPerHour > 0 {
+		kept := a.sent[:0]
+		for _, at := range a.sent {
+			if now.Sub(at) < time.Hour {
+				kept = append(kept, at)
+			}
+		}
+		a.sent = kept
+		if len(a.sent) >= a.maxPerHour {
+			return false
+		}
+		a.sent = append(a.sent, now)
+	}
+	a.last[key] = now
+	return true
+}
+
+// retryQueueFull 推送目标的重试队列已满时告警：之后推送失败的邮件保持未读，下次获取时重新推送
+func (ar *AccountReceiver) retryQueueFull(target string, err error) {
+	ar.alert(alertRetryFull, config.AlertWarning, "推送重试队列已满",
+		fmt.Sprintf("账号 [%s] 推送目标 %s 的重试队列已满，推送失败的邮件不再排队重试、保持未读\n错误: %v", ar.name, target, err))
+}
+
+// isDiskFull 检查错误是否由磁盘空间不足引起（包括 SQLite 的 SQLITE_FULL）
+func isDiskFull(err error) bool {
+	return errors.Is(err, syscall.ENOSPC) || strings.Contains(err.Error(), "disk is full")
+}
+
+// checkDiskFull 写入本地文件失败时检查是否磁盘已满，是则发送告警
+func (ar *AccountReceiver) checkDiskFull(what string, err error) {
+	if err == nil || !isDiskFull(err) {
+		return
+	}
+	ar.alert(alertDiskFull, config.AlertCritical, "磁盘空间不足", fmt.Sprintf("账号 [%s] %s失败，磁盘空间不足\n错误: %v", ar.name, what, err))
+}
@@ -0,0 +1,78 @@
+package receiver
+
+import (
+	"log"
+
+	"mail-receiver/audit"
+)
+
+// 审计日志触发原因
+const (
+	triggerPushSuccess    = "push_success"
+	triggerStrictPending  = "strict_pending"
+	triggerStrictFinalize = "strict_finalize"
+	triggerRule           = "rule"
+	triggerParseFailure   = "parse_failure"
+)
+
+// flaggedFlag 星标（IMAP \Flagged 标志）
+const flaggedFlag = "\\Flagged"
+
+// markAsRead 批量标记已读并记录审计日志
+func (ar *AccountReceiver) markAsRead(folder, trigger string, uids []uint32, msgIDs map[uint32]string) error {
+	if ar.readOnly {
+		return nil
+	}
+	err := ar.client.MarkAsRead(uids...)
+	ar.auditLog(audit.ActionMarkRead, "\\Seen", folder, trigger, uids, msgIDs, err)
+	return err
+}
+
+// setKeyword 批量设置或移除关键字并记录审计日志
+func (ar *AccountReceiver) setKeyword(folder, trigger, keyword string, add bool, uids []uint32, msgIDs map[uint32]string) error {
+	if ar.readOnly {
+		return nil
+	}
+	err := ar.client.SetKeyword(keyword, add, uids...)
+	action := audit.ActionRemoveFlag
+	if add {
+		action = audit.ActionAddFlag
+	}
+	ar.auditLog(action, keyword, folder, trigger, uids, msgIDs, err)
+	return err
+}
+
+// move 批量移动邮件并记录审计日志
+func (ar *AccountReceiver) move(folder, trigger, dest string, uids []uint32, msgIDs map[uint32]string) error {
+	if ar.readOnly {
+		return nil
+	}
+	dest = ar.client.ResolveFolder(dest)
+	err := ar.client.Move(dest, uids...)
+	ar.auditLog(audit.ActionMove, dest, folder, trigger, uids, msgIDs, err)
+	return err
+}
+
+// auditLog 为每个UID写入一条审计记录
+func (ar *AccountReceiver) auditLog(action, detail, folder, trigger string, uids []uint32, msgIDs map[uint32]string, opErr error) {
+	if ar.audit == nil {
+		return
+	}
+	for _, uid := range uids {
+		entry := audit.Entry{
+			Account:   ar.name,
+			Folder:    folder,
+			UID:       uid,
+			MessageID: msgIDs[uid],
+			Action:    action,
+			Detail:    detail,
+			Trigger:   trigger,
+		}
+		if opErr != nil {
+			entry.Error = opErr.Error()
+		}
+		if err := ar.audit.Log(entry); err != nil {
+			log.Printf("[%s] %v", ar.name, err)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package receiver
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"mail-receiver/config"
+)
+
+// holdAuth 登录凭据被拒绝（密码过期、授权码被撤销等）时进入隔离状态：只告警一次，
+// 之后按 retry.auth_retry_interval 重新尝试登录；管理接口恢复或立即获取时提前重试
+func (ar *AccountReceiver) holdAuth(err error) {
+	interval := time.Duration(ar.retry.AuthRetryInterval) * time.Second
+	if !ar.authHold {
+		ar.authHold = true
+		ar.state.update(func(st *AccountStatus) {
+			st.Healthy = false
+			st.Quarantined = true
+		})
+		log.Printf("[%s] 登录凭据被拒绝，停止按退避重试: %v", ar.name, err)
+		ar.alert(alertAuth, config.AlertCritical, "邮箱凭据需要处理", fmt.Sprintf(
+			"账号 [%s] 登录被服务器拒绝（密码过期、授权码失效等），已停止频繁重试以免账号被锁定\n"+
+				"更新密码并重新加载配置，或调用 /api/accounts/%s/resume 立即重试；之后每 %v 自动重试一次\n错误: %v",
+			ar.name, ar.name, interval, err))
+	}
+	log.Printf("[%s] 将在 %v 后重新尝试登录", ar.name, interval)
+
+	// 等待期间收到管理请求（恢复、立即获取、暂停、停止）时提前结束等待
+	ar.ctl.sleep(interval)
+}
@@ -0,0 +1,280 @@
+package receiver
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"log"
+	"mime/quotedprintable"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/emersion/go-message"
+	gomail "github.com/emersion/go-message/mail"
+	mtextproto "github.com/emersion/go-message/textproto"
+
+	"mail-receiver/config"
+	"mail-receiver/imap"
+	"mail-receiver/mailer"
+	"mail-receiver/rules"
+)
+
+// defaultReplySubject 自动回复的默认主题模板
+const defaultReplySubject = "Re: {{.Subject}}"
+
+// defaultReplyInterval 同一发件人的默认最短回复间隔
+const defaultReplyInterval = 24 * time.Hour
+
+// sendMail 发送自动回复（测试中替换）
+var sendMail = mailer.Send
+
+// noReplyPrefixes 不回复的发件人地址前缀（系统退信、通知类地址）
+var noReplyPrefixes = []string{
+	"noreply", "no-reply", "no_reply", "donotreply", "do-not-reply",
+	"mailer-daemon", "postmaster", "bounce",
+}
+
+// replyData 自动回复模板可用的字段
+type replyData struct {
+	Subject string
+	From    string // 发件人名称（联系人名称或地址）
+	Address string // 发件人地址
+	Account string
+	Date    string
+}
+
+// autoReply 规则的自动回复：主题和正文模板、同一发件人的回复间隔
+type autoReply struct {
+	subject  *template.Template
+	body     *template.Template
+	interval time.Duration
+}
+
+// autoReplier 账号的自动回复发信配置，各规则共用；按规则记录每个发件人下次可以回复的时间
+type autoReplier struct {
+	opts    mailer.Options
+	from    string
+	name    string
+	replies map[*rules.Rule]*autoReply
+
+	mu      sync.Mutex
+	replied map[replyKey]time.Time
+}
+
+// replyKey 回复间隔按规则和发件人地址分别计算
+type replyKey struct {
+	rule *rules.Rule
+	addr string
+}
+
+// newAutoReplier 创建账号的自动回复发信配置
+func newAutoReplier(acc *config.AccountConfig) (*autoReplier, error) {
+	cfg := acc.SMTP
+	if cfg.Username == "" {
+		cfg.Username = acc.Username
+		if cfg.Password == "" {
+			cfg.Password = acc.Password
+		}
+	}
+	opts := mailer.Options{
+		Host:     cfg.Host,
+		Port:     cfg.Port,
+		Username: cfg.Username,
+		Password: cfg.Password,
+		Security: cfg.Security,
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	if cfg.From == "" {
+		cfg.From = cfg.Username
+	}
+	if !strings.Contains(cfg.From, "@") {
+		return nil, fmt.Errorf("SMTP 缺少发件人地址 from")
+	}
+	return &autoReplier{
+		opts:    opts,
+		from:    cfg.From,
+		name:    cfg.FromName,
+		replies: make(map[*rules.Rule]*autoReply),
+		replied: make(map[replyKey]time.Time),
+	}, nil
+}
+
+// add 设置规则的自动回复内容
+func (a *autoReplier) add(rule *rules.Rule, cfg *config.ReplyConfig) error {
+	if cfg == nil || strings.TrimSpace(cfg.Body) == "" {
+		return fmt.Errorf("规则 %s 使用了 reply 动作，但未设置回复正文 reply.body", rule.Name)
+	}
+	subject := cfg.Subject
+	if subject == "" {
+		subject = defaultReplySubject
+	}
+	r := &autoReply{interval: defaultReplyInterval}
+	var err error
+	if r.subject, err = template.New(rule.Name).Option("missingkey=error").Parse(subject); err != nil {
+		return fmt.Errorf("规则 %s 的回复主题模板无效: %w", rule.Name, err)
+	}
+	if r.body, err = template.New(rule.Name).Option("missingkey=error").Parse(cfg.Body); err != nil {
+		return fmt.Errorf("规则 %s 的回复正文模板无效: %w", rule.Name, err)
+	}
+	if cfg.Interval > 0 {
+		r.interval = time.Duration(cfg.Interval) * time.Second
+	}
+	a.replies[rule] = r
+	return nil
+}
+
+// allow 同一规则对同一发件人距上次回复超过间隔时记录本次回复并返回true
+func (a *autoReplier) allow(rule *rules.Rule, addr string, interval time.Duration, now time.Time) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	key := replyKey{rule: rule, addr: strings.ToLower(addr)}
+	if until, ok := a.replied[key]; ok && now.Before(until) {
+		return false
+	}
+	// 清理已过期的记录（各规则的间隔不同，按记录各自的到期时间），避免长期运行时无限增长
+	for k, until := range a.replied {
+		if !now.Before(until) {
+			delete(a.replied, k)
+		}
+	}
+	a.replied[key] = now.Add(interval)
+	return true
+}
+
+// autoReply 按规则发送自动回复，自动生成的邮件、邮件列表和系统地址不回复
+func (ar *AccountReceiver) autoReply(email *imap.EmailMessage, rule *rules.Rule) {
+	a := ar.replier
+	r := a.replies[rule]
+	if r == nil {
+		return
+	}
+
+	h, err := replyHeader(email.Raw)
+	if err != nil {
+		log.Printf("[%s] 自动回复跳过（%v）: %s", ar.name, err, email.Subject)
+		return
+	}
+	to := email.FromAddress
+	if list, err := h.AddressList("Reply-To"); err == nil && len(list) > 0 {
+		to = list[0].Address
+	}
+	if reason := skipAutoReply(h, to, a.from); reason != "" {
+		log.Printf("[%s] 不自动回复 %s（%s）: %s", ar.name, to, reason, email.Subject)
+		return
+	}
+	if !a.allow(rule, to, r.interval, ar.clock.Now()) {
+		log.Printf("[%s] %v 内已回复过 %s，跳过: %s", ar.name, r.interval, to, email.Subject)
+		return
+	}
+
+	data := &replyData{
+		Subject: email.Subject,
+		From:    email.DisplayFrom(),
+		Address: email.FromAddress,
+		Account: ar.name,
+		Date:    email.Date.Format("2006-01-02 15:04"),
+	}
+	msg, err := a.message(r, data, to, email.MessageID, ar.clock.Now())
+	if err != nil {
+		log.Printf("[%s] 规则 %s 的自动回复生成失败: %v", ar.name, rule.Name, err)
+		return
+	}
+	if err := sendMail(a.opts, a.from, []string{to}, msg); err != nil {
+		log.Printf("[%s] 自动回复 %s 失败: %v", ar.name, to, err)
+		return
+	}
+	log.Printf("[%s] 已自动回复 %s: %s", ar.name, to, email.Subject)
+}
+
+// replyHeader 解析原邮件头
+func replyHeader(raw []byte) (gomail.Header, error) {
+	if len(raw) == 0 {
+		return gomail.Header{}, fmt.Errorf("没有原始邮件内容")
+	}
+	th, err := mtextproto.ReadHeader(bufio.NewReader(bytes.NewReader(raw)))
+	if err != nil {
+		return gomail.Header{}, fmt.Errorf("解析邮件头失败: %w", err)
+	}
+	return gomail.Header{Header: message.Header{Header: th}}, nil
+}
+
+// skipAutoReply 判断是否不应自动回复（防止回复循环），返回原因，应回复时返回空字符串
+// 参考 RFC 3834：不回复自动生成的邮件、邮件列表和退信
+func skipAutoReply(h gomail.Header, to, self string) string {
+	if v := strings.ToLower(strings.TrimSpace(h.Get("Auto-Submitted"))); v != "" && v != "no" {
+		return "Auto-Submitted: " + v
+	}
+	switch strings.ToLower(strings.TrimSpace(h.Get("Precedence"))) {
+	case "bulk", "junk", "list":
+		return "群发邮件"
+	}
+	if h.Has("List-Id") || h.Has("List-Unsubscribe") {
+		return "邮件列表"
+	}
+	if v := strings.ToLower(h.Get("X-Auto-Response-Suppress")); strings.Contains(v, "all") || strings.Contains(v, "autoreply") {
+		return "X-Auto-Response-Suppress"
+	}
+	if strings.TrimSpace(h.Get("Return-Path")) == "<>" {
+		return "退信"
+	}
+	if to == "" {
+		return "没有发件人地址"
+	}
+	if strings.EqualFold(to, self) {
+		return "发给自己"
+	}
+	local := strings.ToLower(to)
+	if i := strings.LastIndex(local, "@"); i >= 0 {
+		local = local[:i]
+	}
+	for _, p := range noReplyPrefixes {
+		if strings.HasPrefix(local, p) {
+			return "系统地址"
+		}
+	}
+	return ""
+}
+
+// message 生成回复邮件：引用原邮件的 Message-ID，并标记为自动回复，now 为邮件的 Date
+func (a *autoReplier) message(r *autoReply, data *replyData, to, messageID string, now time.Time) ([]byte, error) {
+	var subject, body bytes.Buffer
+	if err := r.subject.Execute(&subject, data); err != nil {
+		return nil, err
+	}
+	if err := r.body.Execute(&body, data); err != nil {
+		return nil, err
+	}
+
+	h := &mailer.Header{}
+	h.Add("From", mailer.FormatAddress(a.name, a.from))
+	h.Add("To", mailer.FormatAddress("", to))
+	h.Add("Subject", mailer.EncodeHeader(subject.String()))
+	h.Add("Date", now.Format(time.RFC1123Z))
+	h.Add("Message-ID", mailer.MessageID(a.from))
+	if messageID != "" {
+		id := "<" + strings.Trim(messageID, "<>") + ">"
+		h.Add("In-Reply-To", id)
+		h.Add("References", id)
+	}
+	h.Add("Auto-Submitted", "auto-replied")
+	h.Add("X-Auto-Response-Suppress", "All")
+	h.Add("MIME-Version", "1.0")
+	h.Add("Content-Type", "text/plain; charset=utf-8")
+	h.Add("Content-Transfer-Encoding", "quoted-printable")
+
+	var b bytes.Buffer
+	b.Write(h.Bytes())
+	b.WriteString("\r\n")
+	qp := quotedprintable.NewWriter(&b)
+	if _, err := qp.Write(body.Bytes()); err != nil {
+		return nil, err
+	}
+	if err := qp.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
@@ -0,0 +1,51 @@
+package receiver
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"mail-receiver/imap"
+)
+
+// bounceTag 退信的标签，规则可按 tags 匹配（如推送到单独的目标）
+const bounceTag = "bounce"
+
+// bounceActions 投递状态的中文名称
+var bounceActions = map[string]string{
+	"failed":  "投递失败",
+	"delayed": "延迟投递",
+}
+
+// bounceSummary 退信摘要：原邮件主题，每个收件人的投递状态、状态码和诊断信息
+func bounceSummary(b *imap.Bounce) string {
+	var sb strings.Builder
+	sb.WriteString("退信")
+	if b.OriginalSubject != "" {
+		fmt.Fprintf(&sb, "（原邮件: %s）", b.OriginalSubject)
+	}
+	sb.WriteString("\n")
+	for _, r := range b.Recipients {
+		fmt.Fprintf(&sb, "%s: %s", r.Recipient, bounceActions[r.Action])
+		if r.Status != "" {
+			fmt.Fprintf(&sb, " %s", r.Status)
+		}
+		if r.Diagnostic != "" {
+			fmt.Fprintf(&sb, " (%s)", r.Diagnostic)
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// addTag 加入标签，保持去重和排序
+func addTag(tags []string, tag string) []string {
+	for _, t := range tags {
+		if t == tag {
+			return tags
+		}
+	}
+	tags = append(tags, tag)
+	sort.Strings(tags)
+	return tags
+}
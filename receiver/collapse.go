@@ -0,0 +1,354 @@
+package receiver
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"mail-receiver/clock"
+	"mail-receiver/config"
+	"mail-receiver/imap"
+	"mail-receiver/metrics"
+	"mail-receiver/push"
+	"mail-receiver/state"
+
+	goimap "github.com/emersion/go-imap"
+)
+
+// collapsedMail 已合并、等待汇总推送的邮件
+type collapsedMail struct {
+	email       *imap.EmailMessage
+	folder      string
+	uidValidity uint32
+	pusher      push.Pusher // 邮件原本的推送目标（账号的推送或规则指定的目标），汇总按目标分别推送
+}
+
+// stormCollapser 邮件风暴合并：统计时间窗口内的推送数，超过阈值后暂存邮件，窗口结束时合并为一条推送
+// 摘要模式下所有邮件都暂存，窗口结束时推送摘要；只在账号的连接协程中使用
+type stormCollapser struct {
+	digest      bool // 摘要模式
+	threshold   int
+	window      time.Duration
+	maxSubjects int
+	arrivals    []time.Time // 窗口内默认推送的邮件时间
+	pending     []collapsedMail
+	since       time.Time     // 第一封暂存邮件的时间
+	ready       chan struct{} // 窗口结束时通知运行循环
+	timer       clock.Timer
+	clock       clock.Clock
+}
+
+// newStormCollapser 创建邮件风暴合并，启用摘要推送时按摘要模式创建（配置校验保证两者不同时启用），都未启用时返回nil
+func newStormCollapser(cfg config.CollapseConfig, digest config.DigestConfig, clk clock.Clock) *stormCollapser {
+	if digest.Window > 0 {
+		return &stormCollapser{
+			digest:      true,
+			window:      time.Duration(digest.Window) * time.Second,
+			maxSubjects: digest.MaxItems,
+			ready:       make(chan struct{}, 1),
+			clock:       clk,
+		}
+	}
+	if cfg.Threshold <= 0 {
+		return nil
+	}
+	return &stormCollapser{
+		threshold:   cfg.Threshold,
+		window:      time.Duration(cfg.Window) * time.Second,
+		maxSubjects: cfg.MaxSubjects,
+		ready:       make(chan struct{}, 1),
+		clock:       clk,
+	}
+}
+
+// due 窗口结束时可读的通道，未启用时返回nil（select 时永不就绪）
+func (s *stormCollapser) due() <-chan struct{} {
+	if s == nil {
+		return nil
+	}
+	return s.ready
+}
+
+// admit 记录一封待推送的邮件，返回是否应合并（摘要模式，窗口内超过阈值，或已有暂存邮件）
+func (s *stormCollapser) admit(now time.Time) bool {
+	if s.digest {
+		return true
+	}
+	cutoff := now.Add(-s.window)
+	i := 0
+	for i < len(s.arrivals) && s.arrivals[i].Before(cutoff) {
+		i++
+	}
+	s.arrivals = append(s.arrivals[i:], now)
+	return len(s.pending) > 0 || len(s.arrivals) > s.threshold
+}
+
+// add 暂存邮件，第一封时开始计时
+func (s *stormCollapser) add(m collapsedMail, now time.Time) {
+	if len(s.pending) == 0 {
+		s.since = now
+		s.schedule(s.window)
+	}
+	s.pending = append(s.pending, m)
+}
+
+// schedule 在 d 之后通知运行循环
+func (s *stormCollapser) schedule(d time.Duration) {
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+	s.timer = s.clock.AfterFunc(d, func() {
+		select {
+		case s.ready <- struct{}{}:
+		default:
+		}
+	})
+}
+
+// take 窗口已结束时取出所有暂存邮件
+func (s *stormCollapser) take(now time.Time) ([]collapsedMail, time.Time) {
+	if len(s.pending) == 0 || now.Sub(s.since) < s.window {
+		return nil, time.Time{}
+	}
+	mails, since := s.pending, s.since
+	s.pending = nil
+	return mails, since
+}
+
+// restore 汇总推送失败时放回暂存邮件，稍后重试
+func (s *stormCollapser) restore(mails []collapsedMail, since time.Time) {
+	s.pending = append(mails, s.pending...)
+	s.since = since
+	s.schedule(time.Minute)
+}
+
+// flushCollapsed 窗口结束时按推送目标分别推送合并的邮件，成功后标记为已读
+func (ar *AccountReceiver) flushCollapsed(folder string, uidValidity uint32) {
+	if ar.storm == nil {
+		return
+	}
+	mails, since := ar.storm.take(ar.clock.Now())
+	if len(mails) == 0 {
+		return
+	}
+
+	for _, group := range groupByPusher(mails) {
+		var title, text string
+		if ar.storm.digest {
+			title, text = digestMessage(group, ar.clock.Since(since), ar.storm.maxSubjects)
+		} else {
+			title, text = collapseMessage(group, ar.clock.Since(since), ar.storm.maxSubjects)
+		}
+		if err := ar.pushHeld(folder, uidValidity, group, title, text); err != nil {
+			log.Printf("[%s] 合并推送失败，稍后重试: %v", ar.name, err)
+			ar.storm.restore(group, since)
+		}
+	}
+}
+
+// groupByPusher 按推送目标分组，保持邮件原来的顺序
+func groupByPusher(mails []collapsedMail) [][]collapsedMail {
+	var groups [][]collapsedMail
+	index := make(map[push.Pusher]int)
+	for _, m := range mails {
+		i, ok := index[m.pusher]
+		if !ok {
+			i = len(groups)
+			index[m.pusher] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], m)
+	}
+	return groups
+}
+
+// heldInMemory 内存中暂存（合并、摘要、免打扰）的 folder 邮件UID
+func (ar *AccountReceiver) heldInMemory(folder string, uidValidity uint32) map[uint32]bool {
+	var lists [][]collapsedMail
+	if ar.storm != nil {
+		lists = append(lists, ar.storm.pending)
+	}
+	if ar.quiet != nil {
+		lists = append(lists, ar.quiet.pending)
+	}
+	held := make(map[uint32]bool)
+	for _, list := range lists {
+		for _, m := range list {
+			if m.folder == folder && m.uidValidity == uidValidity {
+				held[m.email.UID] = true
+			}
+		}
+	}
+	return held
+}
+
+// recoverHeld 重新获取进度中记录为暂存、但不在内存中（held 之外）的邮件，放在本次获取的邮件之前处理
+// 已在其他客户端中标记已读或已删除的邮件不再处理，从进度中移除
+func (ar *AccountReceiver) recoverHeld(folder string, uidValidity uint32, cp state.Checkpoint, held map[uint32]bool, messages []*goimap.Message) ([]*goimap.Message, state.Checkpoint) {
+	if cp.UIDValidity != uidValidity || len(cp.Held) == 0 {
+		return messages, cp
+	}
+	fetched := make(map[uint32]bool, len(messages))
+	for _, msg := range messages {
+		fetched[msg.Uid] = true
+	}
+	var missing []uint32
+	for _, uid := range cp.Held {
+		if !held[uid] && !fetched[uid] {
+			missing = append(missing, uid)
+		}
+	}
+	if len(missing) == 0 {
+		return messages, cp
+	}
+
+	recovered, err := ar.client.FetchUnread(missing)
+	if err != nil {
+		log.Printf("[%s] 获取暂存的邮件失败: %v", ar.name, err)
+		return messages, cp
+	}
+	found := make(map[uint32]bool, len(recovered))
+	for _, msg := range recovered {
+		found[msg.Uid] = true
+	}
+	var gone []uint32
+	for _, uid := range missing {
+		if !found[uid] {
+			gone = append(gone, uid)
+		}
+	}
+	if len(gone) > 0 {
+		cp.Held = removeUIDs(cp.Held, gone)
+		if err := ar.checkpoints.Set(ar.name, folder, cp); err != nil {
+			log.Printf("[%s] 保存处理进度失败: %v", ar.name, err)
+		}
+	}
+	if len(recovered) > 0 {
+		log.Printf("[%s] 重新获取 %d 封暂存后未推送的邮件", ar.name, len(recovered))
+	}
+	return append(recovered, messages...), cp
+}
+
+// pushHeld 推送暂存邮件的汇总，成功后保存记录、标记已读并从进度的暂存记录中移除
+// folder 为当前选中的文件夹，其他文件夹的邮件需要临时选中后标记，完成后重新选中 folder
+// mails 的推送目标相同（groupByPusher 分组）
+func (ar *AccountReceiver) pushHeld(folder string, uidValidity uint32, mails []collapsedMail, title, text string) error {
+	start := time.Now()
+	err := mails[0].pusher.Push(title, text, &push.Meta{Account: ar.name, Folder: folder})
+	metrics.PushDuration.Observe(ar.name, time.Since(start).Seconds())
+	if err != nil {
+		metrics.PushFailures.Inc(ar.name)
+		return err
+	}
+	log.Printf("[%s] 已合并推送 %d 封邮件", ar.name, len(mails))
+	metrics.EmailsPushed.Add(ar.name, float64(len(mails)))
+
+	// 按文件夹分组，UIDVALIDITY 变化后原来的UID已失效，只保存记录不再标记
+	var others []string
+	byFolder := make(map[string][]collapsedMail)
+	for _, m := range mails {
+		ar.observeDelivery(m.email)
+		ar.saveRecord(m.folder, m.uidValidity, m.email, true)
+		if _, ok := byFolder[m.folder]; !ok && m.folder != folder {
+			others = append(others, m.folder)
+		}
+		byFolder[m.folder] = append(byFolder[m.folder], m)
+	}
+	for f, ms := range byFolder {
+		uids := make(map[uint32][]uint32)
+		for _, m := range ms {
+			uids[m.uidValidity] = append(uids[m.uidValidity], m.email.UID)
+		}
+		for v, list := range uids {
+			ar.releaseHeld(f, v, list)
+		}
+	}
+	ar.markCollapsed(folder, uidValidity, byFolder[folder])
+	if len(others) == 0 {
+		return nil
+	}
+	for _, f := range others {
+		mbox, err := ar.client.SelectFolder(f)
+		if err != nil {
+			log.Printf("[%s] %v", ar.name, err)
+			continue
+		}
+		ar.markCollapsed(f, mbox.UidValidity, byFolder[f])
+	}
+	if _, err := ar.client.SelectFolder(folder); err != nil {
+		log.Printf("[%s] %v", ar.name, err)
+	}
+	return nil
+}
+
+// markCollapsed 将已合并推送的邮件标记为已读，folder 必须是当前选中的文件夹
+func (ar *AccountReceiver) markCollapsed(folder string, uidValidity uint32, mails []collapsedMail) {
+	var uids []uint32
+	msgIDs := make(map[uint32]string)
+	for _, m := range mails {
+		if m.uidValidity == uidValidity {
+			uids = append(uids, m.email.UID)
+			msgIDs[m.email.UID] = m.email.MessageID
+		}
+	}
+	if len(uids) == 0 {
+		return
+	}
+	if err := ar.markAsRead(folder, triggerPushSuccess, uids, msgIDs); err != nil {
+		log.Printf("[%s] %v", ar.name, err)
+	}
+}
+
+// collapseMessage 生成汇总推送的标题和内容：列出最新的几封邮件主题
+func collapseMessage(mails []collapsedMail, span time.Duration, maxSubjects int) (title, text string) {
+	minutes := int(math.Ceil(span.Minutes()))
+	if minutes < 1 {
+		minutes = 1
+	}
+	title = fmt.Sprintf("收到 %d 封新邮件（最近%d分钟）", len(mails), minutes)
+
+	var b strings.Builder
+	shown := 0
+	for i := len(mails) - 1; i >= 0 && shown < maxSubjects; i-- {
+		email := mails[i].email
+		subject := email.Subject
+		if subject == "" {
+			subject = "(无主题)"
+		}
+		fmt.Fprintf(&b, "• %s — %s\n", subject, email.DisplayFrom())
+		shown++
+	}
+	if rest := len(mails) - shown; rest > 0 {
+		fmt.Fprintf(&b, "…以及其他 %d 封", rest)
+	}
+	return title, strings.TrimRight(b.String(), "\n")
+}
+
+// digestMessage 生成摘要推送的标题和内容：按收到顺序列出邮件的时间、主题和发件人
+func digestMessage(mails []collapsedMail, span time.Duration, maxItems int) (title, text string) {
+	minutes := int(math.Ceil(span.Minutes()))
+	if minutes < 1 {
+		minutes = 1
+	}
+	title = fmt.Sprintf("邮件摘要：%d 封新邮件（最近%d分钟）", len(mails), minutes)
+
+	var b strings.Builder
+	for i, m := range mails {
+		if i == maxItems {
+			fmt.Fprintf(&b, "…以及其他 %d 封", len(mails)-maxItems)
+			break
+		}
+		email := m.email
+		subject := email.Subject
+		if subject == "" {
+			subject = "(无主题)"
+		}
+		if !email.Date.IsZero() {
+			fmt.Fprintf(&b, "%s ", email.Date.Local().Format("15:04"))
+		}
+		fmt.Fprintf(&b, "%s — %s\n", subject, email.DisplayFrom())
+	}
+	return title, strings.TrimRight(b.String(), "\n")
+}
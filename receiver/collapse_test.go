@@ -0,0 +1,106 @@
+package receiver
+
+import (
+	"testing"
+	"time"
+
+	"mail-receiver/clock"
+	"mail-receiver/config"
+	"mail-receiver/imap"
+)
+
+// expectDue 期望合并窗口结束的通知在短时间内到达（Fake 的 AfterFunc 在新协程中执行）
+func expectDue(t *testing.T, s *stormCollapser) {
+	t.Helper()
+	select {
+	case <-s.due():
+	case <-time.After(2 * time.Second):
+		t.Fatalf("窗口结束后没有收到通知")
+	}
+}
+
+// expectNotDue 期望窗口尚未结束
+func expectNotDue(t *testing.T, s *stormCollapser) {
+	t.Helper()
+	select {
+	case <-s.due():
+		t.Fatalf("窗口未结束却收到了通知")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestDigestWindow(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC))
+	s := newStormCollapser(config.CollapseConfig{}, config.DigestConfig{Window: 600, MaxItems: 10}, fake)
+
+	// 摘要模式下所有邮件都暂存，窗口从第一封开始计时
+	for i := 0; i < 3; i++ {
+		if !s.admit(fake.Now()) {
+			t.Fatalf("摘要模式下第 %d 封邮件未暂存", i+1)
+		}
+		s.add(collapsedMail{email: &imap.EmailMessage{Subject: "邮件"}, folder: "INBOX"}, fake.Now())
+		fake.Advance(time.Minute)
+	}
+
+	fake.Advance(6 * time.Minute)
+	expectNotDue(t, s)
+	if mails, _ := s.take(fake.Now()); mails != nil {
+		t.Fatalf("窗口未结束就取出了 %d 封邮件", len(mails))
+	}
+
+	fake.Advance(time.Minute)
+	expectDue(t, s)
+	mails, since := s.take(fake.Now())
+	if len(mails) != 3 {
+		t.Fatalf("取出 %d 封邮件，期望 3 封", len(mails))
+	}
+	if want := time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC); !since.Equal(want) {
+		t.Fatalf("窗口开始时间 %v，期望 %v", since, want)
+	}
+
+	// 汇总推送失败放回后一分钟重试
+	s.restore(mails, since)
+	fake.Advance(59 * time.Second)
+	expectNotDue(t, s)
+	fake.Advance(time.Second)
+	expectDue(t, s)
+	if mails, _ := s.take(fake.Now()); len(mails) != 3 {
+		t.Fatalf("重试时取出 %d 封邮件，期望 3 封", len(mails))
+	}
+}
+
+func TestCollapseThreshold(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC))
+	s := newStormCollapser(config.CollapseConfig{Threshold: 2, Window: 60}, config.DigestConfig{}, fake)
+
+	// 窗口内不超过阈值时直接推送
+	for i := 0; i < 2; i++ {
+		if s.admit(fake.Now()) {
+			t.Fatalf("第 %d 封邮件未超过阈值却被合并", i+1)
+		}
+		fake.Advance(10 * time.Second)
+	}
+	if !s.admit(fake.Now()) {
+		t.Fatalf("超过阈值的邮件未被合并")
+	}
+	s.add(collapsedMail{email: &imap.EmailMessage{Subject: "邮件"}, folder: "INBOX"}, fake.Now())
+
+	// 已有暂存邮件时，之后的邮件都合并
+	fake.Advance(30 * time.Second)
+	if !s.admit(fake.Now()) {
+		t.Fatalf("已有暂存邮件时新邮件未被合并")
+	}
+	s.add(collapsedMail{email: &imap.EmailMessage{Subject: "邮件"}, folder: "INBOX"}, fake.Now())
+
+	fake.Advance(30 * time.Second)
+	expectDue(t, s)
+	if mails, _ := s.take(fake.Now()); len(mails) != 2 {
+		t.Fatalf("取出 %d 封邮件，期望 2 封", len(mails))
+	}
+
+	// 窗口滑过后计数重新开始
+	fake.Advance(2 * time.Minute)
+	if s.admit(fake.Now()) {
+		t.Fatalf("窗口滑过后的邮件仍被合并")
+	}
+}
@@ -0,0 +1,203 @@
+package receiver
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"mail-receiver/clock"
+)
+
+// ErrAccountNotFound 账号不存在
+var ErrAccountNotFound = errors.New("账号不存在")
+
+// control 账号运行控制（暂停/恢复/立即获取/停止），由管理接口设置，运行循环读取
+type control struct {
+	mu      sync.Mutex
+	paused  bool
+	stopped bool
+	fetch   bool          // 待处理的立即获取请求
+	wake    chan struct{} // 状态变化时通知运行循环
+	clock   clock.Clock   // 重试等待的时间来源
+}
+
+// newControl 创建运行控制
+func newControl(clk clock.Clock) *control {
+	return &control{wake: make(chan struct{}, 1), clock: clk}
+}
+
+// notify 唤醒运行循环（不阻塞）
+func (c *control) notify() {
+	select {
+	case c.wake <- struct{}{}:
+	default:
+	}
+}
+
+// setPaused 暂停或恢复
+func (c *control) setPaused(paused bool) {
+	c.mu.Lock()
+	c.paused = paused
+	c.mu.Unlock()
+	c.notify()
+}
+
+// stop 停止账号，运行循环退出后不再重启
+func (c *control) stop() {
+	c.mu.Lock()
+	c.stopped = true
+	c.mu.Unlock()
+	c.notify()
+}
+
+// requestFetch 请求立即获取邮件
+func (c *control) requestFetch() {
+	c.mu.Lock()
+	c.fetch = true
+	c.mu.Unlock()
+	c.notify()
+}
+
+// takeFetch 取出立即获取请求
+func (c *control) takeFetch() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fetch := c.fetch
+	c.fetch = false
+	return fetch
+}
+
+// state 返回是否暂停、是否已停止
+func (c *control) state() (paused, stopped bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.paused, c.stopped
+}
+
+// waitResume 暂停期间阻塞，返回false表示账号已停止
+func (c *control) waitResume() bool {
+	for {
+		paused, stopped := c.state()
+		if stopped {
+			return false
+		}
+		if !paused {
+			return true
+		}
+		<-c.wake
+	}
+}
+
+// sleep 等待 d，期间收到管理请求时提前返回
+func (c *control) sleep(d time.Duration) {
+	timer := c.clock.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C():
+	case <-c.wake:
+	}
+}
+
+// account 按名称查找账号
+func (r *Receiver) account(name string) (*AccountReceiver, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	ar, ok := r.accounts[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrAccountNotFound, name)
+	}
+	return ar, nil
+}
+
+// Pause 暂停账号：断开连接，恢复前不再获取邮件
+func (r *Receiver) Pause(name string) error {
+	ar, err := r.account(name)
+	if err != nil {
+		return err
+	}
+	log.Printf("[%s] 已暂停", name)
+	ar.state.update(func(st *AccountStatus) { st.Paused = true })
+	ar.ctl.setPaused(true)
+	return nil
+}
+
+// Resume 恢复已暂停的账号；登录凭据被拒绝而隔离的账号立即重新尝试登录
+func (r *Receiver) Resume(name string) error {
+	ar, err := r.account(name)
+	if err != nil {
+		return err
+	}
+	log.Printf("[%s] 已恢复", name)
+	ar.state.update(func(st *AccountStatus) { st.Paused = false })
+	ar.ctl.setPaused(false)
+	return nil
+}
+
+// Fetch 请求账号立即获取一次邮件；正在重试等待时立即重新连接
+func (r *Receiver) Fetch(name string) error {
+	ar, err := r.account(name)
+	if err != nil {
+		return err
+	}
+	if paused, _ := ar.ctl.state(); paused {
+		return fmt.Errorf("账号 %s 已暂停", name)
+	}
+	ar.ctl.requestFetch()
+	return nil
+}
+
+// stopTimeout Start 的 ctx 取消时等待账号停止的时间
+const stopTimeout = 30 * time.Second
+
+// Stop 停止所有账号（断开连接），最多等待 timeout，返回是否全部停止
+func (r *Receiver) Stop(timeout time.Duration) bool {
+	if r.cancel != nil {
+		r.cancel()
+	}
+	r.mu.RLock()
+	for _, ar := range r.accounts {
+		ar.ctl.stop()
+	}
+	r.mu.RUnlock()
+
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+	defer r.FlushRetries()
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// FlushRetries 停止推送重试，仍在等待重试的推送写入推送失败记录（app.dead_letter），程序退出前调用
+func (r *Receiver) FlushRetries() {
+	r.pushRetries.close()
+}
+
+// WaitConnected 等待所有账号完成首次连接（已连接、已暂停或连接失败），最多等待 timeout，返回是否全部完成
+func (r *Receiver) WaitConnected(timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for {
+		settled := true
+		for _, st := range r.Status() {
+			if !st.Connected && !st.Paused && st.Retries == 0 {
+				settled = false
+				break
+			}
+		}
+		if settled {
+			return true
+		}
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+}
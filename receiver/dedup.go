@@ -0,0 +1,49 @@
+package receiver
+
+import "mail-receiver/imap"
+
+// maxFolderDedup 每个账号记录的最近邮件数，用于识别多个文件夹中的同一封邮件
+const maxFolderDedup = 5000
+
+// folderDedup 同一账号监控多个文件夹时识别重复邮件（如 Gmail 的 INBOX 和 [Gmail]/All Mail），
+// 按 X-GM-MSGID 或 Message-ID 记录邮件首次出现的文件夹，超过上限时淘汰最早的记录；只在账号的连接协程中使用
+type folderDedup struct {
+	folders map[string]string
+	order   []string
+}
+
+// dedupKey 邮件的识别键，优先使用 Gmail 的 X-GM-MSGID；都没有时返回空
+func dedupKey(email *imap.EmailMessage) string {
+	if email.GmailMsgID != "" {
+		return "gm:" + email.GmailMsgID
+	}
+	if email.MessageID != "" {
+		return "id:" + email.MessageID
+	}
+	return ""
+}
+
+// seen 记录邮件所在的文件夹，邮件已在其他文件夹中出现过时返回该文件夹，否则返回空
+// 同一文件夹中再次出现（如推送失败后重新获取）不视为重复
+func (d *folderDedup) seen(email *imap.EmailMessage, folder string) string {
+	key := dedupKey(email)
+	if key == "" {
+		return ""
+	}
+	if first, ok := d.folders[key]; ok {
+		if first != folder {
+			return first
+		}
+		return ""
+	}
+	if d.folders == nil {
+		d.folders = make(map[string]string)
+	}
+	d.folders[key] = folder
+	d.order = append(d.order, key)
+	for len(d.order) > maxFolderDedup {
+		delete(d.folders, d.order[0])
+		d.order = d.order[1:]
+	}
+	return ""
+}
@@ -0,0 +1,71 @@
+package receiver
+
+import (
+	"context"
+	"errors"
+
+	"mail-receiver/clock"
+	"mail-receiver/config"
+	"mail-receiver/imap"
+	"mail-receiver/push"
+)
+
+// Message 交给嵌入程序处理的推送
+type Message struct {
+	Account  string
+	Folder   string
+	Email    *imap.EmailMessage // 关联的邮件；系统告警、合并推送等不对应单封邮件的通知为nil
+	Title    string             // 经过规则标题模板、翻译等处理后的标题
+	Text     string             // 推送内容（正文及收件时间、发件人等信息）
+	Priority string             // 规则设置的优先级（low/normal/high/urgent），未设置时为空
+	Sound    string             // 规则设置的铃声
+}
+
+// Handler 嵌入程序处理推送的回调，返回错误视为推送失败（邮件保持未读，之后重试）
+// 各账号在自己的协程中调用，需要自行处理并发；ctx 在接收器停止时取消
+type Handler func(ctx context.Context, msg *Message) error
+
+// Options 创建接收器的选项
+type Options struct {
+	Config  *config.Config // 必填，使用 config.LoadConfig 加载，或在代码中构建后调用 SetDefaults
+	Handler Handler        // 可选，设置后代替配置中的推送目标（包括规则指定的推送目标）
+	Clock   clock.Clock    // 可选，时间来源（心跳、轮询、重试等待、合并/摘要窗口），模拟运行和测试时可传入 clock.NewFake 快进时间
+}
+
+// New 按选项创建接收器，供其他 Go 程序嵌入使用：
+//
+//	r, err := receiver.New(receiver.Options{Config: cfg, Handler: handle})
+//	if err := r.Start(ctx); err != nil { ... }
+//	r.Wait() // ctx 取消或调用 Stop 后返回
+func New(opts Options) (*Receiver, error) {
+	if opts.Config == nil {
+		return nil, errors.New("缺少配置")
+	}
+	r := NewReceiver(opts.Config)
+	r.handler = opts.Handler
+	if opts.Clock != nil {
+		r.clock = opts.Clock
+		r.heartbeat.SetClock(opts.Clock)
+	}
+	return r, nil
+}
+
+// Wait 阻塞直到所有账号停止
+func (r *Receiver) Wait() {
+	r.wg.Wait()
+}
+
+// handlerPusher 将嵌入程序的回调适配为推送后端
+type handlerPusher struct {
+	r *Receiver
+}
+
+// Push 实现 push.Pusher
+func (p *handlerPusher) Push(title, msg string, meta *push.Meta) error {
+	m := &Message{Title: title, Text: msg}
+	if meta != nil {
+		m.Account, m.Folder, m.Email = meta.Account, meta.Folder, meta.Email
+		m.Priority, m.Sound = meta.Priority, meta.Sound
+	}
+	return p.r.handler(p.r.ctx, m)
+}
@@ -0,0 +1,186 @@
+package receiver
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"mail-receiver/audit"
+	"mail-receiver/config"
+	"mail-receiver/imap"
+	"mail-receiver/push"
+)
+
+// triggerExternal 审计日志中其他客户端引起的变化
+const triggerExternal = "external"
+
+// flagDescriptions 标志变化的说明：添加时、移除时（为空表示不提示）
+var flagDescriptions = map[string][2]string{
+	"\\Seen":     {"已读", "标为未读"},
+	"\\Answered": {"已回复", ""},
+	"\\Flagged":  {"已加星标", "取消星标"},
+	"\\Deleted":  {"已标记删除", "取消删除标记"},
+}
+
+// ignoredFlags 不作为状态变化的标志（服务器维护的标志、本程序自己的临时关键字）
+var ignoredFlags = map[string]bool{
+	"\\Recent":     true,
+	pendingKeyword: true,
+}
+
+// flagWatch 跟踪最近推送的邮件，IDLE 期间收到状态变化通知后比较标志，发现其他人已读、回复、加星标或删除
+// 只在账号的连接协程中使用
+type flagWatch struct {
+	action  string
+	limit   int
+	folders map[string]*watchedFolder
+}
+
+// watchedFolder 一个文件夹中跟踪的邮件，UIDVALIDITY 变化后清空
+type watchedFolder struct {
+	uidValidity uint32
+	mails       []*watchedMail // 按推送顺序，超过上限时丢弃最早的
+}
+
+// watchedMail 跟踪的邮件
+type watchedMail struct {
+	uid       uint32
+	messageID string
+	subject   string
+	from      string
+	flags     []string // nil 表示尚未获取基准标志
+}
+
+// newFlagWatch 按配置创建，未启用时返回nil
+func newFlagWatch(cfg config.FlagChangesConfig) *flagWatch {
+	if cfg.Action == "" {
+		return nil
+	}
+	return &flagWatch{action: cfg.Action, limit: cfg.MaxTracked, folders: make(map[string]*watchedFolder)}
+}
+
+// folder 返回文件夹的跟踪记录，UIDVALIDITY 变化时清空
+func (w *flagWatch) folder(name string, uidValidity uint32) *watchedFolder {
+	wf := w.folders[name]
+	if wf == nil || wf.uidValidity != uidValidity {
+		wf = &watchedFolder{uidValidity: uidValidity}
+		w.folders[name] = wf
+	}
+	return wf
+}
+
+// add 开始跟踪已推送的邮件，基准标志在本批次处理完后获取
+func (w *flagWatch) add(folder string, uidValidity uint32, email *imap.EmailMessage) {
+	if w == nil {
+		return
+	}
+	wf := w.folder(folder, uidValidity)
+	wf.mails = append(wf.mails, &watchedMail{
+		uid:       email.UID,
+		messageID: email.MessageID,
+		subject:   email.Subject,
+		from:      email.DisplayFrom(),
+	})
+	if n := len(wf.mails) - w.limit; n > 0 {
+		wf.mails = wf.mails[n:]
+	}
+}
+
+// needsBaseline 是否有尚未获取基准标志的邮件
+func (wf *watchedFolder) needsBaseline() bool {
+	for _, m := range wf.mails {
+		if m.flags == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// checkFlagChanges 收到状态变化通知或有新跟踪的邮件时获取标志并比较，folder 必须是当前选中的文件夹
+func (ar *AccountReceiver) checkFlagChanges(folder string, uidValidity uint32) {
+	if ar.flagWatch == nil {
+		return
+	}
+	changed := ar.client.TakeMessageChanges(folder)
+	wf := ar.flagWatch.folder(folder, uidValidity)
+	if len(wf.mails) == 0 || (!changed && !wf.needsBaseline()) {
+		return
+	}
+
+	uids := make([]uint32, len(wf.mails))
+	for i, m := range wf.mails {
+		uids[i] = m.uid
+	}
+	current, err := ar.client.FetchFlags(uids...)
+	if err != nil {
+		log.Printf("[%s] %v", ar.name, err)
+		return
+	}
+
+	kept := wf.mails[:0]
+	for _, m := range wf.mails {
+		flags, ok := current[m.uid]
+		switch {
+		case !ok:
+			// 邮件已从文件夹中删除（或被移走），之后不再跟踪
+			if m.flags != nil {
+				ar.reportFlagChange(folder, m, []string{"已删除"}, "expunged")
+			}
+			continue
+		case m.flags == nil:
+			m.flags = flags
+		default:
+			if descs, detail := diffFlags(m.flags, flags); detail != "" {
+				ar.reportFlagChange(folder, m, descs, detail)
+			}
+			m.flags = flags
+		}
+		kept = append(kept, m)
+	}
+	wf.mails = kept
+}
+
+// diffFlags 比较标志变化，返回说明和审计日志中的明细（如 "+\Seen -\Flagged"）
+func diffFlags(old, cur []string) (descs []string, detail string) {
+	var parts []string
+	for _, f := range cur {
+		if !ignoredFlags[f] && !hasFlag(old, f) {
+			parts = append(parts, "+"+f)
+			if d, ok := flagDescriptions[f]; ok {
+				descs = append(descs, d[0])
+			} else {
+				descs = append(descs, "添加标签 "+f)
+			}
+		}
+	}
+	for _, f := range old {
+		if !ignoredFlags[f] && !hasFlag(cur, f) {
+			parts = append(parts, "-"+f)
+			if d, ok := flagDescriptions[f]; !ok {
+				descs = append(descs, "移除标签 "+f)
+			} else if d[1] != "" {
+				descs = append(descs, d[1])
+			}
+		}
+	}
+	return descs, strings.Join(parts, " ")
+}
+
+// reportFlagChange 记录日志和审计日志，notify 方式时推送通知
+func (ar *AccountReceiver) reportFlagChange(folder string, m *watchedMail, descs []string, detail string) {
+	if len(descs) == 0 {
+		descs = []string{"状态变化"}
+	}
+	change := strings.Join(descs, "、")
+	log.Printf("[%s] 邮件在服务器上%s: %s (%s)", ar.name, change, m.subject, detail)
+	ar.auditLog(audit.ActionFlagChange, detail, folder, triggerExternal, []uint32{m.uid}, map[uint32]string{m.uid: m.messageID}, nil)
+
+	if ar.flagWatch.action != config.FlagChangeNotify || ar.pusher == nil {
+		return
+	}
+	title := fmt.Sprintf("%s: %s", change, m.subject)
+	text := fmt.Sprintf("邮件已在其他客户端中处理\n\n主题: %s\n发件人: %s\n文件夹: %s\n变化: %s", m.subject, m.from, folder, change)
+	if err := ar.pusher.Push(title, text, &push.Meta{Account: ar.name, Folder: folder}); err != nil {
+		log.Printf("[%s] 状态变化通知推送失败: %v", ar.name, err)
+	}
+}
@@ -0,0 +1,48 @@
+package receiver
+
+import (
+	"fmt"
+
+	"mail-receiver/config"
+	"mail-receiver/imap"
+)
+
+// Folders 通过一个临时连接列出账号的文件夹树，不影响正在运行的监控连接
+// withStatus 为 true 时查询每个文件夹的邮件数和未读数（每个文件夹一条 STATUS 命令）
+func (r *Receiver) Folders(name string, withStatus bool) ([]*imap.Folder, error) {
+	ar, err := r.account(name)
+	if err != nil {
+		return nil, err
+	}
+	release, ok := ar.connections.TryAcquire(ar.config.Server, ar.config.Username, ar.config.MaxConnections)
+	if !ok {
+		return nil, fmt.Errorf("%s 的连接数已达上限，请稍后重试", ar.config.Server)
+	}
+	defer release()
+	return listFolders(name, ar.config, withStatus)
+}
+
+// ListFolders 不启动监控，直接连接配置中的账号列出文件夹树（供命令行使用）
+func ListFolders(cfg *config.Config, name string, withStatus bool) ([]*imap.Folder, error) {
+	accCfg, ok := cfg.Accounts[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrAccountNotFound, name)
+	}
+	return listFolders(name, accCfg, withStatus)
+}
+
+// listFolders 建立临时连接并列出文件夹树
+func listFolders(name string, accCfg *config.AccountConfig, withStatus bool) ([]*imap.Folder, error) {
+	client, err := newIMAPClient(name, accCfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := client.Connect(); err != nil {
+		return nil, err
+	}
+	defer client.Logout()
+	if err := client.Login(); err != nil {
+		return nil, err
+	}
+	return client.FolderTree(withStatus)
+}
@@ -0,0 +1,231 @@
+package receiver
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"mail-receiver/config"
+)
+
+var (
+	// HTML标签正则表达式
+	htmlTagRegex = regexp.MustCompile(`<[^>]*>`)
+
+	styleRegex  = regexp.MustCompile(`(?i)<style[^>]*>[\s\S]*?</style>`)
+	scriptRegex = regexp.MustCompile(`(?i)<script[^>]*>[\s\S]*?</script>`)
+	// 表格行的开始或结束标签，单元格的开始标签（结束标签可省略）
+	trTagRegex   = regexp.MustCompile(`(?i)<tr\b[^>]*>|</tr\s*>`)
+	cellRegex    = regexp.MustCompile(`(?i)<t[dh]\b[^>]*>`)
+	spaceRegex   = regexp.MustCompile(`\s+`)
+	tagNameRegex = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9]*$`)
+	// 引用内联图片的 <img src="cid:..."> 和纯文本正文中的 [cid:...]（如 Outlook）
+	cidImageRegex = regexp.MustCompile(`(?i)<img\b[^>]*?\bsrc\s*=\s*["']?cid:([^"'\s>]+)[^>]*>`)
+	cidTextRegex  = regexp.MustCompile(`\[cid:([^\]\s]+)\]`)
+
+	// defaultBlockTags 默认替换为换行的块级标签
+	defaultBlockTags = []string{"br", "p", "div", "tr", "li"}
+)
+
+// htmlText HTML 正文转纯文本，可按账号调整块级标签、表格和实体的处理
+type htmlText struct {
+	blockRegex *regexp.Regexp    // 替换为换行的标签
+	tableCells bool              // 表格行按 "键: 值" 输出
+	entities   map[string]string // 额外的实体映射，先于标准实体解码替换
+}
+
+// newHTMLText 按账号配置创建 HTML 转纯文本
+func newHTMLText(cfg config.TextExtractionConfig) (*htmlText, error) {
+	tags := cfg.BlockTags
+	if len(tags) == 0 {
+		tags = defaultBlockTags
+	}
+	// 空元素匹配开始标签，其他标签匹配结束标签
+	var parts []string
+	for _, tag := range tags {
+		if !tagNameRegex.MatchString(tag) {
+			return nil, fmt.Errorf("块级标签无效: %q", tag)
+		}
+		switch tag = strings.ToLower(tag); tag {
+		case "br", "hr", "img":
+			parts = append(parts, `<`+tag+`\b[^>]*>`)
+		default:
+			parts = append(parts, `</`+tag+`\s*>`)
+		}
+	}
+
+	for entity := range cfg.Entities {
+		if !strings.HasPrefix(entity, "&") || !strings.HasSuffix(entity, ";") {
+			return nil, fmt.Errorf("HTML实体无效: %q（应为 &name; 形式）", entity)
+		}
+	}
+
+	return &htmlText{
+		blockRegex: regexp.MustCompile(`(?i)` + strings.Join(parts, "|")),
+		tableCells: cfg.TableCells,
+		entities:   cfg.Entities,
+	}, nil
+}
+
+// strip 去除HTML标签并清理文本
+func (h *htmlText) strip(body string) string {
+	if body == "" {
+		return ""
+	}
+
+	// 去除 <style> 和 <script> 标签及其内容
+	text := styleRegex.ReplaceAllString(body, "")
+	text = scriptRegex.ReplaceAllString(text, "")
+
+	// 表格行转为 "键: 值"，避免布局表格被压成一行
+	if h.tableCells {
+		text = h.tableRows(text)
+	}
+
+	// 将块级标签替换为换行（<p> <div> <br> 等）
+	text = h.blockRegex.ReplaceAllString(text, "\n")
+
+	// 去除所有HTML标签
+	text = htmlTagRegex.ReplaceAllString(text, "")
+
+	// 解码HTML实体：先替换账号配置的实体，再解码命名实体和 &#8217; / &#x27; 等数字实体
+	for entity, replacement := range h.entities {
+		text = strings.ReplaceAll(text, entity, replacement)
+	}
+	text = unescapeHTML(text)
+
+	// 去除每行首尾空白并过滤空行
+	lines := strings.Split(text, "\n")
+	var cleanedLines []string
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			cleanedLines = append(cleanedLines, line)
+		}
+	}
+	text = strings.Join(cleanedLines, "\n")
+
+	// 去除首尾空白
+	text = strings.TrimSpace(text)
+
+	return text
+}
+
+// unescapeHTML 解码HTML实体，不换行空格（&nbsp;）转为普通空格以便去除首尾空白
+func unescapeHTML(s string) string {
+	if !strings.Contains(s, "&") {
+		return s
+	}
+	return strings.ReplaceAll(html.UnescapeString(s), "\u00a0", " ")
+}
+
+// tableRows 将每个表格行替换为一行文本：两个单元格输出为 "键: 值"，更多单元格用 " | " 分隔
+// 一次扫描所有 <tr>/</tr>，未结束的行压栈，遇到结束标签时转换最内层的行并写入外层，嵌套的布局表格逐层展开
+func (h *htmlText) tableRows(text string) string {
+	type openRow struct {
+		tag string // 开始标签，行没有结束标签时原样保留
+		buf strings.Builder
+	}
+	var out strings.Builder
+	var stack []*openRow
+	top := func() *strings.Builder {
+		if len(stack) == 0 {
+			return &out
+		}
+		return &stack[len(stack)-1].buf
+	}
+
+	last := 0
+	for _, loc := range trTagRegex.FindAllStringIndex(text, -1) {
+		top().WriteString(text[last:loc[0]])
+		last = loc[1]
+		if tag := text[loc[0]:loc[1]]; tag[1] != '/' {
+			stack = append(stack, &openRow{tag: tag})
+			continue
+		}
+		if len(stack) == 0 {
+			// 没有对应开始标签的 </tr>，直接去掉
+			out.WriteString("\n")
+			continue
+		}
+		row := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		w := top()
+		w.WriteString("\n")
+		w.WriteString(h.tableRow(row.buf.String()))
+		w.WriteString("\n")
+	}
+	top().WriteString(text[last:])
+
+	for len(stack) > 0 {
+		row := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		w := top()
+		w.WriteString(row.tag)
+		w.WriteString(row.buf.String())
+	}
+	return out.String()
+}
+
+// tableRow 合并一行中的单元格；有多行内容的单元格（如布局表格中嵌套的表格或段落）逐行输出
+func (h *htmlText) tableRow(row string) string {
+	parts := cellRegex.Split(row, -1)
+	if len(parts) == 1 {
+		// 没有单元格（如行内只有文本），保留原内容
+		return row
+	}
+	var cells []string
+	multiline := false
+	for _, part := range parts[1:] {
+		part = h.blockRegex.ReplaceAllString(part, "\n")
+		part = htmlTagRegex.ReplaceAllString(part, " ")
+		var lines []string
+		for _, line := range strings.Split(part, "\n") {
+			line = strings.TrimSpace(spaceRegex.ReplaceAllString(strings.ReplaceAll(line, "&nbsp;", " "), " "))
+			if line != "" {
+				lines = append(lines, line)
+			}
+		}
+		if len(lines) > 1 {
+			multiline = true
+		}
+		if len(lines) > 0 {
+			cells = append(cells, strings.Join(lines, "\n"))
+		}
+	}
+	switch {
+	case multiline:
+		return strings.Join(cells, "\n")
+	case len(cells) == 2:
+		return strings.TrimRight(cells[0], ":：") + ": " + cells[1]
+	}
+	return strings.Join(cells, " | ")
+}
+
+// replaceCIDImages 将 HTML 正文中引用内联图片的 <img> 替换为 "[图片: 保存位置]"，未保存时替换为 "[图片]"，
+// 避免转为纯文本后图片完全消失或残留 cid: 引用
+func replaceCIDImages(body string, saved map[string]string) string {
+	return cidImageRegex.ReplaceAllStringFunc(body, func(tag string) string {
+		return " " + html.EscapeString(imagePlaceholder(cidImageRegex.FindStringSubmatch(tag)[1], saved)) + " "
+	})
+}
+
+// replaceCIDText 将纯文本正文中的 [cid:...] 替换为图片占位文字，规则同 replaceCIDImages
+func replaceCIDText(text string, saved map[string]string) string {
+	return cidTextRegex.ReplaceAllStringFunc(text, func(ref string) string {
+		return imagePlaceholder(cidTextRegex.FindStringSubmatch(ref)[1], saved)
+	})
+}
+
+// imagePlaceholder 内联图片的占位文字
+func imagePlaceholder(id string, saved map[string]string) string {
+	if s, err := url.PathUnescape(id); err == nil {
+		id = s
+	}
+	if location := saved[id]; location != "" {
+		return "[图片: " + location + "]"
+	}
+	return "[图片]"
+}
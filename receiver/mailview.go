@@ -0,0 +1,19 @@
+package receiver
+
+import (
+	"errors"
+
+	"mail-receiver/storage"
+)
+
+// LookupMail 按查看链接ID读取已保存的邮件（网页查看邮件），未启用或不存在时返回 storage.ErrNotFound
+func (r *Receiver) LookupMail(viewID string) (*storage.Record, error) {
+	if !r.config.App.MailView.Enabled {
+		return nil, storage.ErrNotFound
+	}
+	s, ok := r.store.(storage.Lookup)
+	if !ok {
+		return nil, errors.New("网页查看邮件需要 SQLite 存储")
+	}
+	return s.Lookup(viewID)
+}
@@ -0,0 +1,54 @@
+package receiver
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"mail-receiver/config"
+	"mail-receiver/imap"
+)
+
+// noticeRepeatInterval 相同的服务器提示在此时间内只推送一次（部分服务器每次登录都会发送相同的 ALERT）
+const noticeRepeatInterval = time.Hour
+
+// noticeLog 最近推送过的服务器提示
+type noticeLog struct {
+	mu   sync.Mutex
+	sent map[string]time.Time
+}
+
+// shouldPush 检查提示是否需要推送，并记录推送时间
+func (l *noticeLog) shouldPush(text string, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.sent == nil {
+		l.sent = make(map[string]time.Time)
+	}
+	for t, at := range l.sent {
+		if now.Sub(at) >= noticeRepeatInterval {
+			delete(l.sent, t)
+		}
+	}
+	if _, ok := l.sent[text]; ok {
+		return false
+	}
+	l.sent[text] = now
+	return true
+}
+
+// serverNotice 记录服务器主动发送的 ALERT/BYE 提示，配置 push_server_notices 时推送通知
+func (ar *AccountReceiver) serverNotice(n imap.Notice) {
+	kind := "提示"
+	if n.Type == imap.NoticeBye {
+		kind = "断开连接"
+	}
+	log.Printf("[%s] 警告: 服务器%s: %s", ar.name, kind, n.Text)
+
+	if !ar.config.PushServerNotices || !ar.notices.shouldPush(n.Type+"\x00"+n.Text, ar.clock.Now()) {
+		return
+	}
+	// 在接收协程中调用，推送放到后台避免阻塞连接
+	go ar.alert(alertNotice, config.AlertWarning, "邮件服务器"+kind, fmt.Sprintf("账号 [%s] 服务器%s: %s", ar.name, kind, n.Text))
+}
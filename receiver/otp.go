@@ -0,0 +1,79 @@
+package receiver
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"text/template"
+
+	"mail-receiver/config"
+	"mail-receiver/content"
+	"mail-receiver/imap"
+	"mail-receiver/push"
+)
+
+// defaultOTPTitle 提取到验证码时的默认推送标题
+const defaultOTPTitle = "验证码 {{.Code}} | {{.Subject}}"
+
+// otpCodes 验证码提取：提取到时放入推送标题，可选不推送正文
+type otpCodes struct {
+	extractor *content.OTPExtractor
+	title     *template.Template
+	hideBody  bool
+}
+
+// otpTitleData 验证码标题模板可用的字段
+type otpTitleData struct {
+	Code    string
+	Subject string // 原推送标题（规则可能已改写）
+	From    string
+	Account string
+}
+
+// newOTPCodes 创建验证码提取，未启用时返回nil
+func newOTPCodes(cfg config.OTPConfig) (*otpCodes, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	extractor, err := content.NewOTPExtractor(cfg.Patterns, !cfg.DisableBuiltin)
+	if err != nil {
+		return nil, err
+	}
+	title := cfg.Title
+	if title == "" {
+		title = defaultOTPTitle
+	}
+	t, err := template.New("otp").Option("missingkey=error").Parse(title)
+	if err != nil {
+		return nil, fmt.Errorf("验证码标题模板无效: %w", err)
+	}
+	return &otpCodes{extractor: extractor, title: t, hideBody: cfg.HideBody}, nil
+}
+
+// applyOTP 从主题和正文中提取验证码：找到时改写标题、记录到 meta.Code，按配置去掉推送正文
+func (ar *AccountReceiver) applyOTP(email *imap.EmailMessage, text, title, body string, meta *push.Meta) (string, string) {
+	if ar.otp == nil {
+		return title, body
+	}
+	code := ar.otp.extractor.Extract(email.Subject, text)
+	if code == "" {
+		return title, body
+	}
+	meta.Code = code
+	var b strings.Builder
+	err := ar.otp.title.Execute(&b, &otpTitleData{
+		Code:    code,
+		Subject: title,
+		From:    email.DisplayFrom(),
+		Account: ar.name,
+	})
+	if err != nil {
+		log.Printf("[%s] 生成验证码标题失败: %v", ar.name, err)
+	} else {
+		title = b.String()
+	}
+	if ar.otp.hideBody {
+		body = ""
+	}
+	return title, body
+}
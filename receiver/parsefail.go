@@ -0,0 +1,88 @@
+package receiver
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"mail-receiver/config"
+	"mail-receiver/imap"
+	"mail-receiver/secure"
+)
+
+// parseFailureNote 没有解析出正文、只推送信封信息时的正文
+const parseFailureNote = "（邮件正文无法解析，只显示主题和发件人，请在邮件客户端中查看）"
+
+// parsePartialNote 正文只解析了一部分时附加在正文后的说明
+const parsePartialNote = "（邮件未能完整解析，以上为部分内容，请在邮件客户端中查看）"
+
+// quarantine 隔离目录：保存无法解析的原始邮件供排查
+type quarantine struct {
+	dir    string
+	cipher *secure.Cipher // 可选，加密保存
+}
+
+// save 保存原始邮件，文件名为 <账号>/<文件夹>/<UIDVALIDITY>-<UID>.eml，返回文件路径
+func (q *quarantine) save(account, folder string, uidValidity uint32, perr *imap.ParseError) (string, error) {
+	dir := filepath.Join(q.dir, safeName(account), safeName(folder))
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", fmt.Errorf("创建隔离目录失败: %w", err)
+	}
+	p := filepath.Join(dir, fmt.Sprintf("%d-%d.eml", uidValidity, perr.Email.UID))
+	write := os.WriteFile
+	if q.cipher != nil {
+		write = q.cipher.WriteFile
+	}
+	if err := write(p, perr.Raw, 0o600); err != nil {
+		return "", fmt.Errorf("保存隔离邮件失败: %w", err)
+	}
+	return p, nil
+}
+
+// safeName 将账号名、文件夹名转换为可用作目录名的形式
+func safeName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', ':', '*', '?', '"', '<', '>', '|':
+			return '_'
+		}
+		return r
+	}, name)
+}
+
+// handleParseFailure 按配置处理无法解析的邮件，返回需要继续推送的邮件（信封信息和已解析的部分正文）
+// 不推送时返回nil；需要标记为已读的UID追加到 readUIDs，需要加星标的追加到 flagUIDs
+// 隔离保存失败时返回错误，邮件保持未读，下次重新获取处理
+func (ar *AccountReceiver) handleParseFailure(folder string, uidValidity uint32, perr *imap.ParseError, readUIDs, flagUIDs *[]uint32) (*imap.EmailMessage, error) {
+	email := perr.Email
+	log.Printf("[%s] 邮件无法解析 (%s): %s", ar.name, email.Subject, strings.TrimSpace(perr.Error()))
+
+	switch ar.config.ParseFailure.Action {
+	case config.ParseFailureQuarantine:
+		p, err := ar.quarantine.save(ar.name, folder, uidValidity, perr)
+		if err != nil {
+			// 保存失败时保持未读，便于在邮件客户端中查看
+			ar.checkDiskFull("隔离邮件", err)
+			return nil, err
+		}
+		log.Printf("[%s] 已隔离无法解析的邮件: %s", ar.name, p)
+		*readUIDs = append(*readUIDs, email.UID)
+	case config.ParseFailureFlag:
+		*readUIDs = append(*readUIDs, email.UID)
+		*flagUIDs = append(*flagUIDs, email.UID)
+	default:
+		switch {
+		case email.Body != "":
+			email.Body = strings.TrimRight(email.Body, "\r\n") + "\n\n" + parsePartialNote
+		case email.HTMLBody != "":
+			email.HTMLBody += "<p>" + parsePartialNote + "</p>"
+		default:
+			email.Body = parseFailureNote
+		}
+		return email, nil
+	}
+	ar.saveRecord(folder, uidValidity, email, false)
+	return nil, nil
+}
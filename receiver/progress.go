@@ -0,0 +1,122 @@
+package receiver
+
+import (
+	"log"
+	"slices"
+
+	"mail-receiver/state"
+)
+
+// progress 单次处理周期内的UID进度
+type progress struct {
+	cp      state.Checkpoint
+	blocked bool // 出现未完成的邮件后不再推进
+}
+
+// newProgress 基于已保存的进度创建，UIDVALIDITY 变化时从头开始
+func newProgress(cp state.Checkpoint, uidValidity uint32) *progress {
+	if cp.UIDValidity != uidValidity {
+		cp = state.Checkpoint{UIDValidity: uidValidity}
+	}
+	return &progress{cp: cp}
+}
+
+// done 标记邮件处理完成；进度因之前的邮件未完成而不能推进时记录UID，再次获取时跳过
+func (p *progress) done(uid uint32) {
+	p.advance(uid)
+	p.cp.Held = removeUIDs(p.cp.Held, []uint32{uid})
+	if uid > p.cp.LastUID && !slices.Contains(p.cp.Done, uid) {
+		p.cp.Done = append(p.cp.Done, uid)
+	}
+}
+
+// hold 邮件已暂存等待汇总推送，进度照常推进，同时记录UID，推送前进程退出时下次重新获取
+func (p *progress) hold(uid uint32) {
+	p.advance(uid)
+	p.cp.Done = removeUIDs(p.cp.Done, []uint32{uid})
+	p.cp.Held = append(removeUIDs(p.cp.Held, []uint32{uid}), uid)
+}
+
+// completed 邮件是否已在之前的处理周期中完成（进度未能越过而再次获取）
+func (p *progress) completed(uid uint32) bool {
+	return slices.Contains(p.cp.Done, uid)
+}
+
+// advance 没有未完成的邮件时推进进度，进度越过的完成记录随之清除
+func (p *progress) advance(uid uint32) {
+	if p.blocked || uid <= p.cp.LastUID {
+		return
+	}
+	p.cp.LastUID = uid
+	var kept []uint32
+	for _, done := range p.cp.Done {
+		if done > uid {
+			kept = append(kept, done)
+		}
+	}
+	p.cp.Done = kept
+}
+
+// fail 标记邮件未处理完成，之后的邮件不再推进进度
+func (p *progress) fail() {
+	p.blocked = true
+}
+
+// delivered 严格投递模式下记录已确认推送的邮件
+func (p *progress) delivered(uid uint32) {
+	p.cp.Delivered = append(p.cp.Delivered, uid)
+}
+
+// confirmed 已读标记完成，从投递记录中移除
+func (p *progress) confirmed(uids []uint32) {
+	p.cp.Delivered = removeUIDs(p.cp.Delivered, uids)
+}
+
+// releaseHeld 暂存的邮件已汇总推送，从 folder 的进度中移除
+func (ar *AccountReceiver) releaseHeld(folder string, uidValidity uint32, uids []uint32) {
+	cp := ar.checkpoints.Get(ar.name, folder)
+	if cp.UIDValidity != uidValidity || len(cp.Held) == 0 {
+		return
+	}
+	cp.Held = removeUIDs(cp.Held, uids)
+	if err := ar.checkpoints.Set(ar.name, folder, cp); err != nil {
+		log.Printf("[%s] 保存处理进度失败: %v", ar.name, err)
+		ar.checkDiskFull("保存处理进度", err)
+	}
+}
+
+// saveDelivered 立即保存投递记录（不推进已处理的UID，规则动作等尚未完成）
+func (ar *AccountReceiver) saveDelivered(folder string, p *progress) {
+	cp := ar.checkpoints.Get(ar.name, folder)
+	if cp.UIDValidity != p.cp.UIDValidity {
+		cp = state.Checkpoint{UIDValidity: p.cp.UIDValidity}
+	}
+	cp.Delivered = p.cp.Delivered
+	if err := ar.checkpoints.Set(ar.name, folder, cp); err != nil {
+		log.Printf("[%s] 保存投递记录失败: %v", ar.name, err)
+		ar.checkDiskFull("保存投递记录", err)
+	}
+}
+
+// removeUIDs 从 list 中去掉 uids 中的UID
+func removeUIDs(list, uids []uint32) []uint32 {
+	drop := make(map[uint32]bool, len(uids))
+	for _, uid := range uids {
+		drop[uid] = true
+	}
+	var kept []uint32
+	for _, uid := range list {
+		if !drop[uid] {
+			kept = append(kept, uid)
+		}
+	}
+	return kept
+}
+
+// saveProgress 保存处理进度
+func (ar *AccountReceiver) saveProgress(folder string, p *progress) {
+	if err := ar.checkpoints.Set(ar.name, folder, p.cp); err != nil {
+		log.Printf("[%s] 保存处理进度失败: %v", ar.name, err)
+		ar.checkDiskFull("保存处理进度", err)
+	}
+}
@@ -0,0 +1,152 @@
+package receiver
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/emersion/go-imap/backend/memory"
+	"github.com/emersion/go-imap/server"
+
+	"mail-receiver/clock"
+	"mail-receiver/config"
+	"mail-receiver/mailer"
+)
+
+// startIMAPServer 在本机启动内存 IMAP 服务器（用户 username/password），INBOX 中依次添加 messages
+func startIMAPServer(t *testing.T, messages ...string) int {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("无法监听本机端口: %v", err)
+	}
+	be := memory.New()
+	user, err := be.Login(nil, "username", "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mbox, err := user.GetMailbox("INBOX")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range messages {
+		if err := mbox.CreateMessage(nil, time.Now(), bytes.NewBufferString(strings.ReplaceAll(m, "\n", "\r\n"))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s := server.New(be)
+	s.AllowInsecureAuth = true
+	go s.Serve(l)
+	t.Cleanup(func() { s.Close() })
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+// counter 并发安全的调用计数
+type counter struct {
+	mu sync.Mutex
+	n  map[string]int
+}
+
+func (c *counter) add(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.n == nil {
+		c.n = make(map[string]int)
+	}
+	c.n[key]++
+}
+
+func (c *counter) get(key string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.n[key]
+}
+
+// waitCount 等待 key 的计数达到 n
+func waitCount(t *testing.T, c *counter, key string, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for c.get(key) < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("等待 %s 第 %d 次调用超时（当前 %d 次）", key, n, c.get(key))
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestFailedPushDoesNotRepeatRuleActions(t *testing.T) {
+	port := startIMAPServer(t,
+		"From: alice@example.org\nSubject: push fails\n\nhello",
+		"From: bob@example.org\nSubject: need reply\n\nhello",
+	)
+
+	var replies counter
+	sendMail = func(o mailer.Options, from string, to []string, msg []byte) error {
+		replies.add(strings.Join(to, ","))
+		return nil
+	}
+	t.Cleanup(func() { sendMail = mailer.Send })
+
+	cfg := &config.Config{Accounts: map[string]*config.AccountConfig{
+		"test": {
+			Server:   "127.0.0.1",
+			Port:     port,
+			Security: config.SecurityNone,
+			Username: "username",
+			Password: "password",
+			SMTP:     config.SMTPConfig{Host: "smtp.example.com", Security: "none", From: "me@example.com"},
+			Rules: []config.RuleConfig{{
+				Name:    "自动回复",
+				Match:   config.RuleMatchConfig{Subject: "need reply"},
+				Actions: []string{"reply"},
+				Reply:   &config.ReplyConfig{Body: "已收到", Interval: 1},
+			}},
+		},
+	}}
+	if err := cfg.SetDefaults(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 主题为 push fails 的邮件始终推送失败，进度停在它之前
+	var pushes counter
+	fake := clock.NewFake(time.Now())
+	r, err := New(Options{Config: cfg, Clock: fake, Handler: func(ctx context.Context, msg *Message) error {
+		pushes.add(msg.Title)
+		if msg.Title == "push fails" {
+			return errors.New("推送失败")
+		}
+		return nil
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer func() {
+		cancel()
+		r.Wait()
+	}()
+	if err := r.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	waitCount(t, &pushes, "push fails", 1)
+	waitCount(t, &replies, "bob@example.org", 1)
+
+	// 超过回复间隔后再获取两次：推送失败的邮件重试，已回复的邮件不再处理
+	for i := 2; i <= 3; i++ {
+		fake.Advance(time.Minute)
+		if err := r.Fetch("test"); err != nil {
+			t.Fatal(err)
+		}
+		waitCount(t, &pushes, "push fails", i)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if n := replies.get("bob@example.org"); n != 1 {
+		t.Fatalf("自动回复了 %d 次，期望 1 次", n)
+	}
+}
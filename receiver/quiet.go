@@ -0,0 +1,150 @@
+package receiver
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"mail-receiver/clock"
+	"mail-receiver/config"
+)
+
+// quietHours 免打扰时段：期间暂存推送，时段结束时按推送目标各合并为一条汇总推送
+// 只在账号的连接协程中使用
+type quietHours struct {
+	start, end  int // 距零点的分钟数
+	loc         *time.Location
+	maxSubjects int
+	clock       clock.Clock
+	pending     []collapsedMail
+	since       time.Time     // 第一封暂存邮件的时间
+	ready       chan struct{} // 时段结束时通知运行循环
+	timer       clock.Timer
+}
+
+// newQuietHours 创建免打扰时段，未启用时返回nil
+func newQuietHours(cfg config.QuietHoursConfig, clk clock.Clock) (*quietHours, error) {
+	if cfg.Start == "" && cfg.End == "" {
+		return nil, nil
+	}
+	start, err := time.Parse("15:04", cfg.Start)
+	if err != nil {
+		return nil, fmt.Errorf("免打扰开始时间无效: %q", cfg.Start)
+	}
+	end, err := time.Parse("15:04", cfg.End)
+	if err != nil {
+		return nil, fmt.Errorf("免打扰结束时间无效: %q", cfg.End)
+	}
+	loc, err := time.LoadLocation(cfg.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("免打扰时区无效: %w", err)
+	}
+	return &quietHours{
+		start:       start.Hour()*60 + start.Minute(),
+		end:         end.Hour()*60 + end.Minute(),
+		loc:         loc,
+		maxSubjects: cfg.MaxSubjects,
+		clock:       clk,
+		ready:       make(chan struct{}, 1),
+	}, nil
+}
+
+// due 时段结束时可读的通道，未启用时返回nil（select 时永不就绪）
+func (q *quietHours) due() <-chan struct{} {
+	if q == nil {
+		return nil
+	}
+	return q.ready
+}
+
+// until 返回 now 是否处于免打扰时段，以及本次时段的结束时间
+func (q *quietHours) until(now time.Time) (time.Time, bool) {
+	local := now.In(q.loc)
+	y, m, d := local.Date()
+	minute := local.Hour()*60 + local.Minute()
+	at := func(day, minutes int) time.Time {
+		return time.Date(y, m, d+day, 0, minutes, 0, 0, q.loc)
+	}
+	switch {
+	case q.start < q.end:
+		if minute >= q.start && minute < q.end {
+			return at(0, q.end), true
+		}
+	case q.start > q.end:
+		// 跨越午夜，如 23:00-07:00
+		if minute >= q.start {
+			return at(1, q.end), true
+		}
+		if minute < q.end {
+			return at(0, q.end), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// hold 处于免打扰时段时暂存邮件并返回true，第一封时安排在时段结束时通知
+func (q *quietHours) hold(m collapsedMail, now time.Time) bool {
+	end, ok := q.until(now)
+	if !ok {
+		return false
+	}
+	if len(q.pending) == 0 {
+		q.since = now
+		q.schedule(end.Sub(now))
+	}
+	q.pending = append(q.pending, m)
+	return true
+}
+
+// schedule 在 d 之后通知运行循环
+func (q *quietHours) schedule(d time.Duration) {
+	if q.timer != nil {
+		q.timer.Stop()
+	}
+	q.timer = q.clock.AfterFunc(d, func() {
+		select {
+		case q.ready <- struct{}{}:
+		default:
+		}
+	})
+}
+
+// take 时段已结束时取出所有暂存邮件
+func (q *quietHours) take(now time.Time) ([]collapsedMail, time.Time) {
+	if len(q.pending) == 0 {
+		return nil, time.Time{}
+	}
+	if _, ok := q.until(now); ok {
+		return nil, time.Time{}
+	}
+	mails, since := q.pending, q.since
+	q.pending = nil
+	return mails, since
+}
+
+// restore 汇总推送失败时放回暂存邮件，稍后重试
+func (q *quietHours) restore(mails []collapsedMail, since time.Time) {
+	q.pending = append(mails, q.pending...)
+	q.since = since
+	q.schedule(time.Minute)
+}
+
+// flushQuiet 免打扰时段结束时按推送目标分别推送暂存的邮件，成功后标记为已读
+func (ar *AccountReceiver) flushQuiet(folder string, uidValidity uint32) {
+	if ar.quiet == nil {
+		return
+	}
+	mails, since := ar.quiet.take(ar.clock.Now())
+	if len(mails) == 0 {
+		return
+	}
+
+	for _, group := range groupByPusher(mails) {
+		_, text := digestMessage(group, ar.clock.Since(since), ar.quiet.maxSubjects)
+		title := fmt.Sprintf("免打扰期间收到 %d 封新邮件", len(group))
+		if err := ar.pushHeld(folder, uidValidity, group, title, text); err != nil {
+			log.Printf("[%s] 免打扰汇总推送失败，稍后重试: %v", ar.name, err)
+			ar.quiet.restore(group, since)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package receiver
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"mail-receiver/clock"
+	"mail-receiver/config"
+	"mail-receiver/push"
+)
+
+// pushWait 推送限速和限流等待的取消信号和时间来源，零值为不取消、使用系统时间（命令行工具）
+type pushWait struct {
+	ctx   context.Context // 程序退出时取消，不再等待
+	clock clock.Clock
+}
+
+// sharedRateLimits 全局推送目标（app.push_targets）的限速在所有账号间共用，
+// 按目标名称和限速参数区分，重新加载配置后参数不变的目标继续使用原来的令牌桶
+var sharedRateLimits = struct {
+	mu     sync.Mutex
+	limits map[string]*push.RateLimit
+}{limits: make(map[string]*push.RateLimit)}
+
+// rateLimit 为推送目标加上限速，未配置时原样返回；shared 为 true 时与其他账号的同名目标共用令牌桶
+func rateLimit(p push.Pusher, cfg config.PushRateLimitConfig, account, target string, shared bool, wait pushWait) push.Pusher {
+	if cfg.Limit <= 0 {
+		return p
+	}
+	period := time.Duration(cfg.Period) * time.Second
+	if period <= 0 {
+		period = time.Minute
+	}
+	if !shared {
+		return push.RateLimited(wait.ctx, p, push.NewRateLimit(cfg.Limit, period, cfg.Burst, wait.clock), account, target)
+	}
+
+	key := fmt.Sprintf("%s/%d/%v/%d", target, cfg.Limit, period, cfg.Burst)
+	sharedRateLimits.mu.Lock()
+	limit, ok := sharedRateLimits.limits[key]
+	if !ok {
+		limit = push.NewRateLimit(cfg.Limit, period, cfg.Burst, wait.clock)
+		sharedRateLimits.limits[key] = limit
+	}
+	sharedRateLimits.mu.Unlock()
+	return push.RateLimited(wait.ctx, p, limit, account, target)
+}
@@ -1,147 +1,677 @@
 package receiver
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
-	"os"
 	"regexp"
 	"strings"
 	"sync"
 	"time"
 
+	"mail-receiver/attachments"
+	"mail-receiver/audit"
+	"mail-receiver/clock"
 	"mail-receiver/config"
+	"mail-receiver/connlimit"
+	"mail-receiver/contacts"
+	"mail-receiver/content"
+	"mail-receiver/enrich"
 	"mail-receiver/heartbeat"
 	"mail-receiver/imap"
+	"mail-receiver/mailauth"
+	"mail-receiver/metrics"
+	"mail-receiver/netcheck"
 	"mail-receiver/push"
+	"mail-receiver/rules"
+	"mail-receiver/secure"
+	"mail-receiver/smime"
+	"mail-receiver/state"
+	"mail-receiver/storage"
+	"mail-receiver/tagging"
 )
 
 // Receiver 邮件接收器
 type Receiver struct {
-	config    *config.Config
-	accounts  map[string]*AccountReceiver
-	heartbeat *heartbeat.Heartbeat
-	wg        sync.WaitGroup
+	config      *config.Config
+	accounts    map[string]*AccountReceiver
+	heartbeat   *heartbeat.Heartbeat
+	contacts    *contacts.Book
+	tagger      *tagging.Tagger
+	store       storage.Store
+	state       *state.Store
+	audit       *audit.Logger
+	attachments *attachments.Saver
+	cipher      *secure.Cipher // 可选，本地存储加密
+	connections *connlimit.Manager
+	alerts      *alerter
+	pushRetries *retryQueues      // 推送目标的重试队列
+	network     *netcheck.Checker // 可选，出口网络检查
+	clock       clock.Clock       // 时间来源（嵌入使用时可替换为模拟时钟）
+	handler     Handler           // 可选，嵌入程序的推送回调
+	hold        <-chan struct{}   // 可选，关闭前账号只登录不获取邮件（平滑升级交接）
+	released    chan struct{}     // hold 关闭并重新读取处理进度后关闭
+	ctx         context.Context   // Start 传入，Stop 时取消
+	cancel      context.CancelFunc
+	mu          sync.RWMutex // 保护 accounts（管理接口重新加载配置时修改）
+	reloadMu    sync.Mutex
+	wg          sync.WaitGroup
 }
 
-var (
-	// HTML标签正则表达式
-	htmlTagRegex = regexp.MustCompile(`<[^>]*>`)
-	// HTML实体映射
-	htmlEntities = map[string]string{
-		"&nbsp;":   " ",
-		"&lt;":     "<",
-		"&gt;":     ">",
-		"&amp;":    "&",
-		"&quot;":   "\"",
-		"&apos;":   "'",
-		"&hellip;": "...",
-		"&mdash;":  "—",
-		"&ndash;":  "–",
+// AccountReceiver 单个账号的接收器
+type AccountReceiver struct {
+	name         string
+	config       *config.AccountConfig
+	client       *imap.Client
+	retries      int // 连续失败次数
+	retry        config.RetryConfig
+	unhealthy    bool // 连续失败达到阈值，已发送告警
+	authHold     bool // 登录凭据被拒绝，已进入隔离状态（长间隔重试）
+	startedAt    time.Time
+	lastSLAAlert time.Time
+	pusher       push.Pusher      // 未配置推送时为nil
+	pushHTTP     *push.HTTPClient // 推送共用的HTTP客户端（限流统计）
+	pushRetries  *retryQueues     // 推送失败重试队列，nil 时不重试（reprocess 命令）
+	retryQueues  *accountRetries  // 本账号各推送目标的重试队列，启动时替换账号原有的队列
+	pushWait     pushWait         // 推送限速、限流等待的取消信号和时间来源
+	alerts       *alerter         // 运维告警策略，nil 时直接使用账号的推送
+	firstConnect bool             // 是否是首次连接
+	strict       bool             // 严格投递模式（推送前设置临时关键字）
+	noKeywords   map[string]bool  // 不支持自定义关键字、不使用严格投递模式的文件夹
+	readOnly     bool             // 只读模式（不修改邮箱）
+	embedded     bool             // 嵌入使用，推送交给调用方的回调（忽略规则指定的推送目标）
+	contacts     *contacts.Book
+	tagger       *tagging.Tagger
+	store        storage.Store             // 可选，已处理邮件存储
+	mailView     config.MailViewConfig     // 网页查看邮件（推送附上查看链接）
+	checkpoints  *state.Store              // 可选，处理进度（最大已处理UID）
+	audit        *audit.Logger             // 可选，邮箱操作审计日志
+	attachments  *attachments.Saver        // 可选，附件保存
+	parseOpts    imap.ParseOptions         // 解析邮件的选项，未配置附件保存时不读取附件内容
+	attachOnly   *regexp.Regexp            // 附件模式下匹配附件文件名，nil 表示未启用附件模式
+	translator   *enrich.Translator        // 可选，非中文邮件翻译
+	summarizer   *enrich.Summarizer        // 可选，推送摘要代替全文
+	signature    *content.SignatureTrimmer // 可选，推送正文去除签名
+	otp          *otpCodes                 // 可选，提取验证码放入推送标题
+	verifier     *mailauth.Verifier        // 可选，发件人真实性检查
+	smime        *smime.Processor          // 可选，S/MIME 解密和签名验证
+	htmlText     *htmlText                 // HTML 正文转纯文本
+	notices      noticeLog                 // 最近推送过的服务器提示
+	storm        *stormCollapser           // 可选，邮件风暴合并推送或摘要推送
+	quiet        *quietHours               // 可选，免打扰时段
+	quarantine   *quarantine               // 可选，保存无法解析的原始邮件
+	flagWatch    *flagWatch                // 可选，跟踪已推送邮件在服务器上的状态变化
+	rules        *rules.Engine
+	rulePushers  map[*rules.Rule]push.Pusher // 规则指定的其他推送目标
+	pushTargets  *push.Fanout                // 账号的所有推送目标，供规则按名称引用
+	replier      *autoReplier                // 规则的自动回复，未使用 reply 动作时为nil
+	scheduler    imap.PollScheduler
+	state        accountState
+	ctl          *control           // 管理接口的暂停/恢复/立即获取请求
+	done         chan struct{}      // 监控协程退出时关闭
+	released     <-chan struct{}    // 可选，关闭后才开始获取邮件（平滑升级交接）
+	connections  *connlimit.Manager // 连接预算（按服务器和用户限制同时打开的连接数）
+	network      *netcheck.Checker  // 可选，出口网络检查，网络中断时暂停连接
+	unsubTargets recentTargets
+	folderDedup  folderDedup // 监控多个文件夹时识别同一封邮件
+	clock        clock.Clock // 轮询、重试等待、合并窗口的时间来源
+}
+
+// NewReceiver 创建新的接收器
+func NewReceiver(cfg *config.Config) *Receiver {
+	return &Receiver{
+		config:    cfg,
+		accounts:  make(map[string]*AccountReceiver),
+		heartbeat: heartbeat.New(cfg.App.HeartbeatURL, cfg.App.HeartbeatInterval, "system"),
+		clock:     clock.Real,
 	}
-)
+}
 
-// stripHTML 去除HTML标签并清理文本
-func stripHTML(html string) string {
-	if html == "" {
-		return ""
+// HoldUntil 设置账号开始获取邮件的时机（Start 之前调用）：账号照常连接和登录，release 关闭并重新读取处理进度后才获取邮件
+// 用于平滑升级交接，旧进程停止获取邮件并写入最终进度前新进程不处理邮件
+func (r *Receiver) HoldUntil(release <-chan struct{}) {
+	r.hold = release
+}
+
+// Start 启动接收器：连接所有账号并在后台运行，ctx 取消时停止
+func (r *Receiver) Start(ctx context.Context) error {
+	r.ctx, r.cancel = context.WithCancel(ctx)
+
+	// 加载联系人
+	book, err := newContactBook(r.config)
+	if err != nil {
+		return err
 	}
+	r.contacts = book
 
-	// 去除 <style> 标签及其内容
-	text := regexp.MustCompile(`(?i)<style[^>]*>[\s\S]*?</style>`).ReplaceAllString(html, "")
+	// 创建分类标签器
+	tagger, err := newTagger(r.config.Tagging)
+	if err != nil {
+		return err
+	}
+	r.tagger = tagger
 
-	// 去除 <script> 标签及其内容
-	text = regexp.MustCompile(`(?i)<script[^>]*>[\s\S]*?</script>`).ReplaceAllString(text, "")
+	// 加载本地存储加密密钥
+	var cipher *secure.Cipher
+	if ec := r.config.App.Encryption; ec.Enabled {
+		if cipher, err = secure.Load(ec.KeyEnv, ec.KeyFile); err != nil {
+			return err
+		}
+	}
+	r.cipher = cipher
 
-	// 将块级标签替换为换行（<p> <div> <br> 等）
-	text = regexp.MustCompile(`(?i)<br\s*/?>|</p>|</div>|</tr>|</li>`).ReplaceAllString(text, "\n")
+	// 打开处理进度状态文件
+	if r.config.App.StateFile != "" {
+		st, err := state.Open(r.config.App.StateFile, cipher)
+		if err != nil {
+			return err
+		}
+		r.state = st
+	} else {
+		// 未配置状态文件时在内存中记录进度，避免已过滤或只读模式下未标记已读的邮件被重复处理
+		r.state = state.NewMemory()
+	}
 
-	// 去除所有HTML标签
-	text = htmlTagRegex.ReplaceAllString(text, "")
+	// 平滑升级交接：旧进程退出后重新读取它写入的处理进度，再允许账号获取邮件
+	if r.hold != nil {
+		r.released = make(chan struct{})
+		go func() {
+			select {
+			case <-r.hold:
+			case <-r.ctx.Done():
+				return
+			}
+			if err := r.state.Reload(); err != nil {
+				log.Printf("重新读取处理进度失败: %v", err)
+			}
+			close(r.released)
+		}()
+	}
 
-	// 解码HTML实体
-	for entity, replacement := range htmlEntities {
-		text = strings.ReplaceAll(text, entity, replacement)
+	// 打开审计日志
+	if r.config.App.AuditLog != "" {
+		logger, err := audit.Open(r.config.App.AuditLog)
+		if err != nil {
+			return err
+		}
+		r.audit = logger
 	}
 
-	// 去除每行首尾空白并过滤空行
-	lines := strings.Split(text, "\n")
-	var cleanedLines []string
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line != "" {
-			cleanedLines = append(cleanedLines, line)
+	// 附件保存
+	if ac := r.config.App.Attachments; ac.S3.Bucket != "" {
+		s3 := ac.S3
+		backend, err := attachments.NewS3Backend(attachments.S3Options{
+			Endpoint:  s3.Endpoint,
+			Region:    s3.Region,
+			Bucket:    s3.Bucket,
+			AccessKey: s3.AccessKey,
+			SecretKey: s3.SecretKey,
+			Prefix:    s3.Prefix,
+			PathStyle: s3.PathStyle,
+			URLExpiry: time.Duration(s3.URLExpiry) * time.Second,
+		})
+		if err != nil {
+			return err
+		}
+		r.attachments = attachments.NewSaver(backend, ac.MaxSize, ac.Extensions)
+		log.Printf("已启用附件保存: S3 %s", s3.Bucket)
+	} else if ac.Dir != "" {
+		r.attachments = attachments.NewSaver(attachments.NewDirBackend(ac.Dir, ac.BaseURL, cipher), ac.MaxSize, ac.Extensions)
+		log.Printf("已启用附件保存: %s", ac.Dir)
+	}
+	if tc := r.config.App.Attachments.Thumbnail; tc.Enabled {
+		if r.attachments == nil {
+			return fmt.Errorf("启用了附件缩略图，但未配置附件保存 (app.attachments.dir 或 s3)")
+		}
+		r.attachments.SetThumbnail(tc.Size)
+	}
+	if r.config.App.Attachments.InlineImages {
+		if r.attachments == nil {
+			return fmt.Errorf("启用了内联图片保存，但未配置附件保存 (app.attachments.dir 或 s3)")
 		}
+		r.attachments.SetInlineImages()
 	}
-	text = strings.Join(cleanedLines, "\n")
 
-	// 去除首尾空白
-	text = strings.TrimSpace(text)
+	// 打开已处理邮件存储
+	if sc := r.config.App.Storage; sc.Path != "" {
+		store, err := storage.Open(sc.Type, sc.Path, cipher)
+		if err != nil {
+			return err
+		}
+		r.store = store
+	}
 
-	return text
-}
+	// 按服务器限制所有账号同时打开的连接数
+	r.connections = connlimit.NewManager(r.config.App.ConnectionLimits)
 
-// AccountReceiver 单个账号的接收器
-type AccountReceiver struct {
-	name         string
-	config       *config.AccountConfig
-	client       *imap.Client
-	retries      int
-	maxRetries   int
-	retryDelay   time.Duration
-	pusher       *push.Pusher
-	firstConnect bool // 是否是首次连接
-}
+	// 出口网络检查（所有账号共用探测结果）
+	if nc := r.config.App.NetworkCheck; nc.Enabled {
+		r.network = netcheck.New(r.ctx, nc.Targets, time.Duration(nc.Timeout)*time.Second, time.Duration(nc.Interval)*time.Second, r.clock)
+	}
 
-// NewReceiver 创建新的接收器
-func NewReceiver(cfg *config.Config) *Receiver {
-	return &Receiver{
-		config:    cfg,
-		accounts:  make(map[string]*AccountReceiver),
-		heartbeat: heartbeat.New(cfg.App.HeartbeatURL, cfg.App.HeartbeatInterval, "system"),
+	// 推送失败重试队列（所有账号共用推送失败记录文件）
+	r.pushRetries = newRetryQueues(r.config.App.DeadLetter, r.clock)
+
+	// 运维告警策略（所有账号共用）
+	if r.alerts, err = newAlerter(r.config.App.Alerts, pushWait{ctx: r.ctx, clock: r.clock}); err != nil {
+		return fmt.Errorf("告警配置无效: %w", err)
 	}
-}
 
-// Start 启动接收器
-func (r *Receiver) Start() error {
 	// 遍历所有账号配置
 	for name, accCfg := range r.config.Accounts {
 		log.Printf("[%s] 启动邮件监控", name)
-
-		accReceiver := &AccountReceiver{
-			name:         name,
-			config:       accCfg,
-			client:       imap.NewClient(accCfg.Server, accCfg.Port, accCfg.Username, accCfg.Password, name, accCfg.IdleTimeout),
-			maxRetries:   3,                // 最多重试3次
-			retryDelay:   30 * time.Second, // 重试间隔30秒
-			pusher:       push.NewPusher(accCfg.SendPush, name),
-			firstConnect: true, // 首次连接标志
+		ar, err := r.newAccountReceiver(name, accCfg)
+		if err != nil {
+			// 停止已启动的账号，避免启动失败后仍在后台收取邮件
+			r.Stop(stopTimeout)
+			return err
 		}
-
-		r.accounts[name] = accReceiver
-
-		r.wg.Add(1)
-		go r.runAccountReceiver(accReceiver)
+		r.startAccount(ar)
 	}
 
 	if len(r.accounts) == 0 {
 		return fmt.Errorf("没有找到任何账号配置")
 	}
 
+	go func() {
+		<-r.ctx.Done()
+		r.Stop(stopTimeout)
+	}()
 	return nil
 }
 
+// newIMAPClient 根据账号配置创建IMAP客户端（连接方式、TLS、只读模式、OAuth2）
+func newIMAPClient(name string, accCfg *config.AccountConfig) (*imap.Client, error) {
+	client := imap.NewClient(accCfg.Server, accCfg.Port, accCfg.Username, accCfg.Password, name, accCfg.IdleTimeout)
+	client.SetSecurity(accCfg.Security)
+	tc := accCfg.TLS
+	if err := client.SetTLS(imap.TLSOptions{
+		CAFile:             tc.CAFile,
+		CertFile:           tc.CertFile,
+		KeyFile:            tc.KeyFile,
+		MinVersion:         tc.MinVersion,
+		InsecureSkipVerify: tc.InsecureSkipVerify,
+	}); err != nil {
+		return nil, fmt.Errorf("账号 %s: %w", name, err)
+	}
+	client.SetReadOnly(accCfg.ReadOnly)
+	if accCfg.AuthType == config.AuthXOAuth2 {
+		oc := accCfg.OAuth2
+		ts, err := imap.NewTokenSource(oc.ClientID, oc.ClientSecret, oc.RefreshToken, oc.TokenURL, accCfg.Server)
+		if err != nil {
+			return nil, fmt.Errorf("账号 %s OAuth2 配置无效: %w", name, err)
+		}
+		client.SetOAuth2(ts)
+	}
+	return client, nil
+}
+
+// newAccountReceiver 根据账号配置创建接收器（不启动）
+func (r *Receiver) newAccountReceiver(name string, accCfg *config.AccountConfig) (*AccountReceiver, error) {
+	client, err := newIMAPClient(name, accCfg)
+	if err != nil {
+		return nil, err
+	}
+	client.SetClock(r.clock)
+	accReceiver := &AccountReceiver{
+		name:         name,
+		config:       accCfg,
+		client:       client,
+		retry:        accCfg.Retry,
+		startedAt:    r.clock.Now(),
+		firstConnect: true, // 首次连接标志
+		strict:       accCfg.StrictDelivery,
+		readOnly:     accCfg.ReadOnly,
+		contacts:     r.contacts,
+		tagger:       r.tagger,
+		store:        r.store,
+		mailView:     r.config.App.MailView,
+		checkpoints:  r.state,
+		audit:        r.audit,
+		attachments:  r.attachments,
+		parseOpts: imap.ParseOptions{
+			AttachmentData:    r.attachments != nil,
+			MaxAttachmentSize: r.config.App.Attachments.MaxSize,
+		},
+		ctl:         newControl(r.clock),
+		released:    r.released,
+		clock:       r.clock,
+		connections: r.connections,
+		network:     r.network,
+		alerts:      r.alerts,
+		pushRetries: r.pushRetries,
+	}
+
+	accReceiver.scheduler = newPollScheduler(accCfg)
+	if accCfg.Security == config.SecurityNone {
+		log.Printf("[%s] 警告: 未加密连接 %s，密码将以明文传输", name, accCfg.Server)
+	}
+	if accReceiver.readOnly {
+		// 只读模式下邮件不会被标记已读，依靠处理进度避免重复推送
+		if accReceiver.strict {
+			log.Printf("[%s] 只读模式下不支持严格投递，已关闭", name)
+			accReceiver.strict = false
+		}
+		log.Printf("[%s] 只读模式：不会修改邮箱", name)
+	}
+	accReceiver.pushHTTP = push.NewHTTPClient(name)
+	accReceiver.pushWait = pushWait{ctx: r.ctx, clock: r.clock}
+	accReceiver.retryQueues = r.pushRetries.forAccount(name, accReceiver.retryQueueFull)
+	if r.handler != nil {
+		// 嵌入使用：所有推送交给调用方处理
+		accReceiver.embedded = true
+		accReceiver.pusher = &handlerPusher{r: r}
+	} else if accReceiver.pusher, accReceiver.pushTargets, err = newAccountPusher(accCfg, r.config.App.PushTargets, name, accReceiver.pushHTTP, accReceiver.retryQueues, accReceiver.pushWait); err != nil {
+		return nil, fmt.Errorf("账号 %s: %w", name, err)
+	}
+	if ac := accCfg.AuthCheck; ac.Enabled {
+		accReceiver.verifier = mailauth.NewVerifier(ac.AuthServID, time.Duration(ac.Timeout)*time.Second)
+	}
+	if sc := accCfg.SMIME; sc.Enabled {
+		if accReceiver.smime, err = smime.Load(smime.Options{PKCS12File: sc.PKCS12, Password: sc.Password, CAFile: sc.CAFile}); err != nil {
+			return nil, fmt.Errorf("账号 %s 的 S/MIME 证书: %w", name, err)
+		}
+	}
+	if err := accReceiver.loadRules(accCfg.Rules); err != nil {
+		return nil, fmt.Errorf("账号 %s: %w", name, err)
+	}
+	if ao := accCfg.AttachmentOnly; ao.Enabled {
+		if r.attachments == nil {
+			return nil, fmt.Errorf("账号 %s 启用了附件模式，但未配置附件保存 (app.attachments)", name)
+		}
+		re, err := regexp.Compile("(?i)" + ao.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("账号 %s 的附件模式正则无效: %w", name, err)
+		}
+		accReceiver.attachOnly = re
+	}
+	if tc := accCfg.Translate; tc.Enabled {
+		translator, err := enrich.NewTranslator(tc.Provider, tc.URL, tc.APIKey, tc.TargetLang)
+		if err != nil {
+			return nil, fmt.Errorf("账号 %s 翻译配置无效: %w", name, err)
+		}
+		accReceiver.translator = translator
+	}
+	if accReceiver.htmlText, err = newHTMLText(accCfg.TextExtraction); err != nil {
+		return nil, fmt.Errorf("账号 %s 的 text_extraction 配置无效: %w", name, err)
+	}
+	if sc := accCfg.TrimSignature; sc.Enabled {
+		trimmer, err := content.NewSignatureTrimmer(sc.Patterns, !sc.DisableBuiltin)
+		if err != nil {
+			return nil, fmt.Errorf("账号 %s: %w", name, err)
+		}
+		accReceiver.signature = trimmer
+	}
+	if accReceiver.otp, err = newOTPCodes(accCfg.OTP); err != nil {
+		return nil, fmt.Errorf("账号 %s 的 otp 配置无效: %w", name, err)
+	}
+	if sc := accCfg.Summarize; sc.Enabled {
+		summarizer, err := enrich.NewSummarizer(sc.URL, sc.APIKey, sc.Model, sc.Prompt, sc.MaxTokens, sc.MaxInputChars)
+		if err != nil {
+			return nil, fmt.Errorf("账号 %s 摘要配置无效: %w", name, err)
+		}
+		accReceiver.summarizer = summarizer
+	}
+	accReceiver.storm = newStormCollapser(accCfg.Collapse, accCfg.Digest, r.clock)
+	if accReceiver.quiet, err = newQuietHours(accCfg.QuietHours, r.clock); err != nil {
+		return nil, fmt.Errorf("账号 %s %w", name, err)
+	}
+	accReceiver.flagWatch = newFlagWatch(accCfg.FlagChanges)
+	if pf := accCfg.ParseFailure; pf.Action == config.ParseFailureQuarantine {
+		accReceiver.quarantine = &quarantine{dir: pf.QuarantineDir, cipher: r.cipher}
+	}
+	accReceiver.client.SetNoticeHandler(accReceiver.serverNotice)
+	accReceiver.state.status.Name = name
+	accReceiver.state.status.Healthy = true
+	return accReceiver, nil
+}
+
+// startAccount 登记并在后台运行账号接收器
+func (r *Receiver) startAccount(ar *AccountReceiver) {
+	r.mu.Lock()
+	r.accounts[ar.name] = ar
+	r.mu.Unlock()
+	r.pushRetries.install(ar.retryQueues)
+
+	ar.done = make(chan struct{})
+	r.wg.Add(1)
+	go r.runAccountReceiver(ar)
+}
+
 // StartHeartbeat 启动全局心跳检测
 func (r *Receiver) StartHeartbeat() {
 	r.heartbeat.Start()
 }
 
+// newPusher 根据推送配置创建推送后端，未配置推送时返回nil
+func newPusher(cfg config.PushConfig, name string, h *push.HTTPClient, wait pushWait) (push.Pusher, error) {
+	h.SetWait(wait.ctx, wait.clock)
+	h.SetSignature(cfg.Secret, cfg.SignatureHeader, cfg.SignatureTimestamp)
+	h.SetHeaders(cfg.Headers, cfg.ContentType)
+	h.SetBasicAuth(cfg.BasicAuth.Username, cfg.BasicAuth.Password)
+	tc := cfg.TLS
+	if err := h.SetClientTLS(tc.CertFile, tc.KeyFile, tc.CAFile); err != nil {
+		return nil, err
+	}
+	return push.New(cfg.Type, &push.Settings{
+		AccountName: name,
+		Raw:         cfg.Raw,
+		HTTP:        h,
+	})
+}
+
+// newAccountPusher 创建账号的推送：push、push_targets 及全局 push_targets，有多个目标时并行推送
+// h 用于账号的 push，其他目标各自使用独立的HTTP客户端（签名、请求头等设置按目标区分）
+// 同时返回包含所有目标的 Fanout，供规则按名称选择；启用重试的目标失败时放入 retries 的重试队列
+func newAccountPusher(accCfg *config.AccountConfig, global []config.PushConfig, name string, h *push.HTTPClient, retries *accountRetries, wait pushWait) (push.Pusher, *push.Fanout, error) {
+	var targets []push.Target
+	names := make(map[string]int)
+	add := func(cfg config.PushConfig, h *push.HTTPClient, shared bool) error {
+		p, err := newPusher(cfg, name, h, wait)
+		if err != nil || p == nil {
+			return err
+		}
+		target := cfg.Name
+		if target == "" {
+			target = cfg.Type
+		}
+		if names[target]++; names[target] > 1 {
+			target = fmt.Sprintf("%s#%d", target, names[target])
+		}
+		p = push.Truncated(p, cfg.MaxBodyLength)
+		p = rateLimit(p, cfg.RateLimit, name, target, shared, wait)
+		p = retries.wrap(p, cfg.Retry, target)
+		targets = append(targets, push.Target{Name: target, Pusher: p, RuleOnly: cfg.RuleOnly})
+		return nil
+	}
+
+	if err := add(accCfg.Push, h, false); err != nil {
+		return nil, nil, err
+	}
+	for i, cfg := range accCfg.PushTargets {
+		if err := add(cfg, push.NewHTTPClient(name), false); err != nil {
+			return nil, nil, fmt.Errorf("push_targets[%d]: %w", i, err)
+		}
+	}
+	for i, cfg := range global {
+		if err := add(cfg, push.NewHTTPClient(name), true); err != nil {
+			return nil, nil, fmt.Errorf("app.push_targets[%d]: %w", i, err)
+		}
+	}
+
+	if len(targets) == 0 {
+		return nil, nil, nil
+	}
+	f := push.NewFanout(targets)
+	switch {
+	case !f.HasDefault():
+		return nil, f, nil
+	case len(targets) == 1:
+		return targets[0].Pusher, f, nil
+	}
+	return f, f, nil
+}
+
+// loadRules 创建账号的过滤规则及规则指定的推送目标
+func (ar *AccountReceiver) loadRules(cfgs []config.RuleConfig) error {
+	var list []*rules.Rule
+	for i, rc := range cfgs {
+		name := rc.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", i+1)
+		}
+		m := rc.Match
+		rule, err := rules.NewRule(name, rules.Match{
+			From:          m.From,
+			To:            m.To,
+			Subject:       m.Subject,
+			Body:          m.Body,
+			HasAttachment: m.HasAttachment,
+			MinSize:       m.MinSize,
+			MaxSize:       m.MaxSize,
+			Tags:          m.Tags,
+			Category:      m.Category,
+			KnownContact:  m.KnownContact,
+			DKIMPass:      m.DKIMPass,
+			Expr:          m.Expr,
+		}, rc.Actions, rc.MoveTo)
+		if err != nil {
+			return err
+		}
+		if m.DKIMPass != nil && !ar.config.AuthCheck.Enabled {
+			return fmt.Errorf("规则 %s 使用了 dkim_pass，但账号未启用 auth_check", name)
+		}
+		if rc.Title != "" && rc.TitleExpr != "" {
+			return fmt.Errorf("规则 %s 不能同时设置 title 和 title_expr", name)
+		}
+		if err := rule.SetTitle(rc.Title); err != nil {
+			return err
+		}
+		if err := rule.SetTitleExpr(rc.TitleExpr); err != nil {
+			return err
+		}
+		if !push.ValidPriority(rc.Priority) {
+			return fmt.Errorf("规则 %s 的优先级无效: %s", name, rc.Priority)
+		}
+		rule.Sound = rc.Sound
+		rule.Priority = rc.Priority
+		if rule.Reply {
+			if ar.replier == nil {
+				if ar.replier, err = newAutoReplier(ar.config); err != nil {
+					return fmt.Errorf("规则 %s 使用了 reply 动作，但账号的 smtp 配置无效: %w", name, err)
+				}
+			}
+			if err := ar.replier.add(rule, rc.Reply); err != nil {
+				return err
+			}
+		}
+		if rc.Push != nil && len(rc.Targets) > 0 {
+			return fmt.Errorf("规则 %s 不能同时设置 push 和 targets", name)
+		}
+		var p push.Pusher
+		switch {
+		case ar.embedded:
+		case rc.Push != nil:
+			if p, err = newPusher(*rc.Push, ar.name, push.NewHTTPClient(ar.name), ar.pushWait); err != nil {
+				return fmt.Errorf("规则 %s 的推送配置无效: %w", name, err)
+			}
+			if p != nil {
+				p = rateLimit(p, rc.Push.RateLimit, ar.name, "rule:"+name, false, ar.pushWait)
+				p = ar.retryQueues.wrap(p, rc.Push.Retry, "rule:"+name)
+			}
+		case len(rc.Targets) > 0:
+			if ar.pushTargets == nil {
+				return fmt.Errorf("规则 %s 指定了推送目标，但账号未配置推送", name)
+			}
+			if p, err = ar.pushTargets.Select(rc.Targets); err != nil {
+				return fmt.Errorf("规则 %s: %w", name, err)
+			}
+		}
+		if p != nil {
+			if ar.rulePushers == nil {
+				ar.rulePushers = make(map[*rules.Rule]push.Pusher)
+			}
+			ar.rulePushers[rule] = p
+		}
+		list = append(list, rule)
+	}
+	ar.rules = rules.NewEngine(list...)
+	if ar.rules.Len() > 0 {
+		log.Printf("[%s] 已加载 %d 条过滤规则", ar.name, ar.rules.Len())
+	}
+	return nil
+}
+
+// newContactBook 加载配置中的联系人、联系人文件和 CardDAV 查询
+func newContactBook(cfg *config.Config) (*contacts.Book, error) {
+	entries := make(map[string]contacts.Contact, len(cfg.Contacts))
+	for addr, c := range cfg.Contacts {
+		entries[addr] = contacts.Contact{Name: c.Name, Category: c.Category, Priority: c.Priority}
+	}
+	book, err := contacts.NewBook(entries, cfg.App.ContactsFile)
+	if err != nil {
+		return nil, err
+	}
+	if book.Len() > 0 {
+		log.Printf("已加载 %d 个联系人", book.Len())
+	}
+	if dav := cfg.App.CardDAV; dav.URL != "" {
+		book.SetRemote(contacts.NewCardDAV(dav.URL, dav.Username, dav.Password, time.Duration(dav.CacheTTL)*time.Second))
+		log.Printf("已启用 CardDAV 联系人查询: %s", dav.URL)
+	}
+	return book, nil
+}
+
+// newTagger 根据配置创建分类标签器
+func newTagger(cfg config.TaggingConfig) (*tagging.Tagger, error) {
+	tagger := tagging.NewTagger()
+	if !cfg.DisableBuiltin {
+		for _, c := range tagging.Builtin() {
+			tagger.Add(c)
+		}
+	}
+	for _, rc := range cfg.Rules {
+		rule, err := tagging.NewRule(rc.Tag, rc.Keywords, rc.Pattern, rc.Fields)
+		if err != nil {
+			return nil, err
+		}
+		tagger.Add(rule)
+	}
+	return tagger, nil
+}
+
+// newPollScheduler 根据账号配置创建轮询调度器
+func newPollScheduler(cfg *config.AccountConfig) imap.PollScheduler {
+	base := time.Duration(cfg.PollInterval) * time.Second
+	if !cfg.AdaptivePoll.Enabled {
+		return imap.FixedInterval(base)
+	}
+	return imap.NewAdaptiveInterval(
+		base,
+		time.Duration(cfg.AdaptivePoll.MinInterval)*time.Second,
+		time.Duration(cfg.AdaptivePoll.MaxInterval)*time.Second,
+	)
+}
+
 // runAccountReceiver 运行单个账号的接收器
 func (r *Receiver) runAccountReceiver(ar *AccountReceiver) {
 	defer r.wg.Done()
+	defer close(ar.done)
 
 	for {
+		if !ar.ctl.waitResume() {
+			log.Printf("[%s] 已停止监控", ar.name)
+			return
+		}
 		if err := ar.run(); err != nil {
-			ar.handleError(err)
+			// 服务器主动断开时附带给出的原因（如停机维护），便于区分普通的连接错误
+			if reason := ar.client.ByeReason(); reason != "" {
+				err = fmt.Errorf("%w（服务器断开连接: %s）", err, reason)
+			}
+			if !ar.handleError(err) {
+				return
+			}
 		}
 	}
 }
@@ -149,18 +679,52 @@ func (r *Receiver) runAccountReceiver(ar *AccountReceiver) {
 // run 运行账号接收器的主逻辑
 func (ar *AccountReceiver) run() error {
 	// 连接并登录IMAP服务器
+	reconnect := !ar.firstConnect
+	if reconnect {
+		metrics.Reconnects.Inc(ar.name)
+	}
+
+	// 网络中断时等待恢复后再连接，不计入失败次数
+	if !ar.network.Wait(ar.ctl.wake) {
+		// 收到管理请求（暂停/停止/立即获取），由外层重新判断
+		return nil
+	}
+
+	// 连接预算：同一服务器/用户同时打开的连接数超过上限时等待其他连接释放
+	release, ok := ar.connections.TryAcquire(ar.config.Server, ar.config.Username, ar.config.MaxConnections)
+	if !ok {
+		log.Printf("[%s] %s 的连接数已达上限，等待其他连接释放", ar.name, ar.config.Server)
+		var err error
+		if release, err = ar.connections.Acquire(ar.config.Server, ar.config.Username, ar.config.MaxConnections, ar.ctl.wake); err != nil {
+			// 收到管理请求（暂停/停止/立即获取），由外层重新判断
+			return nil
+		}
+	}
+	defer release()
+
+	// 分阶段计时，便于比较重连耗时
+	start := time.Now()
 	if err := ar.client.Connect(); err != nil {
 		return fmt.Errorf("连接失败: %w", err)
 	}
 	defer ar.client.Logout()
+	defer ar.state.update(func(st *AccountStatus) { st.Connected = false })
+	connected := time.Now()
 
 	if err := ar.client.Login(); err != nil {
 		return fmt.Errorf("登录失败: %w", err)
 	}
+	loggedIn := time.Now()
 	log.Printf("[%s] 登录成功", ar.name)
 
+	ar.state.update(func(st *AccountStatus) {
+		st.Connected = true
+		st.Mode = ar.client.MonitorMode()
+		st.Capabilities = ar.client.Capabilities()
+	})
+
 	// 登录成功，重置重试计数器
-	ar.retries = 0
+	ar.recover()
 
 	// 首次连接时列出所有可用的文件夹
 	if ar.firstConnect {
@@ -175,42 +739,169 @@ func (ar *AccountReceiver) run() error {
 		}
 	}
 
-	// 获取要监控的文件夹（只使用第一个）
+	// 识别特殊用途文件夹，配置中可用 \Junk、\Archive 等代替实际名称
+	if err := ar.client.DiscoverSpecialFolders(); err != nil {
+		log.Printf("[%s] %v", ar.name, err)
+	}
+
+	if reconnect {
+		total := time.Since(start)
+		metrics.ReconnectDuration.Observe(ar.name, total.Seconds())
+		log.Printf("[%s] 重连耗时 %v（连接 %v，登录 %v，准备 %v）", ar.name,
+			total.Round(time.Millisecond), connected.Sub(start).Round(time.Millisecond),
+			loggedIn.Sub(connected).Round(time.Millisecond), time.Since(loggedIn).Round(time.Millisecond))
+	}
+
+	// 获取要监控的文件夹：第一个实时监控（IDLE/轮询），其余按轮询间隔在同一连接上检查
 	if len(ar.config.Folders) == 0 {
 		return fmt.Errorf("未配置监控文件夹")
 	}
-	folder := ar.config.Folders[0]
+	folders := make([]string, len(ar.config.Folders))
+	for i, f := range ar.config.Folders {
+		folders[i] = ar.client.ResolveFolder(f)
+	}
+	folder, others := folders[0], folders[1:]
+
+	// 交接启动时等待旧进程停止后再获取邮件，避免新旧进程同时处理同一批邮件
+	if ar.released != nil {
+		select {
+		case <-ar.released:
+		case <-ar.ctl.wake:
+			// 收到管理请求（暂停/停止/立即获取），由外层重新判断
+			return nil
+		}
+	}
+
+	// 严格投递模式下先对账上次未完成的投递
+	if ar.strict {
+		for _, f := range folders {
+			ar.reconcilePending(f)
+		}
+	}
 
-	// 首先处理现有邮件
+	// 首先处理现有邮件（同时满足之前的立即获取请求）
+	ar.ctl.takeFetch()
 	ar.fetchAndProcessMessages(folder)
+	ar.checkFolders(others)
 
 	// 开始监控新邮件
-	pollInterval := time.Duration(ar.config.PollInterval) * time.Second
-	monitor := ar.client.IdleWithFallback(folder, pollInterval)
+	monitor := ar.client.IdleWithFallback(folder, ar.scheduler)
 
-	// 等待监控结果
-	err := <-monitor.UpdateCh
-	if err != nil {
-		return err
+	// 其他文件夹的检查定时器，只监控一个文件夹时不触发
+	otherInterval := time.Duration(ar.config.PollInterval) * time.Second
+	var otherTimer clock.Timer
+	var otherTick <-chan time.Time
+	if len(others) > 0 {
+		otherTimer = ar.clock.NewTimer(otherInterval)
+		defer otherTimer.Stop()
+		otherTick = otherTimer.C()
 	}
 
-	// 有新邮件，处理后重新连接
-	ar.fetchAndProcessMessages(folder)
-	return nil
+	// 持续处理监控结果：轮询模式会在同一连接上多次通知，
+	// IDLE模式通知一次后关闭通道，由外层重新建立连接
+	for {
+		select {
+		case err, ok := <-monitor.UpdateCh:
+			if !ok {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			// 处理完成后才通知轮询继续，避免轮询的 SELECT 与本次获取交错
+			ar.fetchAndProcessMessages(folder)
+			monitor.Ack()
+
+		case <-ar.ctl.wake:
+			// 管理接口请求：先停止监控，再在同一连接上执行
+			paused, stopped := ar.ctl.state()
+			fetch := ar.ctl.takeFetch()
+			if !paused && !stopped && !fetch {
+				continue
+			}
+			monitor.Stop()
+			if paused || stopped {
+				return nil
+			}
+			log.Printf("[%s] 立即获取邮件", ar.name)
+			ar.fetchAndProcessMessages(folder)
+			for _, f := range others {
+				ar.fetchAndProcessMessages(f)
+			}
+			monitor = ar.client.IdleWithFallback(folder, ar.scheduler)
+
+		case <-otherTick:
+			// 停止监控后依次检查其他文件夹，再重新选中并监控第一个文件夹
+			monitor.Stop()
+			ar.checkFolders(others)
+			monitor = ar.client.IdleWithFallback(folder, ar.scheduler)
+			otherTimer.Reset(otherInterval)
+
+		case <-ar.storm.due():
+			// 合并窗口结束：在同一连接上推送汇总并标记已读
+			monitor.Stop()
+			ar.fetchAndProcessMessages(folder)
+			monitor = ar.client.IdleWithFallback(folder, ar.scheduler)
+
+		case <-ar.quiet.due():
+			// 免打扰时段结束：推送暂存邮件的汇总
+			monitor.Stop()
+			ar.fetchAndProcessMessages(folder)
+			monitor = ar.client.IdleWithFallback(folder, ar.scheduler)
+		}
+	}
+}
+
+// checkFolders 依次检查文件夹：先用 STATUS 判断是否有新邮件，只在有新邮件时选中并获取，减少 SELECT 次数
+func (ar *AccountReceiver) checkFolders(folders []string) {
+	for _, f := range folders {
+		cp := ar.checkpoints.Get(ar.name, f)
+		changed, err := ar.client.HasNewMessages(f, cp.UIDValidity, cp.LastUID)
+		if err != nil {
+			log.Printf("[%s] %v", ar.name, err)
+			continue
+		}
+		if changed {
+			ar.fetchAndProcessMessages(f)
+		}
+	}
 }
 
 // fetchAndProcessMessages 获取并处理邮件
 func (ar *AccountReceiver) fetchAndProcessMessages(folder string) {
-	messages, err := ar.client.FetchMessages(
+	summary := newCycleSummary(ar.name, folder, ar.clock)
+	defer summary.emit()
+	strict := ar.strictFor(folder)
+
+	cp := ar.checkpoints.Get(ar.name, folder)
+	messages, uidValidity, err := ar.client.FetchMessages(
 		folder,
 		50,    // 每次最多获取50封
 		false, // 不自动标记已读（推送成功后会手动标记）
+		cp.UIDValidity,
+		cp.LastUID,
 	)
 
 	if err != nil {
 		log.Printf("[%s] 获取邮件失败: %v", ar.name, err)
+		summary.failed = true
 		return
 	}
+	now := time.Now()
+	metrics.LastFetch.Set(ar.name, float64(now.Unix()))
+	ar.state.update(func(st *AccountStatus) { st.LastFetch = &now })
+	metrics.EmailsFetched.Add(ar.name, float64(len(messages)))
+
+	// 本批次处理完（包括标记已读）后检查已推送邮件的状态变化
+	defer ar.checkFlagChanges(folder, uidValidity)
+
+	// 本批次处理完后推送到期的合并邮件和免打扰期间暂存的邮件
+	defer ar.flushCollapsed(folder, uidValidity)
+	defer ar.flushQuiet(folder, uidValidity)
+
+	// 进度中记录为暂存、但不在内存中的邮件（进程重启或账号重新加载前暂存的）重新获取处理
+	held := ar.heldInMemory(folder, uidValidity)
+	messages, cp = ar.recoverHeld(folder, uidValidity, cp, held, messages)
 
 	if len(messages) == 0 {
 		return
@@ -218,71 +909,582 @@ func (ar *AccountReceiver) fetchAndProcessMessages(folder string) {
 
 	log.Printf("[%s] 收到 %d 封新邮件", ar.name, len(messages))
 
+	summary.fetched = len(messages)
+	for _, msg := range messages {
+		summary.bytes += uint64(msg.Size)
+	}
+
+	// 处理进度：按UID顺序推进，遇到推送失败的邮件后停止推进，保证其下次仍会被获取
+	progress := newProgress(cp, uidValidity)
+	defer ar.saveProgress(folder, progress)
+
+	// 推送成功的邮件UID，批次结束后统一标记为已读
+	var pushedUIDs []uint32
+	// 严格投递模式下推送失败、需要移除临时关键字的邮件UID
+	var failedUIDs []uint32
+	// 规则要求标记已读（未推送）和移动的邮件UID
+	var ruleReadUIDs []uint32
+	ruleMoves := make(map[string][]uint32)
+	// 无法解析、按配置标记已读或加星标的邮件UID
+	var parseReadUIDs, parseFlagUIDs []uint32
+	// UID → Message-ID，用于审计日志
+	msgIDs := make(map[uint32]string)
+
 	// 处理每条消息
 	for _, msg := range messages {
-		email, err := imap.ParseMessage(msg, ar.name)
-		if err != nil {
-			log.Printf("[%s] 解析邮件失败: %v", ar.name, err)
+		// 已暂存的邮件（进度未能推进时会被再次获取）不重复处理
+		if held[msg.Uid] {
+			progress.hold(msg.Uid)
+			continue
+		}
+		// 之前的周期中已处理完成、因更早的邮件推送失败而再次获取的邮件不重复处理（退订、自动回复等动作只执行一次）
+		if progress.completed(msg.Uid) {
+			progress.done(msg.Uid)
 			continue
 		}
 
+		email, err := imap.ParseMessage(msg, ar.name, ar.parseOpts)
+		parseFailed := false
+		if err != nil {
+			var perr *imap.ParseError
+			if errors.As(err, &perr) {
+				msgIDs[msg.Uid] = perr.Email.MessageID
+				email, err = ar.handleParseFailure(folder, uidValidity, perr, &parseReadUIDs, &parseFlagUIDs)
+				if err != nil {
+					// 隔离失败：不推进进度，下次重新获取
+					log.Printf("[%s] %v", ar.name, err)
+					summary.skipped++
+					progress.fail()
+					continue
+				}
+				parseFailed = email != nil
+			} else {
+				log.Printf("[%s] 解析邮件失败: %v", ar.name, err)
+			}
+			if email == nil {
+				summary.skipped++
+				progress.done(msg.Uid)
+				continue
+			}
+		}
+
+		msgIDs[email.UID] = email.MessageID
+
+		// 监控多个文件夹时，同一封邮件只在第一次出现的文件夹中处理（如 Gmail 的 INBOX 和所有邮件）
+		if len(ar.config.Folders) > 1 {
+			if first := ar.folderDedup.seen(email, folder); first != "" {
+				log.Printf("[%s] 邮件已在文件夹 %s 中处理，跳过: %s", ar.name, first, email.Subject)
+				summary.skipped++
+				progress.done(email.UID)
+				continue
+			}
+		}
+
+		// 关联发件人联系人
+		email.Contact = ar.contacts.Lookup(email.FromAddress)
+
+		// S/MIME：解密加密邮件、验证签名，解密或去掉签名封装后的内容重新解析
+		if ar.smime != nil && len(email.Raw) > 0 && !parseFailed {
+			content, res := ar.smime.Process(email.Raw, email.FromAddress)
+			if content != nil {
+				if err := email.ParseContent(content, ar.name, ar.parseOpts); err != nil {
+					log.Printf("[%s] 解析 S/MIME 邮件内容失败: %v", ar.name, err)
+				}
+			}
+			if res != nil && res.Error != "" {
+				log.Printf("[%s] S/MIME 邮件 %s: %s", ar.name, email.Subject, res.Error)
+			}
+			email.SMIME = res
+		}
+
+		// 发件人真实性检查（DKIM 签名、收件服务器记录的 SPF/DMARC）
+		if ar.verifier != nil && len(email.Raw) > 0 {
+			email.Auth = ar.verifier.Verify(email.Raw, email.FromAddress)
+		}
+
+		// 分类打标签
+		embedded := ar.embeddedText(email)
+		text := email.Body + "\n" + ar.htmlText.strip(email.HTMLBody) + embedded
+		email.Tags = ar.tagger.Tag(&tagging.Input{
+			Subject: email.Subject,
+			From:    email.FromAddress,
+			Body:    text,
+		})
+		if email.Bounce != nil {
+			email.Tags = addTag(email.Tags, bounceTag)
+		}
+
+		// 记录退订方式，供规则动作和管理接口使用
+		unsub := ar.rememberUnsubscribe(email)
+
+		// 按规则决定处理方式，未命中任何规则时默认推送
+		pusher := ar.pusher
+		rule := ar.rules.Evaluate(ar.ruleInput(email, text))
+		if rule != nil && rule.Unsubscribe {
+			go ar.unsubscribe(email, unsub)
+		}
+		if rule != nil && rule.Reply {
+			go ar.autoReply(email, rule)
+		}
+		if rule != nil {
+			if p, ok := ar.rulePushers[rule]; ok {
+				pusher = p
+			}
+			if !rule.Push {
+				pusher = nil
+				summary.filtered++
+				log.Printf("[%s] 规则 %s 已过滤: %s", ar.name, rule.Name, email.Subject)
+			}
+		}
+
+		// 保存附件，推送内容中附上保存路径
+		var saved []string
+		var inline map[string]string // 已保存的内联图片（Content-ID 到保存位置）
+		if ar.attachments != nil {
+			var match func(string) bool
+			if ar.attachOnly != nil {
+				match = ar.attachOnly.MatchString
+			}
+			if saved, err = ar.attachments.Save(ar.name, email, match); err != nil {
+				log.Printf("[%s] %v", ar.name, err)
+				ar.checkDiskFull("保存附件", err)
+			}
+			if ar.attachOnly == nil {
+				if inline, err = ar.attachments.SaveInline(ar.name, email); err != nil {
+					log.Printf("[%s] %v", ar.name, err)
+					ar.checkDiskFull("保存内联图片", err)
+				}
+			}
+		}
+
+		// 附件模式：没有保存任何匹配附件的邮件不推送
+		if ar.attachOnly != nil && len(saved) == 0 && pusher != nil {
+			pusher = nil
+			summary.filtered++
+		}
+
 		// 推送邮件信息
-		if ar.pusher != nil {
-			// 获取邮件正文（优先使用纯文本，否则清理HTML后使用）
-			body := email.Body
-			if body == "" && email.HTMLBody != "" {
-				// 清理HTML标签
-				body = stripHTML(email.HTMLBody)
+		pushed := false
+		failed := false
+		if pusher != nil {
+			// 免打扰时段：暂存邮件（含规则指定推送目标的邮件），结束时按推送目标分别汇总推送（urgent 规则立即推送）
+			if now := ar.clock.Now(); ar.quiet != nil && !strict &&
+				(rule == nil || rule.Priority != push.PriorityUrgent) && ar.quiet.hold(collapsedMail{email: email, folder: folder, uidValidity: uidValidity, pusher: pusher}, now) {
+				summary.collapsed++
+				progress.hold(email.UID)
+				continue
 			}
 
-			// 构建推送消息内容
-			from := ""
-			if len(email.From) > 0 {
-				from = email.From[0]
+			// 邮件风暴或摘要模式：未命中规则的邮件暂存，窗口结束时合并推送（严格投递模式下逐封推送）
+			if now := ar.clock.Now(); ar.storm != nil && rule == nil && !strict && ar.storm.admit(now) {
+				ar.storm.add(collapsedMail{email: email, folder: folder, uidValidity: uidValidity, pusher: pusher}, now)
+				summary.collapsed++
+				progress.hold(email.UID)
+				continue
+			}
+
+			// 获取邮件正文（优先使用纯文本，否则清理HTML后使用），附件模式下忽略正文
+			var body, html string
+			if ar.attachOnly == nil {
+				body = replaceCIDText(email.Body, inline)
+				if body == "" && email.HTMLBody != "" {
+					// 内联图片替换为保存位置或占位文字
+					html = replaceCIDImages(email.HTMLBody, inline)
+					if ar.config.TrimQuotes {
+						html = content.TrimQuotedHTML(html)
+					}
+					// 清理HTML标签
+					body = ar.htmlText.strip(html)
+				}
+				// 只保留回复的新内容
+				if ar.config.TrimQuotes {
+					body = content.TrimQuotedText(body)
+				}
+				if ar.signature != nil {
+					if trimmed := ar.signature.Trim(body); trimmed != body {
+						body = trimmed
+						// 签名只从纯文本中去除，HTML 仍带签名，不再转换 HTML 推送
+						html = ""
+					}
+				}
+			}
+			plain := body
+			// 转发的原邮件附在正文之后
+			if ar.attachOnly == nil && embedded != "" {
+				body = strings.TrimSpace(body + embedded)
+			}
+			// 退信的失败收件人和状态码放在正文开头
+			if ar.attachOnly == nil && email.Bounce != nil {
+				body = strings.TrimSpace(bounceSummary(email.Bounce) + "\n" + body)
+			}
+
+			// 可选生成摘要代替全文，失败时仍推送全文
+			if ar.summarizer != nil && body != "" && !parseFailed {
+				if summary, err := ar.summarizer.Summarize(email.Subject, body); err != nil {
+					log.Printf("[%s] %v", ar.name, err)
+				} else {
+					body = summary
+				}
+			}
+
+			// 非中文邮件可选机器翻译
+			title := email.Subject
+			if ar.translator != nil {
+				title, body = ar.translate(title, body)
+			}
+
+			// 规则可覆盖标题、铃声和优先级
+			meta := &push.Meta{Account: ar.name, Folder: folder, Email: email}
+
+			// 图片附件缩略图：只有对外可访问的URL才能在通知中预览
+			if ar.attachments != nil {
+				if thumb, err := ar.attachments.SaveThumbnail(ar.name, email); err != nil {
+					log.Printf("[%s] %v", ar.name, err)
+				} else if strings.HasPrefix(thumb, "https://") || strings.HasPrefix(thumb, "http://") {
+					meta.ImageURL = thumb
+				}
+			}
+			if rule != nil {
+				title, err = rule.Title(&rules.TitleData{
+					Subject: title,
+					From:    email.DisplayFrom(),
+					Address: email.FromAddress,
+					Account: ar.name,
+					Folder:  folder,
+					Tags:    email.Tags,
+					Body:    text,
+				})
+				if err != nil {
+					log.Printf("[%s] %v", ar.name, err)
+				}
+				meta.Sound = rule.Sound
+				meta.Priority = rule.Priority
 			}
+
+			// 验证码放入标题
+			title, body = ar.applyOTP(email, text, title, body, meta)
+
+			// 正文来自HTML且未被摘要、翻译替换时，Markdown 格式的推送目标可转换HTML代替纯文本
+			if html != "" && body != "" && body == plain {
+				meta.Rich = &push.RichText{Text: body, HTML: html}
+			}
+
+			// 构建推送消息内容
+			from := email.DisplayFrom()
 			receiveTime := email.Date.Format("2006-01-02 15:04:05")
 
-			msgContent := push.BuildMessageContent(body, receiveTime, from, email.To, email.HasAttachments)
+			msgContent := push.BuildMessageContent(body, receiveTime, from, email.To, email.HasAttachments, saved)
+			if email.Auth != nil {
+				msgContent += fmt.Sprintf("真实性: %s\n", email.Auth.Indicator())
+			}
+			if email.SMIME != nil {
+				msgContent += fmt.Sprintf("S/MIME: %s\n", email.SMIME.Indicator())
+			}
+			if meta.ViewURL = ar.viewURL(folder, uidValidity, email.UID); meta.ViewURL != "" {
+				msgContent += fmt.Sprintf("查看完整邮件: %s\n", meta.ViewURL)
+			}
+
+			if strict {
+				if err := ar.setKeyword(folder, triggerStrictPending, pendingKeyword, true, []uint32{email.UID}, msgIDs); err != nil {
+					// 无法设置临时关键字时不推送，留待下次处理
+					log.Printf("[%s] %v", ar.name, err)
+					summary.skipped++
+					progress.fail()
+					continue
+				}
+			}
 
 			// 发送推送
-			success, err := ar.pusher.Push(email.Subject, msgContent)
+			start := time.Now()
+			err := pusher.Push(title, msgContent, meta)
+			metrics.PushDuration.Observe(ar.name, time.Since(start).Seconds())
 			if err != nil {
+				failed = true
+				metrics.PushFailures.Inc(ar.name)
 				log.Printf("[%s] 推送失败: %v", ar.name, err)
-			} else if success {
-				// 推送成功，标记邮件为已读
-				ar.client.MarkAsRead(email.UID)
+				if strict {
+					failedUIDs = append(failedUIDs, email.UID)
+				}
+				summary.skipped++
+				progress.fail()
+			} else {
+				// 推送成功，记录UID待批量标记为已读
+				summary.pushed++
+				metrics.EmailsPushed.Inc(ar.name)
+				ar.observeDelivery(email)
+				pushed = true
+				pushedUIDs = append(pushedUIDs, email.UID)
 				log.Printf("[%s] 已推送: %s", ar.name, email.Subject)
+				if strict {
+					// 立即记录投递，进程在标记已读前退出时对账不会重复推送
+					progress.delivered(email.UID)
+					ar.saveDelivered(folder, progress)
+				}
+				// 规则会移走的邮件不再跟踪状态变化
+				if rule == nil || rule.MoveTo == "" {
+					ar.flagWatch.add(folder, uidValidity, email)
+				}
 			}
 		}
 
+		// 规则动作：推送失败的邮件留待下次处理
+		if rule != nil && !failed {
+			if rule.MarkRead && !pushed {
+				ruleReadUIDs = append(ruleReadUIDs, email.UID)
+			}
+			if rule.MoveTo != "" {
+				ruleMoves[rule.MoveTo] = append(ruleMoves[rule.MoveTo], email.UID)
+			}
+		}
+
+		// 保存处理记录
+		ar.saveRecord(folder, uidValidity, email, pushed)
+		if !failed {
+			progress.done(email.UID)
+		}
+
 		// 这里可以添加更多的处理逻辑，如：
-		// - 保存到数据库
 		// - 转发到其他服务
 		// - 触发webhook
 		// - 保存附件到本地
 	}
+
+	markErr := ar.markAsRead(folder, triggerPushSuccess, pushedUIDs, msgIDs)
+	if markErr != nil {
+		log.Printf("[%s] %v", ar.name, markErr)
+	} else if strict {
+		progress.confirmed(pushedUIDs)
+	}
+
+	// 严格投递模式：移除临时关键字。已读标记失败时已推送的邮件仍在投递记录中，下次对账补做
+	if strict {
+		if err := ar.setKeyword(folder, triggerStrictFinalize, pendingKeyword, false, append(pushedUIDs, failedUIDs...), msgIDs); err != nil {
+			log.Printf("[%s] %v", ar.name, err)
+		}
+	}
+	if markErr != nil {
+		return
+	}
+
+	// 无法解析的邮件：标记已读避免反复获取，flag 方式同时加星标
+	if err := ar.setKeyword(folder, triggerParseFailure, flaggedFlag, true, parseFlagUIDs, msgIDs); err != nil {
+		log.Printf("[%s] %v", ar.name, err)
+	}
+	if err := ar.markAsRead(folder, triggerParseFailure, parseReadUIDs, msgIDs); err != nil {
+		log.Printf("[%s] %v", ar.name, err)
+	}
+
+	// 规则动作：移动放在最后，移动后原文件夹中的UID失效
+	if err := ar.markAsRead(folder, triggerRule, ruleReadUIDs, msgIDs); err != nil {
+		log.Printf("[%s] %v", ar.name, err)
+	}
+	for dest, uids := range ruleMoves {
+		if err := ar.move(folder, triggerRule, dest, uids, msgIDs); err != nil {
+			log.Printf("[%s] %v", ar.name, err)
+		}
+	}
 }
 
-// handleError 处理错误和重试
-func (ar *AccountReceiver) handleError(err error) {
-	ar.retries++
+// ruleInput 构建规则匹配所需的邮件信息
+func (ar *AccountReceiver) ruleInput(email *imap.EmailMessage, text string) *rules.Input {
+	in := &rules.Input{
+		From:          email.FromAddress,
+		To:            strings.Join(email.To, ", "),
+		Subject:       email.Subject,
+		Body:          text,
+		HasAttachment: email.HasAttachments,
+		Size:          email.Size,
+		Tags:          email.Tags,
+	}
+	if email.Contact != nil {
+		in.Category = email.Contact.Category
+		in.KnownContact = true
+	}
+	if email.Auth != nil {
+		in.DKIMPass = email.Auth.DKIMPass()
+	}
+	return in
+}
 
-	if ar.retries >= ar.maxRetries {
-		log.Printf("[%s] 已达到最大重试次数 (%d)，程序退出", ar.name, ar.maxRetries)
+// saveRecord 将处理结果写入存储
+func (ar *AccountReceiver) saveRecord(folder string, uidValidity uint32, email *imap.EmailMessage, pushed bool) {
+	if ar.store == nil {
+		return
+	}
+	rec := &storage.Record{
+		Account:     ar.name,
+		Folder:      folder,
+		UIDValidity: uidValidity,
+		UID:         email.UID,
+		MessageID:   email.MessageID,
+		Subject:     email.Subject,
+		From:        email.FromAddress,
+		To:          email.To,
+		CC:          email.CC,
+		Date:        email.Date,
+		Size:        email.Size,
+		Flags:       email.Flags,
+		Tags:        email.Tags,
+		Body:        email.Body,
+		HTMLBody:    email.HTMLBody,
+		Pushed:      pushed,
+		ProcessedAt: time.Now(),
+	}
+	for _, a := range email.Attachments {
+		rec.Attachments = append(rec.Attachments, a.Filename)
+	}
+	if mv := ar.mailView; mv.Enabled {
+		rec.ViewID = storage.ViewID(mv.Secret, ar.name, folder, uidValidity, email.UID)
+	}
+	if err := ar.store.Save(rec); err != nil {
+		log.Printf("[%s] 保存处理记录失败: %v", ar.name, err)
+		ar.checkDiskFull("保存处理记录", err)
+	}
+}
 
-		// 发送告警推送
-		if ar.pusher != nil {
-			title := "请检查 Mail 服务"
-			msg := fmt.Sprintf("账号 [%s] 已达最大重试次数 (%d)，程序已退出\n最后错误: %v",
-				ar.name, ar.maxRetries, err)
-			ar.pusher.Push(title, msg) // Push 方法会阻塞直到完成或超时
+// viewURL 返回网页查看邮件的地址，未启用或未设置对外访问地址时返回空
+func (ar *AccountReceiver) viewURL(folder string, uidValidity, uid uint32) string {
+	mv := ar.mailView
+	if !mv.Enabled || mv.BaseURL == "" || ar.store == nil {
+		return ""
+	}
+	return mv.BaseURL + "/mail/" + storage.ViewID(mv.Secret, ar.name, folder, uidValidity, uid)
+}
+
+// embeddedText 内嵌邮件（如以附件形式转发的原邮件）的邮件头和正文，没有内嵌邮件时返回空字符串
+func (ar *AccountReceiver) embeddedText(email *imap.EmailMessage) string {
+	var b strings.Builder
+	for _, m := range email.Embedded {
+		b.WriteString("\n\n---------- 转发的邮件 ----------\n")
+		fmt.Fprintf(&b, "发件人: %s\n", m.From)
+		if !m.Date.IsZero() {
+			fmt.Fprintf(&b, "时间: %s\n", m.Date.Format("2006-01-02 15:04:05"))
+		}
+		fmt.Fprintf(&b, "主题: %s\n", m.Subject)
+		body := m.Body
+		if body == "" && m.HTMLBody != "" {
+			body = ar.htmlText.strip(m.HTMLBody)
+		}
+		if body = strings.TrimSpace(body); body != "" {
+			b.WriteString("\n" + body)
 		}
+	}
+	return b.String()
+}
 
-		os.Exit(1)
+// translate 翻译非中文的标题和正文，并在正文后附上原标题；翻译失败时原样返回
+func (ar *AccountReceiver) translate(title, body string) (string, string) {
+	if enrich.DetectLanguage(title+"\n"+body) == enrich.LangChinese {
+		return title, body
 	}
 
-	log.Printf("[%s] %v, 将在 %v 后重试 (第 %d/%d 次尝试)",
-		ar.name, err, ar.retryDelay, ar.retries, ar.maxRetries)
+	result, err := ar.translator.Translate(title, body)
+	if err != nil {
+		log.Printf("[%s] %v", ar.name, err)
+		return title, body
+	}
+
+	return result[0], result[1] + "\n\n原标题: " + title
+}
+
+// handleError 处理错误并按指数退避等待，返回false表示已达最大尝试次数、停止该账号
+// 其他账号不受影响继续运行
+func (ar *AccountReceiver) handleError(err error) bool {
+	// 网络中断导致的失败不计入失败次数，由 run 等待网络恢复后重新连接
+	if ar.network.Offline() {
+		ar.state.update(func(st *AccountStatus) { st.LastError = err.Error() })
+		return true
+	}
 
-	time.Sleep(ar.retryDelay)
+	ar.retries++
+	ar.state.update(func(st *AccountStatus) {
+		st.Retries = ar.retries
+		st.LastError = err.Error()
+	})
+
+	// 凭据被拒绝时按退避重试通常无效，还可能导致服务器锁定账号，改为长间隔重试
+	if errors.Is(err, imap.ErrAuthFailed) {
+		ar.holdAuth(err)
+		return true
+	}
+
+	if ar.retry.MaxAttempts > 0 && ar.retries >= ar.retry.MaxAttempts {
+		log.Printf("[%s] 已达到最大尝试次数 (%d)，停止该账号: %v", ar.name, ar.retry.MaxAttempts, err)
+		ar.state.update(func(st *AccountStatus) { st.Healthy = false })
+		ar.alert(alertStopped, config.AlertCritical, "请检查 Mail 服务", fmt.Sprintf("账号 [%s] 已达最大尝试次数 (%d)，已停止监控\n最后错误: %v",
+			ar.name, ar.retry.MaxAttempts, err))
+		return false
+	}
+
+	if !ar.unhealthy && ar.retries >= ar.retry.UnhealthyAfter {
+		ar.unhealthy = true
+		ar.state.update(func(st *AccountStatus) { st.Healthy = false })
+		log.Printf("[%s] 连续失败 %d 次，标记为异常", ar.name, ar.retries)
+		ar.alert(alertUnhealthy, config.AlertWarning, "请检查 Mail 服务", fmt.Sprintf("账号 [%s] 连续失败 %d 次，将持续重试\n最后错误: %v",
+			ar.name, ar.retries, err))
+	}
+
+	delay := ar.backoff()
+	log.Printf("[%s] %v, 将在 %v 后重试 (第 %d 次失败)", ar.name, err, delay, ar.retries)
+
+	// 等待期间收到管理请求（立即获取、暂停、停止）时提前结束等待
+	ar.ctl.sleep(delay)
+	return true
+}
+
+// backoff 计算下一次重试的等待时间：首次为 initial_delay，之后每次翻倍，不超过 max_delay
+func (ar *AccountReceiver) backoff() time.Duration {
+	delay := time.Duration(ar.retry.InitialDelay) * time.Second
+	max := time.Duration(ar.retry.MaxDelay) * time.Second
+	for i := 1; i < ar.retries && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
+	}
+	return delay
+}
+
+// recover 登录成功后重置失败计数，异常状态恢复时发送通知
+func (ar *AccountReceiver) recover() {
+	if ar.authHold {
+		log.Printf("[%s] 登录凭据已恢复", ar.name)
+		ar.alert(alertRecovered, config.AlertInfo, "Mail 服务已恢复", fmt.Sprintf("账号 [%s] 登录凭据已恢复，继续监控", ar.name))
+	} else if ar.unhealthy {
+		log.Printf("[%s] 连接已恢复", ar.name)
+		ar.alert(alertRecovered, config.AlertInfo, "Mail 服务已恢复", fmt.Sprintf("账号 [%s] 在连续失败 %d 次后已恢复连接", ar.name, ar.retries))
+	}
+	ar.retries = 0
+	ar.unhealthy = false
+	ar.authHold = false
+	ar.state.update(func(st *AccountStatus) {
+		st.Healthy = true
+		st.Quarantined = false
+		st.Retries = 0
+		st.LastError = ""
+	})
+}
+
+// alert 按告警策略发送账号运维告警，配置了单独的告警推送目标时推送到该目标
+func (ar *AccountReceiver) alert(kind, severity, title, msg string) {
+	pusher := ar.pusher
+	if ar.alerts != nil {
+		if ar.alerts.pusher != nil {
+			pusher = ar.alerts.pusher
+		}
+		if pusher == nil || !ar.alerts.wants(severity) {
+			return
+		}
+		if !ar.alerts.allow(ar.name, kind, ar.clock.Now()) {
+			log.Printf("[%s] 告警超过频率限制，未推送: %s", ar.name, title)
+			return
+		}
+	}
+	if pusher == nil {
+		return
+	}
+	meta := &push.Meta{Account: ar.name, Priority: severityPriorities[severity]}
+	if err := pusher.Push(title, msg, meta); err != nil {
+		log.Printf("[%s] 告警推送失败: %v", ar.name, err)
+	}
 }
@@ -0,0 +1,121 @@
+package receiver
+
+import (
+	"fmt"
+	"log"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"mail-receiver/config"
+)
+
+// ReloadResult 重新加载配置的结果
+type ReloadResult struct {
+	Added     []string `json:"added"`     // 新增的账号
+	Removed   []string `json:"removed"`   // 删除的账号
+	Restarted []string `json:"restarted"` // 配置有变化、已重启的账号
+	Failed    []string `json:"failed"`    // 原监控协程未能按时退出的账号：已停止，未删除或启动新配置
+}
+
+// Reload 按新配置增删账号，只重启配置有变化的账号，其他账号的连接不受影响
+// 新配置无效的账号保持原样运行并返回错误；app、contacts、tagging 的修改需要重启程序生效
+func (r *Receiver) Reload(cfg *config.Config) (*ReloadResult, error) {
+	r.reloadMu.Lock()
+	defer r.reloadMu.Unlock()
+
+	if !reflect.DeepEqual(r.config.App, cfg.App) ||
+		!reflect.DeepEqual(r.config.Contacts, cfg.Contacts) ||
+		!reflect.DeepEqual(r.config.Tagging, cfg.Tagging) {
+		log.Printf("app/contacts/tagging 配置的修改需要重启程序才能生效")
+	}
+
+	r.mu.RLock()
+	current := make(map[string]*AccountReceiver, len(r.accounts))
+	for name, ar := range r.accounts {
+		current[name] = ar
+	}
+	r.mu.RUnlock()
+
+	result := &ReloadResult{}
+	var errs []string
+	for name, ar := range current {
+		if _, ok := cfg.Accounts[name]; !ok {
+			log.Printf("[%s] 账号已从配置中删除，停止监控", name)
+			if err := r.removeAccount(ar); err != nil {
+				errs = append(errs, err.Error())
+				result.Failed = append(result.Failed, name)
+				continue
+			}
+			result.Removed = append(result.Removed, name)
+		}
+	}
+
+	for name, accCfg := range cfg.Accounts {
+		old, exists := current[name]
+		if exists && reflect.DeepEqual(old.config, accCfg) {
+			continue
+		}
+
+		ar, err := r.newAccountReceiver(name, accCfg)
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		if exists {
+			log.Printf("[%s] 配置已修改，重新启动邮件监控", name)
+			// 保留暂停状态
+			if paused, _ := old.ctl.state(); paused {
+				ar.ctl.paused = true
+				ar.state.status.Paused = true
+			}
+			if err := r.removeAccount(old); err != nil {
+				// 原协程可能仍在处理邮箱，不启动新配置，避免新旧协程同时处理同一个邮箱
+				ar.retryQueues.close()
+				errs = append(errs, err.Error())
+				result.Failed = append(result.Failed, name)
+				continue
+			}
+			result.Restarted = append(result.Restarted, name)
+		} else {
+			log.Printf("[%s] 启动邮件监控", name)
+			result.Added = append(result.Added, name)
+		}
+		r.startAccount(ar)
+	}
+
+	sort.Strings(result.Added)
+	sort.Strings(result.Removed)
+	sort.Strings(result.Restarted)
+	sort.Strings(result.Failed)
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return result, fmt.Errorf("部分账号未能重新加载: %s", strings.Join(errs, "; "))
+	}
+	return result, nil
+}
+
+// removeTimeout 删除或重启账号时等待原监控协程退出的时间
+const removeTimeout = 30 * time.Second
+
+// removeAccount 停止账号，等待监控协程退出（最多 removeTimeout）后从列表中移除，避免重启后新旧协程同时处理同一个邮箱
+// 超时返回错误：账号保持停止并留在列表中，下次重新加载时再次等待
+func (r *Receiver) removeAccount(ar *AccountReceiver) error {
+	ar.ctl.stop()
+	select {
+	case <-ar.done:
+	case <-time.After(removeTimeout):
+		log.Printf("[%s] 等待监控停止超时（%v），账号已停止，暂不应用新配置", ar.name, removeTimeout)
+		return fmt.Errorf("账号 %s 的监控未能在 %v 内停止", ar.name, removeTimeout)
+	}
+
+	r.mu.Lock()
+	if r.accounts[ar.name] == ar {
+		delete(r.accounts, ar.name)
+	}
+	r.mu.Unlock()
+	// 等待重试的推送写入推送失败记录，重启的账号使用新建的重试队列
+	r.pushRetries.remove(ar.retryQueues)
+	return nil
+}
@@ -0,0 +1,198 @@
+package receiver
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+
+	"mail-receiver/clock"
+	"mail-receiver/config"
+	"mail-receiver/contacts"
+	"mail-receiver/imap"
+	"mail-receiver/push"
+	"mail-receiver/rules"
+	"mail-receiver/storage"
+	"mail-receiver/tagging"
+)
+
+// ReplayResult 按当前配置重新评估一封已存储邮件的结果
+type ReplayResult struct {
+	Record   *storage.Record
+	Tags     []string // 按当前分类规则得到的标签
+	Rule     string   // 命中的规则，未命中为空
+	Push     bool     // 按当前配置是否推送
+	MarkRead bool     // 规则要求标记已读（未推送时）
+	MoveTo   string   // 规则要求移动到的文件夹
+	Changed  bool     // 是否推送或标签与存储时的处理结果不同
+
+	account *AccountReceiver
+	rule    *rules.Rule
+	email   *imap.EmailMessage
+}
+
+// Replayer 不连接 IMAP 服务器，按当前的规则、分类标签和联系人重新评估存储中的邮件
+type Replayer struct {
+	contacts *contacts.Book
+	tagger   *tagging.Tagger
+	accounts map[string]*AccountReceiver
+}
+
+// NewReplayer 按配置创建重新评估器（同时创建推送目标，供重新推送使用）
+// clk 为时间来源（重新推送时的限速等待等），nil 时使用系统时间
+func NewReplayer(cfg *config.Config, clk clock.Clock) (*Replayer, error) {
+	clk = clock.Or(clk)
+	book, err := newContactBook(cfg)
+	if err != nil {
+		return nil, err
+	}
+	tagger, err := newTagger(cfg.Tagging)
+	if err != nil {
+		return nil, err
+	}
+
+	rp := &Replayer{contacts: book, tagger: tagger, accounts: make(map[string]*AccountReceiver)}
+	for name, accCfg := range cfg.Accounts {
+		ar := &AccountReceiver{name: name, config: accCfg, contacts: book, tagger: tagger, clock: clk, pushWait: pushWait{clock: clk}}
+		ar.pushHTTP = push.NewHTTPClient(name)
+		if ar.pusher, ar.pushTargets, err = newAccountPusher(accCfg, cfg.App.PushTargets, name, ar.pushHTTP, nil, ar.pushWait); err != nil {
+			return nil, fmt.Errorf("账号 %s: %w", name, err)
+		}
+		if ar.htmlText, err = newHTMLText(accCfg.TextExtraction); err != nil {
+			return nil, fmt.Errorf("账号 %s 的 text_extraction 配置无效: %w", name, err)
+		}
+		if ar.otp, err = newOTPCodes(accCfg.OTP); err != nil {
+			return nil, fmt.Errorf("账号 %s 的 otp 配置无效: %w", name, err)
+		}
+		if err := ar.loadRules(accCfg.Rules); err != nil {
+			return nil, fmt.Errorf("账号 %s: %w", name, err)
+		}
+		if ao := accCfg.AttachmentOnly; ao.Enabled {
+			if ar.attachOnly, err = regexp.Compile("(?i)" + ao.Pattern); err != nil {
+				return nil, fmt.Errorf("账号 %s 的附件模式正则无效: %w", name, err)
+			}
+		}
+		rp.accounts[name] = ar
+	}
+	return rp, nil
+}
+
+// Evaluate 重新评估一条存储记录，账号已不在配置中时返回错误
+func (rp *Replayer) Evaluate(rec *storage.Record) (*ReplayResult, error) {
+	ar, ok := rp.accounts[rec.Account]
+	if !ok {
+		return nil, fmt.Errorf("账号 %s 不在当前配置中", rec.Account)
+	}
+
+	email := &imap.EmailMessage{
+		UID:            rec.UID,
+		MessageID:      rec.MessageID,
+		Subject:        rec.Subject,
+		FromAddress:    rec.From,
+		To:             rec.To,
+		CC:             rec.CC,
+		Date:           rec.Date,
+		Size:           rec.Size,
+		Flags:          rec.Flags,
+		Body:           rec.Body,
+		HTMLBody:       rec.HTMLBody,
+		HasAttachments: len(rec.Attachments) > 0,
+	}
+	email.Contact = rp.contacts.Lookup(email.FromAddress)
+	text := email.Body + "\n" + ar.htmlText.strip(email.HTMLBody)
+	email.Tags = rp.tagger.Tag(&tagging.Input{
+		Subject: email.Subject,
+		From:    email.FromAddress,
+		Body:    text,
+	})
+
+	res := &ReplayResult{Record: rec, Tags: email.Tags, Push: ar.pusher != nil, account: ar, email: email}
+	if rule := ar.rules.Evaluate(ar.ruleInput(email, text)); rule != nil {
+		res.rule = rule
+		res.Rule = rule.Name
+		if _, ok := ar.rulePushers[rule]; ok {
+			res.Push = true
+		}
+		if !rule.Push {
+			res.Push = false
+		}
+		res.MarkRead = rule.MarkRead && !res.Push
+		res.MoveTo = rule.MoveTo
+	}
+
+	// 附件模式：存储中只有附件文件名，按文件名判断
+	if ar.attachOnly != nil && res.Push {
+		matched := false
+		for _, name := range rec.Attachments {
+			if ar.attachOnly.MatchString(name) {
+				matched = true
+				break
+			}
+		}
+		res.Push = matched
+	}
+
+	res.Changed = res.Push != rec.Pushed || !sameTags(res.Tags, rec.Tags)
+	return res, nil
+}
+
+// Push 按当前规则重新推送（标题模板、铃声、优先级和推送目标），不修改邮箱和存储
+func (rp *Replayer) Push(res *ReplayResult) error {
+	ar, email := res.account, res.email
+	pusher := ar.pusher
+	if p, ok := ar.rulePushers[res.rule]; ok {
+		pusher = p
+	}
+	if pusher == nil || !res.Push {
+		return nil
+	}
+
+	body := email.Body
+	html := ""
+	if body == "" && email.HTMLBody != "" {
+		html = replaceCIDImages(email.HTMLBody, nil)
+		body = ar.htmlText.strip(html)
+	}
+	plain := body
+	title := email.Subject
+	meta := &push.Meta{Account: ar.name, Folder: res.Record.Folder, Email: email}
+	if rule := res.rule; rule != nil {
+		var err error
+		title, err = rule.Title(&rules.TitleData{
+			Subject: title,
+			From:    email.DisplayFrom(),
+			Address: email.FromAddress,
+			Account: ar.name,
+			Folder:  res.Record.Folder,
+			Tags:    email.Tags,
+			Body:    body,
+		})
+		if err != nil {
+			return err
+		}
+		meta.Sound = rule.Sound
+		meta.Priority = rule.Priority
+	}
+	title, body = ar.applyOTP(email, body, title, body, meta)
+	if html != "" && body != "" && body == plain {
+		meta.Rich = &push.RichText{Text: body, HTML: html}
+	}
+	msg := push.BuildMessageContent(body, email.Date.Format("2006-01-02 15:04:05"), email.DisplayFrom(), email.To, email.HasAttachments, nil)
+	return pusher.Push(title, msg, meta)
+}
+
+// sameTags 比较两组标签（不区分顺序）
+func sameTags(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	x := append([]string(nil), a...)
+	y := append([]string(nil), b...)
+	sort.Strings(x)
+	sort.Strings(y)
+	for i := range x {
+		if x[i] != y[i] {
+			return false
+		}
+	}
+	return true
+}
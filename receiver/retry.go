@@ -0,0 +1,165 @@
+package receiver
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"mail-receiver/clock"
+	"mail-receiver/config"
+	"mail-receiver/push"
+)
+
+// retryQueues 所有账号的推送重试队列，放弃的推送写入共用的推送失败记录文件
+type retryQueues struct {
+	dead  *push.DeadLetter // 未配置 app.dead_letter 时为nil
+	clock clock.Clock
+
+	mu     sync.Mutex
+	queues map[string]*accountRetries // 账号 → 正在运行的账号使用的重试队列
+}
+
+// accountRetries 创建账号时为各推送目标新建的重试队列（推送目标 → 队列），账号启动后才替换原有的队列
+type accountRetries struct {
+	all     *retryQueues
+	account string
+	full    func(target string, err error) // 可选，队列已满时调用
+	queues  map[string]*push.RetryQueue
+}
+
+// newRetryQueues 创建重试队列集合，path 为空时放弃的推送只记录日志
+func newRetryQueues(path string, clk clock.Clock) *retryQueues {
+	q := &retryQueues{clock: clk, queues: make(map[string]*accountRetries)}
+	if path != "" {
+		q.dead = push.NewDeadLetter(path)
+	}
+	return q
+}
+
+// forAccount 为创建中的账号准备重试队列，q 为nil（如 reprocess 命令）时返回nil、不启用重试
+// full 在推送目标的队列已满、推送失败不再排队时调用
+func (q *retryQueues) forAccount(account string, full func(target string, err error)) *accountRetries {
+	if q == nil {
+		return nil
+	}
+	return &accountRetries{all: q, account: account, full: full, queues: make(map[string]*push.RetryQueue)}
+}
+
+// wrap 为推送目标加上重试队列，未启用重试（或 a 为nil）时原样返回
+func (a *accountRetries) wrap(p push.Pusher, cfg config.PushRetryConfig, target string) push.Pusher {
+	if a == nil || cfg.MaxAttempts <= 1 {
+		return p
+	}
+	opts := push.RetryOptions{
+		MaxAttempts:  cfg.MaxAttempts,
+		InitialDelay: time.Duration(cfg.InitialDelay) * time.Second,
+		MaxDelay:     time.Duration(cfg.MaxDelay) * time.Second,
+		QueueSize:    cfg.QueueSize,
+		Clock:        a.all.clock,
+	}
+	if a.full != nil {
+		opts.OnFull = func(err error) { a.full(target, err) }
+	}
+	rq := push.NewRetryQueue(p, opts, a.account, target, a.all.dead)
+	a.queues[target] = rq
+	return rq
+}
+
+// install 账号启动时使用新的重试队列，停止账号原有的队列（重新加载配置前等待重试的推送写入推送失败记录）
+func (q *retryQueues) install(a *accountRetries) {
+	if q == nil || a == nil {
+		return
+	}
+	q.mu.Lock()
+	old := q.queues[a.account]
+	q.queues[a.account] = a
+	q.mu.Unlock()
+	old.close()
+}
+
+// remove 账号停止后停止其重试队列；账号已换用新的队列时不处理
+func (q *retryQueues) remove(a *accountRetries) {
+	if q == nil || a == nil {
+		return
+	}
+	q.mu.Lock()
+	if q.queues[a.account] != a {
+		q.mu.Unlock()
+		return
+	}
+	delete(q.queues, a.account)
+	q.mu.Unlock()
+	a.close()
+}
+
+// pending 返回账号等待重试的推送数
+func (q *retryQueues) pending(account string) int {
+	if q == nil {
+		return 0
+	}
+	q.mu.Lock()
+	a := q.queues[account]
+	q.mu.Unlock()
+	if a == nil {
+		return 0
+	}
+	n := 0
+	for _, rq := range a.queues {
+		n += rq.Pending()
+	}
+	return n
+}
+
+// close 停止所有重试，未完成的推送写入推送失败记录
+func (q *retryQueues) close() {
+	if q == nil {
+		return
+	}
+	q.mu.Lock()
+	all := q.queues
+	q.queues = make(map[string]*accountRetries)
+	q.mu.Unlock()
+	for _, a := range all {
+		a.close()
+	}
+}
+
+// close 停止账号的所有重试队列，未完成的推送写入推送失败记录
+func (a *accountRetries) close() {
+	if a == nil {
+		return
+	}
+	for _, rq := range a.queues {
+		rq.Close()
+	}
+}
+
+// TargetPusher 按当前配置创建账号的一个推送目标（不启用重试），供 dead-letter 命令重新推送
+// target 为推送目标名称，规则指定的推送目标为 "rule:<规则名称>"
+func TargetPusher(cfg *config.Config, account, target string) (push.Pusher, error) {
+	accCfg, ok := cfg.Accounts[account]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrAccountNotFound, account)
+	}
+	if rule, ok := strings.CutPrefix(target, "rule:"); ok {
+		for i, rc := range accCfg.Rules {
+			name := rc.Name
+			if name == "" {
+				name = fmt.Sprintf("#%d", i+1)
+			}
+			if name == rule && rc.Push != nil {
+				return newPusher(*rc.Push, account, push.NewHTTPClient(account), pushWait{})
+			}
+		}
+		return nil, fmt.Errorf("账号 %s 的规则 %s 没有推送配置", account, rule)
+	}
+	_, targets, err := newAccountPusher(accCfg, cfg.App.PushTargets, account, push.NewHTTPClient(account), nil, pushWait{})
+	if err != nil {
+		return nil, err
+	}
+	if targets == nil {
+		return nil, fmt.Errorf("账号 %s 未配置推送", account)
+	}
+	return targets.Select([]string{target})
+}
@@ -0,0 +1,40 @@
+package receiver
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"mail-receiver/config"
+	"mail-receiver/imap"
+	"mail-receiver/metrics"
+)
+
+// observeDelivery 记录邮件发出（Date 头）到推送送达的延迟，超过 SLA 时告警
+// 启动前就已存在的邮件不计入，避免积压邮件造成误报
+func (ar *AccountReceiver) observeDelivery(email *imap.EmailMessage) {
+	if email.Date.IsZero() || email.Date.Before(ar.startedAt) {
+		return
+	}
+	latency := ar.clock.Since(email.Date)
+	if latency < 0 {
+		// 发件方时钟偏差
+		latency = 0
+	}
+	metrics.DeliveryLatency.Observe(ar.name, latency.Seconds())
+
+	sla := ar.config.SLA
+	limit := time.Duration(sla.MaxLatency) * time.Second
+	if limit <= 0 || latency <= limit {
+		return
+	}
+	latency = latency.Round(time.Second)
+	log.Printf("[%s] 推送延迟 %v 超过 SLA (%v): %s", ar.name, latency, limit, email.Subject)
+
+	if ar.clock.Since(ar.lastSLAAlert) < time.Duration(sla.AlertInterval)*time.Second {
+		return
+	}
+	ar.lastSLAAlert = ar.clock.Now()
+	ar.alert(alertSLA, config.AlertWarning, "邮件推送延迟告警", fmt.Sprintf("账号 [%s] 邮件推送延迟 %v，超过 SLA %v\n邮件: %s",
+		ar.name, latency, limit, email.Subject))
+}
@@ -0,0 +1,72 @@
+package receiver
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"mail-receiver/push"
+)
+
+// AccountStatus 账号运行状态快照
+type AccountStatus struct {
+	Name         string     `json:"name"`
+	Connected    bool       `json:"connected"`
+	Paused       bool       `json:"paused"`               // 已通过管理接口暂停
+	Healthy      bool       `json:"healthy"`              // 连续失败未达到告警阈值
+	Quarantined  bool       `json:"quarantined"`          // 登录凭据被拒绝，已停止自动重试，等待处理
+	Retries      int        `json:"retries"`              // 当前连续失败次数
+	LastError    string     `json:"last_error,omitempty"` // 最近一次失败原因
+	LastFetch    *time.Time `json:"last_fetch,omitempty"` // 最近一次成功获取邮件的时间
+	Mode         string     `json:"mode"`                 // 监控模式（idle/poll）
+	Capabilities []string   `json:"capabilities"`         // 服务器声明的能力列表
+
+	PushThrottled uint64              `json:"push_throttled"`         // 推送累计被限流（429/503）的次数
+	PushTargets   []push.TargetStatus `json:"push_targets,omitempty"` // 有多个推送目标时各目标的推送结果
+	PushRetrying  int                 `json:"push_retrying"`          // 等待重试的推送数
+}
+
+// accountState 账号运行状态（并发安全）
+type accountState struct {
+	mu     sync.RWMutex
+	status AccountStatus
+}
+
+// update 在加锁状态下修改状态
+func (s *accountState) update(fn func(st *AccountStatus)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fn(&s.status)
+}
+
+// snapshot 返回状态副本
+func (s *accountState) snapshot() AccountStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	st := s.status
+	st.Capabilities = append([]string(nil), s.status.Capabilities...)
+	return st
+}
+
+// Status 返回所有账号的状态（按名称排序）
+func (r *Receiver) Status() []AccountStatus {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var result []AccountStatus
+	for _, ar := range r.accounts {
+		st := ar.state.snapshot()
+		if ar.pushHTTP != nil {
+			st.PushThrottled = ar.pushHTTP.ThrottledCount()
+		}
+		st.PushRetrying = ar.pushRetries.pending(ar.name)
+		if ar.pushTargets != nil && ar.pushTargets.Len() > 1 {
+			st.PushTargets = ar.pushTargets.Status()
+		}
+		result = append(result, st)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
@@ -0,0 +1,104 @@
+package receiver
+
+import (
+	"log"
+
+	"mail-receiver/imap"
+	"mail-receiver/state"
+)
+
+// pendingKeyword 严格投递模式下推送前设置的临时关键字
+// 推送确认成功后立即记入投递记录（处理进度文件），批次结束时标记 \Seen 并移除该关键字；
+// 若进程在两者之间退出，下次启动时对账：已记录投递的邮件只补做已读标记，未记录的邮件按正常流程推送一次
+const pendingKeyword = "$MailReceiverPending"
+
+// reconcilePending 对账上次未完成投递的邮件，并确认服务器支持自定义关键字
+func (ar *AccountReceiver) reconcilePending(folder string) {
+	mbox, err := ar.client.SelectFolder(folder)
+	if err != nil {
+		log.Printf("[%s] 严格投递对账失败: %v", ar.name, err)
+		return
+	}
+
+	if !imap.SupportsKeywords(mbox) {
+		if !ar.noKeywords[folder] {
+			log.Printf("[%s] 文件夹 %s 不支持自定义关键字，该文件夹不使用严格投递模式", ar.name, folder)
+		}
+		if ar.noKeywords == nil {
+			ar.noKeywords = make(map[string]bool)
+		}
+		ar.noKeywords[folder] = true
+		return
+	}
+	delete(ar.noKeywords, folder)
+
+	uids, err := ar.client.SearchKeyword(pendingKeyword)
+	if err != nil {
+		log.Printf("[%s] 严格投递对账失败: %v", ar.name, err)
+		return
+	}
+
+	cp := ar.checkpoints.Get(ar.name, folder)
+	if cp.UIDValidity != mbox.UidValidity {
+		// UIDVALIDITY 变化后投递记录中的UID已失效，进度也会从头开始
+		cp = state.Checkpoint{UIDValidity: mbox.UidValidity}
+	}
+	delivered := make(map[uint32]bool, len(cp.Delivered))
+	for _, uid := range cp.Delivered {
+		delivered[uid] = true
+	}
+	var retry []uint32
+	for _, uid := range uids {
+		if !delivered[uid] {
+			retry = append(retry, uid)
+		}
+	}
+	if len(cp.Delivered) == 0 && len(uids) == 0 {
+		return
+	}
+	msgIDs := make(map[uint32]string)
+
+	// 已确认推送的邮件：补做已读标记，失败时保留投递记录，下次对账重试
+	if confirm := cp.Delivered; len(confirm) > 0 {
+		if err := ar.markAsRead(folder, triggerStrictFinalize, confirm, msgIDs); err != nil {
+			log.Printf("[%s] 严格投递对账失败: %v", ar.name, err)
+		} else {
+			log.Printf("[%s] 对账：%d 封已推送的邮件补做已读标记", ar.name, len(confirm))
+			cp.Delivered = nil
+		}
+	}
+
+	// 未确认推送的邮件：重新推送一次。其UID正常情况下都大于已保存的进度（进度在批次结束后才保存），
+	// 否则回退进度使其被重新获取
+	if len(retry) > 0 {
+		log.Printf("[%s] 对账：%d 封邮件未确认推送，将重新推送", ar.name, len(retry))
+		for _, uid := range retry {
+			if uid <= cp.LastUID {
+				cp.LastUID = uid - 1
+			}
+		}
+	}
+	if err := ar.checkpoints.Set(ar.name, folder, cp); err != nil {
+		log.Printf("[%s] 保存投递记录失败: %v", ar.name, err)
+	}
+
+	// 移除所有临时关键字，重新推送时会再次设置
+	if err := ar.setKeyword(folder, triggerStrictFinalize, pendingKeyword, false, uids, msgIDs); err != nil {
+		log.Printf("[%s] 严格投递对账失败: %v", ar.name, err)
+	}
+}
+
+// strictFor 文件夹是否使用严格投递模式：对账时发现不支持自定义关键字的文件夹按普通模式处理，不影响其他文件夹
+func (ar *AccountReceiver) strictFor(folder string) bool {
+	return ar.strict && !ar.noKeywords[folder]
+}
+
+// hasFlag 检查标志列表中是否包含指定标志
+func hasFlag(flags []string, flag string) bool {
+	for _, f := range flags {
+		if f == flag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,40 @@
+package receiver
+
+import (
+	"log"
+	"time"
+
+	"mail-receiver/clock"
+)
+
+// cycleSummary 单次获取处理周期的统计
+type cycleSummary struct {
+	account   string
+	folder    string
+	start     time.Time
+	fetched   int    // 获取到的邮件数
+	pushed    int    // 推送成功数
+	filtered  int    // 被规则过滤的数量
+	skipped   int    // 解析失败或推送失败而跳过的数量
+	collapsed int    // 邮件风暴中暂存、稍后合并推送的数量
+	bytes     uint64 // 获取到的邮件总大小
+	failed    bool   // 获取邮件失败
+	clock     clock.Clock
+}
+
+// newCycleSummary 开始一次处理周期统计
+func newCycleSummary(account, folder string, clk clock.Clock) *cycleSummary {
+	return &cycleSummary{
+		account: account,
+		folder:  folder,
+		start:   clk.Now(),
+		clock:   clk,
+	}
+}
+
+// emit 输出一行结构化的汇总日志（key=value 格式，便于统计），没有新邮件或获取失败的周期同样输出
+func (s *cycleSummary) emit() {
+	log.Printf("[%s] summary account=%s folder=%s fetched=%d pushed=%d filtered=%d skipped=%d collapsed=%d duration_ms=%d bytes=%d fetch_failed=%t",
+		s.account, s.account, s.folder, s.fetched, s.pushed, s.filtered, s.skipped, s.collapsed,
+		s.clock.Since(s.start).Milliseconds(), s.bytes, s.failed)
+}
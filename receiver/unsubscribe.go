@@ -0,0 +1,105 @@
+package receiver
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"mail-receiver/imap"
+	"mail-receiver/unsubscribe"
+)
+
+// maxUnsubscribeTargets 每个账号记录的最近邮件退订方式数量（供管理接口按 Message-ID 退订）
+const maxUnsubscribeTargets = 500
+
+// unsubscribeTimeout 单次退订请求超时
+const unsubscribeTimeout = 30 * time.Second
+
+// unsubscribeClient 所有账号共用的退订客户端
+var unsubscribeClient = unsubscribe.NewClient()
+
+// recentTargets 最近收到邮件的退订方式（Message-ID → Target），超过上限时淘汰最早的记录
+type recentTargets struct {
+	mu      sync.Mutex
+	targets map[string]*unsubscribe.Target
+	order   []string
+}
+
+// add 记录邮件的退订方式
+func (r *recentTargets) add(messageID string, t *unsubscribe.Target) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.targets == nil {
+		r.targets = make(map[string]*unsubscribe.Target)
+	}
+	if _, exists := r.targets[messageID]; !exists {
+		r.order = append(r.order, messageID)
+	}
+	r.targets[messageID] = t
+	for len(r.order) > maxUnsubscribeTargets {
+		delete(r.targets, r.order[0])
+		r.order = r.order[1:]
+	}
+}
+
+// get 查找邮件的退订方式
+func (r *recentTargets) get(messageID string) *unsubscribe.Target {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.targets[messageID]
+}
+
+// rememberUnsubscribe 解析并记录邮件的退订方式
+func (ar *AccountReceiver) rememberUnsubscribe(email *imap.EmailMessage) *unsubscribe.Target {
+	t := unsubscribe.Parse(email.ListUnsubscribe, email.ListUnsubscribePost)
+	if t != nil && email.MessageID != "" {
+		ar.unsubTargets.add(email.MessageID, t)
+	}
+	return t
+}
+
+// unsubscribe 发送一键退订请求，不支持一键退订时只记录日志
+func (ar *AccountReceiver) unsubscribe(email *imap.EmailMessage, t *unsubscribe.Target) {
+	if t == nil {
+		log.Printf("[%s] 邮件没有退订地址: %s", ar.name, email.Subject)
+		return
+	}
+	if !t.OneClick {
+		link := t.HTTP
+		if link == "" {
+			link = t.Mailto
+		}
+		log.Printf("[%s] 邮件不支持一键退订，请手动退订: %s (%s)", ar.name, email.Subject, link)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), unsubscribeTimeout)
+	defer cancel()
+	if err := unsubscribeClient.Unsubscribe(ctx, t); err != nil {
+		log.Printf("[%s] 退订 %s 失败: %v", ar.name, email.FromAddress, err)
+		return
+	}
+	log.Printf("[%s] 已退订: %s (%s)", ar.name, email.FromAddress, email.Subject)
+}
+
+// Unsubscribe 对账号最近收到的邮件（按 Message-ID）发送一键退订请求
+func (r *Receiver) Unsubscribe(name, messageID string) error {
+	ar, err := r.account(name)
+	if err != nil {
+		return err
+	}
+	t := ar.unsubTargets.get(messageID)
+	if t == nil {
+		return fmt.Errorf("未找到邮件 %s 的退订地址（只保留最近 %d 封邮件）", messageID, maxUnsubscribeTargets)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), unsubscribeTimeout)
+	defer cancel()
+	if err := unsubscribeClient.Unsubscribe(ctx, t); err != nil {
+		return err
+	}
+	log.Printf("[%s] 已通过管理接口退订: %s", name, messageID)
+	return nil
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"log"
+	"strings"
+	"sync"
+
+	"mail-receiver/config"
+	"mail-receiver/receiver"
+)
+
+// reloadMu 串行执行配置重新加载（SIGHUP、文件修改、管理接口）
+var reloadMu sync.Mutex
+
+// reloadConfig 重新读取配置文件，增删账号并只重启配置有变化的账号
+func reloadConfig(recv *receiver.Receiver, reason string) (*receiver.ReloadResult, error) {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+
+	log.Printf("重新加载配置 (%s)", reason)
+	cfg, err := config.LoadConfig(configFile)
+	if err != nil {
+		log.Printf("重新加载配置失败: %v", err)
+		return nil, err
+	}
+
+	result, err := recv.Reload(cfg)
+	if err != nil {
+		log.Printf("重新加载配置失败: %v", err)
+	}
+	if result != nil {
+		log.Printf("配置已重新加载: 新增 [%s] 删除 [%s] 重启 [%s] 失败 [%s]",
+			strings.Join(result.Added, ", "), strings.Join(result.Removed, ", "), strings.Join(result.Restarted, ", "), strings.Join(result.Failed, ", "))
+	}
+	return result, err
+}
+
+// watchConfig 配置文件修改后自动重新加载
+func watchConfig(recv *receiver.Receiver) {
+	log.Printf("已启用配置文件监控: %s", configFile)
+	config.Watch(configFile, config.WatchInterval, func() {
+		reloadConfig(recv, "配置文件已修改")
+	})
+}
@@ -0,0 +1,108 @@
+package report
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+
+	"mail-receiver/storage"
+)
+
+// 分组方式
+const (
+	GroupBySender  = "sender"
+	GroupByDay     = "day"
+	GroupByAccount = "account"
+)
+
+// 输出格式
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+)
+
+// Row 报表行
+type Row struct {
+	Key    string `json:"key"`
+	Count  int    `json:"count"`  // 邮件数
+	Pushed int    `json:"pushed"` // 推送成功数
+	Bytes  uint64 `json:"bytes"`  // 邮件总大小
+}
+
+// Build 按分组方式汇总记录，结果按数量降序排列
+func Build(records []*storage.Record, groupBy string) ([]*Row, error) {
+	rows := make(map[string]*Row)
+	for _, rec := range records {
+		var key string
+		switch groupBy {
+		case GroupBySender:
+			key = rec.From
+		case GroupByDay:
+			key = rec.ProcessedAt.Format("2006-01-02")
+		case GroupByAccount:
+			key = rec.Account
+		default:
+			return nil, fmt.Errorf("不支持的分组方式: %s", groupBy)
+		}
+
+		row, ok := rows[key]
+		if !ok {
+			row = &Row{Key: key}
+			rows[key] = row
+		}
+		row.Count++
+		row.Bytes += uint64(rec.Size)
+		if rec.Pushed {
+			row.Pushed++
+		}
+	}
+
+	result := make([]*Row, 0, len(rows))
+	for _, row := range rows {
+		result = append(result, row)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if groupBy == GroupByDay {
+			return result[i].Key < result[j].Key
+		}
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Key < result[j].Key
+	})
+	return result, nil
+}
+
+// Write 以指定格式输出报表
+func Write(w io.Writer, rows []*Row, groupBy, format string) error {
+	switch format {
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(rows)
+
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write([]string{groupBy, "count", "pushed", "bytes"}); err != nil {
+			return err
+		}
+		for _, row := range rows {
+			record := []string{
+				row.Key,
+				strconv.Itoa(row.Count),
+				strconv.Itoa(row.Pushed),
+				strconv.FormatUint(row.Bytes, 10),
+			}
+			if err := cw.Write(record); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	}
+
+	return fmt.Errorf("不支持的输出格式: %s", format)
+}
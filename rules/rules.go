@@ -0,0 +1,327 @@
+package rules
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"mail-receiver/expr"
+)
+
+// Input 规则匹配所需的邮件信息
+type Input struct {
+	From          string // 发件人地址
+	To            string
+	Subject       string
+	Body          string
+	HasAttachment bool
+	Size          uint32
+	Tags          []string
+	Category      string // 发件人联系人分类
+	KnownContact  bool   // 发件人是否在联系人中
+	DKIMPass      bool   // DKIM 签名是否通过验证且与发件人域名一致
+}
+
+// 规则动作
+const (
+	ActionPush        = "push"        // 推送（可配合 push 使用其他推送目标）
+	ActionDrop        = "drop"        // 丢弃，不做任何处理
+	ActionMarkRead    = "mark_read"   // 标记已读
+	ActionMove        = "move"        // 移动到 move_to 文件夹
+	ActionUnsubscribe = "unsubscribe" // 发送一键退订请求（RFC 8058）
+	ActionReply       = "reply"       // 通过 SMTP 发送自动回复
+)
+
+// Match 匹配条件，所有已设置的条件都满足才算命中
+type Match struct {
+	From          string   // 发件人正则
+	To            string   // 收件人正则
+	Subject       string   // 主题正则
+	Body          string   // 正文正则
+	HasAttachment *bool    // 是否含附件
+	MinSize       uint32   // 最小邮件大小（字节）
+	MaxSize       uint32   // 最大邮件大小（字节）
+	Tags          []string // 含任一标签
+	Category      string   // 发件人联系人分类
+	KnownContact  *bool    // 发件人是否在联系人中
+	DKIMPass      *bool    // DKIM 签名是否通过验证
+	Expr          string   // 条件表达式（见 expr 包），如 from endsWith "@bank.com" && body contains "验证码"
+}
+
+// matchVars 条件表达式可用的变量，对应 Input 的字段
+var matchVars = expr.Vars{
+	"from":           expr.String,
+	"to":             expr.String,
+	"subject":        expr.String,
+	"body":           expr.String,
+	"has_attachment": expr.Bool,
+	"size":           expr.Number,
+	"tags":           expr.List,
+	"category":       expr.String,
+	"known_contact":  expr.Bool,
+	"dkim_pass":      expr.Bool,
+}
+
+// titleVars 标题表达式可用的变量，对应 TitleData 的字段
+var titleVars = expr.Vars{
+	"subject": expr.String,
+	"from":    expr.String,
+	"sender":  expr.String,
+	"account": expr.String,
+	"folder":  expr.String,
+	"rule":    expr.String,
+	"tags":    expr.List,
+	"body":    expr.String,
+}
+
+// Rule 过滤规则
+type Rule struct {
+	Name        string
+	Push        bool
+	MarkRead    bool
+	MoveTo      string // 不为空时移动到该文件夹
+	Unsubscribe bool   // 一键退订
+	Reply       bool   // 自动回复
+	Sound       string // 可选，覆盖推送铃声
+	Priority    string // 可选，覆盖推送优先级
+
+	from, to, subject, body *regexp.Regexp
+	match                   Match
+	cond                    *expr.Program      // 可选，条件表达式
+	title                   *template.Template // 可选，推送标题模板
+	titleExpr               *expr.Program      // 可选，推送标题表达式
+}
+
+// TitleData 标题模板可用的字段
+type TitleData struct {
+	Subject string
+	From    string // 发件人（联系人名称或地址）
+	Address string // 发件人地址
+	Account string
+	Folder  string
+	Rule    string
+	Tags    []string
+	Body    string
+}
+
+// SetTitle 设置推送标题模板（text/template 语法，如 "[银行] {{.Subject}}"）
+func (r *Rule) SetTitle(tmpl string) error {
+	if tmpl == "" {
+		r.title = nil
+		return nil
+	}
+	t, err := template.New(r.Name).Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return fmt.Errorf("规则 %s 的标题模板无效: %w", r.Name, err)
+	}
+	r.title = t
+	return nil
+}
+
+// SetTitleExpr 设置推送标题表达式（如 "[银行] " + subject + " " + find(body, '\d{6}')），代替标题模板
+func (r *Rule) SetTitleExpr(src string) error {
+	if src == "" {
+		r.titleExpr = nil
+		return nil
+	}
+	p, err := expr.Compile(src, titleVars)
+	if err != nil {
+		return fmt.Errorf("规则 %s 的标题表达式无效: %w", r.Name, err)
+	}
+	r.titleExpr = p
+	return nil
+}
+
+// Title 按标题表达式或模板生成推送标题，都未设置时返回 data.Subject
+func (r *Rule) Title(data *TitleData) (string, error) {
+	data.Rule = r.Name
+	if r.titleExpr != nil {
+		title, err := r.titleExpr.EvalString(expr.Env{
+			"subject": data.Subject,
+			"from":    data.Address,
+			"sender":  data.From,
+			"account": data.Account,
+			"folder":  data.Folder,
+			"rule":    data.Rule,
+			"tags":    data.Tags,
+			"body":    data.Body,
+		})
+		if err != nil {
+			return data.Subject, fmt.Errorf("规则 %s 生成标题失败: %w", r.Name, err)
+		}
+		return title, nil
+	}
+	if r.title == nil {
+		return data.Subject, nil
+	}
+	var b strings.Builder
+	if err := r.title.Execute(&b, data); err != nil {
+		return data.Subject, fmt.Errorf("规则 %s 生成标题失败: %w", r.Name, err)
+	}
+	return b.String(), nil
+}
+
+// NewRule 创建规则，actions 为动作列表，move 动作需要 moveTo
+func NewRule(name string, m Match, actions []string, moveTo string) (*Rule, error) {
+	r := &Rule{Name: name, match: m}
+
+	var err error
+	if r.from, err = compile(name, "from", m.From); err != nil {
+		return nil, err
+	}
+	if r.to, err = compile(name, "to", m.To); err != nil {
+		return nil, err
+	}
+	if r.subject, err = compile(name, "subject", m.Subject); err != nil {
+		return nil, err
+	}
+	if r.body, err = compile(name, "body", m.Body); err != nil {
+		return nil, err
+	}
+	if m.Expr != "" {
+		if r.cond, err = expr.Compile(m.Expr, matchVars); err != nil {
+			return nil, fmt.Errorf("规则 %s 的条件表达式无效: %w", name, err)
+		}
+		if r.cond.Type() != expr.Bool {
+			return nil, fmt.Errorf("规则 %s 的条件表达式结果应为布尔值，实际为%s", name, r.cond.Type())
+		}
+	}
+
+	if len(actions) == 0 {
+		return nil, fmt.Errorf("规则 %s 缺少 actions", r.Name)
+	}
+	for _, a := range actions {
+		switch a {
+		case ActionPush:
+			r.Push = true
+		case ActionDrop:
+			if len(actions) > 1 {
+				return nil, fmt.Errorf("规则 %s 的 drop 不能与其他动作同时使用", r.Name)
+			}
+		case ActionMarkRead:
+			r.MarkRead = true
+		case ActionUnsubscribe:
+			r.Unsubscribe = true
+		case ActionReply:
+			r.Reply = true
+		case ActionMove:
+			if moveTo == "" {
+				return nil, fmt.Errorf("规则 %s 的 move 动作缺少 move_to", r.Name)
+			}
+			r.MoveTo = moveTo
+		default:
+			return nil, fmt.Errorf("规则 %s 的动作无效: %s", r.Name, a)
+		}
+	}
+	return r, nil
+}
+
+// compile 编译条件正则（不区分大小写），为空时返回nil
+func compile(rule, field, pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	re, err := regexp.Compile("(?i)" + pattern)
+	if err != nil {
+		return nil, fmt.Errorf("规则 %s 的 %s 正则无效: %w", rule, field, err)
+	}
+	return re, nil
+}
+
+// Matches 判断邮件是否命中规则
+func (r *Rule) Matches(in *Input) bool {
+	if r.from != nil && !r.from.MatchString(in.From) {
+		return false
+	}
+	if r.to != nil && !r.to.MatchString(in.To) {
+		return false
+	}
+	if r.subject != nil && !r.subject.MatchString(in.Subject) {
+		return false
+	}
+	if r.body != nil && !r.body.MatchString(in.Body) {
+		return false
+	}
+	if r.match.HasAttachment != nil && *r.match.HasAttachment != in.HasAttachment {
+		return false
+	}
+	if r.match.MinSize > 0 && in.Size < r.match.MinSize {
+		return false
+	}
+	if r.match.MaxSize > 0 && in.Size > r.match.MaxSize {
+		return false
+	}
+	if len(r.match.Tags) > 0 && !hasAnyTag(in.Tags, r.match.Tags) {
+		return false
+	}
+	if r.match.Category != "" && !strings.EqualFold(r.match.Category, in.Category) {
+		return false
+	}
+	if r.match.KnownContact != nil && *r.match.KnownContact != in.KnownContact {
+		return false
+	}
+	if r.match.DKIMPass != nil && *r.match.DKIMPass != in.DKIMPass {
+		return false
+	}
+	if r.cond != nil {
+		ok, err := r.cond.EvalBool(expr.Env{
+			"from":           in.From,
+			"to":             in.To,
+			"subject":        in.Subject,
+			"body":           in.Body,
+			"has_attachment": in.HasAttachment,
+			"size":           float64(in.Size),
+			"tags":           in.Tags,
+			"category":       in.Category,
+			"known_contact":  in.KnownContact,
+			"dkim_pass":      in.DKIMPass,
+		})
+		// 求值失败（如除数为 0）视为未命中
+		return err == nil && ok
+	}
+	return true
+}
+
+// hasAnyTag 检查是否含任一标签
+func hasAnyTag(tags, want []string) bool {
+	for _, t := range tags {
+		for _, w := range want {
+			if t == w {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Engine 按顺序匹配的规则列表
+type Engine struct {
+	rules []*Rule
+}
+
+// NewEngine 创建规则引擎
+func NewEngine(rules ...*Rule) *Engine {
+	return &Engine{rules: rules}
+}
+
+// Evaluate 返回第一条命中的规则，都未命中时返回nil（默认推送）
+func (e *Engine) Evaluate(in *Input) *Rule {
+	if e == nil {
+		return nil
+	}
+	for _, r := range e.rules {
+		if r.Matches(in) {
+			return r
+		}
+	}
+	return nil
+}
+
+// Len 返回规则数量
+func (e *Engine) Len() int {
+	if e == nil {
+		return 0
+	}
+	return len(e.rules)
+}
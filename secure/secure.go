@@ -0,0 +1,129 @@
+package secure
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Cipher AES-256-GCM 加解密器，用于本地存储的静态加密
+type Cipher struct {
+	aead cipher.AEAD
+}
+
+// LoadKey 从环境变量或密钥文件加载密钥，优先使用环境变量
+// 密钥可以是32字节的 hex/base64 编码值；其他内容视为口令，经 SHA-256 派生为密钥
+func LoadKey(envName, keyFile string) ([]byte, error) {
+	var raw string
+	switch {
+	case envName != "" && os.Getenv(envName) != "":
+		raw = os.Getenv(envName)
+	case keyFile != "":
+		data, err := os.ReadFile(keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("读取密钥文件失败: %w", err)
+		}
+		raw = string(data)
+	default:
+		return nil, fmt.Errorf("未找到加密密钥（环境变量 %s 或密钥文件）", envName)
+	}
+
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, fmt.Errorf("加密密钥为空")
+	}
+	if key, err := hex.DecodeString(raw); err == nil && len(key) == 32 {
+		return key, nil
+	}
+	if key, err := base64.StdEncoding.DecodeString(raw); err == nil && len(key) == 32 {
+		return key, nil
+	}
+	sum := sha256.Sum256([]byte(raw))
+	return sum[:], nil
+}
+
+// Load 加载密钥并创建加解密器
+func Load(envName, keyFile string) (*Cipher, error) {
+	key, err := LoadKey(envName, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	return NewCipher(key)
+}
+
+// NewCipher 使用32字节密钥创建加解密器
+func NewCipher(key []byte) (*Cipher, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("创建加密器失败: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("创建加密器失败: %w", err)
+	}
+	return &Cipher{aead: aead}, nil
+}
+
+// Seal 加密数据，输出为 nonce + 密文
+func (c *Cipher) Seal(plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("生成随机数失败: %w", err)
+	}
+	return c.aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// Open 解密 Seal 的输出
+func (c *Cipher) Open(data []byte) ([]byte, error) {
+	size := c.aead.NonceSize()
+	if len(data) < size {
+		return nil, fmt.Errorf("密文长度无效")
+	}
+	plaintext, err := c.aead.Open(nil, data[:size], data[size:], nil)
+	if err != nil {
+		return nil, fmt.Errorf("解密失败（密钥错误或数据损坏）: %w", err)
+	}
+	return plaintext, nil
+}
+
+// SealString 加密并编码为 base64 文本（适合按行存储）
+func (c *Cipher) SealString(plaintext []byte) (string, error) {
+	data, err := c.Seal(plaintext)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+// OpenString 解密 SealString 的输出
+func (c *Cipher) OpenString(s string) ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("密文编码无效: %w", err)
+	}
+	return c.Open(data)
+}
+
+// WriteFile 加密后写入文件
+func (c *Cipher) WriteFile(path string, data []byte, perm os.FileMode) error {
+	sealed, err := c.Seal(data)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, sealed, perm)
+}
+
+// ReadFile 读取并解密文件
+func (c *Cipher) ReadFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return c.Open(data)
+}
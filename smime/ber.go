@@ -0,0 +1,137 @@
+package smime
+
+import (
+	"bytes"
+	"errors"
+)
+
+// maxBERDepth BER 结构最多嵌套的层数
+const maxBERDepth = 64
+
+var errBER = errors.New("ASN.1 数据格式错误")
+
+// berToDER 将 BER 编码（Outlook、Thunderbird 等生成的 S/MIME 常用不定长编码和分段的 OCTET STRING）转为
+// encoding/asn1 可以解析的 DER：不定长改为定长，分段（constructed）的 OCTET STRING 合并为一段
+func berToDER(b []byte) ([]byte, error) {
+	der, _, err := berElement(b, 0)
+	return der, err
+}
+
+// berElement 转换 b 开头的一个元素，返回转换后的编码和剩余的数据
+func berElement(b []byte, depth int) (der, rest []byte, err error) {
+	if depth > maxBERDepth || len(b) < 2 {
+		return nil, nil, errBER
+	}
+	// 标签（支持多字节的标签号）
+	i := 1
+	if b[0]&0x1f == 0x1f {
+		for i < len(b) && b[i]&0x80 != 0 {
+			i++
+		}
+		i++
+	}
+	if i >= len(b) {
+		return nil, nil, errBER
+	}
+	tag := b[:i]
+	constructed := b[0]&0x20 != 0
+
+	var children [][]byte
+	var content []byte
+	if l := b[i]; l == 0x80 {
+		// 不定长：读取到 00 00 结束
+		if !constructed {
+			return nil, nil, errBER
+		}
+		rest = b[i+1:]
+		for {
+			if len(rest) < 2 {
+				return nil, nil, errBER
+			}
+			if rest[0] == 0 && rest[1] == 0 {
+				rest = rest[2:]
+				break
+			}
+			var child []byte
+			if child, rest, err = berElement(rest, depth+1); err != nil {
+				return nil, nil, err
+			}
+			children = append(children, child)
+		}
+	} else {
+		i++
+		n := int(l)
+		if l&0x80 != 0 {
+			size := int(l & 0x7f)
+			if size > 4 || i+size > len(b) {
+				return nil, nil, errBER
+			}
+			n = 0
+			for _, c := range b[i : i+size] {
+				n = n<<8 | int(c)
+			}
+			i += size
+		}
+		if n < 0 || i+n > len(b) {
+			return nil, nil, errBER
+		}
+		content, rest = b[i:i+n], b[i+n:]
+		if constructed {
+			for body := content; len(body) > 0; {
+				var child []byte
+				if child, body, err = berElement(body, depth+1); err != nil {
+					return nil, nil, err
+				}
+				children = append(children, child)
+			}
+		}
+	}
+
+	if constructed {
+		if len(tag) == 1 && tag[0] == 0x24 {
+			// 分段的 OCTET STRING：合并各段的内容
+			tag = []byte{0x04}
+			var buf bytes.Buffer
+			for _, child := range children {
+				buf.Write(derContent(child))
+			}
+			content = buf.Bytes()
+		} else {
+			content = bytes.Join(children, nil)
+		}
+	}
+	return derEncode(tag, content), rest, nil
+}
+
+// derEncode 按 DER 编码标签、长度和内容
+func derEncode(tag, content []byte) []byte {
+	out := append([]byte{}, tag...)
+	n := len(content)
+	switch {
+	case n < 0x80:
+		out = append(out, byte(n))
+	default:
+		var size []byte
+		for ; n > 0; n >>= 8 {
+			size = append([]byte{byte(n)}, size...)
+		}
+		out = append(out, 0x80|byte(len(size)))
+		out = append(out, size...)
+	}
+	return append(out, content...)
+}
+
+// derContent 取 derEncode 生成的元素的内容部分
+func derContent(der []byte) []byte {
+	i := 1
+	if der[0]&0x1f == 0x1f {
+		for der[i]&0x80 != 0 {
+			i++
+		}
+		i++
+	}
+	if l := der[i]; l&0x80 != 0 {
+		i += int(l & 0x7f)
+	}
+	return der[i+1:]
+}
@@ -0,0 +1,100 @@
+package smime
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+// unhex 解码带空格的十六进制
+func unhex(t testing.TB, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(strings.ReplaceAll(s, " ", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// berGolden BER 输入和转换后的 DER
+var berGolden = []struct {
+	name string
+	ber  string
+	der  string
+}{
+	{"DER 原样保留", "30 03 02 01 05", "30 03 02 01 05"},
+	{"不定长 SEQUENCE", "30 80 02 01 05 00 00", "30 03 02 01 05"},
+	{"不定长空 SEQUENCE", "30 80 00 00", "30 00"},
+	{"分段 OCTET STRING", "24 80 04 02 41 42 04 01 43 00 00", "04 03 41 42 43"},
+	{"定长分段 OCTET STRING", "24 08 04 02 41 42 04 02 43 44", "04 04 41 42 43 44"},
+	{"嵌套分段 OCTET STRING", "24 80 24 80 04 01 41 00 00 04 01 42 00 00", "04 02 41 42"},
+	{"非最短长度", "04 81 03 41 42 43", "04 03 41 42 43"},
+	{"不定长上下文标签", "a0 80 30 80 02 01 01 00 00 00 00", "a0 05 30 03 02 01 01"},
+	{"多字节标签", "1f 81 00 01 41", "1f 81 00 01 41"},
+	{"忽略后续数据", "30 80 02 01 05 00 00 ff ff", "30 03 02 01 05"},
+	{
+		"长内容",
+		"24 80 04 81 80 " + strings.Repeat("61 ", 128) + "04 48 " + strings.Repeat("62 ", 72) + "00 00",
+		"04 81 c8 " + strings.Repeat("61 ", 128) + strings.Repeat("62 ", 72),
+	},
+}
+
+func TestBERToDER(t *testing.T) {
+	for _, tt := range berGolden {
+		got, err := berToDER(unhex(t, tt.ber))
+		if err != nil {
+			t.Errorf("%s: 转换失败: %v", tt.name, err)
+			continue
+		}
+		if want := unhex(t, tt.der); !bytes.Equal(got, want) {
+			t.Errorf("%s: 结果 % x，期望 % x", tt.name, got, want)
+		}
+	}
+}
+
+func TestBERToDERErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		ber  string
+	}{
+		{"空数据", ""},
+		{"只有标签", "30"},
+		{"内容不完整", "30 05 02 01"},
+		{"缺少结束标记", "30 80 02 01 05"},
+		{"不定长基本类型", "04 80 41 00 00"},
+		{"长度字节过多", "04 85 01 02 03 04 05"},
+		{"长度字节不完整", "04 82 01"},
+		{"多字节标签不完整", "1f 81 81"},
+		{"子元素超出范围", "30 03 02 05 01"},
+	}
+	for _, tt := range tests {
+		if der, err := berToDER(unhex(t, tt.ber)); err == nil {
+			t.Errorf("%s: 应转换失败，结果 % x", tt.name, der)
+		}
+	}
+
+	// 嵌套过深
+	deep := bytes.Repeat([]byte{0x30, 0x80}, maxBERDepth+2)
+	deep = append(deep, bytes.Repeat([]byte{0, 0}, maxBERDepth+2)...)
+	if _, err := berToDER(deep); err == nil {
+		t.Errorf("嵌套 %d 层应转换失败", maxBERDepth+2)
+	}
+}
+
+func FuzzBERToDER(f *testing.F) {
+	for _, tt := range berGolden {
+		f.Add(unhex(f, tt.ber))
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		der, err := berToDER(data)
+		if err != nil {
+			return
+		}
+		// 结果是一个完整的定长元素：再次转换没有剩余数据且结果不变（不定长、分段或非最短长度都会改变编码）
+		again, rest, err := berElement(der, 0)
+		if err != nil || len(rest) != 0 || !bytes.Equal(again, der) {
+			t.Fatalf("再次转换结果不同: % x → % x → % x (%v)", data, der, again, err)
+		}
+	})
+}
@@ -0,0 +1,379 @@
+package smime
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	_ "crypto/sha1"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+)
+
+// CMS（RFC 5652）中用到的对象标识符
+var (
+	oidSignedData        = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidEnvelopedData     = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 3}
+	oidAuthEnvelopedData = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 1, 23}
+
+	oidRSAEncryption = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+	oidRSAESOAEP     = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 7}
+	oidRSASSAPSS     = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 10}
+
+	oidAES128CBC  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 2}
+	oidAES192CBC  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 22}
+	oidAES256CBC  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+	oidDESEDE3CBC = asn1.ObjectIdentifier{1, 2, 840, 113549, 3, 7}
+
+	oidMessageDigest = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+	oidSigningTime   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 5}
+)
+
+// digestHashes 摘要算法对应的哈希
+var digestHashes = map[string]crypto.Hash{
+	"1.3.14.3.2.26":          crypto.SHA1,
+	"2.16.840.1.101.3.4.2.1": crypto.SHA256,
+	"2.16.840.1.101.3.4.2.2": crypto.SHA384,
+	"2.16.840.1.101.3.4.2.3": crypto.SHA512,
+	"2.16.840.1.101.3.4.2.4": crypto.SHA224,
+}
+
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"explicit,optional,tag:0"`
+}
+
+type envelopedData struct {
+	Version              int
+	RecipientInfos       []asn1.RawValue `asn1:"set"`
+	EncryptedContentInfo encryptedContentInfo
+}
+
+type keyTransRecipientInfo struct {
+	Version                int
+	RID                    asn1.RawValue
+	KeyEncryptionAlgorithm pkix.AlgorithmIdentifier
+	EncryptedKey           []byte
+}
+
+type issuerAndSerial struct {
+	Issuer asn1.RawValue
+	Serial *big.Int
+}
+
+type encryptedContentInfo struct {
+	ContentType                asn1.ObjectIdentifier
+	ContentEncryptionAlgorithm pkix.AlgorithmIdentifier
+	EncryptedContent           asn1.RawValue `asn1:"optional,tag:0"`
+}
+
+type oaepParams struct {
+	Hash pkix.AlgorithmIdentifier `asn1:"explicit,optional,tag:0"`
+}
+
+type signedData struct {
+	Version          int
+	DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+	ContentInfo      contentInfo
+	Certificates     asn1.RawValue `asn1:"optional,tag:0"`
+	CRLs             asn1.RawValue `asn1:"optional,tag:1"`
+	SignerInfos      []signerInfo  `asn1:"set"`
+}
+
+type signerInfo struct {
+	Version            int
+	SID                asn1.RawValue
+	DigestAlgorithm    pkix.AlgorithmIdentifier
+	SignedAttrs        asn1.RawValue `asn1:"optional,tag:0"`
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          []byte
+	UnsignedAttrs      asn1.RawValue `asn1:"optional,tag:1"`
+}
+
+type attribute struct {
+	Type   asn1.ObjectIdentifier
+	Values asn1.RawValue `asn1:"set"`
+}
+
+// parseContentInfo 解析 DER 或 BER 编码的 ContentInfo
+func parseContentInfo(data []byte) (*contentInfo, error) {
+	der, err := berToDER(data)
+	if err != nil {
+		return nil, err
+	}
+	var ci contentInfo
+	if _, err := asn1.Unmarshal(der, &ci); err != nil {
+		return nil, fmt.Errorf("解析 CMS 数据失败: %w", err)
+	}
+	return &ci, nil
+}
+
+// decryptEnveloped 用本账号的证书和私钥解密 EnvelopedData
+func decryptEnveloped(ci *contentInfo, cert *x509.Certificate, key *rsa.PrivateKey) ([]byte, error) {
+	var ed envelopedData
+	if _, err := asn1.Unmarshal(ci.Content.Bytes, &ed); err != nil {
+		return nil, fmt.Errorf("解析加密数据失败: %w", err)
+	}
+
+	// 找到发给本证书的接收者；只支持 RSA 密钥传输（KeyTransRecipientInfo）
+	var recipients []*keyTransRecipientInfo
+	for _, raw := range ed.RecipientInfos {
+		var ri keyTransRecipientInfo
+		if _, err := asn1.Unmarshal(raw.FullBytes, &ri); err != nil {
+			continue
+		}
+		if matchesCert(ri.RID, cert) {
+			recipients = append([]*keyTransRecipientInfo{&ri}, recipients...)
+		} else {
+			recipients = append(recipients, &ri)
+		}
+	}
+	if len(recipients) == 0 {
+		return nil, errors.New("没有支持的接收者信息（仅支持 RSA 证书）")
+	}
+
+	var cek []byte
+	var err error
+	for _, ri := range recipients {
+		if cek, err = decryptKey(ri, key); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return nil, errors.New("邮件不是发给本证书的，或证书与私钥不匹配")
+	}
+	return decryptContent(&ed.EncryptedContentInfo, cek)
+}
+
+// matchesCert 接收者或签名者标识（IssuerAndSerialNumber 或 [0] SubjectKeyIdentifier）是否为该证书
+func matchesCert(id asn1.RawValue, cert *x509.Certificate) bool {
+	if id.Class == asn1.ClassContextSpecific && id.Tag == 0 {
+		return len(cert.SubjectKeyId) > 0 && bytes.Equal(id.Bytes, cert.SubjectKeyId)
+	}
+	var ias issuerAndSerial
+	if _, err := asn1.Unmarshal(id.FullBytes, &ias); err != nil || ias.Serial == nil {
+		return false
+	}
+	return bytes.Equal(ias.Issuer.FullBytes, cert.RawIssuer) && ias.Serial.Cmp(cert.SerialNumber) == 0
+}
+
+// decryptKey 用 RSA 私钥解密内容加密密钥
+func decryptKey(ri *keyTransRecipientInfo, key *rsa.PrivateKey) ([]byte, error) {
+	switch alg := ri.KeyEncryptionAlgorithm; {
+	case alg.Algorithm.Equal(oidRSAEncryption):
+		return rsa.DecryptPKCS1v15(nil, key, ri.EncryptedKey)
+	case alg.Algorithm.Equal(oidRSAESOAEP):
+		hash := crypto.SHA1
+		var params oaepParams
+		if len(alg.Parameters.FullBytes) > 0 {
+			if _, err := asn1.Unmarshal(alg.Parameters.FullBytes, &params); err == nil && len(params.Hash.Algorithm) > 0 {
+				h, ok := digestHashes[params.Hash.Algorithm.String()]
+				if !ok {
+					return nil, fmt.Errorf("不支持的 OAEP 哈希算法 %s", params.Hash.Algorithm)
+				}
+				hash = h
+			}
+		}
+		return rsa.DecryptOAEP(hash.New(), nil, key, ri.EncryptedKey, nil)
+	default:
+		return nil, fmt.Errorf("不支持的密钥加密算法 %s", alg.Algorithm)
+	}
+}
+
+// decryptContent 用内容加密密钥解密邮件内容（AES-CBC 或 3DES-CBC）
+func decryptContent(eci *encryptedContentInfo, cek []byte) ([]byte, error) {
+	alg := eci.ContentEncryptionAlgorithm
+	var block cipher.Block
+	var err error
+	switch {
+	case alg.Algorithm.Equal(oidAES128CBC), alg.Algorithm.Equal(oidAES192CBC), alg.Algorithm.Equal(oidAES256CBC):
+		block, err = aes.NewCipher(cek)
+	case alg.Algorithm.Equal(oidDESEDE3CBC):
+		block, err = des.NewTripleDESCipher(cek)
+	default:
+		return nil, fmt.Errorf("不支持的内容加密算法 %s", alg.Algorithm)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var iv []byte
+	if _, err := asn1.Unmarshal(alg.Parameters.FullBytes, &iv); err != nil || len(iv) != block.BlockSize() {
+		return nil, errors.New("加密参数（IV）错误")
+	}
+
+	data := octets(eci.EncryptedContent)
+	if len(data) == 0 || len(data)%block.BlockSize() != 0 {
+		return nil, errors.New("加密内容长度错误")
+	}
+	out := make([]byte, len(data))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(out, data)
+
+	// 去掉 PKCS#7 填充
+	pad := int(out[len(out)-1])
+	if pad == 0 || pad > block.BlockSize() || pad > len(out) {
+		return nil, errors.New("解密失败（填充错误）")
+	}
+	for _, c := range out[len(out)-pad:] {
+		if int(c) != pad {
+			return nil, errors.New("解密失败（填充错误）")
+		}
+	}
+	return out[:len(out)-pad], nil
+}
+
+// octets 取隐式标签的 OCTET STRING 内容，分段编码时合并各段
+func octets(v asn1.RawValue) []byte {
+	if !v.IsCompound {
+		return v.Bytes
+	}
+	var buf bytes.Buffer
+	for rest := v.Bytes; len(rest) > 0; {
+		var chunk asn1.RawValue
+		var err error
+		if rest, err = asn1.Unmarshal(rest, &chunk); err != nil {
+			break
+		}
+		buf.Write(chunk.Bytes)
+	}
+	return buf.Bytes()
+}
+
+// parseSigned 解析 SignedData，返回其中封装的内容（multipart/signed 的分离签名为nil）
+func parseSigned(ci *contentInfo) (*signedData, []byte, error) {
+	var sd signedData
+	if _, err := asn1.Unmarshal(ci.Content.Bytes, &sd); err != nil {
+		return nil, nil, fmt.Errorf("解析签名数据失败: %w", err)
+	}
+	var content []byte
+	if len(sd.ContentInfo.Content.Bytes) > 0 {
+		if _, err := asn1.Unmarshal(sd.ContentInfo.Content.Bytes, &content); err != nil {
+			return nil, nil, fmt.Errorf("解析签名内容失败: %w", err)
+		}
+	}
+	return &sd, content, nil
+}
+
+// verify 验证签名：内容摘要、签名和签名证书链（roots 为信任的根证书），返回签名证书
+// 签名有效但证书不可信时同时返回签名证书和错误
+func (sd *signedData) verify(content []byte, roots *x509.CertPool) (*x509.Certificate, error) {
+	if len(sd.SignerInfos) == 0 {
+		return nil, errors.New("没有签名者信息")
+	}
+	certs, err := x509.ParseCertificates(sd.Certificates.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("解析签名证书失败: %w", err)
+	}
+
+	// 只验证第一个签名者（邮件客户端只生成一个）
+	si := sd.SignerInfos[0]
+	var signer *x509.Certificate
+	for _, c := range certs {
+		if matchesCert(si.SID, c) {
+			signer = c
+			break
+		}
+	}
+	if signer == nil {
+		return nil, errors.New("邮件中没有签名证书")
+	}
+
+	hash, ok := digestHashes[si.DigestAlgorithm.Algorithm.String()]
+	if !ok {
+		return signer, fmt.Errorf("不支持的摘要算法 %s", si.DigestAlgorithm.Algorithm)
+	}
+	h := hash.New()
+	h.Write(content)
+	digest := h.Sum(nil)
+
+	// 有签名属性时签名的是属性（DER 编码为 SET），属性中的 messageDigest 为内容摘要
+	var signedTime time.Time
+	if len(si.SignedAttrs.Bytes) > 0 {
+		signed := append([]byte{0x31}, si.SignedAttrs.FullBytes[1:]...)
+		var attrs []attribute
+		if _, err := asn1.UnmarshalWithParams(signed, &attrs, "set"); err != nil {
+			return signer, fmt.Errorf("解析签名属性失败: %w", err)
+		}
+		var md []byte
+		for _, a := range attrs {
+			switch {
+			case a.Type.Equal(oidMessageDigest):
+				asn1.Unmarshal(a.Values.Bytes, &md)
+			case a.Type.Equal(oidSigningTime):
+				var t time.Time
+				if _, err := asn1.Unmarshal(a.Values.Bytes, &t); err == nil {
+					signedTime = t
+				}
+			}
+		}
+		if !bytes.Equal(md, digest) {
+			return signer, errors.New("邮件内容与签名不符（可能被篡改）")
+		}
+		h = hash.New()
+		h.Write(signed)
+		digest = h.Sum(nil)
+	}
+
+	if err := checkSignature(signer.PublicKey, hash, digest, si.Signature, si.SignatureAlgorithm.Algorithm.Equal(oidRSASSAPSS)); err != nil {
+		return signer, err
+	}
+
+	// 验证证书链：使用当前时间。签名时间由签名者自己填写，可以倒填到证书有效期内，不能作为依据；
+	// 证书已过期但签名时间在有效期内时说明原因，仍视为不可信
+	intermediates := x509.NewCertPool()
+	for _, c := range certs {
+		if c != signer {
+			intermediates.AddCert(c)
+		}
+	}
+	opts := x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageEmailProtection},
+	}
+	if _, err = signer.Verify(opts); err != nil {
+		if !signedTime.IsZero() {
+			opts.CurrentTime = signedTime
+			if _, verr := signer.Verify(opts); verr == nil {
+				return signer, fmt.Errorf("签名证书已不在有效期内（邮件声明的签名时间 %s 无法证实）: %w", signedTime.Local().Format("2006-01-02 15:04"), err)
+			}
+		}
+		return signer, fmt.Errorf("签名证书不可信: %w", err)
+	}
+	return signer, nil
+}
+
+// checkSignature 用签名证书的公钥（RSA 或 ECDSA）验证摘要的签名
+func checkSignature(pub any, hash crypto.Hash, digest, sig []byte, pss bool) error {
+	switch pub := pub.(type) {
+	case *rsa.PublicKey:
+		if pss {
+			if rsa.VerifyPSS(pub, hash, digest, sig, nil) != nil {
+				return errors.New("签名错误")
+			}
+			return nil
+		}
+		if rsa.VerifyPKCS1v15(pub, hash, digest, sig) != nil {
+			return errors.New("签名错误")
+		}
+		return nil
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(pub, digest, sig) {
+			return errors.New("签名错误")
+		}
+		return nil
+	default:
+		return fmt.Errorf("不支持的签名公钥类型 %T", pub)
+	}
+}
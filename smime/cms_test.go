@@ -0,0 +1,251 @@
+package smime
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	oidData            = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidSHA256          = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidECDSAWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}
+)
+
+// testContent 签名的邮件内容
+const testContent = "Content-Type: text/plain\r\n\r\nhello\r\n"
+
+// testCA 测试用的根证书
+type testCA struct {
+	cert  *x509.Certificate
+	key   *ecdsa.PrivateKey
+	roots *x509.CertPool
+}
+
+func newTestCA(t testing.TB) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now().Add(-365 * 24 * time.Hour),
+		NotAfter:              time.Now().Add(365 * 24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(cert)
+	return &testCA{cert: cert, key: key, roots: roots}
+}
+
+// issue 签发邮件保护用途的证书
+func (ca *testCA) issue(t testing.TB, email string, notBefore, notAfter time.Time) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:   big.NewInt(2),
+		Subject:        pkix.Name{CommonName: email},
+		EmailAddresses: []string{email},
+		NotBefore:      notBefore,
+		NotAfter:       notAfter,
+		KeyUsage:       x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:    []x509.ExtKeyUsage{x509.ExtKeyUsageEmailProtection},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, key
+}
+
+// explicit0 加上 [0] 显式标签
+func explicit0(t testing.TB, b []byte) []byte {
+	t.Helper()
+	der, err := asn1.Marshal(asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: b})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return der
+}
+
+// mustMarshal 编码为 DER
+func mustMarshal(t testing.TB, v any, params string) []byte {
+	t.Helper()
+	der, err := asn1.MarshalWithParams(v, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return der
+}
+
+// signOpaque 按 RFC 5652 生成内容封装在其中的 SignedData（DER），签名属性含 messageDigest 和 signingTime
+func signOpaque(t testing.TB, cert *x509.Certificate, key *ecdsa.PrivateKey, content string, signingTime time.Time) []byte {
+	t.Helper()
+	digest := sha256.Sum256([]byte(content))
+	attrs := []attribute{
+		{Type: oidMessageDigest, Values: asn1.RawValue{Tag: asn1.TagSet, IsCompound: true, Bytes: mustMarshal(t, digest[:], "")}},
+		{Type: oidSigningTime, Values: asn1.RawValue{Tag: asn1.TagSet, IsCompound: true, Bytes: mustMarshal(t, signingTime.UTC(), "utc")}},
+	}
+	signedAttrs := mustMarshal(t, attrs, "set")
+	h := sha256.Sum256(signedAttrs)
+	sig, err := ecdsa.SignASN1(rand.Reader, key, h[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sd := signedData{
+		Version:          1,
+		DigestAlgorithms: []pkix.AlgorithmIdentifier{{Algorithm: oidSHA256}},
+		ContentInfo: contentInfo{
+			ContentType: oidData,
+			Content:     asn1.RawValue{FullBytes: explicit0(t, mustMarshal(t, []byte(content), ""))},
+		},
+		Certificates: asn1.RawValue{FullBytes: mustMarshal(t, asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: cert.Raw}, "")},
+		SignerInfos: []signerInfo{{
+			Version:            1,
+			SID:                asn1.RawValue{FullBytes: mustMarshal(t, issuerAndSerial{Issuer: asn1.RawValue{FullBytes: cert.RawIssuer}, Serial: cert.SerialNumber}, "")},
+			DigestAlgorithm:    pkix.AlgorithmIdentifier{Algorithm: oidSHA256},
+			SignedAttrs:        asn1.RawValue{FullBytes: append([]byte{0xa0}, signedAttrs[1:]...)},
+			SignatureAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidECDSAWithSHA256},
+			Signature:          sig,
+		}},
+	}
+	return mustMarshal(t, contentInfo{
+		ContentType: oidSignedData,
+		Content:     asn1.RawValue{FullBytes: explicit0(t, mustMarshal(t, sd, ""))},
+	}, "")
+}
+
+// indefinite 将 DER 最外层的元素改为不定长编码（Outlook 等客户端生成的 BER）
+func indefinite(der []byte) []byte {
+	i := 2
+	if der[1]&0x80 != 0 {
+		i += int(der[1] & 0x7f)
+	}
+	out := append([]byte{der[0], 0x80}, der[i:]...)
+	return append(out, 0, 0)
+}
+
+// opaqueMessage 生成 application/pkcs7-mime 签名邮件
+func opaqueMessage(data []byte) []byte {
+	return []byte("From: alice@example.com\r\n" +
+		"Content-Type: application/pkcs7-mime; smime-type=signed-data; name=smime.p7m\r\n" +
+		"Content-Transfer-Encoding: base64\r\n" +
+		"\r\n" +
+		base64.StdEncoding.EncodeToString(data) + "\r\n")
+}
+
+func TestProcessSigned(t *testing.T) {
+	ca := newTestCA(t)
+	now := time.Now()
+	valid, validKey := ca.issue(t, "alice@example.com", now.Add(-time.Hour), now.Add(time.Hour))
+	expired, expiredKey := ca.issue(t, "alice@example.com", now.Add(-48*time.Hour), now.Add(-24*time.Hour))
+
+	signed := signOpaque(t, valid, validKey, testContent, now)
+	tests := []struct {
+		name     string
+		data     []byte
+		roots    *x509.CertPool
+		from     string
+		verified bool
+		err      string
+	}{
+		{"签名有效", signed, ca.roots, "alice@example.com", true, ""},
+		{"不定长编码", indefinite(signed), ca.roots, "alice@example.com", true, ""},
+		{"内容被修改", bytes.Replace(signed, []byte("hello"), []byte("HELLO"), 1), ca.roots, "alice@example.com", false, "邮件内容与签名不符"},
+		{"发件人不一致", signed, ca.roots, "bob@example.com", false, "签名证书与发件人 bob@example.com 不一致"},
+		{"根证书不可信", signed, x509.NewCertPool(), "alice@example.com", false, "签名证书不可信"},
+		// 签名时间倒填到证书有效期内仍不可信
+		{"证书已过期", signOpaque(t, expired, expiredKey, testContent, now.Add(-36*time.Hour)), ca.roots, "alice@example.com", false, "签名证书已不在有效期内（邮件声明的签名时间"},
+		{"证书已过期且签名时间不在有效期内", signOpaque(t, expired, expiredKey, testContent, now), ca.roots, "alice@example.com", false, "签名证书不可信"},
+	}
+	for _, tt := range tests {
+		p := &Processor{roots: tt.roots}
+		content, res := p.Process(opaqueMessage(tt.data), tt.from)
+		if res == nil || !res.Signed {
+			t.Errorf("%s: 未识别为签名邮件: %+v", tt.name, res)
+			continue
+		}
+		if res.Verified != tt.verified {
+			t.Errorf("%s: 验证结果 %v，期望 %v（%s）", tt.name, res.Verified, tt.verified, res.Error)
+		}
+		if tt.err != "" && !strings.Contains(res.Error, tt.err) {
+			t.Errorf("%s: 错误 %q 不包含 %q", tt.name, res.Error, tt.err)
+		}
+		if res.Signer != "alice@example.com" {
+			t.Errorf("%s: 签名者 %q", tt.name, res.Signer)
+		}
+		if tt.verified && string(content) != testContent {
+			t.Errorf("%s: 签名内容 %q，期望 %q", tt.name, content, testContent)
+		}
+	}
+}
+
+func FuzzParseCMS(f *testing.F) {
+	ca := newTestCA(f)
+	now := time.Now()
+	cert, key := ca.issue(f, "alice@example.com", now.Add(-time.Hour), now.Add(time.Hour))
+	signed := signOpaque(f, cert, key, testContent, now)
+	f.Add(signed)
+	f.Add(indefinite(signed))
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		f.Fatal(err)
+	}
+	tmpl := &x509.Certificate{SerialNumber: big.NewInt(3), NotBefore: now.Add(-time.Hour), NotAfter: now.Add(time.Hour)}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &rsaKey.PublicKey, rsaKey)
+	if err != nil {
+		f.Fatal(err)
+	}
+	rsaCert, err := x509.ParseCertificate(der)
+	if err != nil {
+		f.Fatal(err)
+	}
+
+	// 任意输入都不能导致崩溃
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ci, err := parseContentInfo(data)
+		if err != nil {
+			return
+		}
+		switch {
+		case ci.ContentType.Equal(oidSignedData):
+			if sd, content, err := parseSigned(ci); err == nil {
+				sd.verify(content, ca.roots)
+			}
+		case ci.ContentType.Equal(oidEnvelopedData):
+			decryptEnveloped(ci, rsaCert, rsaKey)
+		}
+	})
+}
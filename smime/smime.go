@@ -0,0 +1,360 @@
+package smime
+
+import (
+	"bytes"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/emersion/go-message"
+	"golang.org/x/crypto/pkcs12"
+)
+
+// maxLayers 最多处理的 S/MIME 层数（如先签名后加密为两层）
+const maxLayers = 3
+
+// oidEmailAddress 证书主题中的邮箱地址（旧式证书没有 SAN 时使用）
+var oidEmailAddress = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 1}
+
+// Result S/MIME 邮件的处理结果
+type Result struct {
+	Encrypted bool   `json:"encrypted"`        // 邮件已加密
+	Decrypted bool   `json:"decrypted"`        // 已用本账号的证书解密
+	Signed    bool   `json:"signed"`           // 邮件带有签名
+	Verified  bool   `json:"verified"`         // 签名有效、签名证书可信且与发件人一致
+	Signer    string `json:"signer,omitempty"` // 签名证书中的邮箱地址（没有时为证书名称）
+	Error     string `json:"error,omitempty"`  // 解密或验证失败的原因
+}
+
+// Indicator 推送中显示的 S/MIME 标识，如 "🔒 已解密、✅ 签名有效 (alice@example.com)"
+func (r *Result) Indicator() string {
+	var parts []string
+	if r.Encrypted {
+		if r.Decrypted {
+			parts = append(parts, "🔒 已解密")
+		} else {
+			parts = append(parts, "🔒 无法解密")
+		}
+	}
+	if r.Signed {
+		s := "✅ 签名有效"
+		if !r.Verified {
+			s = "⚠️ 签名无效"
+		}
+		if r.Signer != "" {
+			s += " (" + r.Signer + ")"
+		}
+		parts = append(parts, s)
+	}
+	s := strings.Join(parts, "、")
+	if r.Error != "" {
+		s += "：" + r.Error
+	}
+	return s
+}
+
+// Options S/MIME 配置
+type Options struct {
+	PKCS12File string // 可选，本账号的证书和私钥（PKCS#12），用于解密；未配置时只验证签名
+	Password   string // PKCS#12 文件的密码
+	CAFile     string // 可选，验证签名时额外信任的根证书（PEM），默认只信任系统根证书
+}
+
+// Processor 解密 S/MIME 加密邮件、验证签名
+type Processor struct {
+	cert  *x509.Certificate // 本账号的证书，未配置时不解密
+	key   *rsa.PrivateKey
+	roots *x509.CertPool // 验证签名时信任的根证书
+}
+
+// Load 读取证书并创建处理器
+func Load(opts Options) (*Processor, error) {
+	roots, err := x509.SystemCertPool()
+	if err != nil {
+		roots = x509.NewCertPool()
+	}
+	if opts.CAFile != "" {
+		data, err := os.ReadFile(opts.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("读取 CA 证书失败: %w", err)
+		}
+		if !roots.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("CA 证书文件中没有 PEM 格式的证书: %s", opts.CAFile)
+		}
+	}
+	p := &Processor{roots: roots}
+	if opts.PKCS12File == "" {
+		return p, nil
+	}
+
+	data, err := os.ReadFile(opts.PKCS12File)
+	if err != nil {
+		return nil, fmt.Errorf("读取 PKCS#12 文件失败: %w", err)
+	}
+	// 只支持 3DES/RC2 加密的 PKCS#12（OpenSSL 3 默认使用 AES，需要加 -legacy 导出）
+	blocks, err := pkcs12.ToPEM(data, opts.Password)
+	if err != nil {
+		return nil, fmt.Errorf("解析 PKCS#12 文件失败（密码错误或加密方式不支持，OpenSSL 3 请使用 openssl pkcs12 -export -legacy 导出）: %w", err)
+	}
+	var certs []*x509.Certificate
+	for _, b := range blocks {
+		switch b.Type {
+		case "CERTIFICATE":
+			c, err := x509.ParseCertificate(b.Bytes)
+			if err != nil {
+				return nil, fmt.Errorf("解析证书失败: %w", err)
+			}
+			certs = append(certs, c)
+		case "PRIVATE KEY":
+			if p.key, err = x509.ParsePKCS1PrivateKey(b.Bytes); err != nil {
+				return nil, errors.New("仅支持 RSA 私钥")
+			}
+		}
+	}
+	if p.key == nil {
+		return nil, errors.New("PKCS#12 文件中没有私钥")
+	}
+	for _, c := range certs {
+		if pub, ok := c.PublicKey.(*rsa.PublicKey); ok && pub.Equal(&p.key.PublicKey) {
+			p.cert = c
+			break
+		}
+	}
+	if p.cert == nil {
+		return nil, errors.New("PKCS#12 文件中没有与私钥匹配的证书")
+	}
+	return p, nil
+}
+
+// Process 处理 S/MIME 邮件：解密加密邮件，验证签名并去掉签名封装
+// 返回需要重新解析的邮件内容（MIME 实体；无需重新解析时为nil）和处理结果（不是 S/MIME 邮件时为nil）
+// fromAddress 为发件人地址，用于检查签名证书是否属于发件人
+func (p *Processor) Process(raw []byte, fromAddress string) ([]byte, *Result) {
+	var res *Result
+	var content []byte
+	entity := raw
+	for i := 0; i < maxLayers; i++ {
+		e, err := message.Read(bytes.NewReader(entity))
+		if err != nil && !message.IsUnknownCharset(err) {
+			break
+		}
+		t, params, _ := e.Header.ContentType()
+		var next []byte
+		switch {
+		case t == "multipart/signed" && isPKCS7Signature(params["protocol"]):
+			if res == nil {
+				res = &Result{}
+			}
+			next, err = p.verifyDetached(entity, e, params["boundary"], res, fromAddress)
+		case t == "application/pkcs7-mime" || t == "application/x-pkcs7-mime":
+			if res == nil {
+				res = &Result{}
+			}
+			next, err = p.processOpaque(e, res, fromAddress)
+		}
+		if next == nil {
+			if err != nil && res != nil {
+				res.Error = err.Error()
+			}
+			break
+		}
+		entity, content = next, next
+	}
+	return content, res
+}
+
+// processOpaque 处理 application/pkcs7-mime：加密的邮件（enveloped-data）或内容与签名封装在一起的签名邮件（signed-data）
+func (p *Processor) processOpaque(e *message.Entity, res *Result, fromAddress string) ([]byte, error) {
+	data, err := io.ReadAll(e.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取 S/MIME 内容失败: %w", err)
+	}
+	ci, err := parseContentInfo(data)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case ci.ContentType.Equal(oidEnvelopedData):
+		res.Encrypted = true
+		if p.key == nil {
+			return nil, errors.New("未配置证书，无法解密")
+		}
+		content, err := decryptEnveloped(ci, p.cert, p.key)
+		if err != nil {
+			return nil, err
+		}
+		res.Decrypted = true
+		return content, nil
+	case ci.ContentType.Equal(oidAuthEnvelopedData):
+		res.Encrypted = true
+		return nil, errors.New("不支持 AES-GCM 加密（AuthEnvelopedData）")
+	case ci.ContentType.Equal(oidSignedData):
+		sd, content, err := parseSigned(ci)
+		if err != nil {
+			res.Signed = true
+			return nil, err
+		}
+		signer, err := sd.verify(content, p.roots)
+		p.report(res, signer, err, fromAddress)
+		return content, nil
+	default:
+		return nil, fmt.Errorf("不支持的 S/MIME 内容类型 %s", ci.ContentType)
+	}
+}
+
+// verifyDetached 验证 multipart/signed：第一部分为原样签名的内容，第二部分为签名
+func (p *Processor) verifyDetached(raw []byte, e *message.Entity, boundary string, res *Result, fromAddress string) ([]byte, error) {
+	res.Signed = true
+	_, body := splitEntity(raw)
+	content := firstPart(body, boundary)
+	if content == nil {
+		return nil, errors.New("签名邮件格式错误")
+	}
+
+	var sig []byte
+	if mr := e.MultipartReader(); mr != nil {
+		for {
+			part, err := mr.NextPart()
+			if err != nil && !message.IsUnknownCharset(err) {
+				break
+			}
+			if t, _, _ := part.Header.ContentType(); isPKCS7Signature(t) {
+				sig, _ = io.ReadAll(part.Body)
+				break
+			}
+		}
+	}
+	if sig == nil {
+		return nil, errors.New("签名邮件中没有签名")
+	}
+
+	ci, err := parseContentInfo(sig)
+	if err == nil && !ci.ContentType.Equal(oidSignedData) {
+		err = errors.New("签名格式错误")
+	}
+	var sd *signedData
+	if err == nil {
+		sd, _, err = parseSigned(ci)
+	}
+	if err != nil {
+		res.Error = err.Error()
+		return content, nil
+	}
+	// 签名针对规范化（CRLF 换行）的内容
+	signer, err := sd.verify(canonicalize(content), p.roots)
+	p.report(res, signer, err, fromAddress)
+	return content, nil
+}
+
+// report 记录签名验证结果；签名有效时还要求签名证书中的邮箱地址与发件人一致
+func (p *Processor) report(res *Result, signer *x509.Certificate, err error, fromAddress string) {
+	res.Signed = true
+	if signer != nil {
+		res.Signer = certName(signer)
+	}
+	switch {
+	case err != nil:
+		res.Error = err.Error()
+	case fromAddress != "" && !certHasEmail(signer, fromAddress):
+		res.Error = "签名证书与发件人 " + fromAddress + " 不一致"
+	default:
+		res.Verified = true
+	}
+}
+
+// isPKCS7Signature 是否为 S/MIME 签名的类型
+func isPKCS7Signature(t string) bool {
+	t = strings.ToLower(t)
+	return t == "application/pkcs7-signature" || t == "application/x-pkcs7-signature"
+}
+
+// certEmails 证书中的邮箱地址（SAN 和旧式证书主题中的 emailAddress）
+func certEmails(c *x509.Certificate) []string {
+	emails := append([]string{}, c.EmailAddresses...)
+	for _, name := range c.Subject.Names {
+		if s, ok := name.Value.(string); ok && name.Type.Equal(oidEmailAddress) {
+			emails = append(emails, s)
+		}
+	}
+	return emails
+}
+
+// certName 签名者的显示名称：邮箱地址，没有时为证书名称
+func certName(c *x509.Certificate) string {
+	if emails := certEmails(c); len(emails) > 0 {
+		return emails[0]
+	}
+	return c.Subject.CommonName
+}
+
+// certHasEmail 证书是否包含该邮箱地址
+func certHasEmail(c *x509.Certificate, address string) bool {
+	for _, e := range certEmails(c) {
+		if strings.EqualFold(e, address) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitEntity 分开 MIME 实体的头和正文
+func splitEntity(raw []byte) (header, body []byte) {
+	i, n := bytes.Index(raw, []byte("\r\n\r\n")), 4
+	if j := bytes.Index(raw, []byte("\n\n")); j >= 0 && (i < 0 || j < i) {
+		i, n = j, 2
+	}
+	if i < 0 {
+		return raw, nil
+	}
+	return raw[:i], raw[i+n:]
+}
+
+// firstPart 原样取出 multipart 正文中的第一部分（含部分头），不含分隔行前的换行
+func firstPart(body []byte, boundary string) []byte {
+	if boundary == "" {
+		return nil
+	}
+	delim := []byte("--" + boundary)
+	start := -1
+	for i := 0; i <= len(body)-len(delim); {
+		j := bytes.Index(body[i:], delim)
+		if j < 0 {
+			break
+		}
+		if k := i + j; k == 0 || body[k-1] == '\n' {
+			start = k
+			break
+		}
+		i += j + 1
+	}
+	if start < 0 {
+		return nil
+	}
+	// 跳过分隔行
+	nl := bytes.IndexByte(body[start:], '\n')
+	if nl < 0 {
+		return nil
+	}
+	start += nl + 1
+
+	end := bytes.Index(body[start:], append([]byte("\n"), delim...))
+	if end < 0 {
+		return nil
+	}
+	end += start
+	if end > start && body[end-1] == '\r' {
+		end--
+	}
+	return body[start:end]
+}
+
+// canonicalize 将换行统一为 CRLF
+func canonicalize(b []byte) []byte {
+	b = bytes.ReplaceAll(b, []byte("\r\n"), []byte("\n"))
+	return bytes.ReplaceAll(b, []byte("\n"), []byte("\r\n"))
+}
@@ -0,0 +1,161 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"mail-receiver/secure"
+)
+
+// Checkpoint 单个文件夹的处理进度
+type Checkpoint struct {
+	UIDValidity uint32 `json:"uidvalidity"`
+	LastUID     uint32 `json:"last_uid"` // 已处理的最大UID
+	// Delivered 严格投递模式下已确认推送、尚未标记已读的邮件UID，重启对账时据此补做已读标记而不重新推送
+	Delivered []uint32 `json:"delivered,omitempty"`
+	// Held 已暂存（合并推送、摘要、免打扰）尚未推送的邮件UID，进度已越过这些邮件，重启后据此重新获取
+	Held []uint32 `json:"held,omitempty"`
+	// Done 已处理完成、但之前有未完成的邮件而进度未能越过的邮件UID，再次获取时跳过，不重复执行规则动作
+	Done []uint32 `json:"done,omitempty"`
+}
+
+// equal 进度是否相同
+func (c Checkpoint) equal(o Checkpoint) bool {
+	return c.UIDValidity == o.UIDValidity && c.LastUID == o.LastUID &&
+		slices.Equal(c.Delivered, o.Delivered) && slices.Equal(c.Held, o.Held) && slices.Equal(c.Done, o.Done)
+}
+
+// Store 处理进度存储（JSON文件，按 账号/文件夹 索引）
+type Store struct {
+	mu          sync.Mutex
+	path        string
+	cipher      *secure.Cipher
+	checkpoints map[string]Checkpoint
+}
+
+// Open 打开状态文件，文件不存在时创建空状态；cipher 不为nil时加密保存
+func Open(path string, cipher *secure.Cipher) (*Store, error) {
+	s := &Store{path: path, cipher: cipher}
+	checkpoints, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	s.checkpoints = checkpoints
+	return s, nil
+}
+
+// Reload 重新读取状态文件替换内存中的进度（如平滑升级时旧进程退出前写入的进度），读取失败时保持不变
+func (s *Store) Reload() error {
+	if s == nil || s.path == "" {
+		return nil
+	}
+	checkpoints, err := s.load()
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.checkpoints = checkpoints
+	s.mu.Unlock()
+	return nil
+}
+
+// load 读取状态文件，文件不存在时返回空状态
+func (s *Store) load() (map[string]Checkpoint, error) {
+	checkpoints := make(map[string]Checkpoint)
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return checkpoints, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取状态文件失败: %w", err)
+	}
+	// 非JSON开头的内容为加密数据（兼容加密前写入的明文状态）
+	if len(data) > 0 && data[0] != '{' {
+		if s.cipher == nil {
+			return nil, fmt.Errorf("状态文件已加密，但未配置加密密钥")
+		}
+		if data, err = s.cipher.Open(data); err != nil {
+			return nil, err
+		}
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &checkpoints); err != nil {
+			return nil, fmt.Errorf("解析状态文件失败: %w", err)
+		}
+	}
+	return checkpoints, nil
+}
+
+// NewMemory 创建仅保存在内存中的状态（不写文件，重启后丢失）
+func NewMemory() *Store {
+	return &Store{checkpoints: make(map[string]Checkpoint)}
+}
+
+// Get 返回账号文件夹的处理进度，不存在时返回零值
+func (s *Store) Get(account, folder string) Checkpoint {
+	if s == nil {
+		return Checkpoint{}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.checkpoints[key(account, folder)]
+}
+
+// Set 更新处理进度并写入文件
+func (s *Store) Set(account, folder string, cp Checkpoint) error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.checkpoints[key(account, folder)].equal(cp) {
+		return nil
+	}
+	cp.Delivered = slices.Clone(cp.Delivered)
+	cp.Held = slices.Clone(cp.Held)
+	cp.Done = slices.Clone(cp.Done)
+	s.checkpoints[key(account, folder)] = cp
+	if s.path == "" {
+		return nil
+	}
+	return s.save()
+}
+
+// save 原子写入状态文件（先写临时文件再重命名）
+func (s *Store) save() error {
+	data, err := json.MarshalIndent(s.checkpoints, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化状态失败: %w", err)
+	}
+
+	if dir := filepath.Dir(s.path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("创建状态目录失败: %w", err)
+		}
+	}
+
+	if s.cipher != nil {
+		if data, err = s.cipher.Seal(data); err != nil {
+			return fmt.Errorf("加密状态失败: %w", err)
+		}
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("写入状态文件失败: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("写入状态文件失败: %w", err)
+	}
+	return nil
+}
+
+// key 生成索引键
+func key(account, folder string) string {
+	return account + "/" + folder
+}
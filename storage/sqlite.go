@@ -0,0 +1,293 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	_ "modernc.org/sqlite" // 纯 Go 实现的 SQLite 驱动
+
+	"mail-receiver/secure"
+)
+
+// sqliteMigrations 数据库结构升级脚本，下标+1 为升级后的版本号（记录在 PRAGMA user_version）
+// 只允许追加，不能修改已发布的脚本
+var sqliteMigrations = []string{
+	`CREATE TABLE emails (
+		id           INTEGER PRIMARY KEY AUTOINCREMENT,
+		account      TEXT    NOT NULL,
+		folder       TEXT    NOT NULL,
+		uid_validity INTEGER NOT NULL DEFAULT 0,
+		uid          INTEGER NOT NULL,
+		message_id   TEXT    NOT NULL DEFAULT '',
+		subject      TEXT    NOT NULL DEFAULT '',
+		from_addr    TEXT    NOT NULL DEFAULT '',
+		to_addrs     TEXT    NOT NULL DEFAULT '[]',
+		cc_addrs     TEXT    NOT NULL DEFAULT '[]',
+		date         INTEGER NOT NULL DEFAULT 0,
+		size         INTEGER NOT NULL DEFAULT 0,
+		flags        TEXT    NOT NULL DEFAULT '[]',
+		tags         TEXT    NOT NULL DEFAULT '[]',
+		body         TEXT    NOT NULL DEFAULT '',
+		html_body    TEXT    NOT NULL DEFAULT '',
+		attachments  TEXT    NOT NULL DEFAULT '[]',
+		pushed       INTEGER NOT NULL DEFAULT 0,
+		processed_at INTEGER NOT NULL,
+		UNIQUE (account, folder, uid_validity, uid)
+	)`,
+	`CREATE INDEX idx_emails_processed_at ON emails (processed_at)`,
+	`CREATE INDEX idx_emails_message_id ON emails (message_id)`,
+	`ALTER TABLE emails ADD COLUMN view_id TEXT NOT NULL DEFAULT ''`,
+	`CREATE INDEX idx_emails_view_id ON emails (view_id)`,
+	// sealed 为1时邮件头（Message-ID、主题、发件人、收件人、抄送）和附件文件名也已加密，之前的记录只加密了正文
+	`ALTER TABLE emails ADD COLUMN sealed INTEGER NOT NULL DEFAULT 0`,
+}
+
+// SQLiteStore 基于 SQLite 的邮件归档，保存完整的邮件内容和推送状态
+// 同一封邮件（账号/文件夹/UIDVALIDITY/UID）重复处理时更新原记录
+// 设置加密器后正文、邮件头（Message-ID、主题、发件人、收件人、抄送）和附件文件名加密保存，账号、文件夹、日期等用于查询的列不加密
+type SQLiteStore struct {
+	db     *sql.DB
+	cipher *secure.Cipher
+}
+
+// OpenSQLite 打开（或创建）SQLite 数据库并升级到最新结构，cipher 为nil时不加密
+func OpenSQLite(path string, cipher *secure.Cipher) (*SQLiteStore, error) {
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("创建存储目录失败: %w", err)
+		}
+	}
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("打开数据库失败: %w", err)
+	}
+	// SQLite 同时只允许一个写入者
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(`PRAGMA journal_mode=WAL; PRAGMA busy_timeout=5000`); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("设置数据库参数失败: %w", err)
+	}
+
+	s := &SQLiteStore{db: db, cipher: cipher}
+	if err := s.migrate(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// migrate 按 user_version 执行未应用的升级脚本
+func (s *SQLiteStore) migrate() error {
+	var version int
+	if err := s.db.QueryRow(`PRAGMA user_version`).Scan(&version); err != nil {
+		return fmt.Errorf("读取数据库版本失败: %w", err)
+	}
+	if version > len(sqliteMigrations) {
+		return fmt.Errorf("数据库版本 %d 高于程序支持的版本 %d，请升级程序", version, len(sqliteMigrations))
+	}
+
+	for v := version; v < len(sqliteMigrations); v++ {
+		tx, err := s.db.Begin()
+		if err != nil {
+			return fmt.Errorf("升级数据库失败: %w", err)
+		}
+		if _, err := tx.Exec(sqliteMigrations[v]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("升级数据库到版本 %d 失败: %w", v+1, err)
+		}
+		if _, err := tx.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, v+1)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("升级数据库到版本 %d 失败: %w", v+1, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("升级数据库到版本 %d 失败: %w", v+1, err)
+		}
+	}
+	return nil
+}
+
+// Save 保存一条记录，已存在时更新
+func (s *SQLiteStore) Save(rec *Record) error {
+	// 需要加密的列
+	sealed := []string{rec.Body, rec.HTMLBody, rec.MessageID, rec.Subject, rec.From,
+		jsonList(rec.To), jsonList(rec.CC), jsonList(rec.Attachments)}
+	for i, text := range sealed {
+		var err error
+		if sealed[i], err = s.seal(text); err != nil {
+			return err
+		}
+	}
+	body, htmlBody, messageID, subject, from, to, cc, attachments :=
+		sealed[0], sealed[1], sealed[2], sealed[3], sealed[4], sealed[5], sealed[6], sealed[7]
+
+	_, err := s.db.Exec(`INSERT INTO emails (
+			account, folder, uid_validity, uid, message_id, subject, from_addr, to_addrs, cc_addrs,
+			date, size, flags, tags, body, html_body, attachments, pushed, processed_at, view_id, sealed
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (account, folder, uid_validity, uid) DO UPDATE SET
+			message_id = excluded.message_id,
+			subject = excluded.subject,
+			from_addr = excluded.from_addr,
+			to_addrs = excluded.to_addrs,
+			cc_addrs = excluded.cc_addrs,
+			flags = excluded.flags,
+			tags = excluded.tags,
+			attachments = excluded.attachments,
+			pushed = MAX(pushed, excluded.pushed),
+			processed_at = excluded.processed_at,
+			view_id = CASE WHEN excluded.view_id = '' THEN view_id ELSE excluded.view_id END,
+			sealed = excluded.sealed`,
+		rec.Account, rec.Folder, rec.UIDValidity, rec.UID, messageID, subject, from,
+		to, cc, unixMilli(rec.Date), rec.Size,
+		jsonList(rec.Flags), jsonList(rec.Tags), body, htmlBody, attachments,
+		rec.Pushed, unixMilli(rec.ProcessedAt), rec.ViewID, s.cipher != nil,
+	)
+	if err != nil {
+		return fmt.Errorf("写入数据库失败: %w", err)
+	}
+	return nil
+}
+
+// sqliteColumns 读取记录的列，顺序与 scanRecord 一致
+const sqliteColumns = `account, folder, uid_validity, uid, message_id, subject, from_addr, to_addrs, cc_addrs,
+		date, size, flags, tags, body, html_body, attachments, pushed, processed_at, view_id, sealed`
+
+// Query 查询处理时间在 [from, to) 范围内的记录，零值表示不限
+func (s *SQLiteStore) Query(from, to time.Time) ([]*Record, error) {
+	query := `SELECT ` + sqliteColumns + ` FROM emails WHERE 1 = 1`
+	var args []interface{}
+	if !from.IsZero() {
+		query += ` AND processed_at >= ?`
+		args = append(args, from.UnixMilli())
+	}
+	if !to.IsZero() {
+		query += ` AND processed_at < ?`
+		args = append(args, to.UnixMilli())
+	}
+	query += ` ORDER BY processed_at`
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("查询数据库失败: %w", err)
+	}
+	defer rows.Close()
+
+	var result []*Record
+	for rows.Next() {
+		rec, err := s.scanRecord(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, rec)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("读取数据库记录失败: %w", err)
+	}
+	return result, nil
+}
+
+// Lookup 按查看链接ID查找记录，不存在时返回 ErrNotFound
+func (s *SQLiteStore) Lookup(viewID string) (*Record, error) {
+	if viewID == "" {
+		return nil, ErrNotFound
+	}
+	rows, err := s.db.Query(`SELECT `+sqliteColumns+` FROM emails WHERE view_id = ? LIMIT 1`, viewID)
+	if err != nil {
+		return nil, fmt.Errorf("查询数据库失败: %w", err)
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("读取数据库记录失败: %w", err)
+		}
+		return nil, ErrNotFound
+	}
+	return s.scanRecord(rows)
+}
+
+// scanRecord 读取一行记录并解密
+func (s *SQLiteStore) scanRecord(rows *sql.Rows) (*Record, error) {
+	var (
+		rec                              Record
+		to, cc, flags, tags, attachments string
+		date, processedAt                int64
+		sealed                           bool
+	)
+	if err := rows.Scan(&rec.Account, &rec.Folder, &rec.UIDValidity, &rec.UID, &rec.MessageID, &rec.Subject, &rec.From,
+		&to, &cc, &date, &rec.Size, &flags, &tags, &rec.Body, &rec.HTMLBody, &attachments,
+		&rec.Pushed, &processedAt, &rec.ViewID, &sealed); err != nil {
+		return nil, fmt.Errorf("读取数据库记录失败: %w", err)
+	}
+	fields := []*string{&rec.Body, &rec.HTMLBody}
+	if sealed {
+		fields = append(fields, &rec.MessageID, &rec.Subject, &rec.From, &to, &cc, &attachments)
+	}
+	for _, f := range fields {
+		text, err := s.open(*f)
+		if err != nil {
+			return nil, err
+		}
+		*f = text
+	}
+	json.Unmarshal([]byte(to), &rec.To)
+	json.Unmarshal([]byte(cc), &rec.CC)
+	json.Unmarshal([]byte(flags), &rec.Flags)
+	json.Unmarshal([]byte(tags), &rec.Tags)
+	json.Unmarshal([]byte(attachments), &rec.Attachments)
+	if date != 0 {
+		rec.Date = time.UnixMilli(date)
+	}
+	rec.ProcessedAt = time.UnixMilli(processedAt)
+	return &rec, nil
+}
+
+// Close 关闭数据库
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
+
+// seal 加密文本（未配置加密器时原样返回）
+func (s *SQLiteStore) seal(text string) (string, error) {
+	if s.cipher == nil || text == "" {
+		return text, nil
+	}
+	sealed, err := s.cipher.SealString([]byte(text))
+	if err != nil {
+		return "", fmt.Errorf("加密记录失败: %w", err)
+	}
+	return sealed, nil
+}
+
+// open 解密 seal 加密的文本
+func (s *SQLiteStore) open(text string) (string, error) {
+	if s.cipher == nil || text == "" {
+		return text, nil
+	}
+	plaintext, err := s.cipher.OpenString(text)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// jsonList 将字符串列表序列化为 JSON 数组
+func jsonList(list []string) string {
+	if len(list) == 0 {
+		return "[]"
+	}
+	data, _ := json.Marshal(list)
+	return string(data)
+}
+
+// unixMilli 返回毫秒时间戳，零值时间返回0
+func unixMilli(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixMilli()
+}
@@ -0,0 +1,195 @@
+package storage
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"mail-receiver/secure"
+)
+
+// Record 已处理邮件记录
+type Record struct {
+	Account     string    `json:"account"`
+	Folder      string    `json:"folder"`
+	UIDValidity uint32    `json:"uid_validity,omitempty"`
+	UID         uint32    `json:"uid"`
+	MessageID   string    `json:"message_id,omitempty"`
+	Subject     string    `json:"subject"`
+	From        string    `json:"from"` // 发件人邮箱地址
+	To          []string  `json:"to,omitempty"`
+	CC          []string  `json:"cc,omitempty"`
+	Date        time.Time `json:"date"` // 邮件日期
+	Size        uint32    `json:"size"`
+	Flags       []string  `json:"flags,omitempty"` // 获取邮件时的标志
+	Tags        []string  `json:"tags,omitempty"`
+	Body        string    `json:"body,omitempty"`        // 纯文本正文（仅 SQLite 存储保存）
+	HTMLBody    string    `json:"html_body,omitempty"`   // HTML 正文（仅 SQLite 存储保存）
+	Attachments []string  `json:"attachments,omitempty"` // 附件文件名
+	Pushed      bool      `json:"pushed"`                // 是否推送成功
+	ProcessedAt time.Time `json:"processed_at"`          // 处理时间
+	ViewID      string    `json:"view_id,omitempty"`     // 网页查看链接ID（仅 SQLite 存储保存）
+}
+
+// Store 已处理邮件存储
+type Store interface {
+	// Save 保存一条记录
+	Save(rec *Record) error
+	// Query 查询处理时间在 [from, to) 范围内的记录，零值表示不限
+	Query(from, to time.Time) ([]*Record, error)
+	// Close 关闭存储
+	Close() error
+}
+
+// Lookup 支持按查看链接ID查找记录的存储（SQLite）
+type Lookup interface {
+	// Lookup 按查看链接ID查找记录，不存在时返回 ErrNotFound
+	Lookup(viewID string) (*Record, error)
+}
+
+// ErrNotFound 记录不存在
+var ErrNotFound = errors.New("邮件记录不存在")
+
+// ViewID 生成邮件的查看链接ID：对邮件标识（账号/文件夹/UIDVALIDITY/UID）计算 HMAC，不知道密钥时无法猜测
+func ViewID(secret, account, folder string, uidValidity, uid uint32) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%s\x00%s\x00%d\x00%d", account, folder, uidValidity, uid)
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:16])
+}
+
+// JSONLStore 基于 JSON Lines 文件的追加式存储
+// 设置加密器后每行为一条 base64 编码的密文；读取时兼容未加密的旧记录
+type JSONLStore struct {
+	mu     sync.Mutex
+	path   string
+	file   *os.File
+	cipher *secure.Cipher
+}
+
+// OpenJSONL 打开（或创建）JSON Lines 存储文件，cipher 为nil时不加密
+func OpenJSONL(path string, cipher *secure.Cipher) (*JSONLStore, error) {
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("创建存储目录失败: %w", err)
+		}
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("打开存储文件失败: %w", err)
+	}
+
+	return &JSONLStore{path: path, file: file, cipher: cipher}, nil
+}
+
+// Save 追加一条记录
+func (s *JSONLStore) Save(rec *Record) error {
+	// JSON Lines 仅用于统计，不保存正文
+	r := *rec
+	r.Body, r.HTMLBody, r.ViewID = "", "", ""
+	data, err := json.Marshal(&r)
+	if err != nil {
+		return fmt.Errorf("序列化记录失败: %w", err)
+	}
+	if s.cipher != nil {
+		sealed, err := s.cipher.SealString(data)
+		if err != nil {
+			return fmt.Errorf("加密记录失败: %w", err)
+		}
+		data = []byte(sealed)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("写入存储文件失败: %w", err)
+	}
+	return nil
+}
+
+// Query 顺序扫描文件查询记录
+func (s *JSONLStore) Query(from, to time.Time) ([]*Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file, err := os.Open(s.path)
+	if err != nil {
+		return nil, fmt.Errorf("打开存储文件失败: %w", err)
+	}
+	defer file.Close()
+
+	var result []*Record
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		// 非JSON开头的行为加密记录
+		if line[0] != '{' {
+			if s.cipher == nil {
+				return nil, fmt.Errorf("存储记录已加密，但未配置加密密钥")
+			}
+			plaintext, err := s.cipher.OpenString(string(line))
+			if err != nil {
+				return nil, err
+			}
+			line = plaintext
+		}
+
+		var rec Record
+		if err := json.Unmarshal(line, &rec); err != nil {
+			return nil, fmt.Errorf("解析存储记录失败: %w", err)
+		}
+		if !from.IsZero() && rec.ProcessedAt.Before(from) {
+			continue
+		}
+		if !to.IsZero() && !rec.ProcessedAt.Before(to) {
+			continue
+		}
+		result = append(result, &rec)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取存储文件失败: %w", err)
+	}
+
+	return result, nil
+}
+
+// Close 关闭存储文件
+func (s *JSONLStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
+
+// 存储类型
+const (
+	TypeJSONL  = "jsonl"
+	TypeSQLite = "sqlite" // 完整邮件归档
+)
+
+// Open 按类型打开存储，cipher 不为nil时对存储内容加密
+func Open(kind, path string, cipher *secure.Cipher) (Store, error) {
+	if path == "" {
+		return nil, fmt.Errorf("存储路径为空")
+	}
+	switch kind {
+	case "", TypeJSONL:
+		return OpenJSONL(path, cipher)
+	case TypeSQLite:
+		return OpenSQLite(path, cipher)
+	}
+	return nil, fmt.Errorf("不支持的存储类型: %s", kind)
+}
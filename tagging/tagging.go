@@ -0,0 +1,165 @@
+package tagging
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Input 打标签所需的邮件内容
+type Input struct {
+	Subject string
+	From    string
+	Body    string
+}
+
+// Classifier 分类器，返回邮件命中的标签
+// 除内置的关键字/正则规则外，可实现该接口扩展其他分类方式
+type Classifier interface {
+	Classify(in *Input) []string
+}
+
+// Rule 关键字/正则分类规则
+type Rule struct {
+	tag      string
+	keywords []string
+	pattern  *regexp.Regexp
+	fields   []string
+}
+
+// 可匹配的字段
+const (
+	FieldSubject = "subject"
+	FieldFrom    = "from"
+	FieldBody    = "body"
+)
+
+// NewRule 创建规则，keywords 任一命中或 pattern 匹配即打上 tag
+// fields 为空时匹配主题和正文
+func NewRule(tag string, keywords []string, pattern string, fields []string) (*Rule, error) {
+	if tag == "" {
+		return nil, fmt.Errorf("标签规则缺少 tag")
+	}
+	r := &Rule{tag: tag, fields: fields}
+	for _, k := range keywords {
+		if k != "" {
+			r.keywords = append(r.keywords, strings.ToLower(k))
+		}
+	}
+	if pattern != "" {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("标签 %s 的正则无效: %w", tag, err)
+		}
+		r.pattern = re
+	}
+	if len(r.fields) == 0 {
+		r.fields = []string{FieldSubject, FieldBody}
+	}
+	for _, f := range r.fields {
+		if f != FieldSubject && f != FieldFrom && f != FieldBody {
+			return nil, fmt.Errorf("标签 %s 的字段无效: %s", tag, f)
+		}
+	}
+	if len(r.keywords) == 0 && r.pattern == nil {
+		return nil, fmt.Errorf("标签 %s 缺少 keywords 或 pattern", tag)
+	}
+	return r, nil
+}
+
+// Classify 实现 Classifier
+func (r *Rule) Classify(in *Input) []string {
+	for _, f := range r.fields {
+		var text string
+		switch f {
+		case FieldSubject:
+			text = in.Subject
+		case FieldFrom:
+			text = in.From
+		case FieldBody:
+			text = in.Body
+		}
+		if r.match(text) {
+			return []string{r.tag}
+		}
+	}
+	return nil
+}
+
+// match 检查文本是否命中规则
+func (r *Rule) match(text string) bool {
+	if text == "" {
+		return false
+	}
+	if r.pattern != nil && r.pattern.MatchString(text) {
+		return true
+	}
+	lower := strings.ToLower(text)
+	for _, k := range r.keywords {
+		if strings.Contains(lower, k) {
+			return true
+		}
+	}
+	return false
+}
+
+// Tagger 按顺序执行所有分类器并合并标签
+type Tagger struct {
+	classifiers []Classifier
+}
+
+// NewTagger 创建打标签器
+func NewTagger(classifiers ...Classifier) *Tagger {
+	return &Tagger{classifiers: classifiers}
+}
+
+// Add 追加分类器
+func (t *Tagger) Add(c Classifier) {
+	t.classifiers = append(t.classifiers, c)
+}
+
+// Tag 返回去重并排序后的标签列表
+func (t *Tagger) Tag(in *Input) []string {
+	if t == nil {
+		return nil
+	}
+	seen := make(map[string]bool)
+	var tags []string
+	for _, c := range t.classifiers {
+		for _, tag := range c.Classify(in) {
+			if !seen[tag] {
+				seen[tag] = true
+				tags = append(tags, tag)
+			}
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// Builtin 返回内置分类规则（发票、验证码、告警、订阅邮件）
+func Builtin() []Classifier {
+	defs := []struct {
+		tag      string
+		keywords []string
+		pattern  string
+		fields   []string
+	}{
+		{"invoice", []string{"发票", "invoice", "账单", "receipt", "收据"}, "", nil},
+		// otp 按单词匹配，避免 "hotpot"、"footprint" 等误命中
+		{"otp", []string{"验证码", "校验码", "verification code", "one-time"}, `(?i)code\s*(is|:)\s*\d{4,8}|\botp\b`, nil},
+		{"alert", []string{"告警", "报警", "alert", "alarm", "warning", "故障"}, `(?i)\[(firing|critical|down)\]`, []string{FieldSubject}},
+		{"newsletter", []string{"unsubscribe", "退订", "取消订阅", "newsletter"}, "", []string{FieldBody}},
+	}
+
+	var rules []Classifier
+	for _, d := range defs {
+		r, err := NewRule(d.tag, d.keywords, d.pattern, d.fields)
+		if err != nil {
+			panic(err) // 内置规则有误属于编程错误
+		}
+		rules = append(rules, r)
+	}
+	return rules
+}
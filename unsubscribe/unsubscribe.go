@@ -0,0 +1,122 @@
+package unsubscribe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Target 邮件的退订方式（RFC 2369 List-Unsubscribe / RFC 8058 List-Unsubscribe-Post）
+type Target struct {
+	HTTP     string // HTTPS 退订地址
+	Mailto   string // mailto 退订地址
+	OneClick bool   // 支持一键退订（List-Unsubscribe-Post: List-Unsubscribe=One-Click）
+}
+
+// Parse 解析 List-Unsubscribe 和 List-Unsubscribe-Post 头，没有可用的退订方式时返回nil
+func Parse(header, post string) *Target {
+	t := &Target{}
+	for _, part := range strings.Split(header, ",") {
+		part = strings.TrimSpace(part)
+		if !strings.HasPrefix(part, "<") || !strings.HasSuffix(part, ">") {
+			continue
+		}
+		uri := strings.TrimSpace(part[1 : len(part)-1])
+		u, err := url.Parse(uri)
+		if err != nil {
+			continue
+		}
+		switch strings.ToLower(u.Scheme) {
+		case "https", "http":
+			if t.HTTP == "" {
+				t.HTTP = uri
+			}
+		case "mailto":
+			if t.Mailto == "" {
+				t.Mailto = uri
+			}
+		}
+	}
+	if t.HTTP == "" && t.Mailto == "" {
+		return nil
+	}
+	t.OneClick = t.HTTP != "" && strings.EqualFold(strings.TrimSpace(post), "List-Unsubscribe=One-Click")
+	return t
+}
+
+// ErrNotOneClick 邮件不支持一键退订，需要手动打开退订地址
+var ErrNotOneClick = errors.New("邮件不支持一键退订")
+
+// Client 一键退订客户端：只向公网 HTTPS 地址发送请求，防止邮件中的地址指向内网
+type Client struct {
+	http *http.Client
+}
+
+// NewClient 创建退订客户端
+func NewClient() *Client {
+	dialer := &net.Dialer{
+		Timeout: 10 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !isPublic(ip) {
+				return fmt.Errorf("退订地址指向非公网地址: %s", host)
+			}
+			return nil
+		},
+	}
+	return &Client{http: &http.Client{
+		Timeout: 30 * time.Second,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: 10 * time.Second,
+		},
+		// 一键退订不跟随重定向到其他地址
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}}
+}
+
+// Unsubscribe 按 RFC 8058 发送一键退订 POST 请求
+func (c *Client) Unsubscribe(ctx context.Context, t *Target) error {
+	if t == nil || !t.OneClick {
+		return ErrNotOneClick
+	}
+	u, err := url.Parse(t.HTTP)
+	if err != nil || !strings.EqualFold(u.Scheme, "https") {
+		return fmt.Errorf("一键退订地址必须为 HTTPS: %s", t.HTTP)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.HTTP, strings.NewReader("List-Unsubscribe=One-Click"))
+	if err != nil {
+		return fmt.Errorf("创建退订请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("退订请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("退订请求失败: 状态码 %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// isPublic 检查是否为公网地址
+func isPublic(ip net.IP) bool {
+	return ip.IsGlobalUnicast() && !ip.IsPrivate() && !ip.IsLoopback() && !ip.IsLinkLocalUnicast()
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"log"
+	"os"
+	"time"
+
+	"mail-receiver/handoff"
+	"mail-receiver/receiver"
+)
+
+// 进程交接超时：新进程最多等待 handoffReadyTimeout 让账号完成连接，旧进程等待稍长的时间
+const (
+	handoffReadyTimeout = 90 * time.Second
+	handoffStartTimeout = 2 * time.Minute
+	handoffStopTimeout  = 30 * time.Second
+	// handoffReleaseTimeout 新进程等待旧进程退出的最长时间，超时后（旧进程卡住）仍开始获取邮件
+	handoffReleaseTimeout = handoffStartTimeout + handoffStopTimeout + 30*time.Second
+)
+
+// upgrade 启动新版本程序并在其完成连接后退出，升级期间邮件监控和HTTP服务不中断
+func upgrade(recv *receiver.Receiver) {
+	log.Printf("收到升级信号，启动新进程")
+	pid, err := handoff.Start(handoffStartTimeout)
+	if err != nil {
+		log.Printf("进程交接失败，继续运行: %v", err)
+		return
+	}
+
+	log.Printf("新进程 (PID %d) 已就绪，断开连接并退出", pid)
+	if !recv.Stop(handoffStopTimeout) {
+		log.Printf("部分账号未能在 %v 内断开连接", handoffStopTimeout)
+	}
+	os.Exit(0)
+}
+
+// isUpgradeSignal 检查是否为升级信号
+func isUpgradeSignal(sig os.Signal) bool {
+	for _, s := range upgradeSignals {
+		if sig == s {
+			return true
+		}
+	}
+	return false
+}
+
+// holdUntilReleased 由旧进程交接启动时，账号登录后等待旧进程退出再获取邮件，避免新旧进程同时处理同一批邮件
+func holdUntilReleased(recv *receiver.Receiver) {
+	released := handoff.Released()
+	if released == nil {
+		return
+	}
+	release := make(chan struct{})
+	go func() {
+		select {
+		case <-released:
+			log.Printf("旧进程已退出，开始获取邮件")
+		case <-time.After(handoffReleaseTimeout):
+			log.Printf("等待旧进程退出超时（%v），开始获取邮件", handoffReleaseTimeout)
+		}
+		close(release)
+	}()
+	recv.HoldUntil(release)
+}
+
+// notifyReady 由旧进程交接启动时，等待账号完成连接后通知旧进程退出
+func notifyReady(recv *receiver.Receiver) {
+	if !handoff.Inherited() {
+		return
+	}
+	go func() {
+		if !recv.WaitConnected(handoffReadyTimeout) {
+			log.Printf("部分账号未能在 %v 内完成连接，仍通知旧进程退出", handoffReadyTimeout)
+		}
+		log.Printf("已接管旧进程")
+		handoff.Ready()
+	}()
+}
@@ -0,0 +1,11 @@
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// upgradeSignals 触发进程交接升级的信号
+var upgradeSignals = []os.Signal{syscall.SIGUSR2}
@@ -0,0 +1,6 @@
+package main
+
+import "os"
+
+// upgradeSignals Windows 不支持进程交接升级
+var upgradeSignals []os.Signal